@@ -0,0 +1,306 @@
+package assets
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales
+var _localeFS embed.FS
+
+type locale struct {
+	Tag language.Tag `yaml:"-"`
+	// weekdays start from Sunday, like time.Weekday
+	Weekdays      []string          `yaml:"weekdays"`
+	ShortWeekdays []string          `yaml:"short-weekdays"`
+	Months        []string          `yaml:"months"`
+	Strings       map[string]string `yaml:"strings"`
+
+	printer *message.Printer
+}
+
+// the strings main.js needs, which get passed to it through the page
+var clientStringKeys = []string{"show-more", "show-less", "list-changed-elsewhere"}
+
+var (
+	localesMutex sync.Mutex
+	locales      = map[string]*locale{}
+	// the one selected through SetLanguage, English until then
+	currentLocale atomic.Pointer[locale]
+)
+
+func init() {
+	entries, err := fs.ReadDir(_localeFS, "locales")
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		contents, err := fs.ReadFile(_localeFS, "locales/"+entry.Name())
+
+		if err != nil {
+			panic(err)
+		}
+
+		if err = addLocale(entry.Name(), contents); err != nil {
+			panic(err)
+		}
+	}
+
+	currentLocale.Store(locales["en"])
+}
+
+func addLocale(fileName string, contents []byte) error {
+	name := strings.TrimSuffix(strings.TrimSuffix(fileName, ".yml"), ".yaml")
+	tag, err := language.Parse(name)
+
+	if err != nil {
+		return fmt.Errorf("locale file %s isn't named after a language: %v", fileName, err)
+	}
+
+	parsed := &locale{}
+
+	if err = yaml.Unmarshal(contents, parsed); err != nil {
+		return fmt.Errorf("parsing locale file %s: %v", fileName, err)
+	}
+
+	if (parsed.Weekdays != nil && len(parsed.Weekdays) != 7) ||
+		(parsed.ShortWeekdays != nil && len(parsed.ShortWeekdays) != 7) ||
+		(parsed.Months != nil && len(parsed.Months) != 12) {
+		return fmt.Errorf("locale file %s must have 7 weekdays and 12 months", fileName)
+	}
+
+	parsed.Tag = tag
+	parsed.printer = message.NewPrinter(tag)
+
+	localesMutex.Lock()
+	defer localesMutex.Unlock()
+
+	// a file for a language which is already embedded only has to contain what it changes
+	if existing, ok := locales[tag.String()]; ok {
+		parsed = existing.mergedWith(parsed)
+	}
+
+	locales[tag.String()] = parsed
+
+	return nil
+}
+
+func (l *locale) mergedWith(other *locale) *locale {
+	merged := *l
+	merged.Strings = make(map[string]string, len(l.Strings)+len(other.Strings))
+
+	for key, value := range l.Strings {
+		merged.Strings[key] = value
+	}
+
+	for key, value := range other.Strings {
+		merged.Strings[key] = value
+	}
+
+	if other.Weekdays != nil {
+		merged.Weekdays = other.Weekdays
+	}
+
+	if other.ShortWeekdays != nil {
+		merged.ShortWeekdays = other.ShortWeekdays
+	}
+
+	if other.Months != nil {
+		merged.Months = other.Months
+	}
+
+	return &merged
+}
+
+// LoadLocales adds the translations in the .yml files of dir to the embedded
+// ones, each named after the language it's for such as pt-BR.yml
+func LoadLocales(dir string) error {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return fmt.Errorf("reading locales directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+
+		if err != nil {
+			return err
+		}
+
+		if err = addLocale(entry.Name(), contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetLanguage picks the locale closest to lang, so that de-AT uses de if
+// there's nothing more specific. Strings missing from it fall back to English.
+func SetLanguage(lang string) error {
+	tag, err := language.Parse(lang)
+
+	if err != nil {
+		return fmt.Errorf("invalid language %s: %v", lang, err)
+	}
+
+	localesMutex.Lock()
+	defer localesMutex.Unlock()
+
+	available := make([]language.Tag, 0, len(locales))
+	byTag := make([]*locale, 0, len(locales))
+
+	for _, l := range locales {
+		available = append(available, l.Tag)
+		byTag = append(byTag, l)
+	}
+
+	_, index, confidence := language.NewMatcher(available).Match(tag)
+
+	if confidence == language.No {
+		return fmt.Errorf("no translation available for language %s", lang)
+	}
+
+	currentLocale.Store(byTag[index])
+
+	return nil
+}
+
+func intl() *message.Printer {
+	return currentLocale.Load().printer
+}
+
+// Language is the language of the selected locale, for the lang attribute of pages
+func Language() string {
+	return currentLocale.Load().Tag.String()
+}
+
+func lookupString(key string) (string, bool) {
+	if value, ok := currentLocale.Load().Strings[key]; ok {
+		return value, true
+	}
+
+	localesMutex.Lock()
+	value, ok := locales["en"].Strings[key]
+	localesMutex.Unlock()
+
+	return value, ok
+}
+
+// translate returns the string for key in the selected language, formatted with
+// args if there are any. Unknown keys are returned as they are so that they
+// stand out rather than leaving an empty space.
+func translate(key string, args ...any) string {
+	value, ok := lookupString(key)
+
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return value
+	}
+
+	return intl().Sprintf(value, args...)
+}
+
+func clientStrings() template.HTMLAttr {
+	values := make(map[string]string, len(clientStringKeys))
+
+	for _, key := range clientStringKeys {
+		values[key] = translate(key)
+	}
+
+	encoded, _ := json.Marshal(values)
+
+	return template.HTMLAttr(`data-strings="` + template.HTMLEscapeString(string(encoded)) + `"`)
+}
+
+func englishLocale() *locale {
+	localesMutex.Lock()
+	defer localesMutex.Unlock()
+
+	return locales["en"]
+}
+
+func localizedName(names func(*locale) []string, index int) string {
+	list := names(currentLocale.Load())
+
+	if index < 0 || index >= len(list) {
+		list = names(englishLocale())
+	}
+
+	if index < 0 || index >= len(list) {
+		return ""
+	}
+
+	return list[index]
+}
+
+func toIndex(value any) int {
+	switch v := value.(type) {
+	case time.Weekday:
+		return int(v)
+	case time.Month:
+		return int(v) - 1
+	case time.Time:
+		return int(v.Weekday())
+	}
+
+	return int(toFloat(value))
+}
+
+// weekdayName takes a time.Weekday, a time.Time or a number from 0 for Sunday to 6
+func weekdayName(day any) string {
+	return localizedName(func(l *locale) []string { return l.Weekdays }, toIndex(day))
+}
+
+func shortWeekdayName(day any) string {
+	return localizedName(func(l *locale) []string { return l.ShortWeekdays }, toIndex(day))
+}
+
+// monthName takes a time.Month, a time.Time or a number from 1 to 12
+func monthName(month any) string {
+	switch v := month.(type) {
+	case time.Time:
+		month = v.Month()
+	case time.Month:
+	default:
+		month = time.Month(int(toFloat(month)))
+	}
+
+	return localizedName(func(l *locale) []string { return l.Months }, toIndex(month))
+}
+
+// shortWeekdayNamesFromMonday is for calendars, which start their weeks on Monday
+func shortWeekdayNamesFromMonday() []string {
+	names := make([]string, 7)
+
+	for i := range names {
+		names[i] = shortWeekdayName((i + 1) % 7)
+	}
+
+	return names
+}
@@ -30,8 +30,8 @@ async function fetchPageContent(pageSlug) {
     return content;
 }
 
-function setupCarousels() {
-    const carouselElements = document.getElementsByClassName("carousel-container");
+function setupCarousels(root = document) {
+    const carouselElements = root.getElementsByClassName("carousel-container");
 
     if (carouselElements.length == 0) {
         return;
@@ -71,6 +71,12 @@ const dayInSeconds = hourInSeconds * 24;
 const monthInSeconds = dayInSeconds * 30;
 const yearInSeconds = monthInSeconds * 12;
 
+const translatedStrings = JSON.parse(document.documentElement.dataset.strings || '{}');
+
+function translate(key) {
+    return translatedStrings[key] ?? key;
+}
+
 function relativeTimeSince(timestamp) {
     const delta = Math.round((Date.now() / 1000) - timestamp);
 
@@ -107,8 +113,8 @@ function updateRelativeTimeForElements(elements)
     }
 }
 
-function setupSearchboxes() {
-    const searchWidgets = document.getElementsByClassName("search");
+function setupSearchboxes(root = document) {
+    const searchWidgets = root.getElementsByClassName("search");
 
     if (searchWidgets.length == 0) {
         return;
@@ -117,6 +123,7 @@ function setupSearchboxes() {
     for (let i = 0; i < searchWidgets.length; i++) {
         const widget = searchWidgets[i];
         const defaultSearchUrl = widget.dataset.defaultSearchUrl;
+        const newTab = widget.dataset.newTab === "true";
         const inputElement = widget.getElementsByClassName("search-input")[0];
         const bangElement = widget.getElementsByClassName("search-bang")[0];
         const bangs = widget.querySelectorAll(".search-bangs > input");
@@ -154,7 +161,7 @@ function setupSearchboxes() {
 
                 const url = searchUrlTemplate.replace("!QUERY!", encodeURIComponent(query));
 
-                if (event.ctrlKey) {
+                if (newTab !== event.ctrlKey) {
                     window.open(url, '_blank').focus();
                 } else {
                     window.location.href = url;
@@ -204,8 +211,8 @@ function setupSearchboxes() {
     }
 }
 
-function setupDynamicRelativeTime() {
-    const elements = document.querySelectorAll("[data-dynamic-relative-time]");
+function setupDynamicRelativeTime(root = document) {
+    const elements = root.querySelectorAll("[data-dynamic-relative-time]");
     const updateInterval = 60 * 1000;
     let lastUpdateTime = Date.now();
 
@@ -246,8 +253,8 @@ function setupDynamicRelativeTime() {
     });
 }
 
-function setupLazyImages() {
-    const images = document.querySelectorAll("img[loading=lazy]");
+function setupLazyImages(root = document) {
+    const images = root.querySelectorAll("img[loading=lazy]");
 
     if (images.length == 0) {
         return;
@@ -278,8 +285,8 @@ function setupLazyImages() {
 }
 
 function attachExpandToggleButton(collapsibleContainer) {
-    const showMoreText = "Show more";
-    const showLessText = "Show less";
+    const showMoreText = translate("show-more");
+    const showLessText = translate("show-less");
 
     let expanded = false;
     const button = document.createElement("button");
@@ -321,8 +328,8 @@ function attachExpandToggleButton(collapsibleContainer) {
 };
 
 
-function setupCollapsibleLists() {
-    const collapsibleLists = document.querySelectorAll(".list.collapsible-container");
+function setupCollapsibleLists(root = document) {
+    const collapsibleLists = root.querySelectorAll(".list.collapsible-container");
 
     if (collapsibleLists.length == 0) {
         return;
@@ -355,8 +362,8 @@ function setupCollapsibleLists() {
     }
 }
 
-function setupCollapsibleGrids() {
-    const collapsibleGridElements = document.querySelectorAll(".cards-grid.collapsible-container");
+function setupCollapsibleGrids(root = document) {
+    const collapsibleGridElements = root.querySelectorAll(".cards-grid.collapsible-container");
 
     if (collapsibleGridElements.length == 0) {
         return;
@@ -430,13 +437,31 @@ function setupCollapsibleGrids() {
 }
 
 const contentReadyCallbacks = [];
+let contentReady = false;
 
 function afterContentReady(callback) {
+    // widgets filled in after the page was set up have their callbacks run straight away
+    if (contentReady) {
+        callback();
+        return;
+    }
+
     contentReadyCallbacks.push(callback);
 }
 
-const weekDayNames = ['Sunday', 'Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday'];
-const monthNames = ['January', 'February', 'March', 'April', 'May', 'June', 'July', 'August', 'September', 'October', 'November', 'December'];
+const weekDayNames = localizedDateNames({ weekday: 'long' }, 7, (i) => new Date(2024, 0, 7 + i));
+const monthNames = localizedDateNames({ month: 'long' }, 12, (i) => new Date(2024, i, 1));
+
+function localizedDateNames(options, count, dateAt) {
+    const format = new Intl.DateTimeFormat(document.documentElement.lang || 'en', options);
+    const names = [];
+
+    for (let i = 0; i < count; i++) {
+        names.push(format.format(dateAt(i)));
+    }
+
+    return names;
+}
 
 function makeSettableTimeElement(element, hourFormat) {
     const fragment = document.createDocumentFragment();
@@ -482,8 +507,8 @@ function timeInZone(now, zone) {
     return { time: timeInZone, diffInHours: diffInHours };
 }
 
-function setupClocks() {
-    const clocks = document.getElementsByClassName('clock');
+function setupClocks(root = document) {
+    const clocks = root.getElementsByClassName('clock');
 
     if (clocks.length == 0) {
         return;
@@ -541,6 +566,264 @@ function setupClocks() {
     updateClocks();
 }
 
+function setupBookmarkShortcuts(root = document) {
+    const links = root.querySelectorAll(".bookmarks-link[data-shortcut]");
+
+    if (links.length == 0) {
+        return;
+    }
+
+    const shortcuts = {};
+
+    for (let i = 0; i < links.length; i++) {
+        shortcuts[links[i].dataset.shortcut] = links[i];
+    }
+
+    document.addEventListener("keydown", (event) => {
+        if (event.ctrlKey || event.metaKey || event.altKey) return;
+        if (['INPUT', 'TEXTAREA', 'SELECT'].includes(document.activeElement.tagName)) return;
+        if (document.activeElement.isContentEditable) return;
+
+        const link = shortcuts[event.key];
+
+        if (link === undefined) return;
+
+        link.click();
+        event.preventDefault();
+    });
+}
+
+function setupTodos(root = document) {
+    const todos = root.querySelectorAll(".todo");
+
+    for (let i = 0; i < todos.length; i++) {
+        setupTodo(todos[i]);
+    }
+}
+
+function setupTodo(todo) {
+    const listId = todo.dataset.listId;
+    const itemsElement = todo.querySelector(".todo-items");
+    const inputElement = todo.querySelector(".todo-input");
+    const errorElement = todo.querySelector(".todo-error");
+    let version = todo.dataset.version;
+    let draggedItem = null;
+
+    const renderItems = (items) => {
+        itemsElement.innerHTML = "";
+
+        for (let i = 0; i < items.length; i++) {
+            const item = document.createElement("li");
+            item.className = "todo-item flex items-center gap-10" + (items[i].done ? " todo-item-done" : "");
+            item.dataset.id = items[i].id;
+            item.draggable = true;
+
+            const toggle = document.createElement("input");
+            toggle.className = "todo-toggle";
+            toggle.type = "checkbox";
+            toggle.checked = items[i].done;
+            toggle.title = "Mark as done";
+
+            const text = document.createElement("span");
+            text.className = "todo-text grow";
+            text.textContent = items[i].text;
+
+            const remove = document.createElement("button");
+            remove.className = "todo-delete";
+            remove.type = "button";
+            remove.title = "Delete";
+            remove.textContent = "×";
+
+            item.append(toggle, text, remove);
+            itemsElement.append(item);
+        }
+    };
+
+    const request = async (method, path, body) => {
+        errorElement.textContent = "";
+
+        const response = await fetch(`/api/todo/${listId}${path}`, {
+            method: method,
+            headers: {
+                "Content-Type": "application/json",
+                "If-Match": version,
+                "X-Glance-Token": pageData.token,
+            },
+            body: body === undefined ? undefined : JSON.stringify(body),
+        });
+
+        const data = await response.json();
+
+        if (response.ok || response.status == 409) {
+            version = String(data.version);
+            renderItems(data.items);
+        }
+
+        if (response.status == 409) {
+            errorElement.textContent = translate("list-changed-elsewhere");
+        } else if (!response.ok) {
+            errorElement.textContent = data.error || "Something went wrong";
+        }
+
+        return response.ok;
+    };
+
+    todo.querySelector(".todo-add").addEventListener("submit", async (event) => {
+        event.preventDefault();
+        const text = inputElement.value.trim();
+
+        if (text == "") return;
+
+        if (await request("POST", "/items", { text: text })) {
+            inputElement.value = "";
+        }
+    });
+
+    itemsElement.addEventListener("click", (event) => {
+        const item = event.target.closest(".todo-item");
+
+        if (item === null) return;
+
+        if (event.target.classList.contains("todo-toggle")) {
+            // optimistically update while the request is in flight
+            item.classList.toggle("todo-item-done", event.target.checked);
+            request("POST", `/items/${item.dataset.id}/toggle`);
+        } else if (event.target.classList.contains("todo-delete")) {
+            item.remove();
+            request("DELETE", `/items/${item.dataset.id}`);
+        }
+    });
+
+    itemsElement.addEventListener("dragstart", (event) => {
+        draggedItem = event.target.closest(".todo-item");
+        draggedItem.classList.add("todo-item-dragging");
+    });
+
+    itemsElement.addEventListener("dragover", (event) => {
+        if (draggedItem === null) return;
+
+        event.preventDefault();
+        const target = event.target.closest(".todo-item");
+
+        if (target === null || target === draggedItem) return;
+
+        const bounds = target.getBoundingClientRect();
+        const after = event.clientY > bounds.top + bounds.height / 2;
+        target.parentNode.insertBefore(draggedItem, after ? target.nextSibling : target);
+    });
+
+    itemsElement.addEventListener("dragend", () => {
+        if (draggedItem === null) return;
+
+        draggedItem.classList.remove("todo-item-dragging");
+        draggedItem = null;
+
+        const order = Array.from(itemsElement.children).map((item) => item.dataset.id);
+        request("PUT", "/order", { order: order });
+    });
+}
+
+function setupNotificationAcknowledgements(root = document) {
+    const buttons = root.querySelectorAll(".notification-acknowledge");
+
+    for (let i = 0; i < buttons.length; i++) {
+        const button = buttons[i];
+
+        button.addEventListener("click", async () => {
+            button.disabled = true;
+
+            const response = await fetch(button.dataset.acknowledgeUrl, {
+                method: "DELETE",
+                headers: { "X-Glance-Token": pageData.token },
+            });
+
+            if (response.ok) {
+                button.closest(".notification").remove();
+            } else {
+                button.disabled = false;
+                button.title = "Could not dismiss notification";
+            }
+        });
+    }
+}
+
+function setupFreeGameClaims(root = document) {
+    const checkboxes = root.querySelectorAll(".free-game-claim");
+
+    for (let i = 0; i < checkboxes.length; i++) {
+        const checkbox = checkboxes[i];
+        const game = checkbox.closest(".free-game");
+
+        checkbox.addEventListener("change", async () => {
+            const claimed = checkbox.checked;
+            game.classList.toggle("free-game-claimed", claimed);
+
+            const response = await fetch(`/api/free-games/claimed/${encodeURIComponent(game.dataset.id)}`, {
+                method: claimed ? "PUT" : "DELETE",
+                headers: { "X-Glance-Token": pageData.token },
+            });
+
+            if (!response.ok) {
+                checkbox.checked = !claimed;
+                game.classList.toggle("free-game-claimed", !claimed);
+                checkbox.title = "Could not save, try again";
+                return;
+            }
+
+            if (claimed && game.dataset.hideClaimed !== undefined) {
+                game.remove();
+            }
+        });
+    }
+}
+
+function setupContent(root) {
+    setupClocks(root)
+    setupCarousels(root);
+    setupSearchboxes(root);
+    setupBookmarkShortcuts(root);
+    setupTodos(root);
+    setupNotificationAcknowledgements(root);
+    setupFreeGameClaims(root);
+    setupCollapsibleLists(root);
+    setupCollapsibleGrids(root);
+    setupDynamicRelativeTime(root);
+    setupLazyImages(root);
+}
+
+async function loadPendingWidget(placeholder) {
+    let response;
+
+    try {
+        response = await fetch(`/api/pages/${pageData.slug}/content/widgets/${placeholder.dataset.pendingWidget}`);
+    } catch {
+        return;
+    }
+
+    if (!response.ok) {
+        return;
+    }
+
+    const template = document.createElement("template");
+    template.innerHTML = await response.text();
+    const widget = template.content.firstElementChild;
+
+    if (widget === null) {
+        return;
+    }
+
+    placeholder.replaceWith(widget);
+    setupContent(widget);
+}
+
+function loadPendingWidgets(root) {
+    const placeholders = root.querySelectorAll("[data-pending-widget]");
+
+    for (let i = 0; i < placeholders.length; i++) {
+        loadPendingWidget(placeholders[i]);
+    }
+}
+
 async function setupPage() {
     const pageElement = document.getElementById("page");
     const pageContentElement = document.getElementById("page-content");
@@ -549,15 +832,10 @@ async function setupPage() {
     pageContentElement.innerHTML = pageContent;
 
     try {
-        setupClocks()
-        setupCarousels();
-        setupSearchboxes();
-        setupCollapsibleLists();
-        setupCollapsibleGrids();
-        setupDynamicRelativeTime();
-        setupLazyImages();
+        setupContent(pageContentElement);
     } finally {
         pageElement.classList.add("content-ready");
+        contentReady = true;
 
         for (let i = 0; i < contentReadyCallbacks.length; i++) {
             contentReadyCallbacks[i]();
@@ -567,6 +845,8 @@ async function setupPage() {
             document.body.classList.add("page-columns-transitioned");
         }, 300);
     }
+
+    loadPendingWidgets(pageContentElement);
 }
 
 if (document.readyState === "loading") {
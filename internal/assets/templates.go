@@ -4,16 +4,19 @@ import (
 	"fmt"
 	"html/template"
 	"math"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
-
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
 	PageTemplate                  = compileTemplate("page.html", "document.html", "page-style-overrides.gotmpl")
 	PageContentTemplate           = compileTemplate("content.html")
+	StaticPageTemplate            = compileTemplate("static-page.html", "page-style-overrides.gotmpl")
+	WidgetPlaceholderTemplate     = compileTemplate("widget-placeholder.html")
 	CalendarTemplate              = compileTemplate("calendar.html", "widget-base.html")
 	ClockTemplate                 = compileTemplate("clock.html", "widget-base.html")
 	BookmarksTemplate             = compileTemplate("bookmarks.html", "widget-base.html")
@@ -27,6 +30,7 @@ var (
 	VideosTemplate                = compileTemplate("videos.html", "widget-base.html", "video-card-contents.html")
 	VideosGridTemplate            = compileTemplate("videos-grid.html", "widget-base.html", "video-card-contents.html")
 	MarketsTemplate               = compileTemplate("markets.html", "widget-base.html")
+	CurrencyTemplate              = compileTemplate("currency.html", "widget-base.html")
 	RSSListTemplate               = compileTemplate("rss-list.html", "widget-base.html")
 	RSSDetailedListTemplate       = compileTemplate("rss-detailed-list.html", "widget-base.html")
 	RSSHorizontalCardsTemplate    = compileTemplate("rss-horizontal-cards.html", "widget-base.html")
@@ -37,17 +41,38 @@ var (
 	RepositoryTemplate            = compileTemplate("repository.html", "widget-base.html")
 	SearchTemplate                = compileTemplate("search.html", "widget-base.html")
 	ExtensionTemplate             = compileTemplate("extension.html", "widget-base.html")
+	CustomAPITemplate             = compileTemplate("custom-api.html", "widget-base.html")
+	ScraperTemplate               = compileTemplate("scraper.html", "widget-base.html")
+	ExecTemplate                  = compileTemplate("exec.html", "widget-base.html")
+	TodoTemplate                  = compileTemplate("todo.html", "widget-base.html")
+	MarkdownTemplate              = compileTemplate("markdown.html", "widget-base.html")
+	NotificationsTemplate         = compileTemplate("notifications.html", "widget-base.html")
+	EmailTemplate                 = compileTemplate("email.html", "widget-base.html")
+	DeparturesTemplate            = compileTemplate("departures.html", "widget-base.html")
+	SportsTemplate                = compileTemplate("sports.html", "widget-base.html")
+	SteamTemplate                 = compileTemplate("steam.html", "widget-base.html")
+	FreeGamesTemplate             = compileTemplate("free-games.html", "widget-base.html")
+	SpeedtestTemplate             = compileTemplate("speedtest.html", "widget-base.html")
+	HomeAssistantTemplate         = compileTemplate("home-assistant.html", "widget-base.html")
+	ProxmoxTemplate               = compileTemplate("proxmox.html", "widget-base.html")
+	AnalyticsTemplate             = compileTemplate("analytics.html", "widget-base.html")
+	ServiceUpdatesTemplate        = compileTemplate("service-updates.html", "widget-base.html")
 )
 
 var globalTemplateFunctions = template.FuncMap{
 	"relativeTime":      relativeTimeSince,
 	"formatViewerCount": formatViewerCount,
-	"formatNumber":      intl.Sprint,
+	"abbreviateNumber":  abbreviateNumber,
+	"percentChange":     percentChange,
+	"proxyImage":        proxyImage,
+	"formatNumber": func(value any) string {
+		return intl().Sprint(value)
+	},
 	"absInt": func(i int) int {
 		return int(math.Abs(float64(i)))
 	},
 	"formatPrice": func(price float64) string {
-		return intl.Sprintf("%.2f", price)
+		return intl().Sprintf("%.2f", price)
 	},
 	"formatTime": func(t time.Time) string {
 		return t.Format("2006-01-02 15:04:05")
@@ -65,6 +90,62 @@ var globalTemplateFunctions = template.FuncMap{
 	"dynamicRelativeTimeAttrs": func(t time.Time) template.HTMLAttr {
 		return template.HTMLAttr(fmt.Sprintf(`data-dynamic-relative-time="%d"`, t.Unix()))
 	},
+	"timeFromNow":                 timeFromNow,
+	"compactNumber":               compactNumber,
+	"signedPercent":               signedPercent,
+	"percentClass":                percentClass,
+	"formatBytes":                 formatBytes,
+	"urlHost":                     urlHost,
+	"truncate":                    truncate,
+	"pluralize":                   pluralize,
+	"t":                           translate,
+	"language":                    Language,
+	"clientStrings":               clientStrings,
+	"weekdayName":                 weekdayName,
+	"shortWeekdayName":            shortWeekdayName,
+	"shortWeekdayNamesFromMonday": shortWeekdayNamesFromMonday,
+	"monthName":                   monthName,
+	"linkAttrs":                   LinkAttrs,
+}
+
+// LinkAttrs returns the target and rel attributes of a link, with links which
+// open in a new tab not getting a reference to the page they were opened from
+func LinkAttrs(sameTab bool) template.HTMLAttr {
+	if sameTab {
+		return `rel="noreferrer"`
+	}
+
+	return `target="_blank" rel="noreferrer noopener"`
+}
+
+// set through SetImageProxy, external images are loaded directly when it's nil
+var imageProxyURL func(url string, width int) string
+
+// SetImageProxy routes the external images of widgets through the URLs returned by rewrite,
+// which get the width the image is going to be displayed at, or 0 for its original size.
+// The URLs can also be data URIs of images, which are otherwise filtered out by templates.
+func SetImageProxy(rewrite func(url string, width int) string) {
+	imageProxyURL = rewrite
+}
+
+func proxyImage(url string, width int) any {
+	if imageProxyURL == nil || !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")) {
+		return url
+	}
+
+	rewritten := imageProxyURL(url, width)
+
+	if strings.HasPrefix(rewritten, "data:image/") {
+		return template.URL(rewritten)
+	}
+
+	return rewritten
+}
+
+// RelativeTime formats how long ago t was the same way as the times which
+// are kept up to date in the browser
+func RelativeTime(t time.Time) string {
+	return relativeTimeSince(t)
 }
 
 func compileTemplate(primary string, dependencies ...string) *template.Template {
@@ -79,7 +160,14 @@ func compileTemplate(primary string, dependencies ...string) *template.Template
 	return t
 }
 
-var intl = message.NewPrinter(language.English)
+// CompileUserTemplate compiles a template provided through the config, giving
+// it access to the same functions as the built-in templates
+func CompileUserTemplate(name string, text string, extraFunctions template.FuncMap) (*template.Template, error) {
+	return template.New(name).
+		Funcs(globalTemplateFunctions).
+		Funcs(extraFunctions).
+		Parse(text)
+}
 
 func formatViewerCount(count int) string {
 	if count < 1_000 {
@@ -97,9 +185,58 @@ func formatViewerCount(count int) string {
 	return fmt.Sprintf("%.1fm", float64(count)/1_000_000)
 }
 
+// abbreviateNumber shortens large numbers to one decimal place,
+// such as 1.2k for 1234, leaving out the decimal when it's 0
+func abbreviateNumber(number int) string {
+	value := math.Abs(float64(number))
+	sign := ""
+
+	if number < 0 {
+		sign = "-"
+	}
+
+	var suffix string
+
+	// the thresholds are where rounding would otherwise show 1000.0k
+	switch {
+	case value >= 999_950_000:
+		value, suffix = value/1e9, "b"
+	case value >= 999_950:
+		value, suffix = value/1e6, "m"
+	case value >= 1_000:
+		value, suffix = value/1e3, "k"
+	default:
+		return strconv.Itoa(number)
+	}
+
+	return sign + strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + suffix
+}
+
+// percentChange formats how much current changed compared to previous, such as
+// +12% or -3%, and is empty when there's nothing to compare with
+func percentChange(current, previous int) string {
+	if previous == 0 {
+		return ""
+	}
+
+	change := math.Round(float64(current-previous) / float64(previous) * 100)
+
+	if change > 0 {
+		return fmt.Sprintf("+%.0f%%", change)
+	}
+
+	if change == 0 {
+		return "0%"
+	}
+
+	return fmt.Sprintf("%.0f%%", change)
+}
+
 func relativeTimeSince(t time.Time) string {
-	delta := time.Since(t)
+	return formatRelativeDuration(time.Since(t))
+}
 
+func formatRelativeDuration(delta time.Duration) string {
 	if delta < time.Minute {
 		return "1m"
 	}
@@ -118,3 +255,171 @@ func relativeTimeSince(t time.Time) string {
 
 	return fmt.Sprintf("%dy", delta/(365*24*time.Hour))
 }
+
+// timeFromNow is like relativeTimeSince but says which way, such as 3h ago
+// or in 2d, for times which can be in the future as well as the past
+func timeFromNow(t time.Time) string {
+	delta := time.Until(t)
+
+	if delta > -time.Minute && delta < time.Minute {
+		return translate("just-now")
+	}
+
+	if delta < 0 {
+		return translate("time-ago", formatRelativeDuration(-delta))
+	}
+
+	return translate("time-in", formatRelativeDuration(delta))
+}
+
+// toFloat accepts any of the numeric types that end up in templates, such
+// as the float64 values of parsed JSON and the ints of widget fields
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+
+	return 0
+}
+
+// compactNumber is like abbreviateNumber for any kind of number, with the
+// decimal being formatted for the language of the dashboard, such as 12.4k
+func compactNumber(number any) string {
+	value := toFloat(number)
+	abs := math.Abs(value)
+
+	var divisor float64
+	var suffix string
+
+	switch {
+	case abs >= 999_950_000:
+		divisor, suffix = 1e9, "b"
+	case abs >= 999_950:
+		divisor, suffix = 1e6, "m"
+	case abs >= 999.95:
+		divisor, suffix = 1e3, "k"
+	default:
+		divisor = 1
+	}
+
+	value = math.Round(value/divisor*10) / 10
+
+	if value == math.Trunc(value) {
+		return intl().Sprintf("%.0f", value) + suffix
+	}
+
+	return intl().Sprintf("%.1f", value) + suffix
+}
+
+// signedPercent formats a percentage with one decimal and its sign, such as +1.5%,
+// meant to be used along with percentClass for the color
+func signedPercent(percent any) string {
+	value := math.Round(toFloat(percent)*10) / 10
+
+	if value > 0 {
+		return "+" + intl().Sprintf("%.1f", value) + "%"
+	}
+
+	if value == 0 {
+		return intl().Sprintf("%.1f", 0.0) + "%"
+	}
+
+	return intl().Sprintf("%.1f", value) + "%"
+}
+
+func percentClass(percent any) string {
+	value := math.Round(toFloat(percent)*10) / 10
+
+	if value > 0 {
+		return "color-positive"
+	}
+
+	if value < 0 {
+		return "color-negative"
+	}
+
+	return ""
+}
+
+// formatBytes uses binary units since that's what operating systems and
+// most tools report sizes of files and memory in, such as 1.5 GiB
+func formatBytes(bytes any) string {
+	value := toFloat(bytes)
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	unit := 0
+
+	for math.Abs(value) >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return intl().Sprintf("%.0f", value) + " B"
+	}
+
+	return intl().Sprintf("%.1f", value) + " " + units[unit]
+}
+
+// urlHost returns the host of a URL without www., such as github.com for
+// https://www.github.com/glanceapp, or the URL as it is if it has no host
+func urlHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+
+	if err != nil || parsed.Hostname() == "" {
+		return rawURL
+	}
+
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+// truncate shortens s to at most length characters including the ellipsis, with
+// the length coming first so that it can be used in pipelines like {{ .Title | truncate 40 }}
+func truncate(length int, s string) string {
+	if length <= 0 {
+		return ""
+	}
+
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+
+	runes := []rune(s)
+
+	return strings.TrimRightFunc(string(runes[:length-1]), unicode.IsSpace) + "…"
+}
+
+// pluralize returns singular when count is 1 and plural otherwise, leaving
+// the count itself to the template, such as {{ .Count }} {{ pluralize .Count "item" "items" }}
+func pluralize(count any, singular string, plural string) string {
+	if toFloat(count) == 1 {
+		return singular
+	}
+
+	return plural
+}
@@ -0,0 +1,213 @@
+package assets
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useLanguage selects lang for the duration of the test, going back to English after
+func useLanguage(t *testing.T, lang string) {
+	t.Helper()
+
+	if err := SetLanguage(lang); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { SetLanguage("en") })
+}
+
+func TestTimeFromNow(t *testing.T) {
+	tests := []struct {
+		lang     string
+		offset   time.Duration
+		expected string
+	}{
+		{lang: "en", offset: -3*time.Hour - time.Minute, expected: "3h ago"},
+		{lang: "en", offset: 2*24*time.Hour + time.Minute, expected: "in 2d"},
+		{lang: "en", offset: -20 * time.Second, expected: "just now"},
+		{lang: "en", offset: 20 * time.Second, expected: "just now"},
+		{lang: "en", offset: -45*24*time.Hour - time.Minute, expected: "1mo ago"},
+		{lang: "de", offset: -3*time.Hour - time.Minute, expected: "vor 3h"},
+		{lang: "de", offset: 2*24*time.Hour + time.Minute, expected: "in 2d"},
+	}
+
+	for _, test := range tests {
+		useLanguage(t, test.lang)
+
+		if result := timeFromNow(time.Now().Add(test.offset)); result != test.expected {
+			t.Errorf("%s %v: expected %q, got %q", test.lang, test.offset, test.expected, result)
+		}
+	}
+}
+
+func TestCompactNumber(t *testing.T) {
+	tests := []struct {
+		lang     string
+		number   any
+		expected string
+	}{
+		{lang: "en", number: 12_400, expected: "12.4k"},
+		{lang: "en", number: 999, expected: "999"},
+		{lang: "en", number: 999.96, expected: "1k"},
+		{lang: "en", number: 1_000, expected: "1k"},
+		{lang: "en", number: 999_950, expected: "1m"},
+		{lang: "en", number: 2_500_000_000, expected: "2.5b"},
+		{lang: "en", number: -12_400.0, expected: "-12.4k"},
+		{lang: "en", number: int64(3), expected: "3"},
+		{lang: "en", number: "1234", expected: "1.2k"},
+		{lang: "en", number: nil, expected: "0"},
+		{lang: "de", number: 12_400, expected: "12,4k"},
+		{lang: "de", number: 1.5, expected: "1,5"},
+		{lang: "fr", number: 3_700_000.0, expected: "3,7m"},
+	}
+
+	for _, test := range tests {
+		useLanguage(t, test.lang)
+
+		if result := compactNumber(test.number); result != test.expected {
+			t.Errorf("%s %v: expected %q, got %q", test.lang, test.number, test.expected, result)
+		}
+	}
+}
+
+func TestSignedPercent(t *testing.T) {
+	tests := []struct {
+		lang     string
+		percent  any
+		expected string
+		class    string
+	}{
+		{lang: "en", percent: 1.5, expected: "+1.5%", class: "color-positive"},
+		{lang: "en", percent: -3.25, expected: "-3.3%", class: "color-negative"},
+		{lang: "en", percent: 0.04, expected: "0.0%", class: ""},
+		{lang: "en", percent: -0.04, expected: "0.0%", class: ""},
+		{lang: "en", percent: 12, expected: "+12.0%", class: "color-positive"},
+		{lang: "de", percent: 1.5, expected: "+1,5%", class: "color-positive"},
+		{lang: "de", percent: -1234.5, expected: "-1.234,5%", class: "color-negative"},
+	}
+
+	for _, test := range tests {
+		useLanguage(t, test.lang)
+
+		if result := signedPercent(test.percent); result != test.expected {
+			t.Errorf("%s %v: expected %q, got %q", test.lang, test.percent, test.expected, result)
+		}
+
+		if class := percentClass(test.percent); class != test.class {
+			t.Errorf("%s %v: expected class %q, got %q", test.lang, test.percent, test.class, class)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		lang     string
+		bytes    any
+		expected string
+	}{
+		{lang: "en", bytes: 512, expected: "512 B"},
+		{lang: "en", bytes: 1536, expected: "1.5 KiB"},
+		{lang: "en", bytes: uint64(5 << 30), expected: "5.0 GiB"},
+		{lang: "en", bytes: 3.5 * (1 << 20), expected: "3.5 MiB"},
+		{lang: "en", bytes: float64(1 << 60), expected: "1,024.0 PiB"},
+		{lang: "de", bytes: 1536, expected: "1,5 KiB"},
+		{lang: "de", bytes: 512, expected: "512 B"},
+	}
+
+	for _, test := range tests {
+		useLanguage(t, test.lang)
+
+		if result := formatBytes(test.bytes); result != test.expected {
+			t.Errorf("%s %v: expected %q, got %q", test.lang, test.bytes, test.expected, result)
+		}
+	}
+}
+
+func TestURLHost(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://www.github.com/glanceapp", expected: "github.com"},
+		{url: "http://news.ycombinator.com:8080/item?id=1", expected: "news.ycombinator.com"},
+		{url: "https://[::1]:8080/", expected: "::1"},
+		{url: "not a url", expected: "not a url"},
+		{url: "/relative/path", expected: "/relative/path"},
+		{url: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		if result := urlHost(test.url); result != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.url, test.expected, result)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		length   int
+		s        string
+		expected string
+	}{
+		{length: 10, s: "short", expected: "short"},
+		{length: 5, s: "exact", expected: "exact"},
+		{length: 6, s: "longer text", expected: "longe…"},
+		{length: 7, s: "longer text", expected: "longer…"},
+		{length: 3, s: "日本語のテキスト", expected: "日本…"},
+		{length: 4, s: "héllo wörld", expected: "hél…"},
+		{length: 1, s: "abc", expected: "…"},
+		{length: 0, s: "abc", expected: ""},
+	}
+
+	for _, test := range tests {
+		if result := truncate(test.length, test.s); result != test.expected {
+			t.Errorf("truncate %d %q: expected %q, got %q", test.length, test.s, test.expected, result)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		count    any
+		expected string
+	}{
+		{count: 1, expected: "item"},
+		{count: 1.0, expected: "item"},
+		{count: 0, expected: "items"},
+		{count: 2, expected: "items"},
+		{count: 1.5, expected: "items"},
+	}
+
+	for _, test := range tests {
+		if result := pluralize(test.count, "item", "items"); result != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.count, test.expected, result)
+		}
+	}
+}
+
+func TestUserTemplatesHaveSharedFunctions(t *testing.T) {
+	useLanguage(t, "de")
+
+	tmpl, err := CompileUserTemplate("test", `{{ compactNumber .Count }} {{ pluralize .Count "Stern" "Sterne" }}, {{ formatBytes .Size }}, {{ .Title | truncate 6 }} ({{ urlHost .URL }})`, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output strings.Builder
+	err = tmpl.Execute(&output, map[string]any{
+		"Count": 12_400.0,
+		"Size":  2048,
+		"Title": "Glance dashboard",
+		"URL":   "https://www.github.com/glanceapp/glance",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "12,4k Sterne, 2,0 KiB, Glanc… (github.com)"; output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
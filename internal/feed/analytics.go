@@ -0,0 +1,330 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AnalyticsPage struct {
+	Path     string
+	Visitors int
+}
+
+type AnalyticsSummary struct {
+	VisitorsToday     int
+	VisitorsYesterday int
+	// unique visitors across the whole period rather than the sum of every day
+	Visitors30Days         int
+	VisitorsPrevious30Days int
+	// one for each of the last 30 days, oldest first and ending with today
+	DailyVisitors []int
+	TopPages      []AnalyticsPage
+}
+
+type AnalyticsRequest struct {
+	Provider string
+	URL      string
+	Token    string
+	SiteID   string
+	// how many of the top pages to get, none when 0
+	Pages    int
+	Location *time.Location
+}
+
+func FetchAnalyticsSummary(ctx context.Context, request AnalyticsRequest) (*AnalyticsSummary, error) {
+	if request.Location == nil {
+		request.Location = time.Local
+	}
+
+	var summary *AnalyticsSummary
+	var err error
+
+	switch request.Provider {
+	case "plausible":
+		summary, err = fetchPlausibleSummary(ctx, request)
+	case "umami":
+		summary, err = fetchUmamiSummary(ctx, request)
+	default:
+		return nil, fmt.Errorf("unknown analytics provider: %s", request.Provider)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	return summary, nil
+}
+
+// the visitors of the last 60 days are fetched so that today and yesterday can be
+// compared and the last 30 of them can be shown in a chart, with days which are
+// missing from visitorsByDay being ones without any visitors
+func setDailyVisitors(summary *AnalyticsSummary, today time.Time, visitorsByDay map[string]int) {
+	daily := make([]int, 60)
+
+	for i := range daily {
+		daily[i] = visitorsByDay[today.AddDate(0, 0, i-59).Format(time.DateOnly)]
+	}
+
+	summary.VisitorsToday = daily[59]
+	summary.VisitorsYesterday = daily[58]
+	summary.DailyVisitors = daily[30:]
+}
+
+type plausibleAggregateResponseJson struct {
+	Results struct {
+		Visitors struct {
+			Value int `json:"value"`
+		} `json:"visitors"`
+	} `json:"results"`
+}
+
+type plausibleTimeseriesResponseJson struct {
+	Results []struct {
+		Date     string `json:"date"`
+		Visitors int    `json:"visitors"`
+	} `json:"results"`
+}
+
+type plausibleBreakdownResponseJson struct {
+	Results []struct {
+		Page     string `json:"page"`
+		Visitors int    `json:"visitors"`
+	} `json:"results"`
+}
+
+func newPlausibleRequest(ctx context.Context, request AnalyticsRequest, endpoint string, query url.Values) *http.Request {
+	query.Set("site_id", request.SiteID)
+
+	httpRequest, _ := http.NewRequestWithContext(ctx, "GET", request.URL+"/api/v1/stats/"+endpoint+"?"+query.Encode(), nil)
+	httpRequest.Header.Set("Authorization", "Bearer "+request.Token)
+
+	return httpRequest
+}
+
+func plausibleDateRange(from, to time.Time) string {
+	return from.Format(time.DateOnly) + "," + to.Format(time.DateOnly)
+}
+
+func fetchPlausibleVisitors(ctx context.Context, request AnalyticsRequest, from, to time.Time) (int, error) {
+	query := url.Values{}
+	query.Set("period", "custom")
+	query.Set("date", plausibleDateRange(from, to))
+	query.Set("metrics", "visitors")
+
+	response, err := decodeJsonFromRequest[plausibleAggregateResponseJson](defaultClient(), newPlausibleRequest(ctx, request, "aggregate", query))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Results.Visitors.Value, nil
+}
+
+// Plausible works with dates in the time zone of the site, which is assumed to be the same as the one of glance
+func fetchPlausibleSummary(ctx context.Context, request AnalyticsRequest) (*AnalyticsSummary, error) {
+	today := time.Now().In(request.Location)
+	summary := &AnalyticsSummary{}
+
+	query := url.Values{}
+	query.Set("period", "custom")
+	query.Set("date", plausibleDateRange(today.AddDate(0, 0, -59), today))
+	query.Set("metrics", "visitors")
+
+	timeseries, err := decodeJsonFromRequest[plausibleTimeseriesResponseJson](defaultClient(), newPlausibleRequest(ctx, request, "timeseries", query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	visitorsByDay := make(map[string]int, len(timeseries.Results))
+
+	for _, day := range timeseries.Results {
+		visitorsByDay[day.Date] = day.Visitors
+	}
+
+	setDailyVisitors(summary, today, visitorsByDay)
+
+	if summary.Visitors30Days, err = fetchPlausibleVisitors(ctx, request, today.AddDate(0, 0, -29), today); err != nil {
+		return nil, err
+	}
+
+	if summary.VisitorsPrevious30Days, err = fetchPlausibleVisitors(ctx, request, today.AddDate(0, 0, -59), today.AddDate(0, 0, -30)); err != nil {
+		return nil, err
+	}
+
+	if request.Pages <= 0 {
+		return summary, nil
+	}
+
+	query = url.Values{}
+	query.Set("period", "30d")
+	query.Set("property", "event:page")
+	query.Set("metrics", "visitors")
+	query.Set("limit", strconv.Itoa(request.Pages))
+
+	breakdown, err := decodeJsonFromRequest[plausibleBreakdownResponseJson](defaultClient(), newPlausibleRequest(ctx, request, "breakdown", query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range breakdown.Results {
+		summary.TopPages = append(summary.TopPages, AnalyticsPage{Path: page.Page, Visitors: page.Visitors})
+	}
+
+	return summary, nil
+}
+
+// older versions of Umami return each stat as an object with the value
+// of the previous period, newer ones return just the number
+type umamiStatValue int
+
+func (v *umamiStatValue) UnmarshalJSON(data []byte) error {
+	var number float64
+
+	if err := json.Unmarshal(data, &number); err == nil {
+		*v = umamiStatValue(number)
+		return nil
+	}
+
+	var object struct {
+		Value float64 `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	*v = umamiStatValue(object.Value)
+
+	return nil
+}
+
+type umamiStatsResponseJson struct {
+	Visitors umamiStatValue `json:"visitors"`
+}
+
+type umamiPageviewsResponseJson struct {
+	// sessions are the visitors
+	Sessions []struct {
+		X string `json:"x"`
+		Y int    `json:"y"`
+	} `json:"sessions"`
+}
+
+type umamiMetricsResponseJson []struct {
+	X string `json:"x"`
+	Y int    `json:"y"`
+}
+
+const umamiCloudURL = "https://api.umami.is/v1"
+
+func newUmamiRequest(ctx context.Context, request AnalyticsRequest, endpoint string, query url.Values) *http.Request {
+	base := request.URL + "/api"
+
+	if request.URL == umamiCloudURL {
+		base = request.URL
+	}
+
+	httpRequest, _ := http.NewRequestWithContext(ctx, "GET", base+"/websites/"+url.PathEscape(request.SiteID)+"/"+endpoint+"?"+query.Encode(), nil)
+
+	// the cloud version uses API keys while self-hosted instances use the token from logging in
+	if request.URL == umamiCloudURL {
+		httpRequest.Header.Set("x-umami-api-key", request.Token)
+	} else {
+		httpRequest.Header.Set("Authorization", "Bearer "+request.Token)
+	}
+
+	return httpRequest
+}
+
+func umamiTimeRange(from, to time.Time) url.Values {
+	query := url.Values{}
+	query.Set("startAt", strconv.FormatInt(from.UnixMilli(), 10))
+	query.Set("endAt", strconv.FormatInt(to.UnixMilli(), 10))
+
+	return query
+}
+
+func fetchUmamiVisitors(ctx context.Context, request AnalyticsRequest, from, to time.Time) (int, error) {
+	response, err := decodeJsonFromRequest[umamiStatsResponseJson](defaultClient(), newUmamiRequest(ctx, request, "stats", umamiTimeRange(from, to)))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(response.Visitors), nil
+}
+
+func fetchUmamiSummary(ctx context.Context, request AnalyticsRequest) (*AnalyticsSummary, error) {
+	now := time.Now().In(request.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, request.Location)
+	summary := &AnalyticsSummary{}
+
+	query := umamiTimeRange(today.AddDate(0, 0, -59), now)
+	query.Set("unit", "day")
+
+	// the time zone has to be an IANA name, which the local one doesn't have
+	if request.Location != time.Local {
+		query.Set("timezone", request.Location.String())
+	}
+
+	pageviews, err := decodeJsonFromRequest[umamiPageviewsResponseJson](defaultClient(), newUmamiRequest(ctx, request, "pageviews", query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	visitorsByDay := make(map[string]int, len(pageviews.Sessions))
+
+	for _, session := range pageviews.Sessions {
+		day, _, _ := strings.Cut(strings.Replace(session.X, "T", " ", 1), " ")
+		visitorsByDay[day] = session.Y
+	}
+
+	setDailyVisitors(summary, today, visitorsByDay)
+
+	if summary.Visitors30Days, err = fetchUmamiVisitors(ctx, request, today.AddDate(0, 0, -29), now); err != nil {
+		return nil, err
+	}
+
+	if summary.VisitorsPrevious30Days, err = fetchUmamiVisitors(ctx, request, today.AddDate(0, 0, -59), today.AddDate(0, 0, -29)); err != nil {
+		return nil, err
+	}
+
+	if request.Pages <= 0 {
+		return summary, nil
+	}
+
+	query = umamiTimeRange(today.AddDate(0, 0, -29), now)
+	query.Set("type", "url")
+	query.Set("limit", strconv.Itoa(request.Pages))
+
+	metrics, err := decodeJsonFromRequest[umamiMetricsResponseJson](defaultClient(), newUmamiRequest(ctx, request, "metrics", query))
+
+	if err != nil {
+		// the url type was renamed to path in Umami 3
+		query.Set("type", "path")
+		metrics, err = decodeJsonFromRequest[umamiMetricsResponseJson](defaultClient(), newUmamiRequest(ctx, request, "metrics", query))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range metrics {
+		if i >= request.Pages {
+			break
+		}
+
+		summary.TopPages = append(summary.TopPages, AnalyticsPage{Path: metrics[i].X, Visitors: metrics[i].Y})
+	}
+
+	return summary, nil
+}
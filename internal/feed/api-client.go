@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NewVersionedBaseURL appends /v{version} to base, ignoring any trailing slashes
+// it has. A version of 0 or less returns base as it is for unversioned APIs.
+func NewVersionedBaseURL(base string, version int) string {
+	if version <= 0 {
+		return base
+	}
+
+	return strings.TrimRight(base, "/") + "/v" + strconv.Itoa(version)
+}
+
+// APIClient builds requests for an API whose paths are under a versioned base URL,
+// so that the version is set in one place rather than in every URL. It sends them
+// through the client it was created with, which makes it usable anywhere a
+// RequestDoer is.
+type APIClient struct {
+	base    *url.URL
+	version int
+	client  RequestDoer
+}
+
+func NewAPIClient(base *url.URL, version int, client RequestDoer) *APIClient {
+	return &APIClient{
+		base:    base,
+		version: version,
+		client:  client,
+	}
+}
+
+// WithVersion returns a client for another version of the same API
+func (c *APIClient) WithVersion(version int) *APIClient {
+	return NewAPIClient(c.base, version, c.client)
+}
+
+func (c *APIClient) Do(request *http.Request) (*http.Response, error) {
+	return c.client.Do(request)
+}
+
+// URL resolves path against the versioned base, with path being treated as
+// relative to it whether it starts with a slash or not. Dot segments are dropped
+// so that the path can't end up outside of the versioned base.
+func (c *APIClient) URL(path string, query url.Values) *url.URL {
+	versioned := *c.base
+	versioned.Path = NewVersionedBaseURL(c.base.Path, c.version)
+	versioned.RawPath = ""
+
+	segments := slices.DeleteFunc(strings.Split(strings.Trim(path, "/"), "/"), func(segment string) bool {
+		return segment == "." || segment == ".."
+	})
+	resolved := versioned.JoinPath(segments...)
+
+	// JoinPath keeps trailing slashes of the base but not of the path, which some APIs care about
+	if strings.HasSuffix(path, "/") && !strings.HasSuffix(resolved.Path, "/") {
+		resolved.Path += "/"
+	}
+
+	resolved.RawQuery = query.Encode()
+
+	return resolved
+}
+
+func (c *APIClient) Get(path string, query url.Values) *http.Request {
+	request, _ := http.NewRequest("GET", c.URL(path, query).String(), nil)
+	return request
+}
+
+// Post creates a request with body encoded as JSON
+func (c *APIClient) Post(path string, body any) (*http.Request, error) {
+	encoded, err := json.Marshal(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", c.URL(path, nil).String(), bytes.NewReader(encoded))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	return request, nil
+}
+
+func (c *APIClient) Delete(path string) *http.Request {
+	request, _ := http.NewRequest("DELETE", c.URL(path, nil).String(), nil)
+	return request
+}
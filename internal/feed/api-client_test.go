@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewVersionedBaseURL(t *testing.T) {
+	tests := []struct {
+		base     string
+		version  int
+		expected string
+	}{
+		{base: "https://api.example.com", version: 1, expected: "https://api.example.com/v1"},
+		{base: "https://api.example.com/", version: 2, expected: "https://api.example.com/v2"},
+		{base: "https://api.example.com/api//", version: 3, expected: "https://api.example.com/api/v3"},
+		{base: "https://api.example.com/", version: 0, expected: "https://api.example.com/"},
+		{base: "https://api.example.com", version: -1, expected: "https://api.example.com"},
+	}
+
+	for _, test := range tests {
+		if versioned := NewVersionedBaseURL(test.base, test.version); versioned != test.expected {
+			t.Errorf("%s with version %d: expected %s, got %s", test.base, test.version, test.expected, versioned)
+		}
+	}
+}
+
+func TestAPIClientURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		version  int
+		path     string
+		query    url.Values
+		expected string
+	}{
+		{name: "relative path", base: "https://api.example.com", version: 1, path: "users", expected: "https://api.example.com/v1/users"},
+		{name: "absolute path", base: "https://api.example.com", version: 1, path: "/users/42", expected: "https://api.example.com/v1/users/42"},
+		{name: "base with trailing slash", base: "https://api.example.com/", version: 2, path: "/users", expected: "https://api.example.com/v2/users"},
+		{name: "base with a path", base: "https://example.com/api/", version: 1, path: "users", expected: "https://example.com/api/v1/users"},
+		{name: "path with trailing slash", base: "https://api.example.com", version: 1, path: "users/", expected: "https://api.example.com/v1/users/"},
+		{name: "empty path", base: "https://api.example.com", version: 1, path: "", expected: "https://api.example.com/v1"},
+		{name: "unversioned", base: "https://api.example.com/api", version: 0, path: "/users", expected: "https://api.example.com/api/users"},
+		{name: "path can't go above the base", base: "https://api.example.com", version: 1, path: "../admin", expected: "https://api.example.com/v1/admin"},
+		{name: "escaped segments", base: "https://api.example.com", version: 1, path: "users/a b", expected: "https://api.example.com/v1/users/a%20b"},
+		{name: "query", base: "https://api.example.com", version: 1, path: "users", query: url.Values{"page": {"2"}}, expected: "https://api.example.com/v1/users?page=2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, err := url.Parse(test.base)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resolved := NewAPIClient(base, test.version, nil).URL(test.path, test.query).String(); resolved != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestAPIClientWithVersion(t *testing.T) {
+	base, _ := url.Parse("https://api.example.com")
+	v1 := NewAPIClient(base, 1, nil)
+	v2 := v1.WithVersion(2)
+
+	if resolved := v2.URL("users", nil).String(); resolved != "https://api.example.com/v2/users" {
+		t.Errorf("expected the sub-client to use version 2, got %s", resolved)
+	}
+
+	if resolved := v1.URL("users", nil).String(); resolved != "https://api.example.com/v1/users" {
+		t.Errorf("expected the original client to keep version 1, got %s", resolved)
+	}
+
+	if base.String() != "https://api.example.com" {
+		t.Errorf("expected the base URL to be left unchanged, got %s", base)
+	}
+}
+
+func TestAPIClientRequests(t *testing.T) {
+	type received struct {
+		method, path, contentType string
+		body                      map[string]string
+	}
+
+	requests := make(chan received, 3)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		request := received{method: r.Method, path: r.URL.RequestURI(), contentType: r.Header.Get("Content-Type")}
+		json.NewDecoder(r.Body).Decode(&request.body)
+		requests <- request
+	})
+
+	base, _ := url.Parse(server.URL)
+	client := NewAPIClient(base, 3, server.Client())
+
+	post, err := client.Post("/items", map[string]string{"name": "first"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, request := range []*http.Request{client.Get("/items", url.Values{"limit": {"5"}}), post, client.Delete("/items/1")} {
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response.Body.Close()
+	}
+
+	expected := []received{
+		{method: http.MethodGet, path: "/v3/items?limit=5"},
+		{method: http.MethodPost, path: "/v3/items", contentType: "application/json", body: map[string]string{"name": "first"}},
+		{method: http.MethodDelete, path: "/v3/items/1"},
+	}
+
+	for _, want := range expected {
+		got := <-requests
+
+		if got.method != want.method || got.path != want.path || got.contentType != want.contentType || got.body["name"] != want.body["name"] {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+
+	if _, err := client.Post("/items", func() {}); err == nil {
+		t.Error("expected a body which can't be encoded to fail")
+	}
+}
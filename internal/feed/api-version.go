@@ -0,0 +1,80 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+var ErrAPIVersionMismatch = errors.New("unexpected API version")
+
+// headers checked when no header has been specified for the request
+var commonAPIVersionHeaders = []string{"X-API-Version", "API-Version"}
+
+type apiVersionHeaderContextKey struct{}
+
+// WithAPIVersionExtractor stores the value of header from the first successful
+// response in store, allowing code making later requests to the same API to
+// adapt to its version. Subsequent responses don't overwrite it.
+func WithAPIVersionExtractor(header string, store *atomic.Value) DecodeOption {
+	return func(o *decodeOptions) {
+		o.apiVersionHeader = header
+		o.responseChecks = append(o.responseChecks, func(response *http.Response) error {
+			if version := response.Header.Get(header); version != "" {
+				store.CompareAndSwap(nil, version)
+			}
+
+			return nil
+		})
+	}
+}
+
+func GetAPIVersion(store *atomic.Value) (string, bool) {
+	version, ok := store.Load().(string)
+	return version, ok
+}
+
+// AssertAPIVersion fails the request with ErrAPIVersionMismatch if the version
+// reported by the API differs from expected, the version is read from the header
+// given to WithAPIVersionExtractor or from X-API-Version/API-Version otherwise
+func AssertAPIVersion(expected string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.responseChecks = append(o.responseChecks, func(response *http.Response) error {
+			version := apiVersionFromResponse(response, o.apiVersionHeader)
+
+			if version != expected {
+				return fmt.Errorf("%w for %s: expected %s, got %q", ErrAPIVersionMismatch, response.Request.URL, expected, version)
+			}
+
+			return nil
+		})
+	}
+}
+
+func withAPIVersionHeader(request *http.Request, header string) *http.Request {
+	if header == "" {
+		return request
+	}
+
+	return request.WithContext(context.WithValue(request.Context(), apiVersionHeaderContextKey{}, header))
+}
+
+func apiVersionFromResponse(response *http.Response, header string) string {
+	if header == "" && response.Request != nil {
+		header, _ = response.Request.Context().Value(apiVersionHeaderContextKey{}).(string)
+	}
+
+	if header != "" {
+		return response.Header.Get(header)
+	}
+
+	for _, header := range commonAPIVersionHeaders {
+		if version := response.Header.Get(header); version != "" {
+			return version
+		}
+	}
+
+	return ""
+}
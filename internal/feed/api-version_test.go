@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newAPIVersionTestServer reports the versions in turn, one per request
+func newAPIVersionTestServer(t *testing.T, header string, versions ...string) *httptest.Server {
+	t.Helper()
+
+	requests := 0
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(header, versions[min(requests, len(versions)-1)])
+		requests++
+		fmt.Fprint(w, `{}`)
+	})
+}
+
+func TestAPIVersionExtractorKeepsFirstVersion(t *testing.T) {
+	server := newAPIVersionTestServer(t, "X-Service-Version", "2.1", "3.0")
+	var store atomic.Value
+
+	if _, ok := GetAPIVersion(&store); ok {
+		t.Fatal("expected no version before the first response")
+	}
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+		if _, err := decodeJsonFromRequest[map[string]any](server.Client(), request, WithAPIVersionExtractor("X-Service-Version", &store)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if version, ok := GetAPIVersion(&store); !ok || version != "2.1" {
+		t.Errorf("expected the version of the first response, got %q", version)
+	}
+}
+
+func TestAssertAPIVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		options  func(*atomic.Value) []DecodeOption
+		expected string
+		fails    bool
+	}{
+		{
+			name:     "common header",
+			header:   "X-API-Version",
+			options:  func(*atomic.Value) []DecodeOption { return nil },
+			expected: "1",
+		},
+		{
+			name:   "extractor header",
+			header: "X-Service-Version",
+			options: func(store *atomic.Value) []DecodeOption {
+				return []DecodeOption{WithAPIVersionExtractor("X-Service-Version", store)}
+			},
+			expected: "1",
+		},
+		{
+			name:     "mismatch",
+			header:   "API-Version",
+			options:  func(*atomic.Value) []DecodeOption { return nil },
+			expected: "2",
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newAPIVersionTestServer(t, test.header, "1")
+			request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+			var store atomic.Value
+			options := append(test.options(&store), AssertAPIVersion(test.expected))
+			_, err := decodeJsonFromRequest[map[string]any](server.Client(), request, options...)
+
+			if test.fails && !errors.Is(err, ErrAPIVersionMismatch) {
+				t.Errorf("expected ErrAPIVersionMismatch, got %v", err)
+			}
+
+			if !test.fails && err != nil {
+				t.Errorf("expected the assertion to pass, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoggingRoundTripperIncludesAPIVersion(t *testing.T) {
+	server := newAPIVersionTestServer(t, "X-Service-Version", "4.2")
+
+	var output bytes.Buffer
+	client := &http.Client{Transport: NewLoggingRoundTripper(server.Client().Transport, &output)}
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	var store atomic.Value
+
+	if _, err := decodeJsonFromRequest[map[string]any](client, request, WithAPIVersionExtractor("X-Service-Version", &store)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "api_version=4.2") {
+		t.Errorf("expected the version to be logged, got %q", output.String())
+	}
+}
@@ -0,0 +1,153 @@
+package feed
+
+import (
+	"cmp"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+type hostBandwidth struct {
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// BandwidthMetricsRoundTripper counts the bytes of the bodies of requests and
+// responses passing through it for each host, as they are sent and received. Unlike
+// the bandwidth stats, which are counted once a response has been decompressed, it
+// counts what goes over the wire when placed below content decoding, which is where
+// the default clients have one. Headers aren't counted.
+type BandwidthMetricsRoundTripper struct {
+	next  http.RoundTripper
+	hosts sync.Map
+
+	// for the one in the default clients, which only counts once bandwidth stats are enabled
+	onlyWhenEnabled bool
+}
+
+func NewBandwidthMetricsRoundTripper(next http.RoundTripper) *BandwidthMetricsRoundTripper {
+	return &BandwidthMetricsRoundTripper{next: next}
+}
+
+// wireBandwidth counts for every client created by the package, whatever the transport
+var wireBandwidth = &BandwidthMetricsRoundTripper{onlyWhenEnabled: true}
+
+type wireBandwidthRoundTripper struct {
+	next http.RoundTripper
+}
+
+func withWireBandwidthMetrics(next http.RoundTripper) http.RoundTripper {
+	return &wireBandwidthRoundTripper{next: next}
+}
+
+func (rt *wireBandwidthRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	return wireBandwidth.roundTrip(rt.next, request)
+}
+
+// WireBandwidthMetrics returns what's been counted by the default clients since
+// bandwidth stats were enabled
+func WireBandwidthMetrics() *BandwidthMetricsRoundTripper {
+	return wireBandwidth
+}
+
+func (rt *BandwidthMetricsRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	return rt.roundTrip(rt.next, request)
+}
+
+func (rt *BandwidthMetricsRoundTripper) roundTrip(next http.RoundTripper, request *http.Request) (*http.Response, error) {
+	if rt.onlyWhenEnabled && !bandwidthStats.enabled.Load() {
+		return next.RoundTrip(request)
+	}
+
+	counters := rt.countersFor(request.URL.Host)
+
+	if request.Body != nil && request.Body != http.NoBody {
+		request = request.Clone(request.Context())
+		request.Body = &countingReadCloser{ReadCloser: request.Body, count: &counters.sent}
+	}
+
+	response, err := next.RoundTrip(request)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.Body = &countingReadCloser{ReadCloser: response.Body, count: &counters.received}
+
+	return response, nil
+}
+
+func (rt *BandwidthMetricsRoundTripper) countersFor(host string) *hostBandwidth {
+	if counters, ok := rt.hosts.Load(host); ok {
+		return counters.(*hostBandwidth)
+	}
+
+	counters, _ := rt.hosts.LoadOrStore(host, &hostBandwidth{})
+
+	return counters.(*hostBandwidth)
+}
+
+func (rt *BandwidthMetricsRoundTripper) BytesSent(host string) int64 {
+	if counters, ok := rt.hosts.Load(host); ok {
+		return counters.(*hostBandwidth).sent.Load()
+	}
+
+	return 0
+}
+
+func (rt *BandwidthMetricsRoundTripper) BytesReceived(host string) int64 {
+	if counters, ok := rt.hosts.Load(host); ok {
+		return counters.(*hostBandwidth).received.Load()
+	}
+
+	return 0
+}
+
+// Reset starts counting from zero again. What's left of bodies which are still
+// being read when it's called isn't counted.
+func (rt *BandwidthMetricsRoundTripper) Reset() {
+	rt.hosts.Range(func(key, _ any) bool {
+		rt.hosts.Delete(key)
+		return true
+	})
+}
+
+type HostBandwidth struct {
+	Host          string
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// Hosts returns the counts of every host, sorted by host
+func (rt *BandwidthMetricsRoundTripper) Hosts() []HostBandwidth {
+	var hosts []HostBandwidth
+
+	rt.hosts.Range(func(key, value any) bool {
+		counters := value.(*hostBandwidth)
+		hosts = append(hosts, HostBandwidth{
+			Host:          key.(string),
+			BytesSent:     counters.sent.Load(),
+			BytesReceived: counters.received.Load(),
+		})
+		return true
+	})
+
+	slices.SortFunc(hosts, func(a, b HostBandwidth) int {
+		return cmp.Compare(a.Host, b.Host)
+	})
+
+	return hosts
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	count *atomic.Int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
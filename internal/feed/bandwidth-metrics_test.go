@@ -0,0 +1,167 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBandwidthMetricsCountsWireBytes(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(strings.Repeat("a", 10000)))
+	writer.Close()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	})
+
+	metrics := NewBandwidthMetricsRoundTripper(http.DefaultTransport)
+	client := &http.Client{Transport: withContentDecoding(metrics)}
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("hello"))
+	_, body, err := fetchBodyFromRequest(client, request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, _ := url.Parse(server.URL)
+
+	if len(body) != 10000 {
+		t.Errorf("expected the body to be decompressed, got %d bytes", len(body))
+	}
+
+	if sent := metrics.BytesSent(host.Host); sent != 5 {
+		t.Errorf("expected 5 bytes sent, got %d", sent)
+	}
+
+	if received := metrics.BytesReceived(host.Host); received != int64(compressed.Len()) {
+		t.Errorf("expected the %d compressed bytes to be counted, got %d", compressed.Len(), received)
+	}
+
+	metrics.Reset()
+
+	if hosts := metrics.Hosts(); len(hosts) != 0 {
+		t.Errorf("expected nothing after resetting, got %+v", hosts)
+	}
+}
+
+// newPayloadTestServer reads the whole request body and responds with size bytes
+func newPayloadTestServer(t *testing.T, size int) (*httptest.Server, string) {
+	t.Helper()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write(bytes.Repeat([]byte("b"), size))
+	})
+
+	parsed, _ := url.Parse(server.URL)
+
+	return server, parsed.Host
+}
+
+func TestBandwidthMetricsCountsPerHost(t *testing.T) {
+	first, firstHost := newPayloadTestServer(t, 2500)
+	second, secondHost := newPayloadTestServer(t, 100)
+
+	metrics := NewBandwidthMetricsRoundTripper(http.DefaultTransport)
+	client := &http.Client{Transport: metrics}
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodPost, first.URL, strings.NewReader(strings.Repeat("a", 1000)))
+
+		if _, err := getBody(client, request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, second.URL, nil)
+
+	if _, err := getBody(client, request); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []HostBandwidth{
+		{Host: firstHost, BytesSent: 2000, BytesReceived: 5000},
+		{Host: secondHost, BytesSent: 0, BytesReceived: 100},
+	}
+
+	if firstHost > secondHost {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+
+	hosts := metrics.Hosts()
+
+	if len(hosts) != 2 || hosts[0] != expected[0] || hosts[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, hosts)
+	}
+
+	if metrics.BytesSent("unknown.example.com") != 0 || metrics.BytesReceived("unknown.example.com") != 0 {
+		t.Error("expected nothing for a host which wasn't requested")
+	}
+}
+
+func TestWireBandwidthMetricsOnlyCountWhenEnabled(t *testing.T) {
+	resetBandwidthStats(t)
+	WireBandwidthMetrics().Reset()
+	t.Cleanup(WireBandwidthMetrics().Reset)
+
+	server, host := newPayloadTestServer(t, 300)
+	client := &http.Client{Transport: withWireBandwidthMetrics(http.DefaultTransport)}
+
+	bandwidthStats.enabled.Store(false)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	getBody(client, request)
+
+	if received := WireBandwidthMetrics().BytesReceived(host); received != 0 {
+		t.Errorf("expected nothing to be counted while disabled, got %d", received)
+	}
+
+	bandwidthStats.enabled.Store(true)
+	getBody(client, request)
+
+	if received := WireBandwidthMetrics().BytesReceived(host); received != 300 {
+		t.Errorf("expected 300 bytes once enabled, got %d", received)
+	}
+}
+
+func benchmarkWireBandwidthMetrics(b *testing.B, enabled bool) {
+	resetBandwidthStats(b)
+	bandwidthStats.enabled.Store(enabled)
+
+	body := strings.Repeat("a", 10000)
+	transport := withWireBandwidthMetrics(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: request}, nil
+	}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		request, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+		response, err := transport.RoundTrip(request)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+	}
+}
+
+func BenchmarkWireBandwidthMetricsDisabled(b *testing.B) {
+	benchmarkWireBandwidthMetrics(b, false)
+}
+
+func BenchmarkWireBandwidthMetricsEnabled(b *testing.B) {
+	benchmarkWireBandwidthMetrics(b, true)
+}
@@ -0,0 +1,138 @@
+package feed
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var bandwidthStats struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	hosts   map[string]*BandwidthStats
+	widgets map[uint64]*WidgetBandwidthStats
+}
+
+type BandwidthStats struct {
+	Host     string `json:"host"`
+	Requests uint64 `json:"requests"`
+	// the size of the bodies of requests, headers aren't counted
+	BytesSent int64 `json:"bytes-sent"`
+	// the size of the bodies of responses after being decompressed, responses
+	// shared between coalesced requests being counted once for each of them
+	BytesReceived int64 `json:"bytes-received"`
+}
+
+// WidgetBandwidthStats are counted the same way as BandwidthStats, for the
+// requests made with a context from WithWidgetID
+type WidgetBandwidthStats struct {
+	WidgetID      uint64 `json:"-"`
+	Requests      uint64 `json:"requests"`
+	BytesSent     int64  `json:"bytes-sent"`
+	BytesReceived int64  `json:"bytes-received"`
+}
+
+type widgetIDKey struct{}
+
+// WithWidgetID returns a context which attributes the bandwidth used by the
+// requests made with it to the widget with the given ID
+func WithWidgetID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, widgetIDKey{}, id)
+}
+
+func widgetIDFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(widgetIDKey{}).(uint64)
+	return id, ok
+}
+
+// EnableBandwidthStats starts counting how much data is transferred with each host.
+// Counting is disabled by default so that nothing is done for it when unused.
+func EnableBandwidthStats() {
+	bandwidthStats.mu.Lock()
+	if bandwidthStats.hosts == nil {
+		bandwidthStats.hosts = make(map[string]*BandwidthStats)
+		bandwidthStats.widgets = make(map[uint64]*WidgetBandwidthStats)
+	}
+	bandwidthStats.mu.Unlock()
+
+	bandwidthStats.enabled.Store(true)
+}
+
+// GetBandwidthStats returns the totals since bandwidth stats were enabled,
+// with the hosts responses were received from the most first
+func GetBandwidthStats() []BandwidthStats {
+	bandwidthStats.mu.Lock()
+	stats := make([]BandwidthStats, 0, len(bandwidthStats.hosts))
+
+	for _, host := range bandwidthStats.hosts {
+		stats = append(stats, *host)
+	}
+	bandwidthStats.mu.Unlock()
+
+	slices.SortFunc(stats, func(a, b BandwidthStats) int {
+		return cmp.Or(cmp.Compare(b.BytesReceived, a.BytesReceived), cmp.Compare(a.Host, b.Host))
+	})
+
+	return stats
+}
+
+// GetWidgetBandwidthStats returns the totals of each widget since bandwidth stats
+// were enabled, with the widgets which received the most first
+func GetWidgetBandwidthStats() []WidgetBandwidthStats {
+	bandwidthStats.mu.Lock()
+	stats := make([]WidgetBandwidthStats, 0, len(bandwidthStats.widgets))
+
+	for _, widget := range bandwidthStats.widgets {
+		stats = append(stats, *widget)
+	}
+	bandwidthStats.mu.Unlock()
+
+	slices.SortFunc(stats, func(a, b WidgetBandwidthStats) int {
+		return cmp.Or(cmp.Compare(b.BytesReceived, a.BytesReceived), cmp.Compare(a.WidgetID, b.WidgetID))
+	})
+
+	return stats
+}
+
+func recordBandwidth(request *http.Request, received int) {
+	if !bandwidthStats.enabled.Load() {
+		return
+	}
+
+	bandwidthStats.mu.Lock()
+	defer bandwidthStats.mu.Unlock()
+
+	host := request.URL.Host
+	stats, ok := bandwidthStats.hosts[host]
+
+	if !ok {
+		stats = &BandwidthStats{Host: host}
+		bandwidthStats.hosts[host] = stats
+	}
+
+	sent := max(request.ContentLength, 0)
+
+	stats.Requests++
+	stats.BytesSent += sent
+	stats.BytesReceived += int64(received)
+
+	id, ok := widgetIDFromContext(request.Context())
+
+	if !ok {
+		return
+	}
+
+	widget, ok := bandwidthStats.widgets[id]
+
+	if !ok {
+		widget = &WidgetBandwidthStats{WidgetID: id}
+		bandwidthStats.widgets[id] = widget
+	}
+
+	widget.Requests++
+	widget.BytesSent += sent
+	widget.BytesReceived += int64(received)
+}
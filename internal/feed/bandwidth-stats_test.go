@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func resetBandwidthStats(t testing.TB) {
+	t.Helper()
+
+	EnableBandwidthStats()
+
+	forget := func() {
+		bandwidthStats.mu.Lock()
+		clear(bandwidthStats.hosts)
+		clear(bandwidthStats.widgets)
+		bandwidthStats.mu.Unlock()
+	}
+
+	forget()
+	t.Cleanup(func() {
+		forget()
+		bandwidthStats.enabled.Store(false)
+	})
+}
+
+func TestBandwidthStatsPerHostAndWidget(t *testing.T) {
+	resetBandwidthStats(t)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":"0123456789"}`)
+	})
+
+	send := func(ctx context.Context, body string) {
+		t.Helper()
+
+		request, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(body))
+
+		if _, err := decodeJsonFromRequest[map[string]string](server.Client(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(WithWidgetID(context.Background(), 1), "ab")
+	send(WithWidgetID(context.Background(), 1), "cd")
+	send(WithWidgetID(context.Background(), 2), "efgh")
+	send(context.Background(), "")
+
+	host, _ := url.Parse(server.URL)
+	hosts := GetBandwidthStats()
+
+	if len(hosts) != 1 || hosts[0].Host != host.Host || hosts[0].Requests != 4 || hosts[0].BytesSent != 8 || hosts[0].BytesReceived != 4*21 {
+		t.Errorf("unexpected host stats: %+v", hosts)
+	}
+
+	widgets := GetWidgetBandwidthStats()
+
+	if len(widgets) != 2 {
+		t.Fatalf("expected stats for 2 widgets, got %+v", widgets)
+	}
+
+	expected := []WidgetBandwidthStats{
+		{WidgetID: 1, Requests: 2, BytesSent: 4, BytesReceived: 2 * 21},
+		{WidgetID: 2, Requests: 1, BytesSent: 4, BytesReceived: 21},
+	}
+
+	for i := range expected {
+		if widgets[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], widgets[i])
+		}
+	}
+}
+
+func TestBandwidthStatsDisabled(t *testing.T) {
+	resetBandwidthStats(t)
+	bandwidthStats.enabled.Store(false)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+
+	request, _ := http.NewRequestWithContext(WithWidgetID(context.Background(), 1), http.MethodGet, server.URL, nil)
+
+	if _, err := decodeJsonFromRequest[map[string]string](server.Client(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	if hosts, widgets := GetBandwidthStats(), GetWidgetBandwidthStats(); len(hosts) != 0 || len(widgets) != 0 {
+		t.Errorf("expected nothing to be counted, got %+v and %+v", hosts, widgets)
+	}
+}
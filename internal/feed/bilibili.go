@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -21,18 +22,18 @@ type bilibiliSpaceResponseJson struct {
 	} `json:"data"`
 }
 
-func FetchBilibiliUploads(uidList []int) (Videos, error) {
+func FetchBilibiliUploads(ctx context.Context, uidList []int) (Videos, error) {
 	requests := make([]*http.Request, 0, len(uidList))
 	u := "https://app.bilibili.com/x/v2/space/archive/cursor?vmid="
 	for i := range uidList {
-		request, _ := http.NewRequest("GET", u+strconv.Itoa(uidList[i]), nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", u+strconv.Itoa(uidList[i]), nil)
 		request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
 		request.Header.Set("Referer", "https://www.bilibili.com/")
 
 		requests = append(requests, request)
 	}
 
-	job := newJob(decodeJsonFromRequestTask[bilibiliSpaceResponseJson](defaultClient), requests).withWorkers(30)
+	job := newJob(decodeJsonFromRequestTask[bilibiliSpaceResponseJson](defaultClient()), requests).withWorkers(30)
 
 	responses, errs, err := workerPoolDo(job)
 
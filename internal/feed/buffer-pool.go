@@ -0,0 +1,31 @@
+package feed
+
+import (
+	"bytes"
+	"sync"
+)
+
+// buffers which grew past this are left for the garbage collector rather
+// than being pooled, so that one large response doesn't keep its memory alive
+const maxPooledBufferSize = 1024 * 1024
+
+var bodyBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBodyBuffer() *bytes.Buffer {
+	buffer := bodyBufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+
+	return buffer
+}
+
+func putBodyBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	bodyBufferPool.Put(buffer)
+}
@@ -0,0 +1,130 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bufferPoolBenchmarkItem struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// around 200KB of JSON, large enough for the growth of io.ReadAll to show
+func newBufferPoolBenchmarkServer(b *testing.B) *httptest.Server {
+	b.Helper()
+
+	items := make([]string, 0, 4000)
+
+	for i := range cap(items) {
+		items = append(items, fmt.Sprintf(`{"id":%d,"title":"%s"}`, i, strings.Repeat("x", 32)))
+	}
+
+	body := "[" + strings.Join(items, ",") + "]"
+
+	return newTestServer(b, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	})
+}
+
+// the way bodies were read before the buffers were pooled, for comparison
+func BenchmarkDecodeJsonReadAll(b *testing.B) {
+	server := newBufferPoolBenchmarkServer(b)
+	client := server.Client()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		response, err := client.Get(server.URL)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var items []bufferPoolBenchmarkItem
+
+		if err := json.Unmarshal(body, &items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeJsonPooledBuffer(b *testing.B) {
+	server := newBufferPoolBenchmarkServer(b)
+	client := server.Client()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+		if _, err := decodeJsonFromRequest[[]bufferPoolBenchmarkItem](client, request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFetchBodyReadAll(b *testing.B) {
+	server := newBufferPoolBenchmarkServer(b)
+	client := server.Client()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		response, err := client.Get(server.URL)
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		_, err = io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFetchBodyPooledBuffer(b *testing.B) {
+	server := newBufferPoolBenchmarkServer(b)
+	client := server.Client()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+		if _, _, err := fetchBodyFromRequest(client, request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBodyBufferPoolDropsLargeBuffers(t *testing.T) {
+	buffer := getBodyBuffer()
+	buffer.Grow(maxPooledBufferSize + 1)
+	buffer.WriteString("leftover")
+	putBodyBuffer(buffer)
+
+	// whatever comes out of the pool is empty and not the oversized buffer
+	for range 10 {
+		reused := getBodyBuffer()
+
+		if reused.Len() != 0 || reused.Cap() > maxPooledBufferSize {
+			t.Fatalf("expected an empty buffer of at most %d bytes, got %d bytes with a capacity of %d", maxPooledBufferSize, reused.Len(), reused.Cap())
+		}
+	}
+}
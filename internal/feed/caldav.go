@@ -0,0 +1,437 @@
+package feed
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrCalDAVConflict is returned when a to-do was changed on the server since it was fetched
+var ErrCalDAVConflict = errors.New("to-do was changed on the CalDAV server")
+
+const calDAVTimeFormat = "20060102T150405Z"
+
+// CalDAVTodo is a VTODO stored on a CalDAV server, such as a task of Nextcloud Tasks.
+// Only the properties used by glance are parsed, the others are kept as they are
+// when the to-do is changed so that nothing set by other clients is lost.
+type CalDAVTodo struct {
+	// of the resource, empty for to-dos which haven't been created yet
+	URL       string
+	ETag      string
+	UID       string
+	Summary   string
+	Completed bool
+	Created   time.Time
+	// the X-APPLE-SORT-ORDER used by Nextcloud Tasks and Apple Reminders, 0 when unset
+	SortOrder int
+	// unfolded content lines of the whole calendar object
+	lines []string
+}
+
+// NewCalDAVTodo returns a to-do which is created on the server once passed to PutTodo
+func NewCalDAVTodo(summary string, now time.Time) *CalDAVTodo {
+	b := make([]byte, 16)
+	rand.Read(b)
+	uid := hex.EncodeToString(b)
+	stamp := now.UTC().Format(calDAVTimeFormat)
+
+	return &CalDAVTodo{
+		UID:     uid,
+		Summary: summary,
+		Created: now,
+		lines: []string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//glance//todo//EN",
+			"BEGIN:VTODO",
+			"UID:" + uid,
+			"DTSTAMP:" + stamp,
+			"CREATED:" + stamp,
+			"SUMMARY:" + escapeICalText(summary),
+			"STATUS:NEEDS-ACTION",
+			"END:VTODO",
+			"END:VCALENDAR",
+		},
+	}
+}
+
+// SetCompleted marks the to-do as completed or as still needing action
+func (t *CalDAVTodo) SetCompleted(completed bool, now time.Time) {
+	stamp := now.UTC().Format(calDAVTimeFormat)
+	t.removeProperty("COMPLETED")
+	t.removeProperty("PERCENT-COMPLETE")
+
+	if completed {
+		t.setProperty("STATUS", "COMPLETED")
+		t.setProperty("COMPLETED", stamp)
+		t.setProperty("PERCENT-COMPLETE", "100")
+	} else {
+		t.setProperty("STATUS", "NEEDS-ACTION")
+	}
+
+	t.setProperty("LAST-MODIFIED", stamp)
+	t.setProperty("DTSTAMP", stamp)
+	t.Completed = completed
+}
+
+func (t *CalDAVTodo) SetSortOrder(order int) {
+	t.setProperty("X-APPLE-SORT-ORDER", strconv.Itoa(order))
+	t.SortOrder = order
+}
+
+// todoBounds returns the indexes of the BEGIN and END lines of the first VTODO
+func (t *CalDAVTodo) todoBounds() (int, int) {
+	begin, end := -1, -1
+
+	for i, line := range t.lines {
+		if strings.EqualFold(line, "BEGIN:VTODO") && begin < 0 {
+			begin = i
+		} else if strings.EqualFold(line, "END:VTODO") && begin >= 0 {
+			end = i
+			break
+		}
+	}
+
+	return begin, end
+}
+
+func iCalPropertyName(line string) string {
+	end := strings.IndexAny(line, ":;")
+
+	if end < 0 {
+		return strings.ToUpper(line)
+	}
+
+	return strings.ToUpper(line[:end])
+}
+
+func (t *CalDAVTodo) removeProperty(name string) {
+	begin, end := t.todoBounds()
+
+	if begin < 0 || end < 0 {
+		return
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(t.lines[begin+1:end]), func(line string) bool {
+		return iCalPropertyName(line) == name
+	})
+	t.lines = slices.Concat(t.lines[:begin+1], kept, t.lines[end:])
+}
+
+func (t *CalDAVTodo) setProperty(name, value string) {
+	begin, end := t.todoBounds()
+
+	if begin < 0 || end < 0 {
+		return
+	}
+
+	for i := begin + 1; i < end; i++ {
+		if iCalPropertyName(t.lines[i]) == name {
+			t.lines[i] = name + ":" + value
+			return
+		}
+	}
+
+	t.lines = slices.Insert(t.lines, end, name+":"+value)
+}
+
+func (t *CalDAVTodo) encode() string {
+	var output strings.Builder
+
+	for _, line := range t.lines {
+		output.WriteString(foldICalLine(line))
+		output.WriteString("\r\n")
+	}
+
+	return output.String()
+}
+
+func parseCalDAVTodo(resourceURL, etag, data string) (*CalDAVTodo, bool) {
+	todo := &CalDAVTodo{URL: resourceURL, ETag: etag, lines: unfoldICalLines(data)}
+	begin, end := todo.todoBounds()
+
+	if begin < 0 || end < 0 {
+		return nil, false
+	}
+
+	var stamp time.Time
+
+	for _, line := range todo.lines[begin+1 : end] {
+		_, value, _ := strings.Cut(line, ":")
+
+		switch iCalPropertyName(line) {
+		case "UID":
+			todo.UID = value
+		case "SUMMARY":
+			todo.Summary = unescapeICalText(value)
+		case "STATUS":
+			todo.Completed = strings.EqualFold(value, "COMPLETED")
+		case "CREATED":
+			todo.Created, _ = time.Parse(calDAVTimeFormat, value)
+		case "DTSTAMP":
+			stamp, _ = time.Parse(calDAVTimeFormat, value)
+		case "X-APPLE-SORT-ORDER":
+			todo.SortOrder, _ = strconv.Atoi(value)
+		}
+	}
+
+	if todo.Created.IsZero() {
+		todo.Created = stamp
+	}
+
+	return todo, true
+}
+
+func unfoldICalLines(data string) []string {
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// foldICalLine splits lines longer than 75 bytes as required by RFC 5545,
+// without splitting multi-byte characters
+func foldICalLine(line string) string {
+	const limit = 75
+
+	if len(line) <= limit {
+		return line
+	}
+
+	var output strings.Builder
+	start, width := 0, limit
+
+	for start < len(line) {
+		end := min(start+width, len(line))
+
+		for end < len(line) && !utf8.RuneStart(line[end]) {
+			end--
+		}
+
+		if start > 0 {
+			output.WriteString("\r\n ")
+		}
+
+		output.WriteString(line[start:end])
+		// continuation lines start with a space which counts towards the limit
+		start, width = end, limit-1
+	}
+
+	return output.String()
+}
+
+var iCalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+var iCalTextUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func escapeICalText(text string) string {
+	return iCalTextEscaper.Replace(text)
+}
+
+func unescapeICalText(text string) string {
+	return iCalTextUnescaper.Replace(text)
+}
+
+// CalDAVClient manages the to-dos of a single calendar collection
+type CalDAVClient struct {
+	collection *url.URL
+	username   string
+	password   string
+}
+
+// NewCalDAVClient returns a client for the calendar collection at collectionURL, such as
+// https://cloud.example.com/remote.php/dav/calendars/user/tasks/ for Nextcloud, which
+// authenticates with the username and password when a username is given
+func NewCalDAVClient(collectionURL, username, password string) (*CalDAVClient, error) {
+	collection, err := url.Parse(collectionURL)
+
+	if err != nil || collection.Host == "" {
+		return nil, fmt.Errorf("invalid CalDAV collection URL: %s", collectionURL)
+	}
+
+	// resources are resolved relative to the collection, which needs a trailing slash for it
+	if !strings.HasSuffix(collection.Path, "/") {
+		collection.Path += "/"
+	}
+
+	return &CalDAVClient{
+		collection: collection,
+		username:   username,
+		password:   password,
+	}, nil
+}
+
+func (c *CalDAVClient) newRequest(ctx context.Context, method, target, body string) *http.Request {
+	request, _ := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+
+	if c.username != "" {
+		request.SetBasicAuth(c.username, c.password)
+	}
+
+	return request
+}
+
+const calDAVTodoQuery = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VTODO"/>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+type calDAVMultistatusXml struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ETag         string `xml:"DAV: getetag"`
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// FetchTodos returns the to-dos of the collection ordered by their sort order,
+// with the ones that don't have one last, and then by when they were created
+func (c *CalDAVClient) FetchTodos(ctx context.Context) ([]*CalDAVTodo, error) {
+	request := c.newRequest(ctx, "REPORT", c.collection.String(), calDAVTodoQuery)
+	request.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	request.Header.Set("Depth", "1")
+
+	_, body, err := fetchBodyFromRequestWithStatus(defaultClient(), request, func(status int) bool {
+		return status == http.StatusMultiStatus
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var multistatus calDAVMultistatusXml
+
+	if err = xml.Unmarshal(body, &multistatus); err != nil {
+		return nil, fmt.Errorf("%w: could not parse CalDAV response: %v", ErrInvalidResponse, err)
+	}
+
+	todos := make([]*CalDAVTodo, 0, len(multistatus.Responses))
+
+	for _, response := range multistatus.Responses {
+		resourceURL, err := c.collection.Parse(strings.TrimSpace(response.Href))
+
+		// keeps the credentials from being sent to other hosts when writing
+		if err != nil || resourceURL.Host != c.collection.Host {
+			continue
+		}
+
+		for _, propstat := range response.Propstat {
+			if !strings.Contains(propstat.Status, " 200 ") || propstat.Prop.CalendarData == "" {
+				continue
+			}
+
+			if todo, ok := parseCalDAVTodo(resourceURL.String(), propstat.Prop.ETag, propstat.Prop.CalendarData); ok {
+				todos = append(todos, todo)
+			}
+		}
+	}
+
+	slices.SortStableFunc(todos, func(a, b *CalDAVTodo) int {
+		if (a.SortOrder == 0) != (b.SortOrder == 0) {
+			if a.SortOrder == 0 {
+				return 1
+			}
+
+			return -1
+		}
+
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), a.Created.Compare(b.Created))
+	})
+
+	return todos, nil
+}
+
+func isCalDAVWriteStatus(status int) bool {
+	return status == http.StatusOK || status == http.StatusCreated || status == http.StatusNoContent
+}
+
+func calDAVWriteError(err error) error {
+	var httpErr *HTTPError
+
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %w", ErrCalDAVConflict, err)
+	}
+
+	return err
+}
+
+// PutTodo creates the to-do if it doesn't have a URL yet and updates it otherwise,
+// failing with ErrCalDAVConflict if it was changed on the server since it was fetched
+func (c *CalDAVClient) PutTodo(ctx context.Context, todo *CalDAVTodo) error {
+	creating := todo.URL == ""
+
+	if creating {
+		todo.URL = c.collection.JoinPath(todo.UID + ".ics").String()
+	}
+
+	request := c.newRequest(ctx, http.MethodPut, todo.URL, todo.encode())
+	request.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+
+	if creating {
+		request.Header.Set("If-None-Match", "*")
+	} else if todo.ETag != "" {
+		request.Header.Set("If-Match", todo.ETag)
+	}
+
+	response, _, err := fetchBodyFromRequestWithStatus(defaultClient(), request, isCalDAVWriteStatus)
+
+	if err != nil {
+		if creating {
+			todo.URL = ""
+		}
+
+		return calDAVWriteError(err)
+	}
+
+	// servers may change what they store, in which case they don't send the new ETag
+	todo.ETag = response.Header.Get("ETag")
+
+	return nil
+}
+
+// DeleteTodo deletes the to-do, failing with ErrCalDAVConflict if it was changed
+// on the server since it was fetched
+func (c *CalDAVClient) DeleteTodo(ctx context.Context, todo *CalDAVTodo) error {
+	request := c.newRequest(ctx, http.MethodDelete, todo.URL, "")
+
+	if todo.ETag != "" {
+		request.Header.Set("If-Match", todo.ETag)
+	}
+
+	_, _, err := fetchBodyFromRequestWithStatus(defaultClient(), request, isCalDAVWriteStatus)
+
+	return calDAVWriteError(err)
+}
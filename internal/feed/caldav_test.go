@@ -0,0 +1,230 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type calDAVTestResource struct {
+	etag string
+	data string
+}
+
+// newCalDAVTestServer serves a single calendar collection at /tasks/ which
+// keeps its resources in memory and honors If-Match and If-None-Match
+func newCalDAVTestServer(t *testing.T, resources map[string]*calDAVTestResource) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	etags := 0
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if username, password, _ := r.BasicAuth(); username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		resource := resources[r.URL.Path]
+
+		switch r.Method {
+		case "REPORT":
+			if r.URL.Path != "/tasks/" || r.Header.Get("Depth") != "1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			var body strings.Builder
+			body.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+
+			for path, resource := range resources {
+				fmt.Fprintf(&body,
+					`<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag><c:calendar-data>%s</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`,
+					path, html.EscapeString(resource.etag), html.EscapeString(resource.data),
+				)
+			}
+
+			body.WriteString(`</d:multistatus>`)
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(body.String()))
+		case http.MethodPut:
+			if (r.Header.Get("If-None-Match") == "*" && resource != nil) ||
+				(r.Header.Get("If-Match") != "" && (resource == nil || resource.etag != r.Header.Get("If-Match"))) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			data, _ := io.ReadAll(r.Body)
+			etags++
+			resources[r.URL.Path] = &calDAVTestResource{etag: `"` + strconv.Itoa(etags) + `"`, data: string(data)}
+			w.Header().Set("ETag", resources[r.URL.Path].etag)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			if resource == nil || resource.etag != r.Header.Get("If-Match") {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			delete(resources, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func newCalDAVTestClient(t *testing.T, server *httptest.Server) *CalDAVClient {
+	t.Helper()
+
+	client, err := NewCalDAVClient(server.URL+"/tasks", "user", "secret")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return client
+}
+
+func fetchCalDAVTestTodos(t *testing.T, client *CalDAVClient) []*CalDAVTodo {
+	t.Helper()
+
+	todos, err := client.FetchTodos(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error fetching to-dos: %v", err)
+	}
+
+	return todos
+}
+
+func TestCalDAVCreateToggleAndDeleteTodo(t *testing.T) {
+	server := newCalDAVTestServer(t, map[string]*calDAVTestResource{})
+	client := newCalDAVTestClient(t, server)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := client.PutTodo(ctx, NewCalDAVTodo("Water the plants, then rest", now)); err != nil {
+		t.Fatalf("unexpected error creating to-do: %v", err)
+	}
+
+	todos := fetchCalDAVTestTodos(t, client)
+
+	if len(todos) != 1 || todos[0].Summary != "Water the plants, then rest" || todos[0].Completed || !todos[0].Created.Equal(now) {
+		t.Fatalf("expected the created to-do, got %+v", todos)
+	}
+
+	todos[0].SetCompleted(true, now)
+
+	if err := client.PutTodo(ctx, todos[0]); err != nil {
+		t.Fatalf("unexpected error updating to-do: %v", err)
+	}
+
+	todos = fetchCalDAVTestTodos(t, client)
+
+	if len(todos) != 1 || !todos[0].Completed {
+		t.Fatalf("expected the to-do to be completed, got %+v", todos)
+	}
+
+	if err := client.DeleteTodo(ctx, todos[0]); err != nil {
+		t.Fatalf("unexpected error deleting to-do: %v", err)
+	}
+
+	if todos = fetchCalDAVTestTodos(t, client); len(todos) != 0 {
+		t.Errorf("expected the to-do to be deleted, got %+v", todos)
+	}
+}
+
+func TestCalDAVWritesFailWithConflictWhenChangedElsewhere(t *testing.T) {
+	server := newCalDAVTestServer(t, map[string]*calDAVTestResource{})
+	client := newCalDAVTestClient(t, server)
+	ctx := context.Background()
+
+	if err := client.PutTodo(ctx, NewCalDAVTodo("Buy milk", time.Now())); err != nil {
+		t.Fatalf("unexpected error creating to-do: %v", err)
+	}
+
+	stale := fetchCalDAVTestTodos(t, client)[0]
+	current := fetchCalDAVTestTodos(t, client)[0]
+	current.SetCompleted(true, time.Now())
+
+	if err := client.PutTodo(ctx, current); err != nil {
+		t.Fatalf("unexpected error updating to-do: %v", err)
+	}
+
+	stale.SetSortOrder(5)
+
+	if err := client.PutTodo(ctx, stale); !errors.Is(err, ErrCalDAVConflict) {
+		t.Errorf("expected ErrCalDAVConflict when updating, got %v", err)
+	}
+
+	if err := client.DeleteTodo(ctx, stale); !errors.Is(err, ErrCalDAVConflict) {
+		t.Errorf("expected ErrCalDAVConflict when deleting, got %v", err)
+	}
+}
+
+func TestCalDAVKeepsPropertiesSetByOtherClients(t *testing.T) {
+	description := "A description which is long enough to have been folded by the client that wrote it, " +
+		"so that it spans several lines"
+	resources := map[string]*calDAVTestResource{
+		"/tasks/a.ics": {etag: `"a"`, data: strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"BEGIN:VTODO",
+			"UID:a",
+			"CREATED:20240101T000000Z",
+			"SUMMARY:Renew passport",
+			"DESCRIPTION:" + description[:60],
+			" " + description[60:],
+			"CATEGORIES:errands",
+			"END:VTODO",
+			"END:VCALENDAR",
+		}, "\r\n")},
+	}
+	server := newCalDAVTestServer(t, resources)
+	client := newCalDAVTestClient(t, server)
+	todo := fetchCalDAVTestTodos(t, client)[0]
+	todo.SetCompleted(true, time.Now())
+
+	if err := client.PutTodo(context.Background(), todo); err != nil {
+		t.Fatalf("unexpected error updating to-do: %v", err)
+	}
+
+	written := resources["/tasks/a.ics"].data
+
+	for _, line := range strings.Split(written, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected lines to be folded at 75 octets, got %q", line)
+		}
+	}
+
+	lines := unfoldICalLines(written)
+
+	for _, expected := range []string{"DESCRIPTION:" + description, "CATEGORIES:errands", "STATUS:COMPLETED"} {
+		if !slices.Contains(lines, expected) {
+			t.Errorf("expected %q to be written, got %q", expected, lines)
+		}
+	}
+}
+
+func TestCalDAVIgnoresResourcesOnOtherHosts(t *testing.T) {
+	server := newCalDAVTestServer(t, map[string]*calDAVTestResource{
+		"https://elsewhere.example.com/tasks/a.ics": {etag: `"a"`, data: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:a\r\nSUMMARY:Leak\r\nEND:VTODO\r\nEND:VCALENDAR"},
+	})
+
+	if todos := fetchCalDAVTestTodos(t, newCalDAVTestClient(t, server)); len(todos) != 0 {
+		t.Errorf("expected resources on other hosts to be ignored, got %+v", todos)
+	}
+}
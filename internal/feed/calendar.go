@@ -43,6 +43,7 @@ func NewCalendar(now time.Time) *Calendar {
 		CurrentDay:        now.Day(),
 		CurrentWeekNumber: week,
 		CurrentMonthName:  now.Month().String(),
+		CurrentMonth:      now.Month(),
 		CurrentYear:       year,
 		Days:              days,
 	}
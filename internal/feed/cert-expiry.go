@@ -0,0 +1,18 @@
+package feed
+
+import (
+	"net/http"
+	"time"
+)
+
+// CertificateExpiry returns when the certificate the server presented for the
+// response expires, which is false for responses that weren't sent over TLS. For
+// responses which came after redirects it's the certificate of the last server.
+func CertificateExpiry(response *http.Response) (time.Time, bool) {
+	if response == nil || response.TLS == nil || len(response.TLS.PeerCertificates) == 0 {
+		return time.Time{}, false
+	}
+
+	// the leaf comes first, the ones after it are the intermediates that it was sent with
+	return response.TLS.PeerCertificates[0].NotAfter, true
+}
@@ -1,9 +1,11 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -12,9 +14,12 @@ import (
 type ChangeDetectionWatch struct {
 	Title        string
 	URL          string
+	Domain       string
 	LastChanged  time.Time
 	DiffURL      string
 	PreviousHash string
+	Unviewed     bool
+	Error        string
 }
 
 type ChangeDetectionWatches []ChangeDetectionWatch
@@ -33,16 +38,25 @@ type changeDetectionResponseJson struct {
 	LastChanged  int64  `json:"last_changed"`
 	DateCreated  int64  `json:"date_created"`
 	PreviousHash string `json:"previous_md5"`
+	Viewed       bool   `json:"viewed"`
+	// either false or the error message
+	LastError any `json:"last_error"`
 }
 
-func FetchWatchUUIDsFromChangeDetection(instanceURL string, token string) ([]string, error) {
-	request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/watch", instanceURL), nil)
+func FetchWatchUUIDsFromChangeDetection(ctx context.Context, instanceURL string, token string, tag string) ([]string, error) {
+	listURL := fmt.Sprintf("%s/api/v1/watch", instanceURL)
+
+	if tag != "" {
+		listURL += "?tag=" + url.QueryEscape(tag)
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", listURL, nil)
 
 	if token != "" {
 		request.Header.Add("x-api-key", token)
 	}
 
-	uuidsMap, err := decodeJsonFromRequest[map[string]struct{}](defaultClient, request)
+	uuidsMap, err := decodeJsonFromRequest[map[string]struct{}](defaultClient(), request)
 
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch list of watch UUIDs: %v", err)
@@ -57,7 +71,7 @@ func FetchWatchUUIDsFromChangeDetection(instanceURL string, token string) ([]str
 	return uuids, nil
 }
 
-func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []string, token string) (ChangeDetectionWatches, error) {
+func FetchWatchesFromChangeDetection(ctx context.Context, instanceURL string, requestedWatchIDs []string, token string) (ChangeDetectionWatches, error) {
 	watches := make(ChangeDetectionWatches, 0, len(requestedWatchIDs))
 
 	if len(requestedWatchIDs) == 0 {
@@ -67,7 +81,7 @@ func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []str
 	requests := make([]*http.Request, len(requestedWatchIDs))
 
 	for i, repository := range requestedWatchIDs {
-		request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/watch/%s", instanceURL, repository), nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/watch/%s", instanceURL, repository), nil)
 
 		if token != "" {
 			request.Header.Add("x-api-key", token)
@@ -76,7 +90,7 @@ func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []str
 		requests[i] = request
 	}
 
-	task := decodeJsonFromRequestTask[changeDetectionResponseJson](defaultClient)
+	task := decodeJsonFromRequestTask[changeDetectionResponseJson](defaultClient())
 	job := newJob(task, requests).withWorkers(15)
 	responses, errs, err := workerPoolDo(job)
 
@@ -97,7 +111,14 @@ func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []str
 
 		watch := ChangeDetectionWatch{
 			URL:     watchJson.URL,
+			Domain:  extractDomainFromUrl(watchJson.URL),
 			DiffURL: fmt.Sprintf("%s/diff/%s?from_version=%d", instanceURL, requestedWatchIDs[i], watchJson.LastChanged-1),
+			// watches which have never changed have nothing to view
+			Unviewed: !watchJson.Viewed && watchJson.LastChanged != 0,
+		}
+
+		if lastError, ok := watchJson.LastError.(string); ok {
+			watch.Error = lastError
 		}
 
 		if watchJson.LastChanged == 0 {
@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var clientEnvVars = []string{
+	"PROXY_URL",
+	"INSECURE_TLS",
+	"TIMEOUT",
+	"MAX_IDLE_CONNS",
+	"CA_CERT_FILE",
+	"CLIENT_CERT_FILE",
+}
+
+// the variables read without a prefix, in order of precedence,
+// for settings which have a commonly used variable
+var standardClientEnvVars = map[string][]string{
+	"PROXY_URL":    {"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"},
+	"CA_CERT_FILE": {"SSL_CERT_FILE"},
+}
+
+// ClientOptionsFromEnv returns the options for GetClientWithOptions set through
+// {PREFIX}_PROXY_URL, {PREFIX}_INSECURE_TLS, {PREFIX}_TIMEOUT,
+// {PREFIX}_MAX_IDLE_CONNS, {PREFIX}_CA_CERT_FILE and {PREFIX}_CLIENT_CERT_FILE,
+// variables which aren't set or are empty being left out. Timeouts are either a
+// duration such as 30s or a number of seconds. Other variables starting with the
+// prefix are logged as a warning since they're likely misspelled. With an empty
+// prefix the proxy is read from HTTPS_PROXY or HTTP_PROXY and the CA certificate
+// from SSL_CERT_FILE instead.
+func ClientOptionsFromEnv(prefix string) ([]ClientOption, error) {
+	lookup := func(name string) string {
+		if prefix == "" {
+			for _, standard := range standardClientEnvVars[name] {
+				if value := os.Getenv(standard); value != "" {
+					return value
+				}
+			}
+
+			return ""
+		}
+
+		return os.Getenv(prefix + "_" + name)
+	}
+
+	envName := func(name string) string {
+		if prefix == "" {
+			return standardClientEnvVars[name][0]
+		}
+
+		return prefix + "_" + name
+	}
+
+	var options []ClientOption
+
+	if value := lookup("PROXY_URL"); value != "" {
+		options = append(options, WithProxy(value))
+	}
+
+	if value := lookup("INSECURE_TLS"); value != "" {
+		insecure, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: expected true or false", envName("INSECURE_TLS"), value)
+		}
+
+		options = append(options, WithInsecureSkipVerify(insecure))
+	}
+
+	if value := lookup("TIMEOUT"); value != "" {
+		timeout, err := parseEnvDuration(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %v", envName("TIMEOUT"), value, err)
+		}
+
+		options = append(options, WithTimeout(timeout))
+	}
+
+	if value := lookup("MAX_IDLE_CONNS"); value != "" {
+		maxIdleConns, err := strconv.Atoi(value)
+
+		if err != nil || maxIdleConns < 0 {
+			return nil, fmt.Errorf("invalid %s %s: expected a number of connections", envName("MAX_IDLE_CONNS"), value)
+		}
+
+		options = append(options, WithMaxIdleConns(maxIdleConns))
+	}
+
+	if value := lookup("CA_CERT_FILE"); value != "" {
+		options = append(options, WithCACertFile(value))
+	}
+
+	if value := lookup("CLIENT_CERT_FILE"); value != "" {
+		options = append(options, WithClientCertFile(value))
+	}
+
+	if prefix != "" {
+		warnAboutUnknownClientEnvVars(prefix + "_")
+	}
+
+	return options, nil
+}
+
+func parseEnvDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("must not be negative")
+		}
+
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration such as 30s or a number of seconds")
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+
+	return duration, nil
+}
+
+func warnAboutUnknownClientEnvVars(prefix string) {
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		setting, found := strings.CutPrefix(name, prefix)
+
+		if !found {
+			continue
+		}
+
+		if !slices.Contains(clientEnvVars, setting) {
+			slog.Warn("Unknown client environment variable", "name", name)
+		}
+	}
+}
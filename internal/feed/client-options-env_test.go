@@ -0,0 +1,154 @@
+package feed
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func applyClientOptions(opts []ClientOption) clientOptions {
+	var options clientOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+func TestClientOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected clientOptions
+	}{
+		{name: "PROXY_URL", value: "socks5://127.0.0.1:1080", expected: clientOptions{proxyURL: "socks5://127.0.0.1:1080"}},
+		{name: "INSECURE_TLS", value: "true", expected: clientOptions{insecure: true}},
+		{name: "INSECURE_TLS", value: "0", expected: clientOptions{insecure: false}},
+		{name: "TIMEOUT", value: "1m30s", expected: clientOptions{timeout: 90 * time.Second}},
+		{name: "TIMEOUT", value: "15", expected: clientOptions{timeout: 15 * time.Second}},
+		{name: "MAX_IDLE_CONNS", value: "20", expected: clientOptions{maxIdleConns: 20}},
+		{name: "CA_CERT_FILE", value: "/etc/glance/ca.pem", expected: clientOptions{caCertFile: "/etc/glance/ca.pem"}},
+		{name: "CLIENT_CERT_FILE", value: "/etc/glance/client.pem", expected: clientOptions{clientCertFile: "/etc/glance/client.pem"}},
+		{name: "TIMEOUT", value: "", expected: clientOptions{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"="+test.value, func(t *testing.T) {
+			t.Setenv("GLANCE_"+test.name, test.value)
+
+			opts, err := ClientOptionsFromEnv("GLANCE")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if options := applyClientOptions(opts); options != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, options)
+			}
+		})
+	}
+}
+
+func TestClientOptionsFromEnvInvalidValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "INSECURE_TLS", value: "maybe"},
+		{name: "TIMEOUT", value: "soon"},
+		{name: "TIMEOUT", value: "-5"},
+		{name: "TIMEOUT", value: "-5s"},
+		{name: "MAX_IDLE_CONNS", value: "many"},
+		{name: "MAX_IDLE_CONNS", value: "-1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"="+test.value, func(t *testing.T) {
+			t.Setenv("GLANCE_"+test.name, test.value)
+
+			_, err := ClientOptionsFromEnv("GLANCE")
+
+			if err == nil || !strings.Contains(err.Error(), "GLANCE_"+test.name) {
+				t.Errorf("expected an error naming the variable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClientOptionsFromStandardEnv(t *testing.T) {
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "SSL_CERT_FILE"} {
+		t.Setenv(name, "")
+	}
+
+	t.Setenv("HTTP_PROXY", "http://proxy.internal:3128")
+	t.Setenv("SSL_CERT_FILE", "/etc/ssl/ca.pem")
+	// only read with a prefix
+	t.Setenv("TIMEOUT", "10")
+
+	opts, err := ClientOptionsFromEnv("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := clientOptions{proxyURL: "http://proxy.internal:3128", caCertFile: "/etc/ssl/ca.pem"}
+
+	if options := applyClientOptions(opts); options != expected {
+		t.Errorf("expected %+v, got %+v", expected, options)
+	}
+
+	t.Setenv("HTTPS_PROXY", "http://secure-proxy.internal:3128")
+	opts, _ = ClientOptionsFromEnv("")
+
+	if options := applyClientOptions(opts); options.proxyURL != "http://secure-proxy.internal:3128" {
+		t.Errorf("expected HTTPS_PROXY to take precedence, got %q", options.proxyURL)
+	}
+}
+
+func TestClientOptionsFromEnvWarnsAboutUnknownVariables(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	t.Setenv("GLANCE_TIMEOUT", "5s")
+	t.Setenv("GLANCE_PROXY_ULR", "http://typo.internal")
+
+	opts, err := ClientOptionsFromEnv("GLANCE")
+
+	if err != nil {
+		t.Fatalf("expected unknown variables to not be an error, got %v", err)
+	}
+
+	if options := applyClientOptions(opts); options != (clientOptions{timeout: 5 * time.Second}) {
+		t.Errorf("expected only the known variable to be used, got %+v", options)
+	}
+
+	if !strings.Contains(logs.String(), "GLANCE_PROXY_ULR") || strings.Contains(logs.String(), "GLANCE_TIMEOUT") {
+		t.Errorf("expected a warning about the unknown variable only, got %q", logs.String())
+	}
+}
+
+func TestClientFromEnvOptions(t *testing.T) {
+	t.Cleanup(ResetDefaultClients)
+	t.Setenv("GLANCE_TIMEOUT", "42s")
+
+	opts, err := ClientOptionsFromEnv("GLANCE")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := GetClientWithOptions(opts...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.Timeout != 42*time.Second {
+		t.Errorf("expected the timeout from the environment, got %v", client.Timeout)
+	}
+}
@@ -0,0 +1,406 @@
+package feed
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type clientOptions struct {
+	proxyURL         string
+	insecure         bool
+	bodyReadDeadline time.Duration
+	connectBudget    time.Duration
+	readBudget       time.Duration
+	// kept as strings rather than slices so that the options can be used as a cache key
+	certificatePins string
+	caCertFile      string
+	clientCertFile  string
+	// derived from the key given to WithTorIsolationKey so that each key gets its own circuits
+	torIsolationID     string
+	torControlPort     int
+	torControlPassword string
+	fileTransport      bool
+	localAddress       string
+	skipLocalAddrCheck bool
+	timeout            time.Duration
+	maxIdleConns       int
+}
+
+type ClientOption func(*clientOptions)
+
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.proxyURL = proxyURL
+	}
+}
+
+func WithInsecureSkipVerify(insecure bool) ClientOption {
+	return func(o *clientOptions) {
+		o.insecure = insecure
+	}
+}
+
+// WithBodyReadDeadline limits how long reading the response body can take,
+// measured from the first read rather than from when the request was sent so
+// that it isn't eaten up by a slow connection or response headers
+func WithBodyReadDeadline(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.bodyReadDeadline = d
+	}
+}
+
+// WithConnectBudget limits how long it can take until a connection is ready to
+// send the request on, which includes resolving the host, dialing and the TLS
+// handshake. Setting either this or WithReadBudget replaces the overall timeout
+// of the client, with the budget that isn't set defaulting to that timeout.
+func WithConnectBudget(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.connectBudget = d
+	}
+}
+
+// WithReadBudget limits how long it can take for the response to start arriving
+// once connected, and then separately how long reading the response can take
+// from its first byte, see WithConnectBudget
+func WithReadBudget(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.readBudget = d
+	}
+}
+
+// WithCertificatePin only allows connections to servers which have a certificate
+// in their chain matching one of the pins, each being the base64 encoded SHA-256
+// hash of the SubjectPublicKeyInfo of the certificate as given by ComputeCertPin.
+// This is checked in addition to the usual verification against trusted CAs, with
+// only the certificates of the verified chain counting. When verification is
+// skipped with WithInsecureSkipVerify, only the certificate of the server itself
+// is checked since the others it sends can't be trusted to be part of its chain.
+func WithCertificatePin(pins ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.certificatePins = strings.Join(pins, ",")
+	}
+}
+
+// WithCACertFile trusts the PEM encoded certificates in the file in addition to
+// the system ones, for servers using certificates signed by a private CA
+func WithCACertFile(path string) ClientOption {
+	return func(o *clientOptions) {
+		o.caCertFile = path
+	}
+}
+
+// WithClientCertFile authenticates the client to servers which ask for a client
+// certificate, the file containing both the PEM encoded certificate and its key
+func WithClientCertFile(path string) ClientOption {
+	return func(o *clientOptions) {
+		o.clientCertFile = path
+	}
+}
+
+// WithTimeout replaces the default timeout of the client, which covers the whole
+// request including reading the response body
+func WithTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxIdleConns sets how many idle connections are kept open for reuse,
+// both overall and for each host
+func WithMaxIdleConns(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxIdleConns = n
+	}
+}
+
+// WithTorIsolation sends requests through a local Tor instance, whose SOCKS
+// listener is found through the control port. The circuits are not shared with
+// clients created with a different WithTorIsolationKey.
+func WithTorIsolation(controlPort int, password string) ClientOption {
+	return func(o *clientOptions) {
+		o.torControlPort = controlPort
+		o.torControlPassword = password
+	}
+}
+
+// WithTorIsolationKey gives the client its own Tor circuits for the key, such as
+// the ID of a widget, so that requests made by different widgets can't be correlated.
+// Has no effect without WithTorIsolation.
+func WithTorIsolationKey(key string) ClientOption {
+	isolationID := torIsolationIDFromKey(key)
+
+	return func(o *clientOptions) {
+		o.torIsolationID = isolationID
+	}
+}
+
+// WithLocalAddress makes outgoing connections from the given IP address,
+// which binds them to the network interface the address is assigned to
+func WithLocalAddress(address string) ClientOption {
+	return func(o *clientOptions) {
+		o.localAddress = address
+	}
+}
+
+// WithSkipLocalAddressCheck disables checking that the address given to
+// WithLocalAddress is assigned to one of the interfaces of the host, for
+// environments where the interfaces can't be listed
+func WithSkipLocalAddressCheck(skip bool) ClientOption {
+	return func(o *clientOptions) {
+		o.skipLocalAddrCheck = skip
+	}
+}
+
+// WithFileTransport lets the client also make requests to file:// URLs,
+// which are served from the local filesystem through FileTransport
+func WithFileTransport() ClientOption {
+	return func(o *clientOptions) {
+		o.fileTransport = true
+	}
+}
+
+// hashed so that any key can be used as a SOCKS username, including an empty one
+func torIsolationIDFromKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// the keys given to WithTorIsolationKey can come and go, for example when widgets
+// are reloaded with new IDs, so only the most recently created clients are kept
+const maxTorIsolatedClients = 64
+
+var torIsolatedClients struct {
+	sync.Mutex
+	options []clientOptions
+}
+
+func trackTorIsolatedClient(options clientOptions) {
+	torIsolatedClients.Lock()
+	defer torIsolatedClients.Unlock()
+
+	torIsolatedClients.options = append(torIsolatedClients.options, options)
+
+	if len(torIsolatedClients.options) <= maxTorIsolatedClients {
+		return
+	}
+
+	evicted := torIsolatedClients.options[0]
+	torIsolatedClients.options = torIsolatedClients.options[1:]
+
+	if client, ok := clientCache.LoadAndDelete(evicted); ok {
+		client.(*http.Client).CloseIdleConnections()
+	}
+}
+
+// GetClientWithOptions returns a client configured with the given options,
+// clients are cached so calling it with the same options returns the same client
+func GetClientWithOptions(opts ...ClientOption) (*http.Client, error) {
+	options := clientOptions{}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// the isolation ID is what the rest of the client is built around, so
+	// requests through Tor always have one while requests without never do
+	if options.torControlPort == 0 {
+		options.torIsolationID = ""
+	} else if options.torIsolationID == "" {
+		options.torIsolationID = torIsolationIDFromKey("")
+	}
+
+	if options == (clientOptions{}) {
+		return defaultClient(), nil
+	}
+
+	if client, ok := clientCache.Load(options); ok {
+		return client.(*http.Client), nil
+	}
+
+	var baseTransport *http.Transport
+	var localDialer *net.Dialer
+
+	if options.localAddress != "" {
+		dialer, err := newLocalAddressDialer(options.localAddress, options.skipLocalAddrCheck)
+
+		if err != nil {
+			return nil, err
+		}
+
+		localDialer = dialer
+	}
+
+	if options.proxyURL != "" {
+		dialer := localDialer
+
+		if dialer == nil {
+			dialer = newDefaultDialer()
+		}
+
+		proxyTransport, err := newProxyTransport(options.proxyURL, options.insecure, dialer)
+
+		if err != nil {
+			return nil, err
+		}
+
+		baseTransport = proxyTransport
+	} else if options.insecure {
+		baseTransport = insecureClientTransport()
+	} else {
+		baseTransport = defaultTransport()
+	}
+
+	if localDialer != nil && options.proxyURL == "" {
+		baseTransport = baseTransport.Clone()
+		baseTransport.DialContext = dialContextWithDNSFailureCache(localDialer.DialContext)
+
+		// the clone still has the proxy set with SetProxy apart from
+		// SOCKS proxies, which are dialed through DialContext instead
+		if globalProxyURL != nil {
+			if err := setTransportProxy(baseTransport, globalProxyURL, localDialer); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if options.torIsolationID != "" {
+		torTransport, err := newTorTransport(baseTransport, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		baseTransport = torTransport
+	}
+
+	if options.certificatePins != "" || options.caCertFile != "" || options.clientCertFile != "" {
+		tlsTransport, err := newTLSTransport(baseTransport, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		baseTransport = tlsTransport
+	}
+
+	if options.maxIdleConns > 0 {
+		baseTransport = baseTransport.Clone()
+		baseTransport.MaxIdleConns = options.maxIdleConns
+		baseTransport.MaxIdleConnsPerHost = options.maxIdleConns
+	}
+
+	if options.fileTransport {
+		// cloned since the base transport may be shared with other clients
+		baseTransport = baseTransport.Clone()
+		baseTransport.RegisterProtocol("file", FileTransport{})
+	}
+
+	var transport http.RoundTripper = baseTransport
+
+	if options.bodyReadDeadline > 0 {
+		transport = &bodyReadDeadlineRoundTripper{
+			next:     transport,
+			deadline: options.bodyReadDeadline,
+		}
+	}
+
+	timeout := cmp.Or(options.timeout, defaultClientTimeout)
+
+	if options.connectBudget > 0 || options.readBudget > 0 {
+		transport = &phaseDeadlineRoundTripper{
+			next:          transport,
+			connectBudget: cmp.Or(options.connectBudget, timeout),
+			readBudget:    cmp.Or(options.readBudget, timeout),
+		}
+
+		// the budgets cover every phase of the request
+		timeout = 0
+	}
+
+	if options.torIsolationID != "" {
+		transport = withTorCircuitInfo(transport, baseTransport, options)
+	}
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: withHTTPDebugLogging(withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(transport)))),
+	}
+
+	actual, loaded := clientCache.LoadOrStore(options, client)
+
+	if !loaded && options.torIsolationID != "" {
+		trackTorIsolatedClient(options)
+	}
+
+	return actual.(*http.Client), nil
+}
+
+var ErrBodyReadDeadlineExceeded = errors.New("response body read deadline exceeded")
+
+type bodyReadDeadlineRoundTripper struct {
+	next     http.RoundTripper
+	deadline time.Duration
+}
+
+func (rt *bodyReadDeadlineRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := rt.next.RoundTrip(request)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.Body = &timeoutReader{body: response.Body, deadline: rt.deadline}
+
+	return response, nil
+}
+
+// timeoutReader closes the underlying body once the deadline passes after the
+// first call to Read, which unblocks any pending read
+type timeoutReader struct {
+	body     io.ReadCloser
+	deadline time.Duration
+
+	start    sync.Once
+	timer    *time.Timer
+	exceeded atomic.Bool
+}
+
+func (r *timeoutReader) Read(p []byte) (int, error) {
+	r.start.Do(func() {
+		r.timer = time.AfterFunc(r.deadline, func() {
+			r.exceeded.Store(true)
+			r.body.Close()
+		})
+	})
+
+	if r.exceeded.Load() {
+		return 0, ErrBodyReadDeadlineExceeded
+	}
+
+	n, err := r.body.Read(p)
+
+	if err != nil && r.exceeded.Load() {
+		return n, ErrBodyReadDeadlineExceeded
+	}
+
+	return n, err
+}
+
+func (r *timeoutReader) Close() error {
+	r.start.Do(func() {})
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+
+	return r.body.Close()
+}
@@ -0,0 +1,68 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBodyReadDeadlineFiresWhileBodyIsDelayed(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+
+	client, err := GetClientWithOptions(WithBodyReadDeadline(100 * time.Millisecond))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	startedAt := time.Now()
+	_, err = decodeJsonFromRequest[map[string]bool](client, request)
+	elapsed := time.Since(startedAt)
+
+	if !errors.Is(err, ErrBodyReadDeadlineExceeded) {
+		t.Fatalf("expected ErrBodyReadDeadlineExceeded, got %v", err)
+	}
+
+	if elapsed < 100*time.Millisecond || elapsed >= 200*time.Millisecond {
+		t.Errorf("expected the deadline to fire after 100ms, took %v", elapsed)
+	}
+}
+
+func TestBodyReadDeadlineStartsOnceHeadersArrive(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// slower than the deadline, which shouldn't count yet
+		time.Sleep(150 * time.Millisecond)
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+
+	client, err := GetClientWithOptions(WithBodyReadDeadline(100 * time.Millisecond))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	result, err := decodeJsonFromRequest[map[string]bool](client, request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result["ok"] {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CoalescingClient merges requests for the same resource made within a short
+// window of each other into one, for widgets which refresh on slightly different
+// schedules but end up requesting the same thing. The first request starts the
+// window and is held back until it passes, after which a single request is sent
+// and every caller which came in during the window gets its own copy of the
+// response. Requests made once the window has passed start a new one.
+//
+// Only GET and HEAD requests without a body are merged, and only with ones which
+// have the same headers so that requests with different credentials are kept apart.
+// Other requests are sent right away.
+type CoalescingClient struct {
+	base   RequestDoer
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done     chan struct{}
+	response *http.Response
+	body     []byte
+	err      error
+}
+
+func NewCoalescingClient(base RequestDoer, window time.Duration) *CoalescingClient {
+	if base == nil {
+		base = defaultClient()
+	}
+
+	return &CoalescingClient{
+		base:   base,
+		window: window,
+		calls:  make(map[string]*coalescedCall),
+	}
+}
+
+func coalescingKey(request *http.Request) (string, bool) {
+	if (request.Method != http.MethodGet && request.Method != http.MethodHead) ||
+		(request.Body != nil && request.Body != http.NoBody) {
+		return "", false
+	}
+
+	var key strings.Builder
+	key.WriteString(request.Method + " " + request.URL.String())
+
+	names := make([]string, 0, len(request.Header))
+
+	for name := range request.Header {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(request.Header[name], ", "))
+	}
+
+	return key.String(), true
+}
+
+func (c *CoalescingClient) Do(request *http.Request) (*http.Response, error) {
+	key, ok := coalescingKey(request)
+
+	if !ok {
+		return c.base.Do(request)
+	}
+
+	c.mu.Lock()
+	call, ok := c.calls[key]
+
+	if !ok {
+		call = &coalescedCall{done: make(chan struct{})}
+		c.calls[key] = call
+
+		// the request is shared, so one caller giving up shouldn't cancel it for the others
+		go c.send(key, call, request.Clone(context.WithoutCancel(request.Context())))
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	response := *call.response
+	response.Header = call.response.Header.Clone()
+	response.Body = io.NopCloser(bytes.NewReader(call.body))
+	response.Request = request
+
+	return &response, nil
+}
+
+func (c *CoalescingClient) send(key string, call *coalescedCall, request *http.Request) {
+	defer close(call.done)
+
+	time.Sleep(c.window)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+
+	response, err := c.base.Do(request)
+
+	if err != nil {
+		call.err = err
+		return
+	}
+
+	defer response.Body.Close()
+
+	if call.body, err = io.ReadAll(response.Body); err != nil {
+		call.err = err
+		return
+	}
+
+	call.response = response
+}
@@ -0,0 +1,148 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("X-Call", fmt.Sprint(n))
+		fmt.Fprintf(w, "response %d", n)
+	})
+
+	return server, &calls
+}
+
+func getBody(client RequestDoer, request *http.Request) (string, error) {
+	response, err := client.Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+
+	return string(body), err
+}
+
+func TestCoalescingClientMergesRequestsWithinWindow(t *testing.T) {
+	server, calls := newCountingTestServer(t)
+	client := NewCoalescingClient(server.Client(), 100*time.Millisecond)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	errs := make([]error, 10)
+
+	// 10 requests spread out over 50ms
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			request, _ := http.NewRequest(http.MethodGet, server.URL+"/data", nil)
+			bodies[i], errs[i] = getBody(client, request)
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 server call, got %d", calls.Load())
+	}
+
+	for i := range bodies {
+		if errs[i] != nil || bodies[i] != "response 1" {
+			t.Errorf("request %d: expected the shared response, got %q, %v", i, bodies[i], errs[i])
+		}
+	}
+
+	// the window has passed, so this starts a new one
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/data", nil)
+
+	if body, err := getBody(client, request); err != nil || body != "response 2" {
+		t.Errorf("expected a new request after the window, got %q, %v", body, err)
+	}
+}
+
+func TestCoalescingClientKeepsDifferentRequestsApart(t *testing.T) {
+	server, calls := newCountingTestServer(t)
+	client := NewCoalescingClient(server.Client(), 50*time.Millisecond)
+
+	requests := make([]*http.Request, 0, 4)
+
+	for _, token := range []string{"a", "b"} {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/data", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		requests = append(requests, request)
+	}
+
+	other, _ := http.NewRequest(http.MethodGet, server.URL+"/other", nil)
+	post, _ := http.NewRequest(http.MethodPost, server.URL+"/data", nil)
+	requests = append(requests, other, post)
+
+	var wg sync.WaitGroup
+
+	for _, request := range requests {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := getBody(client, request); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if calls.Load() != 4 {
+		t.Errorf("expected every request to be sent on its own, got %d calls", calls.Load())
+	}
+}
+
+func TestCoalescingClientCallerGivingUp(t *testing.T) {
+	server, calls := newCountingTestServer(t)
+	client := NewCoalescingClient(server.Client(), 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	impatient, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	patient, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := getBody(client, patient)
+		result <- err
+	}()
+
+	if _, err := getBody(client, impatient); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the impatient caller to give up, got %v", err)
+	}
+
+	if err := <-result; err != nil {
+		t.Errorf("expected the shared request to carry on for the other caller, got %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 server call, got %d", calls.Load())
+	}
+}
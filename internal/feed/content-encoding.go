@@ -0,0 +1,188 @@
+package feed
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// sent with every request that doesn't set its own, in order of preference
+const acceptEncodingHeader = "zstd, br;q=0.9, gzip;q=0.8, deflate;q=0.5"
+
+var contentDecoders = map[string]func(io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": newDeflateReader,
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return decoder.IOReadCloser(), nil
+	},
+}
+
+// deflate is meant to be zlib wrapped but some servers send raw deflate data,
+// which is told apart by the zlib header where the first 2 bytes are a multiple of 31
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
+
+type rawContentEncodingContextKey struct{}
+
+type identityEncodingContextKey struct{}
+
+// set through SetIdentityEncodingByDefault
+var identityEncodingByDefault atomic.Bool
+
+// WithIdentityEncoding returns a copy of the request which asks for the response to
+// not be compressed with Accept-Encoding: identity. Whatever the server sends back
+// is left as it is, for telling apart problems with compression, such as proxies
+// mangling compressed bodies, from other problems with a response.
+func WithIdentityEncoding(request *http.Request) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), identityEncodingContextKey{}, true))
+}
+
+// SetIdentityEncodingByDefault has every request which doesn't set its own
+// Accept-Encoding made as if it went through WithIdentityEncoding
+func SetIdentityEncodingByDefault(enabled bool) {
+	identityEncodingByDefault.Store(enabled)
+}
+
+func isIdentityEncodingRequested(request *http.Request) bool {
+	requested, _ := request.Context().Value(identityEncodingContextKey{}).(bool)
+	return requested || identityEncodingByDefault.Load()
+}
+
+// WithoutDecompression returns a copy of the request whose response body is
+// delivered exactly as the server sent it, still compressed and with the
+// Content-Encoding and Content-Length headers left in place, for when the
+// compressed bytes are what's needed such as when forwarding the response
+func WithoutDecompression(request *http.Request) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), rawContentEncodingContextKey{}, true))
+}
+
+func isDecompressionDisabled(request *http.Request) bool {
+	disabled, _ := request.Context().Value(rawContentEncodingContextKey{}).(bool)
+	return disabled
+}
+
+// contentDecodingRoundTripper replaces the transparent gzip handling of
+// http.Transport, which is disabled as soon as Accept-Encoding is set, so
+// that zstd and brotli can be preferred where servers support them. Requests
+// which set their own Accept-Encoding or were made with WithoutDecompression
+// or WithIdentityEncoding get the response as it was sent.
+type contentDecodingRoundTripper struct {
+	next http.RoundTripper
+}
+
+func withContentDecoding(transport http.RoundTripper) http.RoundTripper {
+	return &contentDecodingRoundTripper{next: transport}
+}
+
+func (rt *contentDecodingRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	// same as http.Transport, ranges of an encoded body can't be decoded
+	if request.Header.Get("Accept-Encoding") != "" || request.Header.Get("Range") != "" || request.Method == http.MethodHead {
+		return rt.next.RoundTrip(request)
+	}
+
+	request = request.Clone(request.Context())
+
+	if isIdentityEncodingRequested(request) {
+		request.Header.Set("Accept-Encoding", "identity")
+		return rt.next.RoundTrip(request)
+	}
+
+	request.Header.Set("Accept-Encoding", acceptEncodingHeader)
+
+	response, err := rt.next.RoundTrip(request)
+
+	if err != nil || isDecompressionDisabled(request) {
+		return response, err
+	}
+
+	if err = decodeResponseBody(response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func decodeResponseBody(response *http.Response) error {
+	header := response.Header.Get("Content-Encoding")
+
+	if header == "" || response.StatusCode == http.StatusNoContent || response.StatusCode == http.StatusNotModified {
+		return nil
+	}
+
+	encodings := strings.Split(header, ",")
+	body := response.Body
+
+	// encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+
+		newDecoder, ok := contentDecoders[encoding]
+
+		if !ok {
+			return fmt.Errorf("unsupported content encoding %q for %s", encoding, response.Request.URL)
+		}
+
+		decoder, err := newDecoder(body)
+
+		if err != nil {
+			return fmt.Errorf("could not decode %s response from %s: %w", encoding, response.Request.URL, err)
+		}
+
+		body = &decodedBody{ReadCloser: decoder, underlying: body}
+	}
+
+	response.Body = body
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+
+	return nil
+}
+
+type decodedBody struct {
+	io.ReadCloser
+	underlying io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.underlying.Close()
+}
@@ -0,0 +1,177 @@
+package feed
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const contentEncodingTestBody = `{"message":"hello hello hello hello"}`
+
+var contentEncoders = map[string]func(io.Writer) io.WriteCloser{
+	"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	"deflate": func(w io.Writer) io.WriteCloser {
+		return zlib.NewWriter(w)
+	},
+	"raw-deflate": func(w io.Writer) io.WriteCloser {
+		writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return writer
+	},
+	"br": func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+	"zstd": func(w io.Writer) io.WriteCloser {
+		writer, _ := zstd.NewWriter(w)
+		return writer
+	},
+}
+
+func encodeTestBody(t *testing.T, body []byte, encodings ...string) []byte {
+	t.Helper()
+
+	for _, encoding := range encodings {
+		var buffer bytes.Buffer
+		writer := contentEncoders[encoding](&buffer)
+		writer.Write(body)
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		body = buffer.Bytes()
+	}
+
+	return body
+}
+
+// newContentEncodingTestServer encodes its response with the encodings given
+// in the query, in the order they're listed
+func newContentEncodingTestServer(t *testing.T) (*httptest.Server, *string) {
+	t.Helper()
+
+	var acceptEncoding string
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		encodings := r.URL.Query()["encoding"]
+		headerValues := make([]string, 0, len(encodings))
+
+		for _, encoding := range encodings {
+			headerValues = append(headerValues, strings.TrimPrefix(encoding, "raw-"))
+		}
+
+		if len(headerValues) > 0 {
+			w.Header().Set("Content-Encoding", strings.Join(headerValues, ", "))
+		}
+
+		w.Write(encodeTestBody(t, []byte(contentEncodingTestBody), encodings...))
+	})
+
+	return server, &acceptEncoding
+}
+
+func TestContentDecodingForEachEncoding(t *testing.T) {
+	server, acceptEncoding := newContentEncodingTestServer(t)
+	client := &http.Client{Transport: withContentDecoding(server.Client().Transport)}
+
+	tests := []struct {
+		name      string
+		encodings []string
+	}{
+		{name: "identity"},
+		{name: "zstd", encodings: []string{"zstd"}},
+		{name: "brotli", encodings: []string{"br"}},
+		{name: "gzip", encodings: []string{"gzip"}},
+		{name: "zlib deflate", encodings: []string{"deflate"}},
+		{name: "raw deflate", encodings: []string{"raw-deflate"}},
+		{name: "stacked", encodings: []string{"gzip", "br"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := ""
+
+			for _, encoding := range test.encodings {
+				query += "&encoding=" + encoding
+			}
+
+			response, err := client.Get(server.URL + "/?" + query)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != contentEncodingTestBody {
+				t.Errorf("expected the decoded body, got %q", body)
+			}
+
+			if response.Header.Get("Content-Encoding") != "" {
+				t.Errorf("expected Content-Encoding to be removed, got %q", response.Header.Get("Content-Encoding"))
+			}
+
+			if *acceptEncoding != acceptEncodingHeader {
+				t.Errorf("expected Accept-Encoding %q, got %q", acceptEncodingHeader, *acceptEncoding)
+			}
+		})
+	}
+}
+
+func TestContentDecodingUnsupportedEncoding(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "compress")
+		w.Write([]byte("data"))
+	})
+
+	client := &http.Client{Transport: withContentDecoding(server.Client().Transport)}
+
+	if _, err := client.Get(server.URL); err == nil || !strings.Contains(err.Error(), "unsupported content encoding") {
+		t.Errorf("expected an unsupported encoding error, got %v", err)
+	}
+}
+
+func TestContentDecodingLeftToCaller(t *testing.T) {
+	server, acceptEncoding := newContentEncodingTestServer(t)
+	client := &http.Client{Transport: withContentDecoding(server.Client().Transport)}
+	encoded := encodeTestBody(t, []byte(contentEncodingTestBody), "zstd")
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/?encoding=zstd", nil)
+	response, err := client.Do(WithoutDecompression(request))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if !bytes.Equal(body, encoded) || response.Header.Get("Content-Encoding") != "zstd" {
+		t.Errorf("expected the body to be left compressed with WithoutDecompression")
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	response, err = client.Do(WithIdentityEncoding(request))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if *acceptEncoding != "identity" {
+		t.Errorf("expected Accept-Encoding identity, got %q", *acceptEncoding)
+	}
+}
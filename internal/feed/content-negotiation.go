@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+type ContentTypeInfo struct {
+	// lowercased, such as application and hal+json
+	Type    string
+	Subtype string
+	// the structured syntax suffix of the subtype without the +, such as json
+	Suffix string
+	Params map[string]string
+}
+
+func (c ContentTypeInfo) MediaType() string {
+	if c.Type == "" {
+		return ""
+	}
+
+	return c.Type + "/" + c.Subtype
+}
+
+func (c ContentTypeInfo) IsJSON() bool {
+	return c.Subtype == "json" || c.Suffix == "json" || (c.Type == "text" && c.Subtype == "javascript")
+}
+
+func (c ContentTypeInfo) IsXML() bool {
+	return c.Subtype == "xml" || c.Suffix == "xml"
+}
+
+// ParseContentType splits a Content-Type header into its parts, parameters
+// which can't be parsed are left out rather than the whole header being
+// rejected since servers are often loose with them
+func ParseContentType(ct string) ContentTypeInfo {
+	mediaType, params, err := mime.ParseMediaType(ct)
+
+	if err != nil {
+		mediaType, _, _ = strings.Cut(ct, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		params = nil
+	}
+
+	info := ContentTypeInfo{Params: params}
+
+	if info.Params == nil {
+		info.Params = make(map[string]string)
+	}
+
+	var ok bool
+
+	if info.Type, info.Subtype, ok = strings.Cut(mediaType, "/"); !ok || info.Type == "" || info.Subtype == "" {
+		return ContentTypeInfo{Params: info.Params}
+	}
+
+	if i := strings.LastIndexByte(info.Subtype, '+'); i != -1 {
+		info.Suffix = info.Subtype[i+1:]
+	}
+
+	return info
+}
+
+// NewNegotiatedRequest creates a request with an Accept header listing the
+// preferred content types, most preferred first, with each one getting a lower
+// q value than the one before it. Defaults to JSON followed by XML.
+func NewNegotiatedRequest(ctx context.Context, method, url string, preferred ...string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preferred) == 0 {
+		preferred = []string{"application/json", "application/xml"}
+	}
+
+	accept := make([]string, 0, len(preferred))
+
+	for i, contentType := range preferred {
+		// q values have at most 3 decimals, and anything at 0 would mean not acceptable
+		q := max(1000-i*100, 1)
+
+		if q == 1000 {
+			accept = append(accept, contentType)
+			continue
+		}
+
+		accept = append(accept, contentType+";q="+strings.TrimRight(strconv.FormatFloat(float64(q)/1000, 'f', 3, 64), "0"))
+	}
+
+	request.Header.Set("Accept", strings.Join(accept, ", "))
+
+	return request, nil
+}
+
+func decodeXMLWithCharset(body []byte, target any) error {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	return decoder.Decode(target)
+}
+
+// without a Content-Type the format is guessed from the first character
+func decodeSniffed(body []byte, target any) error {
+	trimmed := bytes.TrimLeft(body, " \t\r\n\ufeff")
+
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		return decodeXMLWithCharset(body, target)
+	}
+
+	return json.Unmarshal(trimmed, target)
+}
+
+// SelectDecoder returns the decoder for the format of the response as given by its
+// Content-Type, either JSON or XML. Responses without a Content-Type get a decoder
+// which guesses the format from the body, others fail with ErrUnsupportedContentType.
+func SelectDecoder(response *http.Response) (func([]byte, any) error, error) {
+	header := response.Header.Get("Content-Type")
+
+	if strings.TrimSpace(header) == "" {
+		return decodeSniffed, nil
+	}
+
+	contentType := ParseContentType(header)
+
+	switch {
+	case contentType.IsJSON():
+		return json.Unmarshal, nil
+	case contentType.IsXML():
+		return decodeXMLWithCharset, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, header)
+}
@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseContentType(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected ContentTypeInfo
+	}{
+		{
+			header:   "application/json; charset=utf-8",
+			expected: ContentTypeInfo{Type: "application", Subtype: "json", Params: map[string]string{"charset": "utf-8"}},
+		},
+		{
+			header:   "Application/HAL+JSON",
+			expected: ContentTypeInfo{Type: "application", Subtype: "hal+json", Suffix: "json", Params: map[string]string{}},
+		},
+		{
+			// the broken parameter is dropped but the media type is kept
+			header:   "application/atom+xml; charset",
+			expected: ContentTypeInfo{Type: "application", Subtype: "atom+xml", Suffix: "xml", Params: map[string]string{}},
+		},
+		{
+			header:   "nonsense",
+			expected: ContentTypeInfo{Params: map[string]string{}},
+		},
+	}
+
+	for _, test := range tests {
+		info := ParseContentType(test.header)
+
+		if info.Type != test.expected.Type || info.Subtype != test.expected.Subtype || info.Suffix != test.expected.Suffix || fmt.Sprint(info.Params) != fmt.Sprint(test.expected.Params) {
+			t.Errorf("%q: expected %+v, got %+v", test.header, test.expected, info)
+		}
+	}
+
+	if mediaType := ParseContentType("text/XML").MediaType(); mediaType != "text/xml" {
+		t.Errorf("expected text/xml, got %s", mediaType)
+	}
+}
+
+func TestNewNegotiatedRequest(t *testing.T) {
+	tests := []struct {
+		preferred []string
+		expected  string
+	}{
+		{expected: "application/json, application/xml;q=0.9"},
+		{preferred: []string{"application/xml", "application/json", "text/plain"}, expected: "application/xml, application/json;q=0.9, text/plain;q=0.8"},
+	}
+
+	for _, test := range tests {
+		request, err := NewNegotiatedRequest(context.Background(), http.MethodGet, "https://example.com/", test.preferred...)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if accept := request.Header.Get("Accept"); accept != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, accept)
+		}
+	}
+}
+
+type negotiationTestItem struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestSelectDecoderPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{name: "json", contentType: "application/json", body: `{"name":"json"}`, expected: "json"},
+		{name: "json suffix", contentType: "application/hal+json", body: `{"name":"hal"}`, expected: "hal"},
+		{name: "javascript", contentType: "text/javascript", body: `{"name":"js"}`, expected: "js"},
+		{name: "xml", contentType: "application/xml", body: `<item><name>xml</name></item>`, expected: "xml"},
+		{name: "xml suffix", contentType: "application/atom+xml", body: `<item><name>atom</name></item>`, expected: "atom"},
+		{
+			name:        "xml with a declared charset",
+			contentType: "text/xml",
+			body:        "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><item><name>caf\xe9</name></item>",
+			expected:    "café",
+		},
+		{name: "sniffed json", body: "\ufeff  {\"name\":\"sniffed\"}", expected: "sniffed"},
+		{name: "sniffed xml", body: "\n<item><name>sniffed xml</name></item>", expected: "sniffed xml"},
+	}
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, test := range tests {
+			if r.URL.Query().Get("case") == test.name {
+				// without it the server would detect one
+				w.Header()["Content-Type"] = []string{test.contentType}
+				io.WriteString(w, test.body)
+				return
+			}
+		}
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request, _ := NewNegotiatedRequest(context.Background(), http.MethodGet, server.URL+"/?case="+url.QueryEscape(test.name))
+			response, err := server.Client().Do(request)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := io.ReadAll(response.Body)
+			response.Body.Close()
+
+			decode, err := SelectDecoder(response)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var item negotiationTestItem
+
+			if err := decode(body, &item); err != nil {
+				t.Fatal(err)
+			}
+
+			if item.Name != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, item.Name)
+			}
+		})
+	}
+}
+
+func TestSelectDecoderUnsupportedContentType(t *testing.T) {
+	response := &http.Response{Header: http.Header{"Content-Type": {"image/png"}}}
+
+	if _, err := SelectDecoder(response); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("expected ErrUnsupportedContentType, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+type APIKey struct {
+	Key    string
+	Weight int
+}
+
+// CredentialRotator is a RequestDoer which spreads requests across several API
+// keys using smooth weighted round-robin, so that with weights 2 and 1 the keys
+// are used in the order A, B, A rather than A, A, B. Optionally, a request which
+// gets rate limited is retried with the next keys.
+type CredentialRotator struct {
+	next              RequestDoer
+	header            string
+	prefix            string
+	rotateOnRateLimit bool
+
+	mu      sync.Mutex
+	keys    []APIKey
+	current []int
+	total   int
+}
+
+// NewCredentialRotator creates a rotator which sends requests through next,
+// or the default client if it's nil
+func NewCredentialRotator(next RequestDoer, header, prefix string, keys []APIKey, rotateOnRateLimit bool) (*CredentialRotator, error) {
+	if next == nil {
+		next = defaultClient()
+	}
+
+	if header == "" {
+		return nil, errors.New("no header specified for API keys")
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no API keys specified")
+	}
+
+	rotator := &CredentialRotator{
+		next:              next,
+		header:            header,
+		prefix:            prefix,
+		rotateOnRateLimit: rotateOnRateLimit,
+		keys:              make([]APIKey, len(keys)),
+		current:           make([]int, len(keys)),
+	}
+
+	for i := range keys {
+		if keys[i].Key == "" {
+			return nil, fmt.Errorf("API key %d is empty", i+1)
+		}
+
+		if keys[i].Weight < 0 {
+			return nil, fmt.Errorf("API key %s has a negative weight", redactAPIKey(keys[i].Key))
+		}
+
+		rotator.keys[i] = keys[i]
+
+		if rotator.keys[i].Weight == 0 {
+			rotator.keys[i].Weight = 1
+		}
+
+		rotator.total += rotator.keys[i].Weight
+	}
+
+	return rotator, nil
+}
+
+func (r *CredentialRotator) pick() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	best := 0
+
+	for i := range r.keys {
+		r.current[i] += r.keys[i].Weight
+
+		if r.current[i] > r.current[best] {
+			best = i
+		}
+	}
+
+	r.current[best] -= r.total
+
+	return best
+}
+
+func (r *CredentialRotator) Do(request *http.Request) (*http.Response, error) {
+	attempts := 1
+
+	// retrying requires being able to send the body again
+	if r.rotateOnRateLimit && (request.Body == nil || request.GetBody != nil) {
+		attempts = len(r.keys)
+	}
+
+	for attempt := 1; ; attempt++ {
+		key := r.keys[r.pick()].Key
+		attemptRequest := request.Clone(request.Context())
+		attemptRequest.Header.Set(r.header, r.prefix+key)
+
+		if attempt > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			attemptRequest.Body = body
+		}
+
+		response, err := r.next.Do(attemptRequest)
+
+		if err != nil || response.StatusCode != http.StatusTooManyRequests || attempt >= attempts {
+			return response, err
+		}
+
+		response.Body.Close()
+		slog.Warn("API key was rate limited, retrying with the next one", "url", request.URL.String(), "key", redactAPIKey(key))
+	}
+}
+
+// redactAPIKey keeps just enough of the key to tell which one it was
+func redactAPIKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+
+	return key[:4] + "****"
+}
@@ -0,0 +1,280 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type CurrencyPairRequest struct {
+	From   string  `yaml:"from"`
+	To     string  `yaml:"to"`
+	Amount float64 `yaml:"amount"`
+	Name   string  `yaml:"name"`
+}
+
+type CurrencyRate struct {
+	CurrencyPairRequest
+	Rate          float64
+	PercentChange float64
+	HasChange     bool
+	Converted     float64
+	AsOf          time.Time
+}
+
+type CurrencyRates []CurrencyRate
+
+// crypto pairs are delegated to the markets provider since neither of the
+// exchange rate sources below have them
+var cryptoCurrencies = map[string]bool{
+	"BTC":  true,
+	"ETH":  true,
+	"LTC":  true,
+	"XRP":  true,
+	"SOL":  true,
+	"ADA":  true,
+	"DOGE": true,
+	"DOT":  true,
+	"BNB":  true,
+	"XMR":  true,
+	"USDT": true,
+	"USDC": true,
+}
+
+func IsCryptoCurrency(code string) bool {
+	return cryptoCurrencies[strings.ToUpper(code)]
+}
+
+type currencyTable struct {
+	AsOf     time.Time
+	Current  map[string]float64
+	Previous map[string]float64
+}
+
+func (t *currencyTable) has(code string) bool {
+	_, ok := t.Current[code]
+	return ok
+}
+
+func crossRate(from, to string, rates map[string]float64) (float64, bool) {
+	fromRate, ok := rates[from]
+
+	if !ok || fromRate == 0 {
+		return 0, false
+	}
+
+	toRate, ok := rates[to]
+
+	if !ok {
+		return 0, false
+	}
+
+	return toRate / fromRate, true
+}
+
+type ecbHistoryResponseXml struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func fetchCurrencyTableFromECB(ctx context.Context) (*currencyTable, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml", nil)
+	response, err := decodeXmlFromRequest[ecbHistoryResponseXml](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	days := response.Cube.Days
+
+	if len(days) == 0 {
+		return nil, errors.New("ECB response contains no rates")
+	}
+
+	toMap := func(i int) map[string]float64 {
+		rates := make(map[string]float64, len(days[i].Rates)+1)
+		rates["EUR"] = 1
+
+		for _, rate := range days[i].Rates {
+			rates[rate.Currency] = rate.Rate
+		}
+
+		return rates
+	}
+
+	table := &currencyTable{
+		Current: toMap(0),
+	}
+
+	// days are sorted from newest to oldest
+	if len(days) > 1 {
+		table.Previous = toMap(1)
+	}
+
+	table.AsOf, err = time.Parse("2006-01-02", days[0].Time)
+
+	if err != nil {
+		table.AsOf = time.Now()
+	}
+
+	return table, nil
+}
+
+type openExchangeRatesResponseJson struct {
+	Result    string             `json:"result"`
+	UpdatedAt int64              `json:"time_last_update_unix"`
+	Rates     map[string]float64 `json:"rates"`
+	ErrorType string             `json:"error-type"`
+}
+
+func fetchCurrencyTableFromOpenExchangeRates(ctx context.Context) (*currencyTable, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://open.er-api.com/v6/latest/EUR", nil)
+	response, err := decodeJsonFromRequest[openExchangeRatesResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Result != "success" {
+		return nil, fmt.Errorf("open.er-api.com returned an error: %s", response.ErrorType)
+	}
+
+	if len(response.Rates) == 0 {
+		return nil, errors.New("open.er-api.com response contains no rates")
+	}
+
+	// the free endpoint has no history so there is nothing to compare against
+	return &currencyTable{
+		AsOf:    time.Unix(response.UpdatedAt, 0),
+		Current: response.Rates,
+	}, nil
+}
+
+var currencyTableSources = []struct {
+	name  string
+	fetch func(context.Context) (*currencyTable, error)
+}{
+	{"ECB", fetchCurrencyTableFromECB},
+	{"open.er-api.com", fetchCurrencyTableFromOpenExchangeRates},
+}
+
+func FetchCurrencyRates(ctx context.Context, pairs []CurrencyPairRequest) (CurrencyRates, error) {
+	rates := make(CurrencyRates, len(pairs))
+	resolved := make([]bool, len(pairs))
+	failed := 0
+
+	cryptoRequests := make([]MarketRequest, 0)
+	cryptoIndexes := make([]int, 0)
+	fiatRemaining := 0
+
+	for i := range pairs {
+		rates[i].CurrencyPairRequest = pairs[i]
+
+		if IsCryptoCurrency(pairs[i].From) || IsCryptoCurrency(pairs[i].To) {
+			cryptoRequests = append(cryptoRequests, MarketRequest{
+				Symbol: pairs[i].From + "-" + pairs[i].To,
+			})
+			cryptoIndexes = append(cryptoIndexes, i)
+			continue
+		}
+
+		fiatRemaining++
+	}
+
+	// fall back to the next source only for the pairs the previous
+	// sources failed to provide, the ECB only tracks around 30 currencies
+	for _, source := range currencyTableSources {
+		if fiatRemaining == 0 {
+			break
+		}
+
+		table, err := source.fetch(ctx)
+
+		if err != nil {
+			slog.Error("Failed to fetch currency rates", "source", source.name, "error", err)
+			continue
+		}
+
+		for i := range pairs {
+			if resolved[i] || IsCryptoCurrency(pairs[i].From) || IsCryptoCurrency(pairs[i].To) {
+				continue
+			}
+
+			if !table.has(pairs[i].From) || !table.has(pairs[i].To) {
+				continue
+			}
+
+			rate, _ := crossRate(pairs[i].From, pairs[i].To, table.Current)
+			rates[i].Rate = rate
+			rates[i].AsOf = table.AsOf
+
+			if previous, ok := crossRate(pairs[i].From, pairs[i].To, table.Previous); ok && previous != 0 {
+				rates[i].PercentChange = percentChange(rate, previous)
+				rates[i].HasChange = true
+			}
+
+			resolved[i] = true
+			fiatRemaining--
+		}
+	}
+
+	if len(cryptoRequests) > 0 {
+		markets, err := FetchMarketsDataFromYahoo(ctx, cryptoRequests)
+
+		if err != nil && !errors.Is(err, ErrPartialContent) {
+			slog.Error("Failed to fetch crypto rates", "error", err)
+		}
+
+		for _, market := range markets {
+			for j, request := range cryptoRequests {
+				if market.Symbol != request.Symbol {
+					continue
+				}
+
+				i := cryptoIndexes[j]
+				rates[i].Rate = market.Price
+				rates[i].PercentChange = market.PercentChange
+				rates[i].HasChange = true
+				rates[i].AsOf = time.Now()
+				resolved[i] = true
+			}
+		}
+	}
+
+	result := make(CurrencyRates, 0, len(rates))
+
+	for i := range rates {
+		if !resolved[i] {
+			failed++
+			slog.Error("No exchange rate found", "from", pairs[i].From, "to", pairs[i].To)
+			continue
+		}
+
+		if rates[i].Amount > 0 {
+			rates[i].Converted = rates[i].Amount * rates[i].Rate
+		}
+
+		result = append(result, rates[i])
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return result, fmt.Errorf("%w: could not get %d exchange rate(s)", ErrPartialContent, failed)
+	}
+
+	return result, nil
+}
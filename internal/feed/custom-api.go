@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type CustomAPIRequest struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Body    string
+	Client  RequestDoer
+	Schema  *JSONSchema
+}
+
+func FetchCustomAPI(ctx context.Context, options CustomAPIRequest) (any, error) {
+	var body io.Reader
+
+	if options.Body != "" {
+		body = strings.NewReader(options.Body)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, options.Method, options.URL, body)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %v", ErrNoContent, err)
+	}
+
+	for key, value := range options.Headers {
+		request.Header.Set(key, value)
+	}
+
+	if options.Body != "" && request.Header.Get("Content-Type") == "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	var client RequestDoer = defaultClient()
+
+	if options.Client != nil {
+		client = options.Client
+	}
+
+	response, err := decodeJsonFromRequest[any](client, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	if options.Schema != nil {
+		if err = options.Schema.Validate(response); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoContent, err)
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,499 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type Departure struct {
+	Line        string
+	Destination string
+	Platform    string
+	// hex color without the leading #, as provided by the backend
+	LineColor string
+	Scheduled time.Time
+	Expected  time.Time
+	Delay     time.Duration
+	RealTime  bool
+	Cancelled bool
+}
+
+type Departures []Departure
+
+func (d Departures) SortByExpected() Departures {
+	sort.SliceStable(d, func(i, j int) bool {
+		return d[i].Expected.Before(d[j].Expected)
+	})
+
+	return d
+}
+
+// Catchable returns the departures which leave no sooner than walkingTime from
+// now, since the departures are cached this has to be called whenever they're
+// displayed rather than once after fetching them
+func (d Departures) Catchable(now time.Time, walkingTime time.Duration) Departures {
+	cutoff := now.Add(walkingTime)
+	catchable := make(Departures, 0, len(d))
+
+	for i := range d {
+		if !d[i].Expected.Before(cutoff) {
+			catchable = append(catchable, d[i])
+		}
+	}
+
+	return catchable
+}
+
+type DepartureStop struct {
+	// either transitous or gtfs-rt
+	Backend     string
+	URL         string
+	StopID      string
+	Name        string
+	WalkingTime time.Duration
+	Headers     map[string]string
+}
+
+type StopDepartures struct {
+	Name        string
+	WalkingTime time.Duration
+	Departures  Departures
+}
+
+const defaultTransitousURL = "https://api.transitous.org"
+
+var hexColorPattern = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+type motisStopTimesResponseJson struct {
+	StopTimes []struct {
+		Place struct {
+			Name               string    `json:"name"`
+			Departure          time.Time `json:"departure"`
+			ScheduledDeparture time.Time `json:"scheduledDeparture"`
+			Track              string    `json:"track"`
+			ScheduledTrack     string    `json:"scheduledTrack"`
+		} `json:"place"`
+		RealTime       bool   `json:"realTime"`
+		Headsign       string `json:"headsign"`
+		RouteShortName string `json:"routeShortName"`
+		RouteColor     string `json:"routeColor"`
+		Cancelled      bool   `json:"cancelled"`
+		TripCancelled  bool   `json:"tripCancelled"`
+	} `json:"stopTimes"`
+}
+
+func fetchDeparturesFromMOTIS(ctx context.Context, stop *DepartureStop, limit int) (string, Departures, error) {
+	baseURL := stop.URL
+
+	if baseURL == "" {
+		baseURL = defaultTransitousURL
+	}
+
+	query := url.Values{}
+	query.Set("stopId", stop.StopID)
+	query.Set("n", strconv.Itoa(limit))
+	// no point in asking for departures that can't be caught anyway
+	query.Set("time", time.Now().Add(stop.WalkingTime).UTC().Format(time.RFC3339))
+
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+"/api/v1/stoptimes?"+query.Encode(), nil)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	setDepartureRequestHeaders(request, stop)
+
+	response, err := decodeJsonFromRequest[motisStopTimesResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	stopName := ""
+	departures := make(Departures, 0, len(response.StopTimes))
+
+	for i := range response.StopTimes {
+		stopTime := &response.StopTimes[i]
+
+		if stopTime.Place.Departure.IsZero() {
+			continue
+		}
+
+		if stopName == "" {
+			stopName = stopTime.Place.Name
+		}
+
+		departure := Departure{
+			Line:        stopTime.RouteShortName,
+			Destination: stopTime.Headsign,
+			Platform:    stopTime.Place.Track,
+			LineColor:   stopTime.RouteColor,
+			Scheduled:   stopTime.Place.ScheduledDeparture,
+			Expected:    stopTime.Place.Departure,
+			RealTime:    stopTime.RealTime,
+			Cancelled:   stopTime.Cancelled || stopTime.TripCancelled,
+		}
+
+		if !hexColorPattern.MatchString(departure.LineColor) {
+			departure.LineColor = ""
+		}
+
+		if departure.Platform == "" {
+			departure.Platform = stopTime.Place.ScheduledTrack
+		}
+
+		if departure.Scheduled.IsZero() {
+			departure.Scheduled = departure.Expected
+		}
+
+		if departure.RealTime {
+			departure.Delay = departure.Expected.Sub(departure.Scheduled)
+		}
+
+		departures = append(departures, departure)
+	}
+
+	return stopName, departures, nil
+}
+
+func fetchDeparturesFromGTFSRealtime(ctx context.Context, stop *DepartureStop) (Departures, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", stop.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/x-protobuf")
+	setDepartureRequestHeaders(request, stop)
+
+	_, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	departures, err := parseGTFSRealtimeDepartures(body, stop.StopID)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse GTFS-RT feed: %w", err)
+	}
+
+	return departures, nil
+}
+
+func setDepartureRequestHeaders(request *http.Request, stop *DepartureStop) {
+	for key, value := range stop.Headers {
+		request.Header.Set(key, value)
+	}
+}
+
+// field numbers from gtfs-realtime.proto, only the ones needed
+// for getting the departures from a stop are decoded
+const (
+	gtfsFeedMessageEntity protowire.Number = 2
+
+	gtfsFeedEntityTripUpdate protowire.Number = 3
+
+	gtfsTripUpdateTrip           protowire.Number = 1
+	gtfsTripUpdateStopTimeUpdate protowire.Number = 2
+	gtfsTripUpdateDelay          protowire.Number = 5
+	gtfsTripUpdateTripProperties protowire.Number = 6
+
+	gtfsTripDescriptorScheduleRelationship protowire.Number = 4
+	gtfsTripDescriptorRouteID              protowire.Number = 5
+
+	gtfsTripPropertiesTripHeadsign protowire.Number = 5
+
+	gtfsStopTimeUpdateArrival              protowire.Number = 2
+	gtfsStopTimeUpdateDeparture            protowire.Number = 3
+	gtfsStopTimeUpdateStopID               protowire.Number = 4
+	gtfsStopTimeUpdateScheduleRelationship protowire.Number = 5
+
+	gtfsStopTimeEventDelay protowire.Number = 1
+	gtfsStopTimeEventTime  protowire.Number = 2
+
+	// schedule relationship values
+	gtfsTripCanceled    = 3
+	gtfsTripDeleted     = 7
+	gtfsStopTimeSkipped = 1
+	gtfsStopTimeNoData  = 2
+)
+
+var errMalformedProtobuf = errors.New("malformed protobuf message")
+
+// forEachProtoField calls fn with every field of the message, for varint fields
+// the value is in varint and for length delimited ones in bytes
+func forEachProtoField(message []byte, fn func(num protowire.Number, varint uint64, bytes []byte)) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+
+		if n < 0 {
+			return errMalformedProtobuf
+		}
+
+		message = message[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(message)
+
+			if n < 0 {
+				return errMalformedProtobuf
+			}
+
+			fn(num, value, nil)
+			message = message[n:]
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(message)
+
+			if n < 0 {
+				return errMalformedProtobuf
+			}
+
+			fn(num, 0, value)
+			message = message[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, message)
+
+			if n < 0 {
+				return errMalformedProtobuf
+			}
+
+			message = message[n:]
+		}
+	}
+
+	return nil
+}
+
+type gtfsStopTimeEvent struct {
+	delay time.Duration
+	time  time.Time
+}
+
+func parseGTFSStopTimeEvent(message []byte) (gtfsStopTimeEvent, error) {
+	var event gtfsStopTimeEvent
+
+	err := forEachProtoField(message, func(num protowire.Number, varint uint64, _ []byte) {
+		switch num {
+		case gtfsStopTimeEventDelay:
+			event.delay = time.Duration(int32(varint)) * time.Second
+		case gtfsStopTimeEventTime:
+			if varint > 0 {
+				event.time = time.Unix(int64(varint), 0)
+			}
+		}
+	})
+
+	return event, err
+}
+
+func parseGTFSRealtimeDepartures(feed []byte, stopID string) (Departures, error) {
+	departures := make(Departures, 0)
+	var tripUpdates [][]byte
+
+	err := forEachProtoField(feed, func(num protowire.Number, _ uint64, entity []byte) {
+		if num != gtfsFeedMessageEntity {
+			return
+		}
+
+		forEachProtoField(entity, func(num protowire.Number, _ uint64, tripUpdate []byte) {
+			if num == gtfsFeedEntityTripUpdate {
+				tripUpdates = append(tripUpdates, tripUpdate)
+			}
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tripUpdate := range tripUpdates {
+		var routeID, headsign string
+		var tripDelay time.Duration
+		var hasTripDelay, tripCancelled bool
+		var stopTimeUpdates [][]byte
+
+		err := forEachProtoField(tripUpdate, func(num protowire.Number, varint uint64, value []byte) {
+			switch num {
+			case gtfsTripUpdateTrip:
+				forEachProtoField(value, func(num protowire.Number, varint uint64, value []byte) {
+					switch num {
+					case gtfsTripDescriptorRouteID:
+						routeID = string(value)
+					case gtfsTripDescriptorScheduleRelationship:
+						tripCancelled = varint == gtfsTripCanceled || varint == gtfsTripDeleted
+					}
+				})
+			case gtfsTripUpdateTripProperties:
+				forEachProtoField(value, func(num protowire.Number, _ uint64, value []byte) {
+					if num == gtfsTripPropertiesTripHeadsign {
+						headsign = string(value)
+					}
+				})
+			case gtfsTripUpdateDelay:
+				tripDelay = time.Duration(int32(varint)) * time.Second
+				hasTripDelay = true
+			case gtfsTripUpdateStopTimeUpdate:
+				stopTimeUpdates = append(stopTimeUpdates, value)
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, update := range stopTimeUpdates {
+			var updateStopID string
+			var relationship uint64
+			var arrival, departure []byte
+
+			err := forEachProtoField(update, func(num protowire.Number, varint uint64, value []byte) {
+				switch num {
+				case gtfsStopTimeUpdateStopID:
+					updateStopID = string(value)
+				case gtfsStopTimeUpdateScheduleRelationship:
+					relationship = varint
+				case gtfsStopTimeUpdateArrival:
+					arrival = value
+				case gtfsStopTimeUpdateDeparture:
+					departure = value
+				}
+			})
+
+			if err != nil {
+				return nil, err
+			}
+
+			if updateStopID != stopID || relationship == gtfsStopTimeNoData {
+				continue
+			}
+
+			// the last stop of a trip only has an arrival
+			if departure == nil {
+				departure = arrival
+			}
+
+			event, err := parseGTFSStopTimeEvent(departure)
+
+			if err != nil {
+				return nil, err
+			}
+
+			// without the static schedule there's no way to know when a
+			// departure that only specifies a delay is supposed to happen
+			if event.time.IsZero() {
+				continue
+			}
+
+			if event.delay == 0 && hasTripDelay {
+				event.delay = tripDelay
+			}
+
+			departures = append(departures, Departure{
+				Line:        routeID,
+				Destination: headsign,
+				Scheduled:   event.time.Add(-event.delay),
+				Expected:    event.time,
+				Delay:       event.delay,
+				RealTime:    true,
+				Cancelled:   tripCancelled || relationship == gtfsStopTimeSkipped,
+			})
+		}
+	}
+
+	return departures, nil
+}
+
+func FetchDepartures(ctx context.Context, stops []DepartureStop, limit int) ([]StopDepartures, error) {
+	task := func(stop *DepartureStop) (StopDepartures, error) {
+		result := StopDepartures{
+			Name:        stop.Name,
+			WalkingTime: stop.WalkingTime,
+		}
+
+		var departures Departures
+		var stopName string
+		var err error
+
+		// fetch more than needed so that there's still enough
+		// left once departures start leaving between updates
+		fetchLimit := limit * 2
+
+		switch stop.Backend {
+		case "transitous":
+			stopName, departures, err = fetchDeparturesFromMOTIS(ctx, stop, fetchLimit)
+		case "gtfs-rt":
+			departures, err = fetchDeparturesFromGTFSRealtime(ctx, stop)
+		default:
+			err = fmt.Errorf("unknown departures backend: %s", stop.Backend)
+		}
+
+		if err != nil {
+			return result, err
+		}
+
+		if result.Name == "" {
+			result.Name = stopName
+		}
+
+		if result.Name == "" {
+			result.Name = stop.StopID
+		}
+
+		departures = departures.Catchable(time.Now(), stop.WalkingTime).SortByExpected()
+
+		if len(departures) > fetchLimit {
+			departures = departures[:fetchLimit]
+		}
+
+		result.Departures = departures
+
+		return result, nil
+	}
+
+	stopPointers := make([]*DepartureStop, len(stops))
+
+	for i := range stops {
+		stopPointers[i] = &stops[i]
+	}
+
+	job := newJob(task, stopPointers).withWorkers(len(stops))
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stopsDepartures := make([]StopDepartures, 0, len(results))
+
+	for i := range results {
+		if errs[i] != nil {
+			slog.Error("Failed to fetch departures", "backend", stops[i].Backend, "stop", stops[i].StopID, "error", errs[i])
+			continue
+		}
+
+		stopsDepartures = append(stopsDepartures, results[i])
+	}
+
+	if len(stopsDepartures) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if failed := len(stops) - len(stopsDepartures); failed > 0 {
+		return stopsDepartures, fmt.Errorf("%w: could not get departures for %d stop(s)", ErrPartialContent, failed)
+	}
+
+	return stopsDepartures, nil
+}
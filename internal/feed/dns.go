@@ -0,0 +1,124 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const defaultDNSFailureCacheTTL = 30 * time.Second
+
+var ErrRecentDNSFailure = errors.New("host recently failed to resolve")
+
+type cachedFailure struct {
+	err     error
+	expires time.Time
+}
+
+// failureCache remembers keys which recently failed so that requests for them
+// can fail immediately instead of being attempted again, entries expire after
+// the TTL so recovery is picked up
+type failureCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	failures map[string]cachedFailure
+}
+
+func newFailureCache(ttl time.Duration) *failureCache {
+	return &failureCache{
+		ttl:      ttl,
+		failures: make(map[string]cachedFailure),
+	}
+}
+
+func (c *failureCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+	clear(c.failures)
+}
+
+// hosts which recently failed to resolve, so that requests to them don't
+// have to wait for the resolver to time out again
+var resolveFailures = newFailureCache(defaultDNSFailureCacheTTL)
+
+// SetDNSFailureCacheTTL changes how long a failed lookup is remembered for,
+// a TTL of 0 disables the cache
+func SetDNSFailureCacheTTL(ttl time.Duration) {
+	resolveFailures.setTTL(ttl)
+}
+
+func (c *failureCache) get(key string) (error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failure, ok := c.failures[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(failure.expires) {
+		delete(c.failures, key)
+		return nil, false
+	}
+
+	return failure.err, true
+}
+
+func (c *failureCache) add(key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	for key, failure := range c.failures {
+		if now.After(failure.expires) {
+			delete(c.failures, key)
+		}
+	}
+
+	c.failures[key] = cachedFailure{err: err, expires: now.Add(c.ttl)}
+}
+
+type dialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func newDefaultDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+func dialContextWithDNSFailureCache(dial dialContextFunc) dialContextFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		if cachedErr, ok := resolveFailures.get(host); ok {
+			return nil, fmt.Errorf("%w: %s: %w", ErrRecentDNSFailure, host, cachedErr)
+		}
+
+		conn, err := dial(ctx, network, address)
+
+		var dnsErr *net.DNSError
+
+		// don't remember failures caused by the caller giving up
+		if err != nil && errors.As(err, &dnsErr) && ctx.Err() == nil {
+			resolveFailures.add(host, dnsErr)
+		}
+
+		return conn, err
+	}
+}
@@ -0,0 +1,48 @@
+package feed
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DetectEmbeddingBlocked sends a HEAD request to the URL and reports why the
+// response headers would prevent it from being embedded in a frame on another
+// site, or an empty string if nothing in them does
+func DetectEmbeddingBlocked(url string) (string, error) {
+	request, err := http.NewRequest(http.MethodHead, url, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	addBrowserUserAgentHeader(request)
+	response, err := defaultClient().Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	response.Body.Close()
+
+	if options := strings.ToUpper(strings.TrimSpace(response.Header.Get("X-Frame-Options"))); options == "DENY" || options == "SAMEORIGIN" {
+		return "X-Frame-Options is set to " + options, nil
+	}
+
+	for _, directive := range strings.Split(response.Header.Get("Content-Security-Policy"), ";") {
+		fields := strings.Fields(directive)
+
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "frame-ancestors") {
+			continue
+		}
+
+		for _, source := range fields[1:] {
+			if source == "*" {
+				return "", nil
+			}
+		}
+
+		return "Content-Security-Policy has frame-ancestors " + strings.Join(fields[1:], " "), nil
+	}
+
+	return "", nil
+}
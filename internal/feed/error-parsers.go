@@ -0,0 +1,222 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+)
+
+// WithErrorBodyParser sets a parser for the bodies of responses with a status code
+// which isn't accepted, for APIs that say what went wrong in a structured format.
+// When parser returns an error it becomes the Detail of the HTTPError, making it
+// reachable through errors.As, while a nil return leaves the raw body as it is.
+func WithErrorBodyParser(parser func(statusCode int, body []byte) error) DecodeOption {
+	return func(o *decodeOptions) {
+		o.errorBodyParser = parser
+	}
+}
+
+func (o *decodeOptions) parseErrorBody(err error, body []byte) error {
+	if o.errorBodyParser == nil || len(body) == 0 {
+		return err
+	}
+
+	httpErr, ok := ExtractHTTPError(err)
+
+	if !ok {
+		return err
+	}
+
+	detail := o.errorBodyParser(httpErr.StatusCode, body)
+
+	if detail == nil {
+		return err
+	}
+
+	// the error may be shared through the cache of not found responses, so it's copied rather than modified
+	if err == error(httpErr) {
+		detailed := *httpErr
+		detailed.Detail = detail
+		return &detailed
+	}
+
+	return fmt.Errorf("%w: %w", err, detail)
+}
+
+// ProblemDetails is an error response as described by RFC 7807
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+func (p *ProblemDetails) Error() string {
+	title := p.Title
+
+	if title == "" {
+		title = p.Type
+	}
+
+	if p.Detail == "" {
+		return title
+	}
+
+	return title + ": " + p.Detail
+}
+
+func RFC7807Parser() func(int, []byte) error {
+	return func(statusCode int, body []byte) error {
+		var problem ProblemDetails
+
+		if err := json.Unmarshal(body, &problem); err != nil {
+			return nil
+		}
+
+		if problem.Type == "" && problem.Title == "" {
+			return nil
+		}
+
+		if problem.Status == 0 {
+			problem.Status = statusCode
+		}
+
+		return &problem
+	}
+}
+
+// AWSError is an error response in the XML format used by AWS and services
+// compatible with it, such as S3 and MinIO
+type AWSError struct {
+	Type      string
+	Code      string
+	Message   string
+	RequestID string
+}
+
+func (e *AWSError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+
+	return e.Code + ": " + e.Message
+}
+
+type awsErrorXML struct {
+	Type      string `xml:"Type"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+	RequestID string `xml:"RequestId"`
+}
+
+// AWSXMLErrorParser handles both the <ErrorResponse><Error>...</Error></ErrorResponse>
+// form used by most services and the bare <Error>...</Error> one used by S3
+func AWSXMLErrorParser() func(int, []byte) error {
+	return func(_ int, body []byte) error {
+		var root struct {
+			XMLName   xml.Name
+			Error     awsErrorXML `xml:"Error"`
+			RequestID string      `xml:"RequestId"`
+			awsErrorXML
+		}
+
+		if err := xml.Unmarshal(body, &root); err != nil {
+			return nil
+		}
+
+		var parsed awsErrorXML
+
+		switch root.XMLName.Local {
+		case "ErrorResponse":
+			parsed = root.Error
+		case "Error":
+			parsed = root.awsErrorXML
+		default:
+			return nil
+		}
+
+		// it's a sibling of <Error> in one form and a child of it in the other,
+		// which in both cases ends up in the outer field
+		if parsed.RequestID == "" {
+			parsed.RequestID = root.RequestID
+		}
+
+		if parsed.Code == "" {
+			return nil
+		}
+
+		return &AWSError{
+			Type:      parsed.Type,
+			Code:      parsed.Code,
+			Message:   parsed.Message,
+			RequestID: parsed.RequestID,
+		}
+	}
+}
+
+// APIError is an error response with a code and a message, which is what most
+// APIs described by an OpenAPI spec return in one shape or another
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+
+	return e.Code + ": " + e.Message
+}
+
+type apiErrorJSON struct {
+	Code    json.RawMessage `json:"code"`
+	Message string          `json:"message"`
+}
+
+// OpenAPIErrorParser handles {"code": ..., "message": "..."}, with the code being
+// either a number or a string, as well as the same nested under "error"
+func OpenAPIErrorParser() func(int, []byte) error {
+	return func(_ int, body []byte) error {
+		var root struct {
+			apiErrorJSON
+			Error *apiErrorJSON `json:"error"`
+		}
+
+		if err := json.Unmarshal(body, &root); err != nil {
+			return nil
+		}
+
+		parsed := root.apiErrorJSON
+
+		if root.Error != nil {
+			parsed = *root.Error
+		}
+
+		if parsed.Message == "" {
+			return nil
+		}
+
+		return &APIError{
+			Code:    rawCodeToString(parsed.Code),
+			Message: parsed.Message,
+		}
+	}
+}
+
+func rawCodeToString(raw json.RawMessage) string {
+	var asString string
+
+	if err := json.Unmarshal(raw, &asString); err == nil {
+		return asString
+	}
+
+	var asNumber json.Number
+
+	if err := json.Unmarshal(raw, &asNumber); err == nil {
+		return asNumber.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRFC7807Parser(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *ProblemDetails
+	}{
+		{
+			name: "full problem",
+			body: `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc"}`,
+			expected: &ProblemDetails{
+				Type:     "https://example.com/probs/out-of-credit",
+				Title:    "You do not have enough credit.",
+				Status:   403,
+				Detail:   "Your current balance is 30, but that costs 50.",
+				Instance: "/account/12345/msgs/abc",
+			},
+		},
+		{
+			name:     "status taken from the response",
+			body:     `{"title":"Not Found"}`,
+			expected: &ProblemDetails{Title: "Not Found", Status: 404},
+		},
+		{name: "unrelated JSON", body: `{"error":"nope"}`},
+		{name: "not JSON", body: `<html>Bad Gateway</html>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := RFC7807Parser()(http.StatusNotFound, []byte(test.body))
+
+			if test.expected == nil {
+				if err != nil {
+					t.Errorf("expected the body to not be recognized, got %v", err)
+				}
+				return
+			}
+
+			problem, ok := err.(*ProblemDetails)
+
+			if !ok || *problem != *test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, err)
+			}
+		})
+	}
+
+	problem := &ProblemDetails{Title: "Bad Request", Detail: "missing id"}
+
+	if message := problem.Error(); message != "Bad Request: missing id" {
+		t.Errorf("unexpected message: %q", message)
+	}
+}
+
+func TestAWSXMLErrorParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *AWSError
+	}{
+		{
+			name: "error response",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>InvalidClientTokenId</Code>
+    <Message>The security token included in the request is invalid.</Message>
+  </Error>
+  <RequestId>4b8a4d7b-1c2c-4b5f-9f3a-4d7b1c2c4b5f</RequestId>
+</ErrorResponse>`,
+			expected: &AWSError{
+				Type:      "Sender",
+				Code:      "InvalidClientTokenId",
+				Message:   "The security token included in the request is invalid.",
+				RequestID: "4b8a4d7b-1c2c-4b5f-9f3a-4d7b1c2c4b5f",
+			},
+		},
+		{
+			name: "S3 error",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>NoSuchKey</Code>
+  <Message>The resource you requested does not exist</Message>
+  <Resource>/mybucket/myfoto.jpg</Resource>
+  <RequestId>4442587FB7D0A2F9</RequestId>
+</Error>`,
+			expected: &AWSError{
+				Code:      "NoSuchKey",
+				Message:   "The resource you requested does not exist",
+				RequestID: "4442587FB7D0A2F9",
+			},
+		},
+		{name: "without a code", body: `<Error><Message>Something</Message></Error>`},
+		{name: "other XML", body: `<rss><channel></channel></rss>`},
+		{name: "not XML", body: `{"code":"NoSuchKey"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := AWSXMLErrorParser()(http.StatusForbidden, []byte(test.body))
+
+			if test.expected == nil {
+				if err != nil {
+					t.Errorf("expected the body to not be recognized, got %v", err)
+				}
+				return
+			}
+
+			awsErr, ok := err.(*AWSError)
+
+			if !ok || *awsErr != *test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestOpenAPIErrorParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *APIError
+	}{
+		{name: "string code", body: `{"code":"rate_limited","message":"Too many requests"}`, expected: &APIError{Code: "rate_limited", Message: "Too many requests"}},
+		{name: "numeric code", body: `{"code":1003,"message":"Invalid key"}`, expected: &APIError{Code: "1003", Message: "Invalid key"}},
+		{name: "nested under error", body: `{"error":{"code":401,"message":"Unauthorized","status":"UNAUTHENTICATED"}}`, expected: &APIError{Code: "401", Message: "Unauthorized"}},
+		{name: "message only", body: `{"message":"Not Found"}`, expected: &APIError{Message: "Not Found"}},
+		{name: "without a message", body: `{"code":500}`},
+		{name: "not JSON", body: `Internal Server Error`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := OpenAPIErrorParser()(http.StatusBadRequest, []byte(test.body))
+
+			if test.expected == nil {
+				if err != nil {
+					t.Errorf("expected the body to not be recognized, got %v", err)
+				}
+				return
+			}
+
+			apiErr, ok := err.(*APIError)
+
+			if !ok || *apiErr != *test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestWithErrorBodyParser(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/problem":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"type":"about:blank","title":"Forbidden","detail":"token expired"}`))
+		default:
+			http.Error(w, "plain text failure", http.StatusInternalServerError)
+		}
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/problem", nil)
+	_, err := decodeJsonFromRequest[map[string]any](server.Client(), request, WithErrorBodyParser(RFC7807Parser()))
+
+	var problem *ProblemDetails
+
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected the problem details to be reachable through errors.As, got %v", err)
+	}
+
+	if problem.Status != http.StatusForbidden || problem.Detail != "token expired" {
+		t.Errorf("unexpected problem details: %+v", problem)
+	}
+
+	if !HasStatusCode(err, http.StatusForbidden) {
+		t.Errorf("expected the HTTPError to still be reachable, got %v", err)
+	}
+
+	// bodies the parser doesn't recognize are kept as they are
+	request, _ = http.NewRequest(http.MethodGet, server.URL+"/plain", nil)
+	_, err = decodeJsonFromRequest[map[string]any](server.Client(), request, WithErrorBodyParser(RFC7807Parser()))
+	httpErr, ok := ExtractHTTPError(err)
+
+	if !ok || httpErr.Detail != nil || httpErr.Body == "" {
+		t.Errorf("expected the raw body without a detail, got %+v", httpErr)
+	}
+
+	if errors.As(err, &problem) {
+		t.Error("expected no problem details for a plain text body")
+	}
+}
@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const maxCommandOutputSize = 1024 * 1024
+
+var ErrCommandFailed = errors.New("command exited with a non-zero status")
+
+type CommandRequest struct {
+	Command []string
+	Dir     string
+	Env     []string
+	Timeout time.Duration
+}
+
+type CommandOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// limitedBuffer silently discards anything written past its limit so that a
+// runaway command can't use up all of the memory
+type limitedBuffer struct {
+	buffer bytes.Buffer
+	limit  int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buffer.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buffer.Write(p[:remaining])
+		} else {
+			b.buffer.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+// RunCommand runs the command with exactly the given environment, nothing is
+// inherited from the current process. A non-zero exit status is reported as
+// ErrCommandFailed with the output still being returned.
+func RunCommand(ctx context.Context, request CommandRequest) (*CommandOutput, error) {
+	if len(request.Command) == 0 {
+		return nil, errors.New("no command specified")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, request.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, request.Command[0], request.Command[1:]...)
+	cmd.Dir = request.Dir
+	cmd.Env = append(make([]string, 0, len(request.Env)), request.Env...)
+	// don't wait forever on children which inherited the pipes
+	cmd.WaitDelay = time.Second
+
+	stdout := &limitedBuffer{limit: maxCommandOutputSize}
+	stderr := &limitedBuffer{limit: maxCommandOutputSize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	startedAt := time.Now()
+	err := cmd.Run()
+
+	output := &CommandOutput{
+		Stdout:   stdout.buffer.String(),
+		Stderr:   stderr.buffer.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(startedAt),
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("command timed out after %s", request.Timeout)
+	}
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		return output, fmt.Errorf("%w: exit status %d", ErrCommandFailed, output.ExitCode)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
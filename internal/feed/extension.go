@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"html/template"
@@ -50,8 +51,8 @@ func convertExtensionContent(options ExtensionRequestOptions, content []byte, co
 	}
 }
 
-func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
-	request, _ := http.NewRequest("GET", options.URL, nil)
+func FetchExtension(ctx context.Context, options ExtensionRequestOptions) (Extension, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", options.URL, nil)
 
 	query := url.Values{}
 
@@ -71,6 +72,7 @@ func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
 	defer response.Body.Close()
 
 	body, err := io.ReadAll(response.Body)
+	recordBandwidth(request, len(body))
 
 	if err != nil {
 		slog.Error("failed reading response body of extension", "error", err, "url", options.URL)
@@ -0,0 +1,298 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	faviconCacheSize     = 256
+	faviconCacheDuration = 24 * time.Hour
+	maxFaviconSize       = 256 * 1024
+	// icons larger than this are scaled down by the browser anyway so there's
+	// no point in embedding them in the page
+	preferredFaviconSize = 64
+)
+
+var ErrNoFavicon = errors.New("no usable favicon found")
+
+type faviconCacheEntry struct {
+	dataURI string
+	err     error
+	expires time.Time
+}
+
+type faviconCache struct {
+	mu      sync.Mutex
+	entries map[string]*faviconCacheEntry
+	order   []string
+}
+
+var favicons = &faviconCache{
+	entries: make(map[string]*faviconCacheEntry),
+}
+
+func (c *faviconCache) get(host string) (*faviconCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[host]
+
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (c *faviconCache) add(host string, entry *faviconCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[host]; !ok {
+		c.order = append(c.order, host)
+	}
+
+	c.entries[host] = entry
+
+	// evict the oldest entries first
+	for len(c.order) > faviconCacheSize {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+type faviconCandidate struct {
+	url  string
+	size int
+}
+
+// ResolveFavicon finds the favicon of the site the URL points to and returns it
+// as a data URI. The icons declared in the page through <link rel="icon"> are
+// preferred, falling back to /favicon.ico. Results, including failures, are
+// cached per host.
+func ResolveFavicon(ctx context.Context, siteURL string) (string, error) {
+	parsed, err := url.Parse(siteURL)
+
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid URL: %s", siteURL)
+	}
+
+	if entry, ok := favicons.get(parsed.Host); ok {
+		return entry.dataURI, entry.err
+	}
+
+	dataURI, err := fetchFavicon(ctx, parsed)
+
+	// failures are cached for less time so that a site that was temporarily
+	// down gets a chance to show its icon
+	duration := faviconCacheDuration
+
+	if err != nil {
+		duration = time.Hour
+	}
+
+	favicons.add(parsed.Host, &faviconCacheEntry{
+		dataURI: dataURI,
+		err:     err,
+		expires: time.Now().Add(duration),
+	})
+
+	return dataURI, err
+}
+
+func fetchFavicon(ctx context.Context, siteURL *url.URL) (string, error) {
+	candidates, base := faviconCandidatesFromPage(ctx, siteURL)
+	candidates = append(candidates, faviconCandidate{url: "/favicon.ico"})
+
+	for _, candidate := range candidates {
+		iconURL, err := base.Parse(candidate.url)
+
+		if err != nil {
+			continue
+		}
+
+		if dataURI, err := fetchFaviconAsDataURI(ctx, iconURL); err == nil {
+			return dataURI, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w for %s", ErrNoFavicon, siteURL.Host)
+}
+
+// faviconCandidatesFromPage returns the icons declared in the page ordered with
+// the best first along with the URL they're relative to, which may differ from
+// the given one if the page redirected
+func faviconCandidatesFromPage(ctx context.Context, siteURL *url.URL) ([]faviconCandidate, *url.URL) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", siteURL.String(), nil)
+	addBrowserUserAgentHeader(request)
+
+	response, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, siteURL
+	}
+
+	base := response.Request.URL
+	reader, err := charset.NewReader(bytes.NewReader(body), response.Header.Get("Content-Type"))
+
+	if err != nil {
+		return nil, base
+	}
+
+	document, err := goquery.NewDocumentFromReader(reader)
+
+	if err != nil {
+		return nil, base
+	}
+
+	if href, ok := document.Find("base[href]").First().Attr("href"); ok {
+		if parsed, err := base.Parse(href); err == nil {
+			base = parsed
+		}
+	}
+
+	candidates := make([]faviconCandidate, 0)
+
+	document.Find("link[rel][href]").Each(func(_ int, s *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+
+		if href == "" || !containsAny(rel, "icon", "apple-touch-icon") {
+			return
+		}
+
+		candidates = append(candidates, faviconCandidate{
+			url:  href,
+			size: parseFaviconSizes(s.AttrOr("sizes", ""), s.AttrOr("type", "")),
+		})
+	})
+
+	sortFaviconCandidates(candidates)
+
+	return candidates, base
+}
+
+func containsAny(values []string, targets ...string) bool {
+	for _, value := range values {
+		for _, target := range targets {
+			if value == target {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseFaviconSizes returns the largest size listed in the sizes attribute,
+// vector icons and sizes="any" scale to anything so they're treated as ideal
+func parseFaviconSizes(sizes string, contentType string) int {
+	if strings.EqualFold(sizes, "any") || strings.Contains(contentType, "svg") {
+		return preferredFaviconSize
+	}
+
+	largest := 0
+
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		width, _, ok := strings.Cut(size, "x")
+
+		if !ok {
+			continue
+		}
+
+		if parsed, err := strconv.Atoi(width); err == nil && parsed > largest {
+			largest = parsed
+		}
+	}
+
+	return largest
+}
+
+// sortFaviconCandidates orders the candidates by how close their size is to the
+// preferred one, bigger icons look better than smaller ones when resized so
+// they're preferred when the distance is the same, icons without a size are last
+func sortFaviconCandidates(candidates []faviconCandidate) {
+	distance := func(c faviconCandidate) int {
+		if c.size == 0 {
+			return 1 << 30
+		}
+
+		if c.size >= preferredFaviconSize {
+			return c.size - preferredFaviconSize
+		}
+
+		return (preferredFaviconSize - c.size) * 2
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distance(candidates[i]) < distance(candidates[j])
+	})
+}
+
+func fetchFaviconAsDataURI(ctx context.Context, iconURL *url.URL) (string, error) {
+	if strings.HasPrefix(iconURL.String(), "data:image/") {
+		return iconURL.String(), nil
+	}
+
+	if iconURL.Scheme != "http" && iconURL.Scheme != "https" {
+		return "", fmt.Errorf("unsupported favicon URL scheme: %s", iconURL.Scheme)
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", iconURL.String(), nil)
+	addBrowserUserAgentHeader(request)
+
+	response, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) == 0 {
+		return "", errors.New("favicon is empty")
+	}
+
+	if len(body) > maxFaviconSize {
+		return "", fmt.Errorf("favicon is larger than %d bytes", maxFaviconSize)
+	}
+
+	contentType, err := faviconContentType(response.Header.Get("Content-Type"), body)
+
+	if err != nil {
+		return "", err
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(body), nil
+}
+
+// faviconContentType validates that the icon is actually an image, servers
+// commonly respond to missing icons with a 200 and an HTML page
+func faviconContentType(declared string, body []byte) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(declared)
+	sniffed := http.DetectContentType(body)
+
+	// DetectContentType doesn't know about SVG and reports it as text
+	if mediaType == "image/svg+xml" && bytes.Contains(body[:min(len(body), 1024)], []byte("<svg")) {
+		return mediaType, nil
+	}
+
+	if strings.HasPrefix(sniffed, "image/") {
+		return sniffed, nil
+	}
+
+	return "", fmt.Errorf("favicon has unsupported content type %q", mediaType)
+}
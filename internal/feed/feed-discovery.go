@@ -0,0 +1,176 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+var ErrNoFeedsFound = errors.New("no feeds found")
+
+type DiscoveredFeed struct {
+	Title string
+	URL   string
+}
+
+type opmlOutlineXml struct {
+	Text     string           `xml:"text,attr"`
+	Title    string           `xml:"title,attr"`
+	XMLURL   string           `xml:"xmlUrl,attr"`
+	Outlines []opmlOutlineXml `xml:"outline"`
+}
+
+type opmlDocumentXml struct {
+	XMLName  xml.Name         `xml:"opml"`
+	Outlines []opmlOutlineXml `xml:"body>outline"`
+}
+
+// ParseOPML returns the feeds listed in an OPML document such as the ones
+// exported by feed readers, including those nested within categories
+func ParseOPML(contents []byte) ([]DiscoveredFeed, error) {
+	var document opmlDocumentXml
+
+	decoder := xml.NewDecoder(bytes.NewReader(contents))
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("could not parse OPML: %w", err)
+	}
+
+	feeds := make([]DiscoveredFeed, 0)
+	seen := make(map[string]bool)
+
+	var collect func(outlines []opmlOutlineXml)
+	collect = func(outlines []opmlOutlineXml) {
+		for i := range outlines {
+			outline := &outlines[i]
+			feedURL := strings.TrimSpace(outline.XMLURL)
+
+			if feedURL != "" && !seen[feedURL] {
+				seen[feedURL] = true
+
+				title := outline.Title
+
+				if title == "" {
+					title = outline.Text
+				}
+
+				feeds = append(feeds, DiscoveredFeed{Title: strings.TrimSpace(title), URL: feedURL})
+			}
+
+			collect(outline.Outlines)
+		}
+	}
+
+	collect(document.Outlines)
+
+	if len(feeds) == 0 {
+		return nil, ErrNoFeedsFound
+	}
+
+	return feeds, nil
+}
+
+func FetchFeedsFromOPML(opmlURL string) ([]DiscoveredFeed, error) {
+	request, err := http.NewRequest("GET", opmlURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseOPML(body)
+}
+
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/json":      true,
+}
+
+// DiscoverFeeds returns the feeds a page advertises through <link rel="alternate">
+// tags, with their URLs resolved against the page
+func DiscoverFeeds(pageURL string) ([]DiscoveredFeed, error) {
+	request, err := http.NewRequest("GET", pageURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addBrowserUserAgentHeader(request)
+
+	response, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := charset.NewReader(bytes.NewReader(body), response.Header.Get("Content-Type"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := goquery.NewDocumentFromReader(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base := response.Request.URL
+
+	if href, ok := document.Find("base[href]").First().Attr("href"); ok {
+		if parsed, err := base.Parse(href); err == nil {
+			base = parsed
+		}
+	}
+
+	feeds := make([]DiscoveredFeed, 0)
+	seen := make(map[string]bool)
+
+	document.Find("link[rel][href][type]").Each(func(_ int, s *goquery.Selection) {
+		rel := strings.Fields(strings.ToLower(s.AttrOr("rel", "")))
+		linkType := strings.ToLower(strings.TrimSpace(s.AttrOr("type", "")))
+
+		if !containsAny(rel, "alternate") || !feedLinkTypes[linkType] {
+			return
+		}
+
+		// plain JSON alternates are only feeds when they say so, otherwise
+		// they tend to be things like oEmbed or API representations of the page
+		if linkType == "application/json" && !strings.Contains(strings.ToLower(s.AttrOr("title", "")), "feed") {
+			return
+		}
+
+		feedURL, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+
+		if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || seen[feedURL.String()] {
+			return
+		}
+
+		seen[feedURL.String()] = true
+		feeds = append(feeds, DiscoveredFeed{
+			Title: strings.TrimSpace(s.AttrOr("title", "")),
+			URL:   feedURL.String(),
+		})
+	})
+
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("%w on %s", ErrNoFeedsFound, (&url.URL{Scheme: base.Scheme, Host: base.Host}).String())
+	}
+
+	return feeds, nil
+}
@@ -0,0 +1,128 @@
+package feed
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileTransport serves requests for file:// URLs from the local filesystem so
+// that they can go through the same decode helpers as remote data. Missing
+// files result in a 404 and unreadable ones in a 403, files ending in .gz are
+// decompressed with the Content-Type being guessed from the name without it.
+type FileTransport struct{}
+
+func (FileTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+
+	if request.URL.Scheme != "file" {
+		return nil, fmt.Errorf("unsupported scheme for file transport: %s", request.URL.Scheme)
+	}
+
+	if request.URL.Host != "" && request.URL.Host != "localhost" {
+		return nil, fmt.Errorf("file URLs with a remote host are not supported: %s", request.URL.Host)
+	}
+
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return newFileResponse(request, http.StatusMethodNotAllowed, nil), nil
+	}
+
+	path := filepath.FromSlash(request.URL.Path)
+	file, err := os.Open(path)
+
+	if err != nil {
+		return newFileResponse(request, statusFromFileError(err), nil), nil
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return newFileResponse(request, statusFromFileError(err), nil), nil
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return newFileResponse(request, http.StatusForbidden, nil), nil
+	}
+
+	response := newFileResponse(request, http.StatusOK, file)
+	response.Header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+
+	name := path
+
+	if strings.HasSuffix(name, ".gz") {
+		reader, err := gzip.NewReader(file)
+
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("could not decompress %s: %w", path, err)
+		}
+
+		name = strings.TrimSuffix(name, ".gz")
+		response.Body = &gzipFileReader{Reader: reader, file: file}
+	} else {
+		response.ContentLength = info.Size()
+		response.Header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		response.Header.Set("Content-Type", contentType)
+	}
+
+	if request.Method == http.MethodHead {
+		response.Body.Close()
+		response.Body = http.NoBody
+	}
+
+	return response, nil
+}
+
+func statusFromFileError(err error) int {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func newFileResponse(request *http.Request, status int, body io.ReadCloser) *http.Response {
+	if body == nil {
+		body = http.NoBody
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          body,
+		ContentLength: -1,
+		Request:       request,
+	}
+}
+
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *gzipFileReader) Close() error {
+	r.Reader.Close()
+	return r.file.Close()
+}
@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"compress/gzip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func fileURL(path string) string {
+	return "file://" + filepath.ToSlash(path)
+}
+
+func writeTestFile(t *testing.T, name string, content []byte, gzipped bool) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	file, err := os.Create(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	if !gzipped {
+		file.Write(content)
+		return path
+	}
+
+	writer := gzip.NewWriter(file)
+	writer.Write(content)
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestFileTransportDecodesFiles(t *testing.T) {
+	t.Cleanup(ResetDefaultClients)
+
+	client, err := GetClientWithOptions(WithFileTransport())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name    string
+		gzipped bool
+	}{{"data.json", false}, {"data.json.gz", true}} {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeTestFile(t, test.name, []byte(`{"count": 3}`), test.gzipped)
+			request, _ := http.NewRequest(http.MethodGet, fileURL(path), nil)
+
+			result, err := decodeJsonFromRequest[map[string]int](client, request)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result["count"] != 3 {
+				t.Errorf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func TestFileTransportStatusCodes(t *testing.T) {
+	dir := t.TempDir()
+	existing := writeTestFile(t, "data.json", []byte(`{}`), false)
+
+	unreadable := writeTestFile(t, "secret.json", []byte(`{}`), false)
+	os.Chmod(unreadable, 0)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{name: "existing file", method: http.MethodGet, path: existing, expected: http.StatusOK},
+		{name: "head request", method: http.MethodHead, path: existing, expected: http.StatusOK},
+		{name: "missing file", method: http.MethodGet, path: filepath.Join(dir, "missing.json"), expected: http.StatusNotFound},
+		{name: "directory", method: http.MethodGet, path: dir, expected: http.StatusForbidden},
+		{name: "permission denied", method: http.MethodGet, path: unreadable, expected: http.StatusForbidden},
+		{name: "unsupported method", method: http.MethodPost, path: existing, expected: http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.path == unreadable && os.Geteuid() == 0 {
+				t.Skip("permissions don't apply to root")
+			}
+
+			request, _ := http.NewRequest(test.method, fileURL(test.path), nil)
+			response, err := FileTransport{}.RoundTrip(request)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response.Body.Close()
+
+			if response.StatusCode != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestFileTransportContentHeaders(t *testing.T) {
+	path := writeTestFile(t, "data.json", []byte(`{"a":1}`), false)
+	request, _ := http.NewRequest(http.MethodGet, fileURL(path), nil)
+	response, err := FileTransport{}.RoundTrip(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if response.ContentLength != 7 || response.Header.Get("Content-Type") != "application/json" || response.Header.Get("Last-Modified") == "" {
+		t.Errorf("unexpected headers: %d, %v", response.ContentLength, response.Header)
+	}
+}
+
+func TestFileTransportRejectsRemoteHosts(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodGet, "file://example.com/etc/hosts", nil)
+
+	if _, err := (FileTransport{}).RoundTrip(request); err == nil {
+		t.Error("expected file URLs with a remote host to be rejected")
+	}
+}
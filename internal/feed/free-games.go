@@ -0,0 +1,372 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FreeGame struct {
+	// prefixed with the store so that it's unique across stores and
+	// stable across updates, used to remember which games were claimed
+	ID            string
+	Store         string
+	Title         string
+	URL           string
+	ImageURL      string
+	OriginalPrice string
+	StartsAt      time.Time
+	// zero when the store doesn't say when the giveaway ends
+	EndsAt   time.Time
+	Upcoming bool
+}
+
+type FreeGames []FreeGame
+
+// SortByAvailability puts the games which are currently free first, ending
+// soonest first, followed by the upcoming ones in the order they start in
+func (g FreeGames) SortByAvailability() {
+	sort.SliceStable(g, func(i, j int) bool {
+		if g[i].Upcoming != g[j].Upcoming {
+			return !g[i].Upcoming
+		}
+
+		if g[i].Upcoming {
+			return g[i].StartsAt.Before(g[j].StartsAt)
+		}
+
+		if g[i].EndsAt.IsZero() != g[j].EndsAt.IsZero() {
+			return !g[i].EndsAt.IsZero()
+		}
+
+		return g[i].EndsAt.Before(g[j].EndsAt)
+	})
+}
+
+type FreeGamesRequest struct {
+	Country string
+	Locale  string
+	GOG     bool
+}
+
+func FetchFreeGames(ctx context.Context, request FreeGamesRequest) (FreeGames, error) {
+	games := make(FreeGames, 0)
+	failed := 0
+
+	epicGames, err := fetchEpicFreeGames(ctx, request.Country, request.Locale)
+
+	if err != nil {
+		failed++
+		slog.Error("Failed to fetch Epic Games free games", "error", err)
+	} else {
+		games = append(games, epicGames...)
+	}
+
+	if request.GOG {
+		gogGames, err := fetchGOGGiveaways(ctx, request.Country, request.Locale)
+
+		if err != nil {
+			failed++
+			slog.Error("Failed to fetch GOG giveaways", "error", err)
+		} else {
+			games = append(games, gogGames...)
+		}
+	}
+
+	if failed > 0 && len(games) == 0 {
+		return nil, ErrNoContent
+	}
+
+	games.SortByAvailability()
+
+	if failed > 0 {
+		return games, fmt.Errorf("%w: could not fetch games from %d store(s)", ErrPartialContent, failed)
+	}
+
+	return games, nil
+}
+
+type epicPromotionalOffersJson []struct {
+	PromotionalOffers []struct {
+		StartDate       string `json:"startDate"`
+		EndDate         string `json:"endDate"`
+		DiscountSetting struct {
+			DiscountType       string `json:"discountType"`
+			DiscountPercentage int    `json:"discountPercentage"`
+		} `json:"discountSetting"`
+	} `json:"promotionalOffers"`
+}
+
+type epicPageMappingsJson []struct {
+	PageSlug string `json:"pageSlug"`
+	PageType string `json:"pageType"`
+}
+
+type epicFreeGamesResponseJson struct {
+	Data struct {
+		Catalog struct {
+			SearchStore struct {
+				Elements []struct {
+					Title     string `json:"title"`
+					ID        string `json:"id"`
+					Namespace string `json:"namespace"`
+					OfferType string `json:"offerType"`
+					KeyImages []struct {
+						Type string `json:"type"`
+						URL  string `json:"url"`
+					} `json:"keyImages"`
+					ProductSlug   string               `json:"productSlug"`
+					URLSlug       string               `json:"urlSlug"`
+					OfferMappings epicPageMappingsJson `json:"offerMappings"`
+					CatalogNs     struct {
+						Mappings epicPageMappingsJson `json:"mappings"`
+					} `json:"catalogNs"`
+					Price struct {
+						TotalPrice struct {
+							DiscountPrice int `json:"discountPrice"`
+							OriginalPrice int `json:"originalPrice"`
+							FmtPrice      struct {
+								OriginalPrice string `json:"originalPrice"`
+							} `json:"fmtPrice"`
+						} `json:"totalPrice"`
+					} `json:"price"`
+					// null for games without any promotions
+					Promotions *struct {
+						PromotionalOffers         epicPromotionalOffersJson `json:"promotionalOffers"`
+						UpcomingPromotionalOffers epicPromotionalOffersJson `json:"upcomingPromotionalOffers"`
+					} `json:"promotions"`
+				} `json:"elements"`
+			} `json:"searchStore"`
+		} `json:"Catalog"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// formats seen in promotion dates, most have milliseconds but some are
+// missing them, the seconds or even the time zone
+var epicDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseEpicDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range epicDateLayouts {
+		// dates without a time zone are in UTC
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// findFreeEpicOffer returns the window of the first offer which makes the
+// game free, offers can also be regular discounts which are ignored
+func findFreeEpicOffer(offers epicPromotionalOffersJson) (time.Time, time.Time, bool) {
+	for i := range offers {
+		for _, offer := range offers[i].PromotionalOffers {
+			if offer.DiscountSetting.DiscountPercentage != 0 {
+				continue
+			}
+
+			startsAt, ok := parseEpicDate(offer.StartDate)
+
+			if !ok {
+				continue
+			}
+
+			// a missing or invalid end date is treated as the game staying free
+			endsAt, _ := parseEpicDate(offer.EndDate)
+
+			return startsAt, endsAt, true
+		}
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+func epicPageSlug(mappings ...epicPageMappingsJson) string {
+	for _, m := range mappings {
+		for i := range m {
+			if m[i].PageType == "productHome" && m[i].PageSlug != "" {
+				return m[i].PageSlug
+			}
+		}
+	}
+
+	return ""
+}
+
+func fetchEpicFreeGames(ctx context.Context, country, locale string) (FreeGames, error) {
+	query := url.Values{}
+	query.Set("locale", locale)
+	query.Set("country", country)
+	query.Set("allowCountries", country)
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://store-site-backend-static-ipv4.ak.epicgames.com/freeGamesPromotions?"+query.Encode(), nil)
+	response, err := decodeJsonFromRequest[epicFreeGamesResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Errors) > 0 && len(response.Data.Catalog.SearchStore.Elements) == 0 {
+		return nil, fmt.Errorf("epic games store returned an error: %s", response.Errors[0].Message)
+	}
+
+	now := time.Now()
+	games := make(FreeGames, 0)
+
+	for _, element := range response.Data.Catalog.SearchStore.Elements {
+		if element.Promotions == nil {
+			continue
+		}
+
+		game := FreeGame{
+			ID:    "epic:" + element.Namespace + ":" + element.ID,
+			Store: "Epic Games Store",
+			Title: element.Title,
+		}
+
+		startsAt, endsAt, ok := findFreeEpicOffer(element.Promotions.PromotionalOffers)
+
+		// current offers can include ones which already ended
+		// or are for games which still cost something
+		if ok && (endsAt.IsZero() || endsAt.After(now)) && element.Price.TotalPrice.DiscountPrice == 0 {
+			game.StartsAt, game.EndsAt = startsAt, endsAt
+		} else if startsAt, endsAt, ok = findFreeEpicOffer(element.Promotions.UpcomingPromotionalOffers); ok {
+			game.StartsAt, game.EndsAt = startsAt, endsAt
+			game.Upcoming = true
+		} else {
+			continue
+		}
+
+		if element.Price.TotalPrice.OriginalPrice > 0 {
+			game.OriginalPrice = element.Price.TotalPrice.FmtPrice.OriginalPrice
+		}
+
+		for _, imageType := range []string{"OfferImageWide", "DieselStoreFrontWide", "Thumbnail", "OfferImageTall"} {
+			for _, image := range element.KeyImages {
+				if image.Type == imageType && game.ImageURL == "" {
+					game.ImageURL = image.URL
+				}
+			}
+		}
+
+		slug := epicPageSlug(element.OfferMappings, element.CatalogNs.Mappings)
+
+		if slug == "" {
+			slug = strings.TrimSuffix(element.ProductSlug, "/home")
+		}
+
+		// mystery games have a placeholder slug until they're revealed
+		if slug == "" || slug == "[]" {
+			game.URL = "https://store.epicgames.com/" + locale + "/free-games"
+		} else if element.OfferType == "BUNDLE" {
+			game.URL = "https://store.epicgames.com/" + locale + "/bundles/" + slug
+		} else {
+			game.URL = "https://store.epicgames.com/" + locale + "/p/" + slug
+		}
+
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+type gogCatalogResponseJson struct {
+	Products []struct {
+		ID              string `json:"id"`
+		Slug            string `json:"slug"`
+		Title           string `json:"title"`
+		CoverHorizontal string `json:"coverHorizontal"`
+		StoreLink       string `json:"storeLink"`
+		Price           *struct {
+			Base       string `json:"base"`
+			FinalMoney struct {
+				Amount string `json:"amount"`
+			} `json:"finalMoney"`
+			BaseMoney struct {
+				Amount string `json:"amount"`
+			} `json:"baseMoney"`
+		} `json:"price"`
+	} `json:"products"`
+}
+
+// the catalog requires a currency which is valid for the country, anything
+// else gets every price back as null, countries not listed fall back to USD
+var gogCurrencies = map[string]string{
+	"US": "USD", "GB": "GBP", "DE": "EUR", "FR": "EUR", "ES": "EUR", "IT": "EUR",
+	"NL": "EUR", "PL": "PLN", "CA": "CAD", "AU": "AUD", "BR": "BRL", "RU": "RUB",
+	"CN": "CNY", "JP": "JPY", "SE": "SEK", "NO": "NOK", "DK": "DKK", "CH": "CHF",
+}
+
+// GOG doesn't have an API for its giveaways, so they're approximated by the
+// products which are fully discounted, which is how giveaways show up in the
+// catalog. Unlike with Epic there's no way to find out when they end.
+func fetchGOGGiveaways(ctx context.Context, country, locale string) (FreeGames, error) {
+	currency, ok := gogCurrencies[country]
+
+	if !ok {
+		currency = "USD"
+	}
+
+	query := url.Values{}
+	query.Set("limit", "48")
+	query.Set("price", "between:0,0")
+	query.Set("discounted", "eq:true")
+	query.Set("productType", "in:game,pack")
+	query.Set("order", "desc:trending")
+	query.Set("locale", locale)
+	query.Set("countryCode", country)
+	query.Set("currencyCode", currency)
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://catalog.gog.com/v1/catalog?"+query.Encode(), nil)
+	response, err := decodeJsonFromRequest[gogCatalogResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	games := make(FreeGames, 0)
+
+	for _, product := range response.Products {
+		// games which are always free also match the filters
+		if product.Price == nil || !isZeroPrice(product.Price.FinalMoney.Amount) || isZeroPrice(product.Price.BaseMoney.Amount) {
+			continue
+		}
+
+		game := FreeGame{
+			ID:            "gog:" + product.ID,
+			Store:         "GOG",
+			Title:         product.Title,
+			URL:           product.StoreLink,
+			ImageURL:      product.CoverHorizontal,
+			OriginalPrice: product.Price.Base,
+		}
+
+		if game.URL == "" {
+			game.URL = "https://www.gog.com/game/" + product.Slug
+		}
+
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+func isZeroPrice(amount string) bool {
+	return strings.Trim(strings.TrimSpace(amount), "0.,") == ""
+}
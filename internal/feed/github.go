@@ -1,6 +1,8 @@
 package feed
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -29,7 +31,59 @@ func parseGithubTime(t string) time.Time {
 	return parsedTime
 }
 
-func FetchLatestReleasesFromGithub(repositories []string, token string) (AppReleases, error) {
+var (
+	errNoGithubReleases     = errors.New("no releases found")
+	errNoLiveGithubReleases = errors.New("no live release found")
+)
+
+func newGithubReleasesRequest(ctx context.Context, repository string, token string) *http.Request {
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=10", repository), nil)
+
+	if token != "" {
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	return request
+}
+
+// liveGithubRelease picks the most recent release which is neither a draft nor a pre-release
+func liveGithubRelease(repository string, releases []githubReleaseResponseJson) (AppRelease, error) {
+	if len(releases) < 1 {
+		return AppRelease{}, errNoGithubReleases
+	}
+
+	var liveRelease *githubReleaseResponseJson
+
+	for i := range releases {
+		release := &releases[i]
+
+		if !release.Draft && !release.PreRelease {
+			liveRelease = release
+			break
+		}
+	}
+
+	if liveRelease == nil {
+		return AppRelease{}, errNoLiveGithubReleases
+	}
+
+	version := liveRelease.TagName
+
+	if version[0] != 'v' {
+		version = "v" + version
+	}
+
+	return AppRelease{
+		Name:         repository,
+		Version:      version,
+		Tag:          liveRelease.TagName,
+		NotesUrl:     liveRelease.HtmlUrl,
+		TimeReleased: parseGithubTime(liveRelease.PublishedAt),
+		Downvotes:    liveRelease.Reactions.Downvotes,
+	}, nil
+}
+
+func FetchLatestReleasesFromGithub(ctx context.Context, repositories []string, token string) (AppReleases, error) {
 	appReleases := make(AppReleases, 0, len(repositories))
 
 	if len(repositories) == 0 {
@@ -39,16 +93,10 @@ func FetchLatestReleasesFromGithub(repositories []string, token string) (AppRele
 	requests := make([]*http.Request, len(repositories))
 
 	for i, repository := range repositories {
-		request, _ := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=10", repository), nil)
-
-		if token != "" {
-			request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-		}
-
-		requests[i] = request
+		requests[i] = newGithubReleasesRequest(ctx, repository, token)
 	}
 
-	task := decodeJsonFromRequestTask[[]githubReleaseResponseJson](defaultClient)
+	task := decodeJsonFromRequestTask[[]githubReleaseResponseJson](defaultClient())
 	job := newJob(task, requests).withWorkers(15)
 	responses, errs, err := workerPoolDo(job)
 
@@ -65,43 +113,18 @@ func FetchLatestReleasesFromGithub(repositories []string, token string) (AppRele
 			continue
 		}
 
-		releases := responses[i]
+		release, err := liveGithubRelease(repositories[i], responses[i])
 
-		if len(releases) < 1 {
+		if errors.Is(err, errNoGithubReleases) {
 			failed++
-			slog.Error("No releases found", "repository", repositories[i], "url", requests[i].URL)
-			continue
 		}
 
-		var liveRelease *githubReleaseResponseJson
-
-		for i := range releases {
-			release := &releases[i]
-
-			if !release.Draft && !release.PreRelease {
-				liveRelease = release
-				break
-			}
-		}
-
-		if liveRelease == nil {
-			slog.Error("No live release found", "repository", repositories[i], "url", requests[i].URL)
+		if err != nil {
+			slog.Error("Could not get github release", "error", err, "repository", repositories[i], "url", requests[i].URL)
 			continue
 		}
 
-		version := liveRelease.TagName
-
-		if version[0] != 'v' {
-			version = "v" + version
-		}
-
-		appReleases = append(appReleases, AppRelease{
-			Name:         repositories[i],
-			Version:      version,
-			NotesUrl:     liveRelease.HtmlUrl,
-			TimeReleased: parseGithubTime(liveRelease.PublishedAt),
-			Downvotes:    liveRelease.Reactions.Downvotes,
-		})
+		appReleases = append(appReleases, release)
 	}
 
 	if len(appReleases) == 0 {
@@ -148,15 +171,15 @@ type githubTicketResponseJson struct {
 	} `json:"items"`
 }
 
-func FetchRepositoryDetailsFromGithub(repository string, token string, maxPRs int, maxIssues int) (RepositoryDetails, error) {
-	repositoryRequest, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s", repository), nil)
+func FetchRepositoryDetailsFromGithub(ctx context.Context, repository string, token string, maxPRs int, maxIssues int) (RepositoryDetails, error) {
+	repositoryRequest, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.github.com/repos/%s", repository), nil)
 
 	if err != nil {
 		return RepositoryDetails{}, fmt.Errorf("%w: could not create request with repository: %v", ErrNoContent, err)
 	}
 
-	PRsRequest, _ := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/search/issues?q=is:pr+is:open+repo:%s&per_page=%d", repository, maxPRs), nil)
-	issuesRequest, _ := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/search/issues?q=is:issue+is:open+repo:%s&per_page=%d", repository, maxIssues), nil)
+	PRsRequest, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.github.com/search/issues?q=is:pr+is:open+repo:%s&per_page=%d", repository, maxPRs), nil)
+	issuesRequest, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.github.com/search/issues?q=is:issue+is:open+repo:%s&per_page=%d", repository, maxIssues), nil)
 
 	if token != "" {
 		token = fmt.Sprintf("Bearer %s", token)
@@ -176,14 +199,14 @@ func FetchRepositoryDetailsFromGithub(repository string, token string, maxPRs in
 	wg.Add(1)
 	go (func() {
 		defer wg.Done()
-		detailsResponse, detailsErr = decodeJsonFromRequest[githubRepositoryDetailsResponseJson](defaultClient, repositoryRequest)
+		detailsResponse, detailsErr = decodeJsonFromRequest[githubRepositoryDetailsResponseJson](defaultClient(), repositoryRequest)
 	})()
 
 	if maxPRs > 0 {
 		wg.Add(1)
 		go (func() {
 			defer wg.Done()
-			PRsResponse, PRsErr = decodeJsonFromRequest[githubTicketResponseJson](defaultClient, PRsRequest)
+			PRsResponse, PRsErr = decodeJsonFromRequest[githubTicketResponseJson](defaultClient(), PRsRequest)
 		})()
 	}
 
@@ -191,7 +214,7 @@ func FetchRepositoryDetailsFromGithub(repository string, token string, maxPRs in
 		wg.Add(1)
 		go (func() {
 			defer wg.Done()
-			issuesResponse, issuesErr = decodeJsonFromRequest[githubTicketResponseJson](defaultClient, issuesRequest)
+			issuesResponse, issuesErr = decodeJsonFromRequest[githubTicketResponseJson](defaultClient(), issuesRequest)
 		})()
 	}
 
@@ -0,0 +1,314 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var ErrHALLinkNotFound = errors.New("link relation not found")
+
+type HALLink struct {
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Deprecation string `json:"deprecation"`
+}
+
+// Expand returns the href with the variables filled in when it's templated
+func (l HALLink) Expand(vars map[string]string) (string, error) {
+	if !l.Templated {
+		return l.Href, nil
+	}
+
+	return expandURITemplate(l.Href, vars)
+}
+
+// HALDocument is a resource in the HAL format (https://stateless.group/hal_specification.html)
+type HALDocument struct {
+	// where the document was fetched from, for embedded resources it's their
+	// self link when they have one and otherwise that of the parent document
+	URL *url.URL
+	// every property of the resource, including _links and _embedded
+	Body     map[string]any
+	Links    map[string]HALLink
+	Embedded map[string][]*HALDocument
+	raw      json.RawMessage
+}
+
+// Decode decodes the resource into target, typically a struct with the fields of
+// interest since the links and embedded resources can be accessed separately
+func (d *HALDocument) Decode(target any) error {
+	return json.Unmarshal(d.raw, target)
+}
+
+// FirstEmbedded returns the first resource embedded under rel, or nil
+func (d *HALDocument) FirstEmbedded(rel string) *HALDocument {
+	if resources := d.Embedded[rel]; len(resources) > 0 {
+		return resources[0]
+	}
+
+	return nil
+}
+
+type halDocumentJson struct {
+	Links    map[string]json.RawMessage `json:"_links"`
+	Embedded map[string]json.RawMessage `json:"_embedded"`
+}
+
+// HAL allows both a single object and an array of objects for links and embedded
+// resources, a single one gets returned as an array to only have to handle one
+func unmarshalHALObjects[T any](data json.RawMessage) ([]T, error) {
+	var list []T
+
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+
+	var object T
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	return []T{object}, nil
+}
+
+func parseHALLinks(links map[string]json.RawMessage) (map[string]HALLink, error) {
+	parsed := make(map[string]HALLink, len(links))
+
+	for rel, data := range links {
+		list, err := unmarshalHALObjects[HALLink](data)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s link: %w", rel, err)
+		}
+
+		// with several links under one relation the first one is used, usually
+		// they're the same resource in different formats or languages
+		if len(list) > 0 {
+			parsed[rel] = list[0]
+		}
+	}
+
+	return parsed, nil
+}
+
+// ParseHALLinks extracts the _links of a HAL document without decoding the rest of it
+func ParseHALLinks(body []byte) (map[string]HALLink, error) {
+	var document halDocumentJson
+
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	return parseHALLinks(document.Links)
+}
+
+// ParseHALDocument decodes body as a HAL document along with all of its embedded
+// resources, relative links are resolved against base when it's not nil
+func ParseHALDocument(body []byte, base *url.URL) (*HALDocument, error) {
+	var document halDocumentJson
+
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	links, err := parseHALLinks(document.Links)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var properties map[string]any
+
+	if err = json.Unmarshal(body, &properties); err != nil {
+		return nil, err
+	}
+
+	parsed := &HALDocument{
+		URL:      base,
+		Body:     properties,
+		Links:    links,
+		Embedded: make(map[string][]*HALDocument, len(document.Embedded)),
+		raw:      body,
+	}
+
+	if self, ok := links["self"]; ok && !self.Templated && base != nil {
+		if selfURL, err := base.Parse(self.Href); err == nil {
+			parsed.URL = selfURL
+		}
+	}
+
+	for rel, data := range document.Embedded {
+		resources, err := unmarshalHALObjects[json.RawMessage](data)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s embedded resource: %w", rel, err)
+		}
+
+		for _, resource := range resources {
+			// embedded resources are relative to the document they're in
+			embedded, err := ParseHALDocument(resource, base)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s embedded resource: %w", rel, err)
+			}
+
+			parsed.Embedded[rel] = append(parsed.Embedded[rel], embedded)
+		}
+	}
+
+	return parsed, nil
+}
+
+// HALNavigator walks a HAL API by following link relations from an entry point
+// instead of the links having to be put together by hand. It's not safe for
+// concurrent use since every Follow moves it to the document it arrives at.
+type HALNavigator struct {
+	client     RequestDoer
+	entryPoint *url.URL
+	current    *HALDocument
+	vars       map[string]string
+	decodeOpts []DecodeOption
+}
+
+type HALNavigatorOption func(*HALNavigator)
+
+// WithURITemplateVars sets the variables used to expand templated links
+func WithURITemplateVars(vars map[string]string) HALNavigatorOption {
+	return func(n *HALNavigator) {
+		n.vars = vars
+	}
+}
+
+// WithHALDecodeOptions applies opts to every request made while navigating,
+// such as for retries or accepting statuses other than 200
+func WithHALDecodeOptions(opts ...DecodeOption) HALNavigatorOption {
+	return func(n *HALNavigator) {
+		n.decodeOpts = append(n.decodeOpts, opts...)
+	}
+}
+
+func NewHALNavigator(client RequestDoer, entryPoint string, opts ...HALNavigatorOption) (*HALNavigator, error) {
+	if client == nil {
+		client = defaultClient()
+	}
+
+	parsed, err := url.Parse(entryPoint)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry point: %w", err)
+	}
+
+	if !parsed.IsAbs() {
+		return nil, fmt.Errorf("entry point must be an absolute url: %s", entryPoint)
+	}
+
+	navigator := &HALNavigator{
+		client:     client,
+		entryPoint: parsed,
+	}
+
+	for _, opt := range opts {
+		opt(navigator)
+	}
+
+	return navigator, nil
+}
+
+// Current returns the document the navigator is at, fetching the
+// entry point if nothing has been fetched yet
+func (n *HALNavigator) Current(ctx context.Context) (*HALDocument, error) {
+	if n.current != nil {
+		return n.current, nil
+	}
+
+	document, err := n.get(ctx, n.entryPoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n.current = document
+
+	return document, nil
+}
+
+// Follow moves to the resource linked under rel from the current document. When
+// the document has no such link but has a resource embedded under rel, that
+// resource is used as is without making a request.
+func (n *HALNavigator) Follow(ctx context.Context, rel string) (*HALDocument, error) {
+	current, err := n.Current(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	link, ok := current.Links[rel]
+
+	if !ok {
+		if embedded := current.FirstEmbedded(rel); embedded != nil {
+			n.current = embedded
+			return embedded, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrHALLinkNotFound, rel)
+	}
+
+	href, err := link.Expand(n.vars)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not expand %s link: %w", rel, err)
+	}
+
+	target, err := current.URL.Parse(href)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s link: %w", rel, err)
+	}
+
+	document, err := n.get(ctx, target)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n.current = document
+
+	return document, nil
+}
+
+func (n *HALNavigator) get(ctx context.Context, target *url.URL) (*HALDocument, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/hal+json, application/json;q=0.9")
+
+	options := newDecodeOptions(n.decodeOpts)
+	_, body, err := options.fetch(n.client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := ParseHALDocument(body, target)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not decode hal document from %s: %w", target, err)
+	}
+
+	if err = options.validate(document, request); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
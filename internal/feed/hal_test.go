@@ -0,0 +1,194 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newHALTestServer serves a small HAL API, counting the requests it receives
+func newHALTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/hal+json")
+
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `{
+				"name": "store",
+				"_links": {
+					"self": {"href": "/"},
+					"orders": {"href": "/orders{?page,status}", "templated": true},
+					"lang": [{"href": "/?lang=en"}, {"href": "/?lang=fr"}]
+				},
+				"_embedded": {
+					"featured": [
+						{"id": 1, "_links": {"self": {"href": "/products/1"}}},
+						{"id": 2, "_links": {"self": {"href": "/products/2"}}}
+					]
+				}
+			}`)
+		case "/orders":
+			query := r.URL.Query()
+			fmt.Fprintf(w, `{
+				"page": %q,
+				"status": %q,
+				"_links": {"next": {"href": "orders?page=3"}},
+				"_embedded": {"order": {"id": 7, "total": 12.5}}
+			}`, query.Get("page"), query.Get("status"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	return server, &requests
+}
+
+func TestHALNavigatorFollowsLinks(t *testing.T) {
+	server, requests := newHALTestServer(t)
+	navigator, err := NewHALNavigator(server.Client(), server.URL, WithURITemplateVars(map[string]string{"page": "2"}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	root, err := navigator.Current(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Body["name"] != "store" || root.Links["lang"].Href != "/?lang=en" {
+		t.Errorf("unexpected root document: %v, %v", root.Body, root.Links)
+	}
+
+	orders, err := navigator.Follow(ctx, "orders")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct {
+		Page   string `json:"page"`
+		Status string `json:"status"`
+	}
+
+	if err := orders.Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+
+	// the undefined status variable is left out of the expanded link
+	if page.Page != "2" || page.Status != "" {
+		t.Errorf("expected the templated link to be expanded with page 2 only, got %+v", page)
+	}
+
+	var order struct {
+		ID    int     `json:"id"`
+		Total float64 `json:"total"`
+	}
+
+	if err := orders.FirstEmbedded("order").Decode(&order); err != nil || order.ID != 7 || order.Total != 12.5 {
+		t.Errorf("expected the single embedded order to be decoded, got %+v, %v", order, err)
+	}
+
+	if next, _ := orders.URL.Parse(orders.Links["next"].Href); next.String() != server.URL+"/orders?page=3" {
+		t.Errorf("expected the relative link to resolve against the orders document, got %s", next)
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestHALNavigatorFollowsEmbeddedResources(t *testing.T) {
+	server, requests := newHALTestServer(t)
+	navigator, _ := NewHALNavigator(server.Client(), server.URL)
+
+	featured, err := navigator.Follow(context.Background(), "featured")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if featured.Body["id"] != float64(1) || featured.URL.String() != server.URL+"/products/1" {
+		t.Errorf("expected the first embedded product with its self link, got %v at %s", featured.Body, featured.URL)
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("expected only the entry point to be requested, got %d requests", requests.Load())
+	}
+
+	if _, err := navigator.Follow(context.Background(), "missing"); !errors.Is(err, ErrHALLinkNotFound) {
+		t.Errorf("expected ErrHALLinkNotFound, got %v", err)
+	}
+}
+
+func TestNewHALNavigatorRequiresAbsoluteEntryPoint(t *testing.T) {
+	if _, err := NewHALNavigator(nil, "/api"); err == nil {
+		t.Error("expected a relative entry point to be rejected")
+	}
+}
+
+func TestParseHALLinks(t *testing.T) {
+	links, err := ParseHALLinks([]byte(`{
+		"_links": {
+			"self": {"href": "/items/1", "title": "Item"},
+			"alternate": [{"href": "/items/1.xml", "type": "application/xml"}, {"href": "/items/1.csv"}],
+			"search": {"href": "/items{?q}", "templated": true}
+		}
+	}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if links["self"].Href != "/items/1" || links["self"].Title != "Item" {
+		t.Errorf("unexpected self link: %+v", links["self"])
+	}
+
+	if links["alternate"].Type != "application/xml" {
+		t.Errorf("expected the first of several links to be used, got %+v", links["alternate"])
+	}
+
+	if href, err := links["search"].Expand(map[string]string{"q": "a b"}); err != nil || href != "/items?q=a%20b" {
+		t.Errorf("unexpected expansion: %q, %v", href, err)
+	}
+
+	if _, err := ParseHALLinks([]byte(`{"_links": {"self": "nope"}}`)); err == nil {
+		t.Error("expected an invalid link to be rejected")
+	}
+}
+
+func TestExpandURITemplate(t *testing.T) {
+	vars := map[string]string{"id": "42", "path": "a/b", "q": "x y", "empty": ""}
+
+	tests := map[string]string{
+		"/items/{id}":         "/items/42",
+		"/files/{+path}":      "/files/a/b",
+		"/files/{path}":       "/files/a%2Fb",
+		"/search{?q,missing}": "/search?q=x%20y",
+		"/search{?empty}":     "/search?empty=",
+		"/items{/id}{.fmt}":   "/items/42",
+		"/items?a=1{&id}":     "/items?a=1&id=42",
+		"/page{#id}":          "/page#42",
+	}
+
+	for template, expected := range tests {
+		if actual, err := expandURITemplate(template, vars); err != nil || actual != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", template, expected, actual, err)
+		}
+	}
+
+	if _, err := expandURITemplate("/items/{id", vars); err == nil {
+		t.Error("expected an unclosed expression to be rejected")
+	}
+}
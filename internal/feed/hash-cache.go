@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var hashCacheStats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// HashCacheStats returns how many responses decoded through a HashCachingDecoder
+// were the same as the previous one for their URL and how many weren't
+func HashCacheStats() (hits, misses int64) {
+	return hashCacheStats.hits.Load(), hashCacheStats.misses.Load()
+}
+
+type hashCacheEntry[T any] struct {
+	hash  uint64
+	size  int
+	value T
+}
+
+// HashCachingDecoder decodes JSON responses like decodeJsonFromRequest, except that
+// when a response has the same body as the last one from the same URL the value
+// decoded back then is returned rather than unmarshaling it again. This is meant for
+// endpoints which are polled often and rarely change. Since values are shared between
+// calls, callers must not modify anything they point to.
+type HashCachingDecoder[T any] struct {
+	options *decodeOptions
+
+	mu      sync.Mutex
+	entries map[string]hashCacheEntry[T]
+}
+
+func NewHashCachingDecoder[T any](opts ...DecodeOption) *HashCachingDecoder[T] {
+	return &HashCachingDecoder[T]{
+		options: newDecodeOptions(opts),
+		entries: make(map[string]hashCacheEntry[T]),
+	}
+}
+
+func (d *HashCachingDecoder[T]) Decode(client RequestDoer, request *http.Request) (result T, err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+
+	buffer := getBodyBuffer()
+	defer putBodyBuffer(buffer)
+
+	response, err := d.options.fetchIntoBuffer(client, request, buffer)
+
+	if err != nil {
+		return result, err
+	}
+
+	body := buffer.Bytes()
+	hash := fnv.New64a()
+	hash.Write(body)
+	sum := hash.Sum64()
+	key := request.URL.String()
+
+	d.mu.Lock()
+	entry, ok := d.entries[key]
+	d.mu.Unlock()
+
+	// the size is compared as well to make a collision even less likely
+	if ok && entry.hash == sum && entry.size == len(body) {
+		hashCacheStats.hits.Add(1)
+		return entry.value, nil
+	}
+
+	hashCacheStats.misses.Add(1)
+
+	result, err = decodeJsonBody[T](d.options, request, response, body)
+
+	// failures aren't cached, so a body that failed is decoded again each time
+	if err != nil {
+		return result, err
+	}
+
+	d.mu.Lock()
+	d.entries[key] = hashCacheEntry[T]{hash: sum, size: len(body), value: result}
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+func (d *HashCachingDecoder[T]) Task(client RequestDoer) func(*http.Request) (T, error) {
+	return func(request *http.Request) (T, error) {
+		return d.Decode(client, request)
+	}
+}
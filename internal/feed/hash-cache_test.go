@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type hashCacheTestItem struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// around 10KB of JSON
+func newHashCacheTestBody(title string) string {
+	items := make([]string, 0, 200)
+
+	for i := range cap(items) {
+		items = append(items, fmt.Sprintf(`{"id":%d,"title":"%s"}`, i, title+strings.Repeat("x", 30)))
+	}
+
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// newInMemoryClient responds with whatever body returns without going through
+// the network, so that only the cost of decoding is measured
+func newInMemoryClient(body func() string) *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body())),
+			Request:    request,
+		}, nil
+	})}
+}
+
+func TestHashCachingDecoder(t *testing.T) {
+	bodies := []string{newHashCacheTestBody("a"), newHashCacheTestBody("a"), newHashCacheTestBody("b"), `{`}
+	sent := 0
+	client := newInMemoryClient(func() string {
+		sent++
+		return bodies[sent-1]
+	})
+
+	decoder := NewHashCachingDecoder[[]hashCacheTestItem]()
+	hitsBefore, missesBefore := HashCacheStats()
+
+	decode := func() ([]hashCacheTestItem, error) {
+		request, _ := http.NewRequest(http.MethodGet, "https://example.com/items", nil)
+		return decoder.Decode(client, request)
+	}
+
+	first, err := decode()
+
+	if err != nil || len(first) != 200 {
+		t.Fatalf("unexpected first result: %d items, %v", len(first), err)
+	}
+
+	second, err := decode()
+
+	if err != nil || &second[0] != &first[0] {
+		t.Errorf("expected the unchanged body to return the cached value, got %v", err)
+	}
+
+	third, err := decode()
+
+	if err != nil || &third[0] == &first[0] || !strings.HasPrefix(third[0].Title, "b") {
+		t.Errorf("expected the changed body to be decoded again, got %v", err)
+	}
+
+	if _, err := decode(); err == nil {
+		t.Error("expected the invalid body to fail")
+	}
+
+	hits, misses := HashCacheStats()
+
+	if hits-hitsBefore != 1 || misses-missesBefore != 3 {
+		t.Errorf("expected 1 hit and 3 misses, got %d and %d", hits-hitsBefore, misses-missesBefore)
+	}
+}
+
+func benchmarkHashCachingDecoder(b *testing.B, bodies ...string) {
+	sent := 0
+	client := newInMemoryClient(func() string {
+		sent++
+		return bodies[sent%len(bodies)]
+	})
+
+	decoder := NewHashCachingDecoder[[]hashCacheTestItem]()
+	b.SetBytes(int64(len(bodies[0])))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		request, _ := http.NewRequest(http.MethodGet, "https://example.com/items", nil)
+
+		if _, err := decoder.Decode(client, request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHashCachingDecoderHit(b *testing.B) {
+	benchmarkHashCachingDecoder(b, newHashCacheTestBody("a"))
+}
+
+// the body alternates so that every response differs from the previous one
+func BenchmarkHashCachingDecoderMiss(b *testing.B) {
+	benchmarkHashCachingDecoder(b, newHashCacheTestBody("a"), newHashCacheTestBody("b"))
+}
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+)
+
+// MiddlewareFunc wraps the transport of a client, such as with
+// client.Transport = middleware(client.Transport)
+type MiddlewareFunc func(next http.RoundTripper) http.RoundTripper
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+type inboundHeadersKey struct{}
+
+// WithInboundHeaders returns a context carrying the headers of a request that
+// was received, for the requests sent with it to forward the ones allowed by
+// NewHeaderForwardingMiddleware. The headers are copied, so they can be changed
+// afterwards without affecting the context.
+func WithInboundHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, inboundHeadersKey{}, headers.Clone())
+}
+
+func inboundHeadersFromContext(ctx context.Context) (http.Header, bool) {
+	headers, ok := ctx.Value(inboundHeadersKey{}).(http.Header)
+	return headers, ok
+}
+
+// NewHeaderForwardingMiddleware copies the headers in allowList from the ones
+// set through WithInboundHeaders on the context of a request onto the request,
+// so that things such as trace IDs make it to the upstream APIs. No other inbound
+// header is ever forwarded, and headers which the request already sets are kept
+// as they are rather than being replaced with inbound ones. Requests without
+// inbound headers in their context are sent unchanged. Not meant for the
+// requests of widget updates, whose results are shared by every viewer.
+func NewHeaderForwardingMiddleware(allowList []string) MiddlewareFunc {
+	allowed := make([]string, 0, len(allowList))
+
+	for _, name := range allowList {
+		if name != "" {
+			allowed = append(allowed, http.CanonicalHeaderKey(name))
+		}
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			inbound, ok := inboundHeadersFromContext(request.Context())
+
+			if !ok || len(allowed) == 0 {
+				return next.RoundTrip(request)
+			}
+
+			var forwarded *http.Request
+
+			for _, name := range allowed {
+				values := inbound.Values(name)
+
+				if len(values) == 0 || len(request.Header.Values(name)) > 0 {
+					continue
+				}
+
+				// round trippers must not modify the request they're given
+				if forwarded == nil {
+					forwarded = request.Clone(request.Context())
+				}
+
+				forwarded.Header[name] = append([]string(nil), values...)
+			}
+
+			if forwarded == nil {
+				return next.RoundTrip(request)
+			}
+
+			return next.RoundTrip(forwarded)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newHeaderCaptureTestServer keeps the headers of the last request it got
+func newHeaderCaptureTestServer(t *testing.T) (*httptest.Server, func() http.Header) {
+	t.Helper()
+
+	received := make(chan http.Header, 1)
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+	})
+
+	return server, func() http.Header { return <-received }
+}
+
+func sendWithInboundHeaders(t *testing.T, client RequestDoer, url string) {
+	t.Helper()
+
+	inbound := headersOf(
+		"Traceparent", "00-trace-01",
+		"X-Request-Id", "inbound",
+		"Authorization", "Bearer inbound",
+		"Cookie", "session=inbound",
+	)
+
+	request, _ := http.NewRequestWithContext(WithInboundHeaders(context.Background(), inbound), http.MethodGet, url, nil)
+	request.Header.Set("X-Request-Id", "own")
+
+	if _, err := getBody(client, request); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkForwardedHeaders(t *testing.T, headers http.Header) {
+	t.Helper()
+
+	if headers.Get("Traceparent") != "00-trace-01" {
+		t.Errorf("expected the allowed header to be forwarded, got %q", headers.Get("Traceparent"))
+	}
+
+	if headers.Get("X-Request-Id") != "own" {
+		t.Errorf("expected the header set by the request to be kept, got %q", headers.Get("X-Request-Id"))
+	}
+
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if headers.Get(name) != "" {
+			t.Errorf("expected %s to not be forwarded, got %q", name, headers.Get(name))
+		}
+	}
+}
+
+func TestHeaderForwardingMiddlewareAllowList(t *testing.T) {
+	server, received := newHeaderCaptureTestServer(t)
+	client := &http.Client{Transport: NewHeaderForwardingMiddleware([]string{"traceparent", "x-request-id"})(nil)}
+
+	sendWithInboundHeaders(t, client, server.URL)
+	checkForwardedHeaders(t, received())
+}
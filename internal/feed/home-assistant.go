@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type HomeAssistantEntity struct {
+	ID           string
+	State        string
+	Unit         string
+	FriendlyName string
+	DeviceClass  string
+	// as set in Home Assistant, such as mdi:thermometer
+	Icon        string
+	LastChanged time.Time
+}
+
+// Domain is the part of the entity id before the dot, such as sensor or switch
+func (e *HomeAssistantEntity) Domain() string {
+	domain, _, _ := strings.Cut(e.ID, ".")
+	return domain
+}
+
+func (e *HomeAssistantEntity) IsUnavailable() bool {
+	return e.State == "unavailable" || e.State == "unknown"
+}
+
+type homeAssistantStateJson struct {
+	EntityID   string `json:"entity_id"`
+	State      string `json:"state"`
+	Attributes struct {
+		UnitOfMeasurement string `json:"unit_of_measurement"`
+		FriendlyName      string `json:"friendly_name"`
+		DeviceClass       string `json:"device_class"`
+		Icon              string `json:"icon"`
+	} `json:"attributes"`
+	LastChanged time.Time `json:"last_changed"`
+}
+
+// FetchHomeAssistantEntities gets the state of the given entities from the REST
+// API of a Home Assistant instance. Rather than making a request per entity the
+// states of every entity are fetched in one request and the requested ones are
+// picked out of them, which is a lot quicker once more than a few are needed.
+func FetchHomeAssistantEntities(ctx context.Context, instanceURL, token string, entityIDs []string) (map[string]HomeAssistantEntity, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(instanceURL, "/")+"/api/states", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/json")
+
+	states, err := decodeJsonFromRequest[[]homeAssistantStateJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	requested := make(map[string]bool, len(entityIDs))
+
+	for _, id := range entityIDs {
+		requested[id] = true
+	}
+
+	entities := make(map[string]HomeAssistantEntity, len(entityIDs))
+
+	for i := range states {
+		state := &states[i]
+
+		if !requested[state.EntityID] {
+			continue
+		}
+
+		entities[state.EntityID] = HomeAssistantEntity{
+			ID:           state.EntityID,
+			State:        state.State,
+			Unit:         state.Attributes.UnitOfMeasurement,
+			FriendlyName: state.Attributes.FriendlyName,
+			DeviceClass:  state.Attributes.DeviceClass,
+			Icon:         state.Attributes.Icon,
+			LastChanged:  state.LastChanged,
+		}
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("%w: none of the entities were found", ErrNoContent)
+	}
+
+	if missing := len(requested) - len(entities); missing > 0 {
+		return entities, fmt.Errorf("%w: %d entities were not found", ErrPartialContent, missing)
+	}
+
+	return entities, nil
+}
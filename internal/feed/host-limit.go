@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// hostConcurrencyLimiter caps how many requests can be in flight to a single
+// host and in total across every client of this package, counting a request
+// as in flight until its response body is closed. Unlike MaxConnsPerHost on a
+// transport it applies across all transports and to retries made on top of them.
+type hostConcurrencyLimiter struct {
+	mu           sync.Mutex
+	defaultLimit int
+	overrides    map[string]int
+	maxQueued    int
+	queues       map[string]*requestQueue
+	global       *requestQueue
+}
+
+var hostLimiter = &hostConcurrencyLimiter{
+	overrides: make(map[string]int),
+	queues:    make(map[string]*requestQueue),
+}
+
+// SetHostConcurrencyLimits sets the maximum number of concurrent requests per host,
+// overrides are keyed by either the hostname or the host:port and a limit of
+// 0 means no limit. Must be called before any requests are made.
+func SetHostConcurrencyLimits(defaultLimit int, overrides map[string]int) {
+	hostLimiter.mu.Lock()
+	defer hostLimiter.mu.Unlock()
+
+	hostLimiter.defaultLimit = defaultLimit
+	hostLimiter.overrides = make(map[string]int, len(overrides))
+
+	for host, limit := range overrides {
+		hostLimiter.overrides[host] = limit
+	}
+
+	clear(hostLimiter.queues)
+}
+
+// SetConcurrencyLimit sets the maximum number of concurrent requests across all
+// hosts and how many requests can be waiting for a slot in any one queue before
+// new ones fail with ErrRequestQueueFull, 0 means no limit for either.
+// Must be called before any requests are made.
+func SetConcurrencyLimit(limit int, maxQueued int) {
+	hostLimiter.mu.Lock()
+	defer hostLimiter.mu.Unlock()
+
+	hostLimiter.maxQueued = maxQueued
+	hostLimiter.global = newRequestQueue(limit, maxQueued)
+	clear(hostLimiter.queues)
+}
+
+func (l *hostConcurrencyLimiter) queuesFor(request *http.Request) (*requestQueue, *requestQueue) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	host := request.URL.Host
+
+	if queue, ok := l.queues[host]; ok {
+		return queue, l.global
+	}
+
+	limit, ok := l.overrides[host]
+
+	if !ok {
+		limit, ok = l.overrides[request.URL.Hostname()]
+	}
+
+	if !ok {
+		limit = l.defaultLimit
+	}
+
+	// a nil queue is stored as well so that the lookup
+	// doesn't have to be repeated for unlimited hosts
+	queue := newRequestQueue(limit, l.maxQueued)
+	l.queues[host] = queue
+
+	return queue, l.global
+}
+
+type hostConcurrencyLimitRoundTripper struct {
+	next http.RoundTripper
+}
+
+func withHostConcurrencyLimit(transport http.RoundTripper) http.RoundTripper {
+	return &hostConcurrencyLimitRoundTripper{next: transport}
+}
+
+func (rt *hostConcurrencyLimitRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	hostQueue, globalQueue := hostLimiter.queuesFor(request)
+
+	if hostQueue == nil && globalQueue == nil {
+		return rt.next.RoundTrip(request)
+	}
+
+	// the host slot is taken first so that requests waiting on a busy
+	// host don't hold up requests to other hosts
+	releaseHost, err := hostQueue.acquire(request.Context())
+
+	if err != nil {
+		return nil, err
+	}
+
+	releaseGlobal, err := globalQueue.acquire(request.Context())
+
+	if err != nil {
+		releaseHost()
+		return nil, err
+	}
+
+	release := func() {
+		releaseGlobal()
+		releaseHost()
+	}
+
+	response, err := rt.next.RoundTrip(request)
+
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	response.Body = &releaseOnCloseBody{ReadCloser: response.Body, release: release}
+
+	return response, nil
+}
+
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+
+	return err
+}
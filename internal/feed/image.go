@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrNotAnImage = errors.New("response is not a supported image")
+
+// SVGs are left out since they can contain scripts which would run
+// on the origin of glance when served through it
+var proxiableImageTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/avif",
+	"image/bmp",
+	"image/x-icon",
+	"image/vnd.microsoft.icon",
+}
+
+func imageContentType(response *http.Response, body []byte) (string, bool) {
+	contentType := http.DetectContentType(body)
+
+	// sniffing doesn't know about every format, in which case
+	// the type reported by the server is used instead
+	if contentType == "application/octet-stream" {
+		contentType = ParseContentType(response.Header.Get("Content-Type")).MediaType()
+	}
+
+	for _, allowed := range proxiableImageTypes {
+		if contentType == allowed {
+			return contentType, true
+		}
+	}
+
+	return contentType, false
+}
+
+// FetchImage downloads the image at url for serving it from glance, returning
+// the image along with its content type as determined from its contents
+func FetchImage(url string) ([]byte, string, error) {
+	request, err := http.NewRequest("GET", url, nil)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, "", fmt.Errorf("unsupported image URL scheme: %s", request.URL.Scheme)
+	}
+
+	request.Header.Set("Accept", "image/avif,image/webp,image/png,image/jpeg,image/*;q=0.8")
+	addBrowserUserAgentHeader(request)
+
+	response, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType, ok := imageContentType(response, body)
+
+	if !ok {
+		return nil, "", fmt.Errorf("%w: got %s from %s", ErrNotAnImage, contentType, url)
+	}
+
+	return body, contentType, nil
+}
@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"golang.org/x/net/html/charset"
+)
+
+const imapTimeout = 15 * time.Second
+
+func init() {
+	// without this only subjects encoded as UTF-8 or ISO-8859-1 get decoded
+	imap.CharsetReader = charset.NewReaderLabel
+}
+
+type IMAPAccount struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	Folders    []string
+	CACertFile string
+	Name       string
+}
+
+type MailMessage struct {
+	Subject string
+	From    string
+	Date    time.Time
+}
+
+type MailFolder struct {
+	Account  string
+	Name     string
+	Unread   int
+	Messages []MailMessage
+}
+
+// fetchUnreadFromIMAPAccount connects to the account only for as long as it takes to
+// get the envelopes of the most recent unread messages in each folder, which hold
+// just the headers. Folders are opened read-only so that nothing gets marked as read.
+func fetchUnreadFromIMAPAccount(account *IMAPAccount, limit int) ([]MailFolder, error) {
+	tlsConfig := &tls.Config{ServerName: account.Host, ClientSessionCache: tlsSessionCacheFor(account.CACertFile)}
+
+	if account.CACertFile != "" {
+		pool, err := loadCACertPool(account.CACertFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	address := net.JoinHostPort(account.Host, strconv.Itoa(account.Port))
+	c, err := client.DialWithDialerTLS(&net.Dialer{Timeout: imapTimeout}, address, tlsConfig)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", address, err)
+	}
+
+	defer c.Logout()
+	c.Timeout = imapTimeout
+
+	if err = c.Login(account.Username, account.Password); err != nil {
+		return nil, fmt.Errorf("could not log in to %s: %w", address, err)
+	}
+
+	folders := make([]MailFolder, 0, len(account.Folders))
+
+	for _, name := range account.Folders {
+		folder, err := fetchUnreadFromIMAPFolder(c, name, limit)
+
+		if err != nil {
+			return nil, fmt.Errorf("folder %s: %w", name, err)
+		}
+
+		folder.Account = account.Name
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+func fetchUnreadFromIMAPFolder(c *client.Client, name string, limit int) (MailFolder, error) {
+	folder := MailFolder{Name: name, Messages: make([]MailMessage, 0)}
+
+	if _, err := c.Select(name, true); err != nil {
+		return folder, err
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+
+	unread, err := c.Search(criteria)
+
+	if err != nil {
+		return folder, err
+	}
+
+	folder.Unread = len(unread)
+
+	if len(unread) == 0 || limit <= 0 {
+		return folder, nil
+	}
+
+	// sequence numbers increase with the order messages were added in,
+	// so the most recent ones are at the end
+	sort.Slice(unread, func(i, j int) bool { return unread[i] < unread[j] })
+
+	if len(unread) > limit {
+		unread = unread[len(unread)-limit:]
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(unread...)
+
+	messages := make(chan *imap.Message, len(unread))
+
+	if err = c.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope, imap.FetchInternalDate}, messages); err != nil {
+		return folder, err
+	}
+
+	for message := range messages {
+		if message.Envelope == nil {
+			continue
+		}
+
+		mail := MailMessage{
+			Subject: message.Envelope.Subject,
+			Date:    message.Envelope.Date,
+		}
+
+		if mail.Date.IsZero() {
+			mail.Date = message.InternalDate
+		}
+
+		if len(message.Envelope.From) > 0 {
+			from := message.Envelope.From[0]
+
+			if from.PersonalName != "" {
+				mail.From = from.PersonalName
+			} else {
+				mail.From = from.Address()
+			}
+		}
+
+		folder.Messages = append(folder.Messages, mail)
+	}
+
+	sort.SliceStable(folder.Messages, func(i, j int) bool {
+		return folder.Messages[i].Date.After(folder.Messages[j].Date)
+	})
+
+	return folder, nil
+}
+
+func FetchUnreadMail(accounts []IMAPAccount, limit int) ([]MailFolder, error) {
+	indexes := make([]int, len(accounts))
+
+	for i := range accounts {
+		indexes[i] = i
+	}
+
+	task := func(i int) ([]MailFolder, error) {
+		return fetchUnreadFromIMAPAccount(&accounts[i], limit)
+	}
+
+	job := newJob(task, indexes).withWorkers(len(accounts))
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]MailFolder, 0)
+
+	for i := range results {
+		if errs[i] != nil {
+			slog.Error("Failed to fetch unread mail", "host", accounts[i].Host, "username", accounts[i].Username, "error", errs[i])
+			continue
+		}
+
+		folders = append(folders, results[i]...)
+	}
+
+	if err = mergeErr(errs); err != nil {
+		return folders, err
+	}
+
+	return folders, nil
+}
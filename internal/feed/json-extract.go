@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ResolveJSONPath walks a decoded JSON value using a dot separated path such
+// as "data.items.0.name". A "#" segment returns the length of the array when
+// it's the last segment, otherwise the rest of the path is resolved against
+// every element of the array, as in "items.#.name". The second return value
+// is false when any part of the path doesn't exist.
+func ResolveJSONPath(value any, path string) (any, bool) {
+	path = strings.Trim(path, ".")
+
+	if path == "" {
+		return value, true
+	}
+
+	segments := strings.Split(path, ".")
+
+	return resolveJSONPathSegments(value, segments)
+}
+
+func resolveJSONPathSegments(value any, segments []string) (any, bool) {
+	for i, segment := range segments {
+		switch current := value.(type) {
+		case map[string]any:
+			next, ok := current[segment]
+
+			if !ok {
+				return nil, false
+			}
+
+			value = next
+		case []any:
+			if segment == "#" {
+				if i == len(segments)-1 {
+					return float64(len(current)), true
+				}
+
+				results := make([]any, 0, len(current))
+
+				for j := range current {
+					if result, ok := resolveJSONPathSegments(current[j], segments[i+1:]); ok {
+						results = append(results, result)
+					}
+				}
+
+				return results, true
+			}
+
+			index, err := strconv.Atoi(segment)
+
+			if err != nil {
+				return nil, false
+			}
+
+			if index < 0 {
+				index += len(current)
+			}
+
+			if index < 0 || index >= len(current) {
+				return nil, false
+			}
+
+			value = current[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type JSONMode int
+
+const (
+	// fields of the response which aren't in the target are ignored, as with json.Unmarshal
+	JSONModeDefault JSONMode = iota
+	// comments and trailing commas are removed from the response before decoding,
+	// for APIs and files which are closer to JSON5 than to JSON
+	JSONModeLenient
+	// fields of the response which aren't in the target fail the decoding, for
+	// widgets which want to notice when an API changes the shape of its responses
+	JSONModeStrict
+)
+
+func WithJSONMode(mode JSONMode) DecodeOption {
+	return func(o *decodeOptions) {
+		o.jsonMode = mode
+	}
+}
+
+func (o *decodeOptions) prepareJSON(body []byte) []byte {
+	if o.jsonMode != JSONModeLenient {
+		return body
+	}
+
+	return stripJSONExtensions(body)
+}
+
+func (o *decodeOptions) unmarshalJSON(body []byte, target any) error {
+	if o.jsonMode != JSONModeStrict {
+		return json.Unmarshal(body, target)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(target)
+}
+
+// stripJSONExtensions removes // and /* */ comments along with commas directly
+// before a closing bracket, leaving strings untouched. Anything else which isn't
+// valid JSON is left for the decoder to complain about.
+func stripJSONExtensions(body []byte) []byte {
+	result := make([]byte, 0, len(body))
+	// index in result of the last byte which isn't whitespace, -1 if there's none
+	lastSignificant := -1
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+
+		switch {
+		case c == '"':
+			start := i
+
+			for i++; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' {
+					i++
+				}
+			}
+
+			result = append(result, body[start:min(i+1, len(body))]...)
+			lastSignificant = len(result) - 1
+		case c == '/' && i+1 < len(body) && body[i+1] == '/':
+			for i < len(body) && body[i] != '\n' {
+				i++
+			}
+
+			// keep the newline so that line numbers in errors stay the same
+			if i < len(body) {
+				result = append(result, '\n')
+			}
+		case c == '/' && i+1 < len(body) && body[i+1] == '*':
+			end := bytes.Index(body[i+2:], []byte("*/"))
+
+			if end == -1 {
+				i = len(body)
+				break
+			}
+
+			i += end + 3
+		case c == '}' || c == ']':
+			if lastSignificant != -1 && result[lastSignificant] == ',' {
+				result = append(result[:lastSignificant], result[lastSignificant+1:]...)
+			}
+
+			result = append(result, c)
+			lastSignificant = len(result) - 1
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			result = append(result, c)
+		default:
+			result = append(result, c)
+			lastSignificant = len(result) - 1
+		}
+	}
+
+	return result
+}
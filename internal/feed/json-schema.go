@@ -0,0 +1,120 @@
+package feed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var ErrSchemaMismatch = errors.New("response does not match schema")
+
+type SchemaViolation struct {
+	// dot separated path to the offending value, using the same syntax
+	// as ResolveJSONPath, empty for the root of the document
+	Path    string
+	Message string
+}
+
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+
+	for i := range e.Violations {
+		path := e.Violations[i].Path
+
+		if path == "" {
+			path = "(root)"
+		}
+
+		messages[i] = path + ": " + e.Violations[i].Message
+	}
+
+	return ErrSchemaMismatch.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *SchemaValidationError) Unwrap() error {
+	return ErrSchemaMismatch
+}
+
+type JSONSchema struct {
+	schema *jsonschema.Schema
+}
+
+const jsonSchemaResourceURL = "schema.json"
+
+// CompileJSONSchema compiles a JSON encoded schema, drafts 4 through
+// 2020-12 are supported and the draft is picked from $schema, defaulting
+// to 2020-12 when it isn't present
+func CompileJSONSchema(source []byte) (*JSONSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+
+	if err := compiler.AddResource(jsonSchemaResourceURL, bytes.NewReader(source)); err != nil {
+		return nil, fmt.Errorf("could not parse schema: %v", err)
+	}
+
+	schema, err := compiler.Compile(jsonSchemaResourceURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not compile schema: %v", err)
+	}
+
+	return &JSONSchema{schema: schema}, nil
+}
+
+// Validate checks data decoded with encoding/json against the schema and
+// returns a *SchemaValidationError listing every violation when it doesn't match
+func (s *JSONSchema) Validate(data any) error {
+	err := s.schema.Validate(data)
+
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
+
+	violations := make([]SchemaViolation, 0)
+	collectSchemaViolations(validationErr, &violations)
+
+	return &SchemaValidationError{Violations: violations}
+}
+
+// only the leaves are kept since the errors above them just say that
+// one of their subschemas failed
+func collectSchemaViolations(err *jsonschema.ValidationError, violations *[]SchemaViolation) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, SchemaViolation{
+			Path:    jsonPointerToPath(err.InstanceLocation),
+			Message: err.Message,
+		})
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectSchemaViolations(cause, violations)
+	}
+}
+
+func jsonPointerToPath(pointer string) string {
+	if pointer == "" || pointer == "/" {
+		return ""
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+
+	for i := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[i])
+	}
+
+	return strings.Join(tokens, ".")
+}
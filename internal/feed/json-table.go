@@ -0,0 +1,108 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONTableColumn selects the value of a column of every row, with the path
+// being resolved against the row through ResolveJSONPath
+type JSONTableColumn struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// FlattenJSONArrays expands the arrays nested within list into a single list of
+// their elements, which is what paths such as "teams.#.players" resolve to
+func FlattenJSONArrays(list []any) []any {
+	flattened := make([]any, 0, len(list))
+
+	for i := range list {
+		if nested, ok := list[i].([]any); ok {
+			flattened = append(flattened, FlattenJSONArrays(nested)...)
+		} else {
+			flattened = append(flattened, list[i])
+		}
+	}
+
+	return flattened
+}
+
+// FlattenJSONRows turns the array which rootPath points to into rows for a table,
+// with nested arrays being flattened into it so that every element is a row.
+// Columns whose path doesn't exist for a row are empty and those which resolve to
+// an array have its values joined with a comma.
+func FlattenJSONRows(data any, rootPath string, columns []JSONTableColumn) ([]map[string]string, error) {
+	root, ok := ResolveJSONPath(data, rootPath)
+	list, isList := root.([]any)
+
+	if !ok || !isList {
+		return nil, fmt.Errorf("path %q does not point to an array", rootPath)
+	}
+
+	list = FlattenJSONArrays(list)
+	rows := make([]map[string]string, 0, len(list))
+
+	for i := range list {
+		row := make(map[string]string, len(columns))
+
+		for _, column := range columns {
+			if value, ok := ResolveJSONPath(list[i], column.Path); ok {
+				row[column.Name] = jsonValueToString(value)
+			} else {
+				row[column.Name] = ""
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// FetchJSONRows decodes the response to the request and flattens it, see
+// FlattenJSONRows. Rows are also returned with an ErrPartialContent error, such
+// as when they're from an earlier response because of WithLastGoodFallback.
+func FetchJSONRows(client RequestDoer, request *http.Request, rootPath string, columns []JSONTableColumn, opts ...DecodeOption) ([]map[string]string, error) {
+	data, err := decodeJsonFromRequest[any](client, request, opts...)
+
+	if err != nil && !errors.Is(err, ErrPartialContent) {
+		return nil, err
+	}
+
+	rows, flattenErr := FlattenJSONRows(data, rootPath, columns)
+
+	if flattenErr != nil {
+		return nil, flattenErr
+	}
+
+	return rows, err
+}
+
+func jsonValueToString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, element := range FlattenJSONArrays(v) {
+			values = append(values, jsonValueToString(element))
+		}
+
+		return strings.Join(values, ", ")
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
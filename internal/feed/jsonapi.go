@@ -0,0 +1,233 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type JSONAPILink string
+
+// UnmarshalJSON accepts both forms of links allowed by the spec,
+// a plain URL or a link object with an href
+func (l *JSONAPILink) UnmarshalJSON(data []byte) error {
+	var href string
+
+	if err := json.Unmarshal(data, &href); err == nil {
+		*l = JSONAPILink(href)
+		return nil
+	}
+
+	var object struct {
+		Href string `json:"href"`
+	}
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	*l = JSONAPILink(object.Href)
+
+	return nil
+}
+
+type JSONAPILinks struct {
+	Self    JSONAPILink `json:"self"`
+	Related JSONAPILink `json:"related"`
+	First   JSONAPILink `json:"first"`
+	Last    JSONAPILink `json:"last"`
+	Prev    JSONAPILink `json:"prev"`
+	Next    JSONAPILink `json:"next"`
+}
+
+type JSONAPIError struct {
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+type JSONAPIDocument[T any] struct {
+	Data     T
+	Included []map[string]any
+	Meta     map[string]any
+	Links    JSONAPILinks
+}
+
+type jsonAPIResponseJson struct {
+	Data     json.RawMessage  `json:"data"`
+	Included []map[string]any `json:"included"`
+	Meta     map[string]any   `json:"meta"`
+	Links    JSONAPILinks     `json:"links"`
+	Errors   []JSONAPIError   `json:"errors"`
+}
+
+var ErrJSONAPIErrors = errors.New("json:api response contains errors")
+
+// WithRelationshipResolution replaces the resource identifiers in the
+// relationships of the primary data with the full resources from included.
+// Only one level is resolved, the relationships of included resources are
+// left as identifiers since they can reference each other in cycles.
+func WithRelationshipResolution() DecodeOption {
+	return func(o *decodeOptions) {
+		o.resolveRelationships = true
+	}
+}
+
+// DecodeJSONAPI decodes a json:api (https://jsonapi.org) document, with the
+// primary data being decoded into T which is typically either a struct with
+// the Type, ID, Attributes and Relationships of the resource or a slice of such
+func DecodeJSONAPI[T any](client RequestDoer, request *http.Request, opts ...DecodeOption) (_ *JSONAPIDocument[T], err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+
+	options := newDecodeOptions(opts)
+	_, body, err := options.fetch(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var response jsonAPIResponseJson
+
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+
+		for _, e := range response.Errors {
+			message := e.Title
+
+			if e.Detail != "" {
+				message += ": " + e.Detail
+			}
+
+			messages = append(messages, message)
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrJSONAPIErrors, strings.Join(messages, "; "))
+	}
+
+	data := []byte(response.Data)
+
+	if options.resolveRelationships && len(response.Included) > 0 {
+		if data, err = resolveJSONAPIRelationships(data, response.Included); err != nil {
+			return nil, err
+		}
+	}
+
+	document := &JSONAPIDocument[T]{
+		Included: response.Included,
+		Meta:     response.Meta,
+		Links:    response.Links,
+	}
+
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &document.Data); err != nil {
+			return nil, fmt.Errorf("could not decode json:api data: %w", err)
+		}
+	}
+
+	if err = options.validate(document.Data, request); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func jsonAPIResourceKey(resource map[string]any) (string, bool) {
+	resourceType, ok := resource["type"].(string)
+
+	if !ok {
+		return "", false
+	}
+
+	id, ok := resource["id"].(string)
+
+	if !ok {
+		return "", false
+	}
+
+	return resourceType + "\x00" + id, true
+}
+
+func resolveJSONAPIRelationships(data []byte, included []map[string]any) ([]byte, error) {
+	resources := make(map[string]map[string]any, len(included))
+
+	for _, resource := range included {
+		if key, ok := jsonAPIResourceKey(resource); ok {
+			resources[key] = resource
+		}
+	}
+
+	var primary any
+
+	if err := json.Unmarshal(data, &primary); err != nil {
+		return nil, err
+	}
+
+	resolve := func(identifier any) any {
+		object, ok := identifier.(map[string]any)
+
+		if !ok {
+			return identifier
+		}
+
+		key, ok := jsonAPIResourceKey(object)
+
+		if !ok {
+			return identifier
+		}
+
+		if resource, ok := resources[key]; ok {
+			return resource
+		}
+
+		return identifier
+	}
+
+	resolveResource := func(value any) {
+		resource, ok := value.(map[string]any)
+
+		if !ok {
+			return
+		}
+
+		relationships, ok := resource["relationships"].(map[string]any)
+
+		if !ok {
+			return
+		}
+
+		for _, relationship := range relationships {
+			relationship, ok := relationship.(map[string]any)
+
+			if !ok {
+				continue
+			}
+
+			switch linkage := relationship["data"].(type) {
+			case []any:
+				for i := range linkage {
+					linkage[i] = resolve(linkage[i])
+				}
+			case map[string]any:
+				relationship["data"] = resolve(linkage)
+			}
+		}
+	}
+
+	if list, ok := primary.([]any); ok {
+		for i := range list {
+			resolveResource(list[i])
+		}
+	} else {
+		resolveResource(primary)
+	}
+
+	return json.Marshal(primary)
+}
@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const jsonAPIArticlesDocument = `{
+	"data": [
+		{
+			"type": "articles",
+			"id": "1",
+			"attributes": {"title": "First"},
+			"relationships": {
+				"author": {"data": {"type": "people", "id": "9"}},
+				"comments": {"data": [{"type": "comments", "id": "5"}, {"type": "comments", "id": "404"}]}
+			}
+		}
+	],
+	"included": [
+		{"type": "people", "id": "9", "attributes": {"name": "Dan"}},
+		{"type": "comments", "id": "5", "attributes": {"body": "Nice"}}
+	],
+	"meta": {"total": 1},
+	"links": {"self": "https://example.com/articles", "next": {"href": "https://example.com/articles?page=2"}}
+}`
+
+type jsonAPITestArticle struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Attributes struct {
+		Title string `json:"title"`
+	} `json:"attributes"`
+	Relationships struct {
+		Author struct {
+			Data struct {
+				Type       string `json:"type"`
+				ID         string `json:"id"`
+				Attributes struct {
+					Name string `json:"name"`
+				} `json:"attributes"`
+			} `json:"data"`
+		} `json:"author"`
+		Comments struct {
+			Data []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Body string `json:"body"`
+				} `json:"attributes"`
+			} `json:"data"`
+		} `json:"comments"`
+	} `json:"relationships"`
+}
+
+func newJSONAPITestServer(t *testing.T, document string) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		fmt.Fprint(w, document)
+	})
+}
+
+func TestDecodeJSONAPIWithoutResolution(t *testing.T) {
+	server := newJSONAPITestServer(t, jsonAPIArticlesDocument)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	document, err := DecodeJSONAPI[[]jsonAPITestArticle](server.Client(), request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(document.Data) != 1 || document.Data[0].Attributes.Title != "First" {
+		t.Fatalf("unexpected data: %+v", document.Data)
+	}
+
+	author := document.Data[0].Relationships.Author.Data
+
+	if author.ID != "9" || author.Attributes.Name != "" {
+		t.Errorf("expected the author to be left as an identifier, got %+v", author)
+	}
+
+	if len(document.Included) != 2 || document.Meta["total"] != float64(1) {
+		t.Errorf("unexpected included or meta: %v, %v", document.Included, document.Meta)
+	}
+
+	if document.Links.Self != "https://example.com/articles" || document.Links.Next != "https://example.com/articles?page=2" {
+		t.Errorf("unexpected links: %+v", document.Links)
+	}
+}
+
+func TestDecodeJSONAPIWithRelationshipResolution(t *testing.T) {
+	server := newJSONAPITestServer(t, jsonAPIArticlesDocument)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	document, err := DecodeJSONAPI[[]jsonAPITestArticle](server.Client(), request, WithRelationshipResolution())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relationships := document.Data[0].Relationships
+
+	if relationships.Author.Data.Attributes.Name != "Dan" {
+		t.Errorf("expected the author to be resolved from included, got %+v", relationships.Author.Data)
+	}
+
+	comments := relationships.Comments.Data
+
+	if len(comments) != 2 || comments[0].Attributes.Body != "Nice" {
+		t.Fatalf("expected the first comment to be resolved, got %+v", comments)
+	}
+
+	if comments[1].ID != "404" || comments[1].Attributes.Body != "" {
+		t.Errorf("expected the comment missing from included to stay an identifier, got %+v", comments[1])
+	}
+}
+
+func TestDecodeJSONAPISingleResource(t *testing.T) {
+	server := newJSONAPITestServer(t, `{"data": {"type": "articles", "id": "2", "attributes": {"title": "Only"}}}`)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	document, err := DecodeJSONAPI[jsonAPITestArticle](server.Client(), request, WithRelationshipResolution())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if document.Data.ID != "2" || document.Data.Attributes.Title != "Only" {
+		t.Errorf("unexpected data: %+v", document.Data)
+	}
+}
+
+func TestDecodeJSONAPIErrors(t *testing.T) {
+	server := newJSONAPITestServer(t, `{"errors": [{"status": "422", "title": "Invalid", "detail": "title is required"}]}`)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	_, err := DecodeJSONAPI[jsonAPITestArticle](server.Client(), request)
+
+	if !errors.Is(err, ErrJSONAPIErrors) {
+		t.Fatalf("expected ErrJSONAPIErrors, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrMalformedJWT = errors.New("malformed JWT")
+
+type JWTClaims struct {
+	Header    map[string]any
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	NotBefore time.Time
+	Custom    map[string]any
+}
+
+// ParseJWT decodes the header and claims of a JWT. The signature is not
+// verified, that's the job of the API which issued or accepts the token.
+func ParseJWT(token string) (*JWTClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrMalformedJWT, len(parts))
+	}
+
+	header, err := decodeJWTSegment(parts[0])
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformedJWT, err)
+	}
+
+	payload, err := decodeJWTSegment(parts[1])
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrMalformedJWT, err)
+	}
+
+	claims := &JWTClaims{
+		Header: header,
+		Custom: make(map[string]any),
+	}
+
+	for key, value := range payload {
+		switch key {
+		case "sub":
+			claims.Subject, _ = value.(string)
+		case "iss":
+			claims.Issuer, _ = value.(string)
+		case "aud":
+			claims.Audience, err = jwtAudience(value)
+		case "exp":
+			claims.ExpiresAt, err = jwtNumericDate(value)
+		case "iat":
+			claims.IssuedAt, err = jwtNumericDate(value)
+		case "nbf":
+			claims.NotBefore, err = jwtNumericDate(value)
+		default:
+			claims.Custom[key] = value
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: claim %s: %v", ErrMalformedJWT, key, err)
+		}
+	}
+
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string) (map[string]any, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+
+	var result map[string]any
+
+	if err = decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, errors.New("segment is not a JSON object")
+	}
+
+	return result, nil
+}
+
+func jwtNumericDate(value any) (time.Time, error) {
+	number, ok := value.(json.Number)
+
+	if !ok {
+		return time.Time{}, errors.New("expected a numeric date")
+	}
+
+	seconds, err := number.Float64()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	whole, fraction := math.Modf(seconds)
+
+	return time.Unix(int64(whole), int64(fraction*1e9)), nil
+}
+
+func jwtAudience(value any) ([]string, error) {
+	switch audience := value.(type) {
+	case string:
+		return []string{audience}, nil
+	case []any:
+		result := make([]string, 0, len(audience))
+
+		for i := range audience {
+			s, ok := audience[i].(string)
+
+			if !ok {
+				return nil, errors.New("expected an array of strings")
+			}
+
+			result = append(result, s)
+		}
+
+		return result, nil
+	default:
+		return nil, errors.New("expected a string or an array of strings")
+	}
+}
+
+// IsExpired reports whether the token has an expiry which has passed,
+// tokens without an exp claim never expire
+func (c *JWTClaims) IsExpired() bool {
+	if c.ExpiresAt.IsZero() {
+		return false
+	}
+
+	return !time.Now().Before(c.ExpiresAt)
+}
+
+// TimeUntilExpiry returns how long until the token expires, negative if it
+// already has, or the maximum duration if it has no exp claim
+func (c *JWTClaims) TimeUntilExpiry() time.Duration {
+	if c.ExpiresAt.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Until(c.ExpiresAt)
+}
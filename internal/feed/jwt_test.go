@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func makeTestJWT(header, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
+
+func TestParseJWTStandardClaims(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	token := makeTestJWT(
+		`{"alg":"HS256","typ":"JWT"}`,
+		`{"sub":"user-1","iss":"auth.example.com","aud":["api","web"],"exp":`+strconv.FormatInt(expiresAt, 10)+`,"iat":1700000000,"scope":"read write"}`,
+	)
+
+	claims, err := ParseJWT(token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "user-1" || claims.Issuer != "auth.example.com" {
+		t.Errorf("unexpected subject or issuer: %q, %q", claims.Subject, claims.Issuer)
+	}
+
+	if len(claims.Audience) != 2 || claims.Audience[0] != "api" || claims.Audience[1] != "web" {
+		t.Errorf("unexpected audience: %v", claims.Audience)
+	}
+
+	if claims.ExpiresAt.Unix() != expiresAt {
+		t.Errorf("expected expiry %d, got %d", expiresAt, claims.ExpiresAt.Unix())
+	}
+
+	if !claims.IssuedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected issued at: %v", claims.IssuedAt)
+	}
+
+	if claims.Custom["scope"] != "read write" {
+		t.Errorf("expected the scope to be kept as a custom claim, got %v", claims.Custom)
+	}
+
+	if claims.Header["alg"] != "HS256" {
+		t.Errorf("unexpected header: %v", claims.Header)
+	}
+
+	if claims.IsExpired() {
+		t.Error("token which expires in an hour reported as expired")
+	}
+
+	if left := claims.TimeUntilExpiry(); left <= 59*time.Minute || left > time.Hour {
+		t.Errorf("unexpected time until expiry: %v", left)
+	}
+}
+
+func TestParseJWTSingleAudience(t *testing.T) {
+	claims, err := ParseJWT(makeTestJWT(`{"alg":"none"}`, `{"aud":"api"}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claims.Audience) != 1 || claims.Audience[0] != "api" {
+		t.Errorf("unexpected audience: %v", claims.Audience)
+	}
+
+	if claims.IsExpired() {
+		t.Error("token without exp reported as expired")
+	}
+}
+
+func TestParseJWTExpired(t *testing.T) {
+	claims, err := ParseJWT(makeTestJWT(`{"alg":"HS256"}`, `{"exp":`+strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)+`}`))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !claims.IsExpired() {
+		t.Error("expected the token to be expired")
+	}
+
+	if claims.TimeUntilExpiry() >= 0 {
+		t.Errorf("expected a negative time until expiry, got %v", claims.TimeUntilExpiry())
+	}
+}
+
+func TestParseJWTMalformed(t *testing.T) {
+	tests := map[string]string{
+		"too few parts":        "abc.def",
+		"invalid base64":       "!!!.def.ghi",
+		"payload not JSON":     base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte("nope")) + ".sig",
+		"payload not object":   makeTestJWT(`{}`, `null`),
+		"exp not a number":     makeTestJWT(`{}`, `{"exp":"tomorrow"}`),
+		"aud not strings":      makeTestJWT(`{}`, `{"aud":[1,2]}`),
+		"header not an object": makeTestJWT(`[]`, `{}`),
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJWT(token)
+
+			if !errors.Is(err, ErrMalformedJWT) {
+				t.Errorf("expected ErrMalformedJWT, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !kerberos
+
+package feed
+
+import "errors"
+
+var ErrKerberosNotSupported = errors.New("kerberos support is not included in this build, it is included on Windows and can be enabled elsewhere with -tags kerberos")
+
+func newKerberosAuthenticator(config KerberosConfig) (kerberosAuthenticator, error) {
+	return nil, ErrKerberosNotSupported
+}
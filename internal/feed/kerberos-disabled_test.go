@@ -0,0 +1,20 @@
+//go:build !windows && !kerberos
+
+package feed
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestKerberosClientNotSupported(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client := NewKerberosClient(server.Client(), KerberosConfig{})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, err := client.Do(request); !errors.Is(err, ErrKerberosNotSupported) {
+		t.Errorf("expected ErrKerberosNotSupported, got %v", err)
+	}
+}
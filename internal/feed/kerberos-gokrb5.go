@@ -0,0 +1,64 @@
+//go:build windows || kerberos
+
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+type gokrb5Authenticator struct {
+	client *krbclient.Client
+}
+
+func newKerberosAuthenticator(config KerberosConfig) (kerberosAuthenticator, error) {
+	if config.KDC == "" || config.Realm == "" || config.Username == "" || config.KeytabPath == "" {
+		return nil, errors.New("kerberos requires a KDC, realm, username and keytab path")
+	}
+
+	kt, err := keytab.Load(config.KeytabPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("loading keytab: %w", err)
+	}
+
+	krb5conf := krbconfig.New()
+	krb5conf.LibDefaults.DefaultRealm = config.Realm
+	krb5conf.LibDefaults.TicketLifetime = config.TicketLifetime
+	krb5conf.Realms = []krbconfig.Realm{{
+		Realm: config.Realm,
+		KDC:   []string{config.KDC},
+	}}
+
+	// Active Directory doesn't support FAST
+	client := krbclient.NewWithKeytab(config.Username, config.Realm, kt, krb5conf, krbclient.DisablePAFXFAST(true))
+
+	// the session is renewed in the background until the client is destroyed,
+	// which happens once a new one is logged in before TicketLifetime is up
+	if err := client.Login(); err != nil {
+		return nil, fmt.Errorf("logging in to %s: %w", config.KDC, err)
+	}
+
+	return &gokrb5Authenticator{client: client}, nil
+}
+
+func (a *gokrb5Authenticator) setNegotiateHeader(request *http.Request, spn string) error {
+	// logs in again if the session expired without being renewed, such as
+	// when the KDC was unreachable at the time
+	if err := a.client.AffirmLogin(); err != nil {
+		return err
+	}
+
+	// service tickets are cached and renewed by the client when they've expired
+	return spnego.SetSPNEGOHeader(a.client, request, spn)
+}
+
+func (a *gokrb5Authenticator) destroy() {
+	a.client.Destroy()
+}
@@ -0,0 +1,274 @@
+//go:build windows || kerberos
+
+package feed
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/msgtype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+const (
+	testKerberosRealm = "GLANCE.TEST"
+	testKerberosUser  = "glance"
+	testKerberosSPN   = "HTTP/127.0.0.1"
+)
+
+func newTestKeytab(t *testing.T, principals ...string) *keytab.Keytab {
+	t.Helper()
+
+	kt := keytab.New()
+
+	for _, principal := range principals {
+		if err := kt.AddEntry(principal, testKerberosRealm, principal+" password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return kt
+}
+
+// mockKDC answers AS and TGS requests over UDP without pre-authentication,
+// issuing tickets for the principals in its keytab
+type mockKDC struct {
+	keytab      *keytab.Keytab
+	address     string
+	asExchanges atomic.Int32
+}
+
+func newMockKDC(t *testing.T) *mockKDC {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	kdc := &mockKDC{
+		keytab:  newTestKeytab(t, testKerberosUser, "krbtgt/"+testKerberosRealm, testKerberosSPN),
+		address: conn.LocalAddr().String(),
+	}
+
+	go func() {
+		buffer := make([]byte, 65535)
+
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+
+			if err != nil {
+				return
+			}
+
+			response, err := kdc.reply(buffer[:n])
+
+			if err != nil {
+				t.Errorf("mock KDC: %v", err)
+				continue
+			}
+
+			conn.WriteTo(response, addr)
+		}
+	}()
+
+	return kdc
+}
+
+func (kdc *mockKDC) reply(request []byte) ([]byte, error) {
+	var asReq messages.ASReq
+
+	if err := asReq.Unmarshal(request); err == nil {
+		kdc.asExchanges.Add(1)
+
+		// the encrypted part is for the client, using the key from its password
+		clientKey, _, err := kdc.keytab.GetEncryptionKey(asReq.ReqBody.CName, asReq.ReqBody.Realm, 0, etypeID.AES256_CTS_HMAC_SHA1_96)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := kdc.issue(asReq.ReqBody, asReq.ReqBody.CName, clientKey, keyusage.AS_REP_ENCPART, msgtype.KRB_AS_REP)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return (&messages.ASRep{KDCRepFields: fields}).Marshal()
+	}
+
+	var tgsReq messages.TGSReq
+
+	if err := tgsReq.Unmarshal(request); err != nil {
+		return nil, err
+	}
+
+	// the TGT sent as the authenticator holds the session key to encrypt the reply with
+	var apReq messages.APReq
+
+	if err := apReq.Unmarshal(tgsReq.PAData[0].PADataValue); err != nil {
+		return nil, err
+	}
+
+	if err := apReq.Ticket.DecryptEncPart(kdc.keytab, &apReq.Ticket.SName); err != nil {
+		return nil, err
+	}
+
+	fields, err := kdc.issue(tgsReq.ReqBody, apReq.Ticket.DecryptedEncPart.CName, apReq.Ticket.DecryptedEncPart.Key, keyusage.TGS_REP_ENCPART_SESSION_KEY, msgtype.KRB_TGS_REP)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return (&messages.TGSRep{KDCRepFields: fields}).Marshal()
+}
+
+func (kdc *mockKDC) issue(body messages.KDCReqBody, cname types.PrincipalName, replyKey types.EncryptionKey, usage uint32, msgType int) (messages.KDCRepFields, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	flags := types.NewKrbFlags()
+
+	ticket, sessionKey, err := messages.NewTicket(cname, body.Realm, body.SName, body.Realm, flags, kdc.keytab, etypeID.AES256_CTS_HMAC_SHA1_96, 1, now, now, body.Till, body.Till)
+
+	if err != nil {
+		return messages.KDCRepFields{}, err
+	}
+
+	encPart, err := (&messages.EncKDCRepPart{
+		Key:       sessionKey,
+		LastReqs:  []messages.LastReq{},
+		Nonce:     body.Nonce,
+		Flags:     flags,
+		AuthTime:  now,
+		StartTime: now,
+		EndTime:   body.Till,
+		RenewTill: body.Till,
+		SRealm:    body.Realm,
+		SName:     body.SName,
+	}).Marshal()
+
+	if err != nil {
+		return messages.KDCRepFields{}, err
+	}
+
+	encrypted, err := crypto.GetEncryptedData(encPart, replyKey, usage, 1)
+
+	if err != nil {
+		return messages.KDCRepFields{}, err
+	}
+
+	return messages.KDCRepFields{
+		PVNO:    5,
+		MsgType: msgType,
+		CRealm:  body.Realm,
+		CName:   cname,
+		Ticket:  ticket,
+		EncPart: encrypted,
+	}, nil
+}
+
+// newKerberosTestService only lets through requests with a service ticket from
+// the mock KDC, challenging the others to negotiate
+func newKerberosTestService(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return newTestServer(t, spnego.SPNEGOKRB5Authenticate(inner, newTestKeytab(t, testKerberosSPN)).ServeHTTP)
+}
+
+func newTestKerberosConfig(t *testing.T, kdc *mockKDC) KerberosConfig {
+	t.Helper()
+
+	data, err := newTestKeytab(t, testKerberosUser).Marshal()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "glance.keytab")
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return KerberosConfig{
+		KDC:        kdc.address,
+		Realm:      testKerberosRealm,
+		Username:   testKerberosUser,
+		KeytabPath: path,
+	}
+}
+
+func TestKerberosClientAuthenticatesWithKDC(t *testing.T) {
+	kdc := newMockKDC(t)
+	service := newKerberosTestService(t)
+	client := NewKerberosClient(service.Client(), newTestKerberosConfig(t, kdc))
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, service.URL, nil)
+		sendKerberosTestRequest(t, client, request)
+	}
+
+	if exchanges := kdc.asExchanges.Load(); exchanges != 1 {
+		t.Errorf("expected the TGT to be reused, got %d AS exchanges", exchanges)
+	}
+}
+
+func TestKerberosClientRejectsKeytabWithWrongKey(t *testing.T) {
+	kdc := newMockKDC(t)
+	service := newKerberosTestService(t)
+	config := newTestKerberosConfig(t, kdc)
+
+	// the KDC's reply can't be decrypted with a key from another password
+	wrongKey := keytab.New()
+	wrongKey.AddEntry(testKerberosUser, testKerberosRealm, "wrong password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96)
+	data, _ := wrongKey.Marshal()
+
+	if err := os.WriteFile(config.KeytabPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, service.URL, nil)
+
+	if _, err := NewKerberosClient(service.Client(), config).Do(request); err == nil {
+		t.Error("expected logging in with the wrong key to fail")
+	}
+}
+
+func TestKerberosClientLogsInAgainBeforeTicketsExpire(t *testing.T) {
+	kdc := newMockKDC(t)
+	service := newKerberosTestService(t)
+	config := newTestKerberosConfig(t, kdc)
+	config.TicketLifetime = 2 * time.Second
+	client := NewKerberosClient(service.Client(), config)
+
+	request, _ := http.NewRequest(http.MethodGet, service.URL, nil)
+	sendKerberosTestRequest(t, client, request)
+
+	// half of the lifetime, as it's shorter than the refresh margin
+	time.Sleep(1100 * time.Millisecond)
+
+	request, _ = http.NewRequest(http.MethodGet, service.URL, nil)
+	sendKerberosTestRequest(t, client, request)
+
+	if exchanges := kdc.asExchanges.Load(); exchanges != 2 {
+		t.Errorf("expected a new TGT before the first one expired, got %d AS exchanges", exchanges)
+	}
+}
@@ -0,0 +1,169 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKerberosTicketLifetime = 10 * time.Hour
+	kerberosRefreshMargin         = 5 * time.Minute
+)
+
+type KerberosConfig struct {
+	// host or host:port of the KDC, port 88 being used when left out
+	KDC   string
+	Realm string
+	// the principal to authenticate as, as it appears in the keytab, without the realm
+	Username   string
+	KeytabPath string
+	// the service principal of the API, HTTP/<host of the request> when empty
+	SPN string
+	// how long tickets are requested for, which is also how long they're assumed to
+	// last since the KDC doesn't say otherwise. Defaults to 10 hours, the default
+	// of Active Directory, and should be lowered to match a KDC granting less.
+	TicketLifetime time.Duration
+}
+
+// sets the Authorization header of a request to a Negotiate token for spn,
+// getting new tickets when the ones it has are about to expire
+type kerberosAuthenticator interface {
+	setNegotiateHeader(request *http.Request, spn string) error
+	// stops renewing the session in the background
+	destroy()
+}
+
+type kerberosClient struct {
+	base   RequestDoer
+	config KerberosConfig
+	login  func(KerberosConfig) (kerberosAuthenticator, error)
+
+	mu            sync.Mutex
+	authenticator kerberosAuthenticator
+	refreshAt     time.Time
+
+	// hosts which responded with a Negotiate challenge before, to which requests
+	// are authenticated straight away rather than after another challenge
+	negotiateHosts sync.Map
+}
+
+// NewKerberosClient authenticates requests sent through base with SPNEGO, for APIs
+// behind Windows authentication. Requests are first sent as they are, and if the
+// server responds with a 401 asking to Negotiate, they're sent again with a ticket
+// for the SPN. The keytab is only loaded and the KDC contacted on the first request
+// which needs it, with errors from doing so being returned by that request, and
+// again a few minutes before the tickets expire so that they never do while in
+// use. Kerberos support is included in Windows builds and in builds for other
+// platforms made with -tags kerberos, without it requests which need
+// authenticating fail with ErrKerberosNotSupported.
+func NewKerberosClient(base RequestDoer, config KerberosConfig) RequestDoer {
+	if config.TicketLifetime <= 0 {
+		config.TicketLifetime = defaultKerberosTicketLifetime
+	}
+
+	return &kerberosClient{base: base, config: config, login: newKerberosAuthenticator}
+}
+
+func (c *kerberosClient) Do(request *http.Request) (*http.Response, error) {
+	host := request.URL.Host
+
+	if _, required := c.negotiateHosts.Load(host); required {
+		return c.doAuthenticated(request)
+	}
+
+	// a body which can't be sent twice would be lost to the challenge
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return c.doAuthenticated(request)
+	}
+
+	response, err := c.base.Do(request)
+
+	if err != nil || !isNegotiateChallenge(response) {
+		return response, err
+	}
+
+	// reading what's left of the body allows the connection to be reused for the retry
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+	response.Body.Close()
+
+	c.negotiateHosts.Store(host, struct{}{})
+
+	retry := request.Clone(request.Context())
+
+	if request.GetBody != nil {
+		if retry.Body, err = request.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.doAuthenticated(retry)
+}
+
+// currentAuthenticator logs in on first use and again once the tickets are close
+// to expiring. A failed login is tried again by the next request.
+func (c *kerberosClient) currentAuthenticator() (kerberosAuthenticator, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.authenticator != nil && time.Now().Before(c.refreshAt) {
+		return c.authenticator, nil
+	}
+
+	authenticator, err := c.login(c.config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// requests which got the previous one just before may still be using it
+	if previous := c.authenticator; previous != nil {
+		time.AfterFunc(time.Minute, previous.destroy)
+	}
+
+	c.authenticator = authenticator
+	c.refreshAt = time.Now().Add(c.config.TicketLifetime - min(kerberosRefreshMargin, c.config.TicketLifetime/2))
+
+	return authenticator, nil
+}
+
+func (c *kerberosClient) doAuthenticated(request *http.Request) (*http.Response, error) {
+	authenticator, err := c.currentAuthenticator()
+
+	if err != nil {
+		return nil, err
+	}
+
+	spn := c.config.SPN
+
+	if spn == "" {
+		spn = "HTTP/" + request.URL.Hostname()
+	}
+
+	authenticated := request.Clone(request.Context())
+
+	if err := authenticator.setNegotiateHeader(authenticated, spn); err != nil {
+		return nil, fmt.Errorf("kerberos authentication for %s: %w", spn, err)
+	}
+
+	return c.base.Do(authenticated)
+}
+
+func isNegotiateChallenge(response *http.Response) bool {
+	if response.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	for _, challenge := range response.Header.Values("WWW-Authenticate") {
+		scheme, _, _ := strings.Cut(challenge, " ")
+
+		if strings.EqualFold(scheme, "Negotiate") {
+			return true
+		}
+	}
+
+	return false
+}
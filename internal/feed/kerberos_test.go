@@ -0,0 +1,274 @@
+package feed
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeKerberosAuthenticator makes tokens out of the SPN and how many of them
+// it's made, with login counting how many of these were created
+type fakeKerberosAuthenticator struct {
+	id        int32
+	tokens    atomic.Int32
+	destroyed atomic.Bool
+}
+
+func (a *fakeKerberosAuthenticator) setNegotiateHeader(request *http.Request, spn string) error {
+	a.tokens.Add(1)
+	request.Header.Set("Authorization", "Negotiate "+base64.StdEncoding.EncodeToString([]byte(spn)))
+	return nil
+}
+
+func (a *fakeKerberosAuthenticator) destroy() {
+	a.destroyed.Store(true)
+}
+
+type fakeKerberosLogin struct {
+	mu             sync.Mutex
+	authenticators []*fakeKerberosAuthenticator
+	err            error
+}
+
+func (l *fakeKerberosLogin) login(KerberosConfig) (kerberosAuthenticator, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	authenticator := &fakeKerberosAuthenticator{id: int32(len(l.authenticators))}
+	l.authenticators = append(l.authenticators, authenticator)
+
+	return authenticator, nil
+}
+
+func (l *fakeKerberosLogin) logins() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.authenticators)
+}
+
+func newFakeKerberosClient(base RequestDoer, config KerberosConfig) (*kerberosClient, *fakeKerberosLogin) {
+	login := &fakeKerberosLogin{}
+	client := NewKerberosClient(base, config).(*kerberosClient)
+	client.login = login.login
+
+	return client, login
+}
+
+type negotiateTestServer struct {
+	*httptest.Server
+	challenges    atomic.Int32
+	authorization chan string
+	bodies        chan string
+}
+
+// newNegotiateTestServer challenges requests without an Authorization header and
+// hands over the header and body of the ones which have one
+func newNegotiateTestServer(t *testing.T) *negotiateTestServer {
+	t.Helper()
+
+	server := &negotiateTestServer{authorization: make(chan string, 10), bodies: make(chan string, 10)}
+	server.Server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if r.Header.Get("Authorization") == "" {
+			server.challenges.Add(1)
+			w.Header().Add("WWW-Authenticate", `Basic realm="test"`)
+			w.Header().Add("WWW-Authenticate", "Negotiate")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		server.authorization <- r.Header.Get("Authorization")
+		server.bodies <- string(body)
+	})
+
+	return server
+}
+
+func sendKerberosTestRequest(t *testing.T, client RequestDoer, request *http.Request) {
+	t.Helper()
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", response.StatusCode)
+	}
+}
+
+func negotiateHeaderFor(spn string) string {
+	return "Negotiate " + base64.StdEncoding.EncodeToString([]byte(spn))
+}
+
+func TestKerberosClientNegotiatesAfterChallenge(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, login := newFakeKerberosClient(server.Client(), KerberosConfig{})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/first", nil)
+	sendKerberosTestRequest(t, client, request)
+
+	if header := <-server.authorization; header != negotiateHeaderFor("HTTP/127.0.0.1") {
+		t.Errorf("expected a token for the SPN of the host, got %q", header)
+	}
+
+	// once a host has asked to negotiate, requests to it are authenticated straight away
+	request, _ = http.NewRequest(http.MethodGet, server.URL+"/second", nil)
+	sendKerberosTestRequest(t, client, request)
+	<-server.authorization
+
+	if challenges := server.challenges.Load(); challenges != 1 {
+		t.Errorf("expected a single challenge, got %d", challenges)
+	}
+
+	if logins := login.logins(); logins != 1 {
+		t.Errorf("expected a single login, got %d", logins)
+	}
+
+	if request.Header.Get("Authorization") != "" {
+		t.Error("expected the request given to the client to be left unchanged")
+	}
+}
+
+func TestKerberosClientConfiguredSPN(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, _ := newFakeKerberosClient(server.Client(), KerberosConfig{SPN: "HTTP/api.corp.example.com"})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	sendKerberosTestRequest(t, client, request)
+
+	if header := <-server.authorization; header != negotiateHeaderFor("HTTP/api.corp.example.com") {
+		t.Errorf("expected a token for the configured SPN, got %q", header)
+	}
+}
+
+func TestKerberosClientResendsBodyAfterChallenge(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, _ := newFakeKerberosClient(server.Client(), KerberosConfig{})
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"a":1}`))
+	sendKerberosTestRequest(t, client, request)
+
+	if body := <-server.bodies; body != `{"a":1}` {
+		t.Errorf("expected the body to be sent again with the token, got %q", body)
+	}
+
+	if server.challenges.Load() != 1 {
+		t.Errorf("expected the first attempt to be challenged, got %d challenges", server.challenges.Load())
+	}
+}
+
+func TestKerberosClientAuthenticatesBodiesWhichCantBeResent(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, _ := newFakeKerberosClient(server.Client(), KerberosConfig{})
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("payload")))
+	sendKerberosTestRequest(t, client, request)
+
+	if body := <-server.bodies; body != "payload" {
+		t.Errorf("unexpected body: %q", body)
+	}
+
+	if server.challenges.Load() != 0 {
+		t.Errorf("expected the request to be authenticated without a challenge, got %d", server.challenges.Load())
+	}
+}
+
+func TestKerberosClientRetriesFailedLogin(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, login := newFakeKerberosClient(server.Client(), KerberosConfig{})
+	errKDCDown := errors.New("KDC unreachable")
+	login.err = errKDCDown
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, err := client.Do(request); !errors.Is(err, errKDCDown) {
+		t.Fatalf("expected the login error, got %v", err)
+	}
+
+	login.mu.Lock()
+	login.err = nil
+	login.mu.Unlock()
+
+	request, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	sendKerberosTestRequest(t, client, request)
+	<-server.authorization
+}
+
+func TestKerberosClientRefreshesBeforeExpiry(t *testing.T) {
+	server := newNegotiateTestServer(t)
+	client, login := newFakeKerberosClient(server.Client(), KerberosConfig{TicketLifetime: 200 * time.Millisecond})
+
+	send := func() {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		sendKerberosTestRequest(t, client, request)
+		<-server.authorization
+	}
+
+	send()
+	send()
+
+	if logins := login.logins(); logins != 1 {
+		t.Fatalf("expected the tickets to be reused while they're valid, got %d logins", logins)
+	}
+
+	// with lifetimes shorter than the margin, half of the lifetime is used instead
+	time.Sleep(120 * time.Millisecond)
+	send()
+
+	if logins := login.logins(); logins != 2 {
+		t.Fatalf("expected a new login before the tickets expired, got %d logins", logins)
+	}
+
+	if login.authenticators[1].tokens.Load() != 1 {
+		t.Error("expected the request to be authenticated with the new tickets")
+	}
+}
+
+func TestKerberosClientDefaultTicketLifetime(t *testing.T) {
+	client := NewKerberosClient(http.DefaultClient, KerberosConfig{}).(*kerberosClient)
+
+	if client.config.TicketLifetime != defaultKerberosTicketLifetime {
+		t.Errorf("expected the default ticket lifetime, got %v", client.config.TicketLifetime)
+	}
+}
+
+func TestIsNegotiateChallenge(t *testing.T) {
+	tests := []struct {
+		status     int
+		challenges []string
+		expected   bool
+	}{
+		{status: http.StatusUnauthorized, challenges: []string{"Negotiate"}, expected: true},
+		{status: http.StatusUnauthorized, challenges: []string{"negotiate YIIB"}, expected: true},
+		{status: http.StatusUnauthorized, challenges: []string{`Basic realm="x"`, "Negotiate"}, expected: true},
+		{status: http.StatusUnauthorized, challenges: []string{`Basic realm="x"`}, expected: false},
+		{status: http.StatusUnauthorized, challenges: []string{"NegotiateExtra"}, expected: false},
+		{status: http.StatusUnauthorized, expected: false},
+		{status: http.StatusForbidden, challenges: []string{"Negotiate"}, expected: false},
+	}
+
+	for _, test := range tests {
+		response := &http.Response{StatusCode: test.status, Header: http.Header{"Www-Authenticate": test.challenges}}
+
+		if isNegotiateChallenge(response) != test.expected {
+			t.Errorf("%d %v: expected %v", test.status, test.challenges, test.expected)
+		}
+	}
+}
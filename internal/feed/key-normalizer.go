@@ -0,0 +1,168 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type NamingConvention int
+
+const (
+	CamelCase NamingConvention = iota
+	SnakeCase
+	KebabCase
+	PascalCase
+)
+
+// WithKeyNormalizer rewrites the keys of the response from the naming
+// convention used by the API to snake_case before it gets decoded, allowing
+// the struct tags of every response to follow the same convention
+func WithKeyNormalizer(conv NamingConvention) DecodeOption {
+	return func(o *decodeOptions) {
+		o.keyConvention = &conv
+	}
+}
+
+func (o *decodeOptions) normalizeKeys(body []byte) ([]byte, error) {
+	if o.keyConvention == nil || *o.keyConvention == SnakeCase {
+		return body, nil
+	}
+
+	normalized, err := rewriteJSONKeys(body, toSnakeCase)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not normalize keys: %w", err)
+	}
+
+	return normalized, nil
+}
+
+type jsonRewriterFrame struct {
+	object  bool
+	written int
+}
+
+// rewriteJSONKeys streams through the tokens of the document and writes them
+// back out with every object key passed through rewrite, the document is
+// never decoded into maps so large responses don't get copied around
+func rewriteJSONKeys(body []byte, rewrite func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var output bytes.Buffer
+	output.Grow(len(body))
+
+	stack := make([]jsonRewriterFrame, 0, 8)
+
+	for {
+		token, err := decoder.Token()
+
+		if errors.Is(err, io.EOF) {
+			if len(stack) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := token.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			output.WriteByte(byte(delim))
+			continue
+		}
+
+		if len(stack) == 0 && output.Len() > 0 {
+			return nil, errors.New("unexpected data after top-level value")
+		}
+
+		isKey := false
+
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			isKey = top.object && top.written%2 == 0
+
+			if top.written > 0 && (!top.object || isKey) {
+				output.WriteByte(',')
+			}
+
+			top.written++
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			output.WriteByte(byte(value))
+			stack = append(stack, jsonRewriterFrame{object: value == '{'})
+		case string:
+			if isKey {
+				value = rewrite(value)
+			}
+
+			encoded, _ := json.Marshal(value)
+			output.Write(encoded)
+		case json.Number:
+			output.WriteString(value.String())
+		case bool:
+			if value {
+				output.WriteString("true")
+			} else {
+				output.WriteString("false")
+			}
+		case nil:
+			output.WriteString("null")
+		}
+
+		if isKey {
+			output.WriteByte(':')
+		}
+	}
+
+	return output.Bytes(), nil
+}
+
+// toSnakeCase splits the key into words on separators and changes of case,
+// keeping runs of capitals together as one word so that acronyms come out
+// whole, e.g. HTTPSPort becomes https_port and userID becomes user_id
+func toSnakeCase(key string) string {
+	runes := []rune(key)
+	var builder strings.Builder
+	builder.Grow(len(key) + 4)
+
+	wordStarted := false
+
+	for i, r := range runes {
+		if r == '-' || r == '_' || r == ' ' || r == '.' {
+			// leading underscores such as in _id are usually significant
+			if r == '_' && builder.Len() == i {
+				builder.WriteByte('_')
+			} else if wordStarted {
+				builder.WriteByte('_')
+				wordStarted = false
+			}
+
+			continue
+		}
+
+		if unicode.IsUpper(r) && wordStarted && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				builder.WriteByte('_')
+			}
+		}
+
+		builder.WriteRune(unicode.ToLower(r))
+		wordStarted = true
+	}
+
+	return strings.TrimSuffix(builder.String(), "_")
+}
@@ -0,0 +1,91 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"userName":   "user_name",
+		"user_name":  "user_name",
+		"user-name":  "user_name",
+		"UserName":   "user_name",
+		"HTTPSPort":  "https_port",
+		"https-port": "https_port",
+		"userID":     "user_id",
+		"APIKey2":    "api_key2",
+		"ipv4Addr":   "ipv4_addr",
+		"_id":        "_id",
+		"URL":        "url",
+	}
+
+	for key, expected := range tests {
+		if actual := toSnakeCase(key); actual != expected {
+			t.Errorf("%s: expected %s, got %s", key, expected, actual)
+		}
+	}
+}
+
+type keyNormalizerTestServer struct {
+	ServerName string `json:"server_name"`
+	HTTPSPort  int    `json:"https_port"`
+	Tags       []struct {
+		TagValue string `json:"tag_value"`
+	} `json:"tags"`
+}
+
+func TestWithKeyNormalizerConventions(t *testing.T) {
+	documents := map[NamingConvention]string{
+		CamelCase:  `{"serverName": "alpha", "httpsPort": 443, "tags": [{"tagValue": "serverName"}]}`,
+		SnakeCase:  `{"server_name": "alpha", "https_port": 443, "tags": [{"tag_value": "serverName"}]}`,
+		KebabCase:  `{"server-name": "alpha", "https-port": 443, "tags": [{"tag-value": "serverName"}]}`,
+		PascalCase: `{"ServerName": "alpha", "HTTPSPort": 443, "Tags": [{"TagValue": "serverName"}]}`,
+	}
+
+	for convention, document := range documents {
+		t.Run(fmt.Sprint(convention), func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, document)
+			})
+
+			request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			result, err := decodeJsonFromRequest[keyNormalizerTestServer](server.Client(), request, WithKeyNormalizer(convention))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.ServerName != "alpha" || result.HTTPSPort != 443 || len(result.Tags) != 1 {
+				t.Fatalf("unexpected result: %+v", result)
+			}
+
+			// values are left as they are, even when they look like keys
+			if result.Tags[0].TagValue != "serverName" {
+				t.Errorf("expected the value to be left alone, got %q", result.Tags[0].TagValue)
+			}
+		})
+	}
+}
+
+func TestRewriteJSONKeysPreservesValues(t *testing.T) {
+	document := `{"outerKey":{"innerKey":[1,2.50,12345678901234567890,true,false,null,"a\"b"]},"emptyList":[],"emptyObject":{}}`
+	expected := `{"outer_key":{"inner_key":[1,2.50,12345678901234567890,true,false,null,"a\"b"]},"empty_list":[],"empty_object":{}}`
+
+	rewritten, err := rewriteJSONKeys([]byte(document), toSnakeCase)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(rewritten) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, rewritten)
+	}
+
+	for _, invalid := range []string{`{"a":1`, `{"a":1} {"b":2}`, `[1,}`} {
+		if _, err := rewriteJSONKeys([]byte(invalid), toSnakeCase); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
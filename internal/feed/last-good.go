@@ -0,0 +1,253 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStaleContent is wrapped along with ErrPartialContent by errors returned with a
+// value from a previous successful request, so that widgets keep showing it while
+// being able to tell that it's out of date
+var ErrStaleContent = errors.New("showing previously fetched data")
+
+type StaleContentError struct {
+	FetchedAt time.Time
+	// why the value couldn't be fetched again
+	Err error
+}
+
+func (e *StaleContentError) Error() string {
+	return fmt.Sprintf("%v, showing data from %s ago", e.Err, time.Since(e.FetchedAt).Round(time.Second))
+}
+
+func (e *StaleContentError) Unwrap() []error {
+	return []error{ErrStaleContent, ErrPartialContent, e.Err}
+}
+
+const maxLastGoodEntries = 1024
+
+type lastGoodEntry struct {
+	value        any
+	fetchedAt    time.Time
+	etag         string
+	lastModified string
+}
+
+// lastGoodResponses holds the last value successfully decoded for each request
+// made with the fallback enabled, with the oldest being dropped once it's full
+var lastGoodResponses = struct {
+	mu      sync.Mutex
+	entries map[string]*lastGoodEntry
+}{entries: make(map[string]*lastGoodEntry)}
+
+// 0 for the fallback only being used by requests decoded with WithLastGoodFallback
+var lastGoodMaxAgeByDefault atomic.Int64
+
+// SetLastGoodFallbackByDefault enables WithLastGoodFallback for every request decoded
+// by the decode functions, 0 or less disabling it again
+func SetLastGoodFallbackByDefault(maxAge time.Duration) {
+	lastGoodMaxAgeByDefault.Store(int64(max(maxAge, 0)))
+}
+
+// WithLastGoodFallback keeps the last value decoded for the request, which is returned
+// instead of an error when the request fails because of the network or the server, as
+// long as it's not older than maxAge. The error it's returned with is a
+// StaleContentError. Requests for which a value is kept are sent with If-None-Match or
+// If-Modified-Since when the server provided an ETag or Last-Modified, with a 304
+// response returning the kept value as it's still up to date. The kept value is the
+// same one that was returned, so changes made to what it points to end up in it.
+func WithLastGoodFallback(maxAge time.Duration) DecodeOption {
+	return func(o *decodeOptions) {
+		o.lastGoodMaxAge = maxAge
+	}
+}
+
+// WithLastGoodKey adds key to what the value kept by WithLastGoodFallback is kept
+// under, for requests which get their credentials from the client they're sent
+// through, such as one from NewServiceAccountTokenClient, so that requests to the
+// same URL on behalf of different users don't share what they fetched
+func WithLastGoodKey(key string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.lastGoodKeyPart = key
+	}
+}
+
+// lastGoodKey tells requests apart by their method and URL as well as the headers
+// which may hold credentials, so that a value fetched with one API key is never
+// returned for a request made with another. The credentials are hashed so that
+// they're not kept around as they are.
+func (o *decodeOptions) lastGoodKey(request *http.Request) string {
+	key := request.Method + " " + request.URL.String()
+
+	names := make([]string, 0, 2)
+
+	for name := range request.Header {
+		if isSensitiveHeader(name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 && o.lastGoodKeyPart == "" {
+		return key
+	}
+
+	slices.Sort(names)
+	hash := sha256.New()
+	hash.Write([]byte(o.lastGoodKeyPart))
+
+	for _, name := range names {
+		hash.Write([]byte("\n" + name + ":"))
+
+		for _, value := range request.Header[name] {
+			hash.Write([]byte(value + "\x00"))
+		}
+	}
+
+	return key + " " + hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+func getLastGood(key string) (lastGoodEntry, bool) {
+	lastGoodResponses.mu.Lock()
+	defer lastGoodResponses.mu.Unlock()
+
+	entry, ok := lastGoodResponses.entries[key]
+
+	if !ok {
+		return lastGoodEntry{}, false
+	}
+
+	return *entry, true
+}
+
+func putLastGood(key string, entry *lastGoodEntry) {
+	lastGoodResponses.mu.Lock()
+	defer lastGoodResponses.mu.Unlock()
+
+	if _, exists := lastGoodResponses.entries[key]; !exists && len(lastGoodResponses.entries) >= maxLastGoodEntries {
+		var oldestKey string
+		var oldest time.Time
+
+		for k, e := range lastGoodResponses.entries {
+			if oldestKey == "" || e.fetchedAt.Before(oldest) {
+				oldestKey, oldest = k, e.fetchedAt
+			}
+		}
+
+		delete(lastGoodResponses.entries, oldestKey)
+	}
+
+	lastGoodResponses.entries[key] = entry
+}
+
+// addConditionalHeaders asks the server to only send the response if it's changed
+// since the kept value was fetched, only for GET requests since others aren't cached
+func (o *decodeOptions) addConditionalHeaders(request *http.Request) *http.Request {
+	if o.lastGoodMaxAge <= 0 || request.Method != "GET" {
+		return request
+	}
+
+	entry, ok := getLastGood(o.lastGoodKey(request))
+
+	if !ok || (entry.etag == "" && entry.lastModified == "") {
+		return request
+	}
+
+	if request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != "" {
+		return request
+	}
+
+	request = request.Clone(request.Context())
+
+	if entry.etag != "" {
+		request.Header.Set("If-None-Match", entry.etag)
+	} else {
+		request.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+
+	return request
+}
+
+func (o *decodeOptions) recordValidators(response *http.Response) {
+	if o.lastGoodMaxAge <= 0 || response == nil {
+		return
+	}
+
+	o.etag = response.Header.Get("ETag")
+	o.lastModified = response.Header.Get("Last-Modified")
+}
+
+// useLastGood is deferred by the decode functions after wrapRequestError, so that it
+// runs first. It keeps result if decoding succeeded and replaces err with the kept
+// value if it's one which is worth falling back on.
+func useLastGood[T any](options *decodeOptions, request *http.Request, result *T, err *error) {
+	if options.lastGoodMaxAge <= 0 {
+		return
+	}
+
+	key := options.lastGoodKey(request)
+
+	if *err == nil {
+		putLastGood(key, &lastGoodEntry{
+			value:        *result,
+			fetchedAt:    time.Now(),
+			etag:         options.etag,
+			lastModified: options.lastModified,
+		})
+
+		return
+	}
+
+	entry, ok := getLastGood(key)
+
+	if !ok {
+		return
+	}
+
+	value, ok := entry.value.(T)
+
+	if !ok {
+		return
+	}
+
+	if HasStatusCode(*err, http.StatusNotModified) {
+		*result, *err = value, nil
+		entry.fetchedAt = time.Now()
+		putLastGood(key, &entry)
+		return
+	}
+
+	if !shouldFallBackToLastGood(*err) || time.Since(entry.fetchedAt) > options.lastGoodMaxAge {
+		return
+	}
+
+	*result = value
+	*err = &StaleContentError{FetchedAt: entry.fetchedAt, Err: *err}
+}
+
+// only failures which are likely to go away on their own are covered up, errors such
+// as a 404 or a response which doesn't decode usually mean that something's wrong
+// with the config and should be shown
+func shouldFallBackToLastGood(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrShuttingDown) {
+		return false
+	}
+
+	if httpErr, ok := ExtractHTTPError(err); ok {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// covers timeouts, refused connections and failed DNS lookups
+	var netErr net.Error
+
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,211 @@
+package feed
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type lastGoodTestServer struct {
+	*httptest.Server
+	failing      atomic.Bool
+	conditionals atomic.Int32
+}
+
+// newLastGoodTestServer responds with who the request was made for based on its
+// Authorization header, failing with a 503 while failing is set
+func newLastGoodTestServer(t *testing.T) *lastGoodTestServer {
+	t.Helper()
+
+	server := &lastGoodTestServer{}
+	server.Server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if server.failing.Load() {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.URL.Path == "/etag" {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				server.conditionals.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+		}
+
+		w.Write([]byte(`{"user":"` + strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") + `"}`))
+	})
+
+	return server
+}
+
+type lastGoodTestResponse struct {
+	User string `json:"user"`
+}
+
+func fetchLastGoodTestUser(server *lastGoodTestServer, path string, token string, opts ...DecodeOption) (string, error) {
+	request, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := decodeJsonFromRequest[lastGoodTestResponse](server.Client(), request, append([]DecodeOption{WithLastGoodFallback(time.Hour)}, opts...)...)
+
+	return response.User, err
+}
+
+func TestLastGoodFallbackOnServerError(t *testing.T) {
+	server := newLastGoodTestServer(t)
+
+	if user, err := fetchLastGoodTestUser(server, "/", "alice"); err != nil || user != "alice" {
+		t.Fatalf("unexpected first response: %q, %v", user, err)
+	}
+
+	server.failing.Store(true)
+	user, err := fetchLastGoodTestUser(server, "/", "alice")
+
+	if user != "alice" || !errors.Is(err, ErrStaleContent) || !errors.Is(err, ErrPartialContent) || !HasStatusCode(err, http.StatusServiceUnavailable) {
+		t.Fatalf("expected the kept value with a stale content error, got %q, %v", user, err)
+	}
+
+	var stale *StaleContentError
+
+	if !errors.As(err, &stale) || time.Since(stale.FetchedAt) > time.Second {
+		t.Errorf("expected the time the value was fetched at, got %v", err)
+	}
+
+	// it's too old with a shorter max age
+	if _, err := fetchLastGoodTestUser(server, "/", "alice", WithLastGoodFallback(time.Nanosecond)); errors.Is(err, ErrStaleContent) {
+		t.Errorf("expected the kept value to be too old, got %v", err)
+	}
+}
+
+func TestLastGoodFallbackKeptPerCredentials(t *testing.T) {
+	server := newLastGoodTestServer(t)
+	fetchLastGoodTestUser(server, "/", "alice")
+	fetchLastGoodTestUser(server, "/", "")
+
+	server.failing.Store(true)
+
+	if user, err := fetchLastGoodTestUser(server, "/", "bob"); user != "" || errors.Is(err, ErrStaleContent) {
+		t.Errorf("expected nothing to be kept for another token, got %q, %v", user, err)
+	}
+
+	if user, _ := fetchLastGoodTestUser(server, "/", "alice"); user != "alice" {
+		t.Errorf("expected the value kept for the same token, got %q", user)
+	}
+
+	if user, err := fetchLastGoodTestUser(server, "/", ""); user != "" || !errors.Is(err, ErrStaleContent) {
+		t.Errorf("expected the value kept for the request without a token, got %q, %v", user, err)
+	}
+}
+
+func TestLastGoodFallbackKeptPerCallerKey(t *testing.T) {
+	server := newLastGoodTestServer(t)
+	fetchLastGoodTestUser(server, "/", "alice", WithLastGoodKey("widget 1"))
+
+	server.failing.Store(true)
+
+	if _, err := fetchLastGoodTestUser(server, "/", "alice", WithLastGoodKey("widget 2")); errors.Is(err, ErrStaleContent) {
+		t.Errorf("expected nothing to be kept for another key, got %v", err)
+	}
+
+	if user, _ := fetchLastGoodTestUser(server, "/", "alice", WithLastGoodKey("widget 1")); user != "alice" {
+		t.Errorf("expected the value kept for the same key, got %q", user)
+	}
+}
+
+func TestLastGoodKey(t *testing.T) {
+	newRequest := func(headers ...string) *http.Request {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.example.com/items?page=1", nil)
+		request.Header = headersOf(headers...)
+		return request
+	}
+
+	options := newDecodeOptions(nil)
+	plain := options.lastGoodKey(newRequest("Accept", "application/json"))
+
+	if plain != "GET https://api.example.com/items?page=1" {
+		t.Errorf("expected only the method and URL without credentials, got %q", plain)
+	}
+
+	withKey := options.lastGoodKey(newRequest("X-Api-Key", "secret-key"))
+
+	if strings.Contains(withKey, "secret-key") {
+		t.Errorf("expected the credentials to be hashed, got %q", withKey)
+	}
+
+	tests := []struct {
+		name    string
+		a, b    *http.Request
+		sameKey bool
+	}{
+		{name: "other headers", a: newRequest("Accept", "a"), b: newRequest("Accept", "b"), sameKey: true},
+		{name: "same API key", a: newRequest("X-Api-Key", "1", "Accept", "a"), b: newRequest("X-Api-Key", "1"), sameKey: true},
+		{name: "different API keys", a: newRequest("X-Api-Key", "1"), b: newRequest("X-Api-Key", "2")},
+		{name: "different cookies", a: newRequest("Cookie", "session=1"), b: newRequest("Cookie", "session=2")},
+		{name: "same value in another header", a: newRequest("X-Api-Key", "1"), b: newRequest("X-Auth-Token", "1")},
+		{name: "with and without credentials", a: newRequest(), b: newRequest("Authorization", "Basic dXNlcjpwYXNz")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sameKey := options.lastGoodKey(test.a) == options.lastGoodKey(test.b)
+
+			if sameKey != test.sameKey {
+				t.Errorf("expected the keys being the same to be %v", test.sameKey)
+			}
+		})
+	}
+}
+
+func TestLastGoodFallbackConditionalRequests(t *testing.T) {
+	server := newLastGoodTestServer(t)
+	fetchLastGoodTestUser(server, "/etag", "alice")
+
+	user, err := fetchLastGoodTestUser(server, "/etag", "alice")
+
+	if err != nil || user != "alice" {
+		t.Errorf("expected the kept value for a 304, got %q, %v", user, err)
+	}
+
+	if server.conditionals.Load() != 1 {
+		t.Errorf("expected the request to be sent with If-None-Match, got %d conditional requests", server.conditionals.Load())
+	}
+
+	// a 304 for another token can't be answered with what was kept for this one
+	if user, err := fetchLastGoodTestUser(server, "/etag", "bob"); err != nil || user != "bob" || server.conditionals.Load() != 1 {
+		t.Errorf("expected an unconditional request for another token, got %q, %v", user, err)
+	}
+}
+
+func TestLastGoodFallbackNotUsedForClientErrors(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: &HTTPError{StatusCode: http.StatusServiceUnavailable}, expected: true},
+		{err: &HTTPError{StatusCode: http.StatusTooManyRequests}, expected: true},
+		{err: &HTTPError{StatusCode: http.StatusNotFound}, expected: false},
+		{err: &HTTPError{StatusCode: http.StatusUnauthorized}, expected: false},
+		{err: ErrShuttingDown, expected: false},
+		{err: errors.New("invalid character"), expected: false},
+	}
+
+	for _, test := range tests {
+		if shouldFallBackToLastGood(test.err) != test.expected {
+			t.Errorf("%v: expected %v", test.err, test.expected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -18,14 +19,14 @@ type lobstersPostResponseJson struct {
 
 type lobstersFeedResponseJson []lobstersPostResponseJson
 
-func getLobstersPostsFromFeed(feedUrl string) (ForumPosts, error) {
-	request, err := http.NewRequest("GET", feedUrl, nil)
+func getLobstersPostsFromFeed(ctx context.Context, feedUrl string) (ForumPosts, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", feedUrl, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	feed, err := decodeJsonFromRequest[lobstersFeedResponseJson](defaultClient, request)
+	feed, err := decodeJsonFromRequest[lobstersFeedResponseJson](defaultClient(), request)
 
 	if err != nil {
 		return nil, err
@@ -55,7 +56,7 @@ func getLobstersPostsFromFeed(feedUrl string) (ForumPosts, error) {
 	return posts, nil
 }
 
-func FetchLobstersPosts(sortBy string, tags []string) (ForumPosts, error) {
+func FetchLobstersPosts(ctx context.Context, sortBy string, tags []string) (ForumPosts, error) {
 	var feedUrl string
 
 	if sortBy == "hot" {
@@ -71,7 +72,7 @@ func FetchLobstersPosts(sortBy string, tags []string) (ForumPosts, error) {
 		feedUrl = "https://lobste.rs/t/" + tags + ".json"
 	}
 
-	posts, err := getLobstersPostsFromFeed(feedUrl)
+	posts, err := getLobstersPostsFromFeed(ctx, feedUrl)
 
 	if err != nil {
 		return nil, err
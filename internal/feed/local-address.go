@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+var ErrLocalAddressNotFound = errors.New("address is not assigned to any network interface")
+
+// newLocalAddressDialer returns a dialer whose connections originate from the
+// given IP, which on hosts with several interfaces determines the interface
+// traffic goes out through. Unless skipCheck is set, the address must be
+// assigned to one of the interfaces of the host.
+func newLocalAddressDialer(address string, skipCheck bool) (*net.Dialer, error) {
+	addr, err := netip.ParseAddr(address)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid local address %q: %w", address, err)
+	}
+
+	if !skipCheck {
+		if err := checkAddressIsAssigned(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := newDefaultDialer()
+	dialer.LocalAddr = &net.TCPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+
+	return dialer, nil
+}
+
+func checkAddressIsAssigned(addr netip.Addr) error {
+	interfaceAddrs, err := net.InterfaceAddrs()
+
+	if err != nil {
+		return fmt.Errorf("could not list network interface addresses: %w", err)
+	}
+
+	unzoned := addr.WithZone("").Unmap()
+
+	for _, interfaceAddr := range interfaceAddrs {
+		ipNet, ok := interfaceAddr.(*net.IPNet)
+
+		if !ok {
+			continue
+		}
+
+		if assigned, ok := netip.AddrFromSlice(ipNet.IP); ok && assigned.Unmap() == unzoned {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrLocalAddressNotFound, addr)
+}
@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestLocalAddressDialer(t *testing.T) {
+	dialer, err := newLocalAddressDialer("127.0.0.1", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, ok := dialer.LocalAddr.(*net.TCPAddr)
+
+	if !ok || !local.IP.Equal(net.IPv4(127, 0, 0, 1)) || local.Port != 0 {
+		t.Errorf("expected the dialer to be bound to 127.0.0.1, got %v", dialer.LocalAddr)
+	}
+}
+
+func TestLocalAddressValidation(t *testing.T) {
+	if _, err := newLocalAddressDialer("not-an-ip", true); err == nil {
+		t.Error("expected an invalid address to be rejected")
+	}
+
+	// reserved for documentation, so it's never assigned to an interface
+	const unassigned = "203.0.113.7"
+
+	if _, err := newLocalAddressDialer(unassigned, false); !errors.Is(err, ErrLocalAddressNotFound) {
+		t.Errorf("expected ErrLocalAddressNotFound, got %v", err)
+	}
+
+	dialer, err := newLocalAddressDialer(unassigned, true)
+
+	if err != nil {
+		t.Fatalf("expected the check to be skipped, got %v", err)
+	}
+
+	if local := dialer.LocalAddr.(*net.TCPAddr); local.IP.String() != unassigned {
+		t.Errorf("expected the dialer to be bound to %s, got %v", unassigned, local)
+	}
+}
+
+func TestClientWithLocalAddress(t *testing.T) {
+	t.Cleanup(ResetDefaultClients)
+
+	var remoteHost string
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		remoteHost, _, _ = net.SplitHostPort(r.RemoteAddr)
+	})
+
+	client, err := GetClientWithOptions(WithLocalAddress("127.0.0.1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.Get(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if remoteHost != "127.0.0.1" {
+		t.Errorf("expected the connection to come from 127.0.0.1, got %s", remoteHost)
+	}
+
+	if _, err := GetClientWithOptions(WithLocalAddress("203.0.113.7")); !errors.Is(err, ErrLocalAddressNotFound) {
+		t.Errorf("expected creating a client with an unassigned address to fail, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package feed
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithErrorThrottling only logs the first of the failures with the same error for the
+// same host within window, the others being counted and logged as a single summary
+// once it's over, so that an upstream which is down for hours doesn't flood the log.
+// A failure is a request which errored or got a 5xx response. Failures which aren't
+// logged count as dropped in SamplingStats.
+func WithErrorThrottling(window time.Duration) LoggingOption {
+	return func(rt *LoggingRoundTripper) {
+		if window > 0 {
+			rt.throttle = &errorLogThrottle{window: window, failures: make(map[string]*throttledFailure)}
+		}
+	}
+}
+
+type errorLogThrottle struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	failures map[string]*throttledFailure
+}
+
+type throttledFailure struct {
+	host        string
+	description string
+	since       time.Time
+	suppressed  int
+}
+
+// allow reports whether a failure should be logged, which it should if it's the
+// first of its kind for the host within the window
+func (t *errorLogThrottle) allow(logger *slog.Logger, host, description string) bool {
+	key := host + " " + description
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if failure, ok := t.failures[key]; ok {
+		failure.suppressed++
+		return false
+	}
+
+	t.failures[key] = &throttledFailure{host: host, description: description, since: time.Now()}
+	time.AfterFunc(t.window, func() { t.summarize(logger, key) })
+
+	return true
+}
+
+func (t *errorLogThrottle) summarize(logger *slog.Logger, key string) {
+	t.mu.Lock()
+	failure := t.failures[key]
+	delete(t.failures, key)
+	t.mu.Unlock()
+
+	if failure == nil || failure.suppressed == 0 {
+		return
+	}
+
+	logger.Warn("requests kept failing",
+		"host", failure.host,
+		"error", failure.description,
+		"count", failure.suppressed,
+		"over", time.Since(failure.since).Round(time.Second),
+	)
+}
+
+// describeFailure returns what's the same about failures which only differ in
+// details such as the URL, which is what makes them repeats of one another
+func describeFailure(response *http.Response, err error) string {
+	if err == nil {
+		return "status " + strconv.Itoa(response.StatusCode)
+	}
+
+	var urlErr *url.Error
+
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+
+	return err.Error()
+}
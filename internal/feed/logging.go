@@ -0,0 +1,361 @@
+package feed
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoggingRoundTripper logs every request that passes through it along with
+// the response status and how long it took to complete, or only a sample of
+// them when created with WithResponseSampling
+type LoggingRoundTripper struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+
+	sampleRate      float64
+	alwaysLogErrors bool
+	throttle        *errorLogThrottle
+	sequence        atomic.Uint64
+	sampled         atomic.Uint64
+	dropped         atomic.Uint64
+}
+
+type LoggingOption func(*LoggingRoundTripper)
+
+// WithResponseSampling only logs a fraction of requests, between 0 and 1, which
+// is useful for widgets refreshing often enough to flood the log. Whether
+// a request is logged is decided from its ID, which is the X-Request-ID header
+// when it has one and its sequence number otherwise, so the same request is
+// always either logged or not.
+func WithResponseSampling(rate float64) LoggingOption {
+	return func(rt *LoggingRoundTripper) {
+		rt.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithAlwaysLogErrors logs requests which failed or got a 4xx or a 5xx
+// response regardless of sampling
+func WithAlwaysLogErrors() LoggingOption {
+	return func(rt *LoggingRoundTripper) {
+		rt.alwaysLogErrors = true
+	}
+}
+
+type SamplingStats struct {
+	Sampled uint64
+	Dropped uint64
+	Total   uint64
+}
+
+func NewLoggingRoundTripper(next http.RoundTripper, output io.Writer, opts ...LoggingOption) *LoggingRoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	rt := &LoggingRoundTripper{
+		next:       next,
+		logger:     slog.New(slog.NewTextHandler(output, nil)),
+		sampleRate: 1,
+	}
+
+	for _, opt := range opts {
+		opt(rt)
+	}
+
+	return rt
+}
+
+func (rt *LoggingRoundTripper) SamplingStats() SamplingStats {
+	sampled, dropped := rt.sampled.Load(), rt.dropped.Load()
+
+	return SamplingStats{
+		Sampled: sampled,
+		Dropped: dropped,
+		Total:   sampled + dropped,
+	}
+}
+
+func (rt *LoggingRoundTripper) isSampled(request *http.Request) bool {
+	if rt.sampleRate >= 1 {
+		return true
+	}
+
+	id := request.Header.Get("X-Request-ID")
+
+	if id == "" {
+		id = strconv.FormatUint(rt.sequence.Add(1), 10)
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+
+	return rand.New(rand.NewPCG(hash.Sum64(), 0)).Float64() < rt.sampleRate
+}
+
+func (rt *LoggingRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	sampled := rt.isSampled(request)
+
+	requestSentAt := time.Now()
+	response, err := rt.next.RoundTrip(request)
+	elapsed := time.Since(requestSentAt)
+
+	if !sampled && rt.alwaysLogErrors && (err != nil || response.StatusCode >= 400) {
+		sampled = true
+	}
+
+	if sampled && rt.throttle != nil && (err != nil || response.StatusCode >= 500) {
+		sampled = rt.throttle.allow(rt.logger, request.URL.Host, describeFailure(response, err))
+	}
+
+	if !sampled {
+		rt.dropped.Add(1)
+		return response, err
+	}
+
+	rt.sampled.Add(1)
+
+	if err != nil {
+		rt.logger.Error("request failed",
+			"method", request.Method,
+			"url", request.URL.String(),
+			"duration", elapsed,
+			"error", err,
+		)
+
+		return response, err
+	}
+
+	attrs := []any{
+		"method", request.Method,
+		"url", request.URL.String(),
+		"status", response.StatusCode,
+		"duration", elapsed,
+	}
+
+	if version := apiVersionFromResponse(response, ""); version != "" {
+		attrs = append(attrs, "api_version", version)
+	}
+
+	if stats := GetRequestQueueStats(); stats.Depth > 0 {
+		attrs = append(attrs, "queue_depth", stats.Depth)
+	}
+
+	rt.logger.Info("request completed", attrs...)
+
+	return response, nil
+}
+
+var (
+	httpDebugLogOutput  io.Writer
+	httpDebugLogOptions []LoggingOption
+)
+
+// EnableHTTPDebugLogging routes all outgoing requests made through the clients
+// of this package through a LoggingRoundTripper that writes to output
+func EnableHTTPDebugLogging(output io.Writer, opts ...LoggingOption) {
+	httpDebugLogOutput = output
+	httpDebugLogOptions = opts
+
+	defaultClient().Transport = NewLoggingRoundTripper(withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(defaultTransport()))), output, opts...)
+	defaultInsecureClient().Transport = NewLoggingRoundTripper(withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(insecureClientTransport()))), output, opts...)
+
+	clientCache.Range(func(_, value any) bool {
+		client := value.(*http.Client)
+
+		if _, ok := client.Transport.(*LoggingRoundTripper); !ok {
+			client.Transport = NewLoggingRoundTripper(client.Transport, output, opts...)
+		}
+
+		return true
+	})
+}
+
+func withHTTPDebugLogging(transport http.RoundTripper) http.RoundTripper {
+	if httpDebugLogOutput == nil {
+		return transport
+	}
+
+	return NewLoggingRoundTripper(transport, httpDebugLogOutput, httpDebugLogOptions...)
+}
+
+// RotatingFileLogger is an io.Writer that writes to a file and rotates it once
+// it grows past a given size, keeping a limited number of gzipped backups
+// named <path>.1.gz (most recent) through <path>.<maxBackups>.gz (oldest)
+type RotatingFileLogger struct {
+	path         string
+	maxSizeBytes int64
+	maxBackups   int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewRotatingFileLogger(path string, maxSizeBytes int64, maxBackups int) (*RotatingFileLogger, error) {
+	if path == "" {
+		return nil, errors.New("log file path is required")
+	}
+
+	if maxSizeBytes <= 0 {
+		return nil, fmt.Errorf("max log file size must be positive, got %d", maxSizeBytes)
+	}
+
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+
+	logger := &RotatingFileLogger{
+		path:         path,
+		maxSizeBytes: maxSizeBytes,
+		maxBackups:   maxBackups,
+	}
+
+	if err := logger.open(); err != nil {
+		return nil, err
+	}
+
+	return logger, nil
+}
+
+func (l *RotatingFileLogger) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+func (l *RotatingFileLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if l.size > 0 && l.size+int64(len(p)) > l.maxSizeBytes {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+
+	return n, err
+}
+
+func (l *RotatingFileLogger) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d.gz", l.path, i)
+}
+
+func (l *RotatingFileLogger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("could not close log file: %w", err)
+	}
+
+	l.file = nil
+
+	if l.maxBackups == 0 {
+		if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove log file: %w", err)
+		}
+
+		return l.open()
+	}
+
+	os.Remove(l.backupPath(l.maxBackups))
+
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(l.backupPath(i), l.backupPath(i+1))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not shift log backup: %w", err)
+		}
+	}
+
+	if err := compressFileTo(l.path, l.backupPath(1)); err != nil {
+		return err
+	}
+
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("could not remove rotated log file: %w", err)
+	}
+
+	return l.open()
+}
+
+func compressFileTo(sourcePath, destinationPath string) error {
+	source, err := os.Open(sourcePath)
+
+	if err != nil {
+		return fmt.Errorf("could not open log file for compression: %w", err)
+	}
+
+	defer source.Close()
+
+	destination, err := os.OpenFile(destinationPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not create log backup: %w", err)
+	}
+
+	writer := gzip.NewWriter(destination)
+
+	if _, err = io.Copy(writer, source); err != nil {
+		writer.Close()
+		destination.Close()
+		return fmt.Errorf("could not compress log file: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		destination.Close()
+		return fmt.Errorf("could not compress log file: %w", err)
+	}
+
+	return destination.Close()
+}
+
+// Close flushes and closes the current log file, subsequent writes will fail
+func (l *RotatingFileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		l.file = nil
+		return err
+	}
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
@@ -0,0 +1,202 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileLoggerRotatesAndRemovesOldBackups(t *testing.T) {
+	const maxSize = 1024 * 1024
+	const maxBackups = 3
+
+	path := filepath.Join(t.TempDir(), "http.log")
+	logger, err := NewRotatingFileLogger(path, maxSize, maxBackups)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := bytes.Repeat([]byte("x"), 1023)
+	line = append(line, '\n')
+
+	for written := 0; written < 10*1024*1024; written += len(line) {
+		if _, err := logger.Write(line); err != nil {
+			t.Fatalf("write failed after %d bytes: %v", written, err)
+		}
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() > maxSize {
+		t.Errorf("current log file is %d bytes, larger than the max of %d", info.Size(), maxSize)
+	}
+
+	for i := 1; i <= maxBackups; i++ {
+		backup := fmt.Sprintf("%s.%d.gz", path, i)
+		size := gunzippedSize(t, backup)
+
+		if size == 0 || size > maxSize {
+			t.Errorf("backup %s has %d bytes once decompressed, expected between 1 and %d", backup, size, maxSize)
+		}
+	}
+
+	if _, err := os.Stat(fmt.Sprintf("%s.%d.gz", path, maxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("expected only %d backups to be kept, stat of the next one returned %v", maxBackups, err)
+	}
+
+	if _, err := logger.Write(line); err == nil {
+		t.Error("expected writing after Close to fail")
+	}
+}
+
+func TestRotatingFileLoggerWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "http.log")
+	logger, err := NewRotatingFileLogger(path, 100, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer logger.Close()
+
+	for range 5 {
+		logger.Write(bytes.Repeat([]byte("y"), 60))
+	}
+
+	matches, _ := filepath.Glob(path + ".*")
+
+	if len(matches) != 0 {
+		t.Errorf("expected no backups, got %v", matches)
+	}
+}
+
+func gunzippedSize(t *testing.T, path string) int {
+	t.Helper()
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+
+	if err != nil {
+		t.Fatalf("%s is not gzipped: %v", path, err)
+	}
+
+	contents, err := io.ReadAll(reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(contents)
+}
+
+func newSamplingTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+func sendSamplingTestRequests(t *testing.T, client *http.Client, url string, n int, requestID func(int) string) {
+	t.Helper()
+
+	for i := range n {
+		request, _ := http.NewRequest(http.MethodGet, url, nil)
+
+		if requestID != nil {
+			request.Header.Set("X-Request-ID", requestID(i))
+		}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response.Body.Close()
+	}
+}
+
+func TestLoggingRoundTripperResponseSampling(t *testing.T) {
+	server := newSamplingTestServer(t)
+
+	var output bytes.Buffer
+	logger := NewLoggingRoundTripper(server.Client().Transport, &output, WithResponseSampling(0.1))
+	client := &http.Client{Transport: logger}
+
+	sendSamplingTestRequests(t, client, server.URL, 1000, nil)
+
+	logged := strings.Count(output.String(), "request completed")
+
+	if logged < 80 || logged > 120 {
+		t.Errorf("expected about 100 of 1000 requests to be logged, got %d", logged)
+	}
+
+	stats := logger.SamplingStats()
+
+	if stats.Sampled != uint64(logged) || stats.Total != 1000 || stats.Sampled+stats.Dropped != stats.Total {
+		t.Errorf("unexpected stats for %d logged requests: %+v", logged, stats)
+	}
+}
+
+func TestLoggingRoundTripperSamplingIsDeterministic(t *testing.T) {
+	server := newSamplingTestServer(t)
+	requestID := func(i int) string { return fmt.Sprintf("request-%d", i) }
+
+	var first, second bytes.Buffer
+
+	for _, output := range []*bytes.Buffer{&first, &second} {
+		client := &http.Client{Transport: NewLoggingRoundTripper(server.Client().Transport, output, WithResponseSampling(0.5))}
+		sendSamplingTestRequests(t, client, server.URL, 50, requestID)
+	}
+
+	// only the durations differ between the two logs
+	count := func(output *bytes.Buffer) int { return strings.Count(output.String(), "request completed") }
+
+	if count(&first) != count(&second) || count(&first) == 0 || count(&first) == 50 {
+		t.Errorf("expected the same requests to be sampled both times, got %d and %d", count(&first), count(&second))
+	}
+}
+
+func TestLoggingRoundTripperAlwaysLogErrors(t *testing.T) {
+	server := newSamplingTestServer(t)
+
+	var output bytes.Buffer
+	client := &http.Client{Transport: NewLoggingRoundTripper(server.Client().Transport, &output, WithResponseSampling(0), WithAlwaysLogErrors())}
+
+	sendSamplingTestRequests(t, client, server.URL+"/ok", 20, nil)
+	sendSamplingTestRequests(t, client, server.URL+"/error", 20, nil)
+
+	if logged := strings.Count(output.String(), "status=500"); logged != 20 {
+		t.Errorf("expected every error to be logged, got %d", logged)
+	}
+
+	if logged := strings.Count(output.String(), "status=200"); logged != 0 {
+		t.Errorf("expected successful requests to not be logged, got %d", logged)
+	}
+}
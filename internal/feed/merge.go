@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MergeFunc combines the outputs of a worker pool job into a single result,
+// results has a zero value wherever the corresponding task failed
+type MergeFunc[O any, R any] func(results []O, errs []error) (R, error)
+
+func workerPoolDoMerge[I any, O any, R any](job *workerPoolJob[I, O], merge MergeFunc[O, R]) (R, error) {
+	results, errs, err := workerPoolDo(job)
+
+	// returning early because of withMinResults still leaves results to merge
+	if err != nil && !errors.Is(err, ErrPartialContent) {
+		var zero R
+		return zero, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	return merge(results, errs)
+}
+
+// mergeErr follows the usual convention of ErrNoContent when every task failed
+// and ErrPartialContent when only some did
+func mergeErr(errs []error) error {
+	failed := 0
+	var first error
+
+	for i := range errs {
+		if errs[i] != nil {
+			failed++
+
+			if first == nil {
+				first = errs[i]
+			}
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	if failed == len(errs) {
+		return fmt.Errorf("%w: %v", ErrNoContent, first)
+	}
+
+	return fmt.Errorf("%w: %d of %d tasks failed", ErrPartialContent, failed, len(errs))
+}
+
+// MergeFirstSuccess returns the result of the first task, in the order the
+// tasks were given, which didn't fail
+func MergeFirstSuccess[O any]() MergeFunc[O, O] {
+	return func(results []O, errs []error) (O, error) {
+		for i := range results {
+			if errs[i] == nil {
+				return results[i], nil
+			}
+		}
+
+		var zero O
+
+		if len(errs) == 0 {
+			return zero, ErrNoContent
+		}
+
+		return zero, mergeErr(errs)
+	}
+}
+
+// MergeAll returns the results of all tasks which didn't fail
+func MergeAll[O any]() MergeFunc[O, []O] {
+	return func(results []O, errs []error) ([]O, error) {
+		merged := make([]O, 0, len(results))
+
+		for i := range results {
+			if errs[i] == nil {
+				merged = append(merged, results[i])
+			}
+		}
+
+		return merged, mergeErr(errs)
+	}
+}
+
+// MergeSumFloat64 returns the sum of the values which key returns for each of
+// the tasks that didn't fail
+func MergeSumFloat64[O any](key func(O) float64) MergeFunc[O, float64] {
+	return func(results []O, errs []error) (float64, error) {
+		sum := 0.0
+
+		for i := range results {
+			if errs[i] == nil {
+				sum += key(results[i])
+			}
+		}
+
+		return sum, mergeErr(errs)
+	}
+}
@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+)
+
+var errMergeTestFailed = errors.New("task failed")
+
+type mergeTestPrice struct {
+	exchange string
+	price    float64
+}
+
+// prices are returned for every exchange except the ones named "down"
+func fetchMergeTestPrice(exchange string) (mergeTestPrice, error) {
+	if exchange == "down" {
+		return mergeTestPrice{}, errMergeTestFailed
+	}
+
+	return mergeTestPrice{exchange: exchange, price: float64(len(exchange))}, nil
+}
+
+func TestWorkerPoolDoMergeFirstSuccess(t *testing.T) {
+	job := newJob(fetchMergeTestPrice, []string{"down", "ab", "abc"}).withWorkers(1)
+	first, err := workerPoolDoMerge(job, MergeFirstSuccess[mergeTestPrice]())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.exchange != "ab" {
+		t.Errorf("expected the first successful task in input order, got %+v", first)
+	}
+
+	job = newJob(fetchMergeTestPrice, []string{"down", "down"})
+	_, err = workerPoolDoMerge(job, MergeFirstSuccess[mergeTestPrice]())
+
+	if !errors.Is(err, ErrNoContent) {
+		t.Errorf("expected ErrNoContent when every task failed, got %v", err)
+	}
+}
+
+func TestWorkerPoolDoMergeAll(t *testing.T) {
+	job := newJob(fetchMergeTestPrice, []string{"a", "down", "abc"})
+	all, err := workerPoolDoMerge(job, MergeAll[mergeTestPrice]())
+
+	if !errors.Is(err, ErrPartialContent) {
+		t.Errorf("expected ErrPartialContent, got %v", err)
+	}
+
+	if len(all) != 2 || all[0].exchange != "a" || all[1].exchange != "abc" {
+		t.Errorf("expected the 2 successful results in order, got %+v", all)
+	}
+
+	job = newJob(fetchMergeTestPrice, []string{"a", "ab"})
+
+	if all, err = workerPoolDoMerge(job, MergeAll[mergeTestPrice]()); err != nil || len(all) != 2 {
+		t.Errorf("expected both results without an error, got %+v, %v", all, err)
+	}
+}
+
+func TestWorkerPoolDoMergeSumFloat64(t *testing.T) {
+	price := func(p mergeTestPrice) float64 { return p.price }
+
+	job := newJob(fetchMergeTestPrice, []string{"a", "ab", "abc"})
+	sum, err := workerPoolDoMerge(job, MergeSumFloat64(price))
+
+	if err != nil || sum != 6 {
+		t.Errorf("expected 6 without an error, got %v, %v", sum, err)
+	}
+
+	job = newJob(fetchMergeTestPrice, []string{"a", "down", "abc"})
+	sum, err = workerPoolDoMerge(job, MergeSumFloat64(price))
+
+	if !errors.Is(err, ErrPartialContent) || sum != 4 {
+		t.Errorf("expected 4 with ErrPartialContent, got %v, %v", sum, err)
+	}
+}
+
+func TestWorkerPoolDoMergeReceivesZeroValuesForFailedTasks(t *testing.T) {
+	job := newJob(fetchMergeTestPrice, []string{"a", "down"})
+
+	_, err := workerPoolDoMerge(job, func(results []mergeTestPrice, errs []error) (int, error) {
+		if len(results) != 2 || len(errs) != 2 {
+			t.Fatalf("expected a result and an error for each task, got %d and %d", len(results), len(errs))
+		}
+
+		if results[1] != (mergeTestPrice{}) || !errors.Is(errs[1], errMergeTestFailed) {
+			t.Errorf("expected a zero value and the error for the failed task, got %+v, %v", results[1], errs[1])
+		}
+
+		return 0, nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -10,6 +10,8 @@ import (
 type SiteStatusRequest struct {
 	URL           string `yaml:"url"`
 	AllowInsecure bool   `yaml:"allow-insecure"`
+	// whether to set CertificateExpiresAt of the status
+	CheckCertificate bool `yaml:"-"`
 }
 
 type SiteStatus struct {
@@ -17,10 +19,12 @@ type SiteStatus struct {
 	TimedOut     bool
 	ResponseTime time.Duration
 	Error        error
+	// zero unless the request asked for it and the site is served over TLS
+	CertificateExpiresAt time.Time
 }
 
-func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
-	request, err := http.NewRequest(http.MethodGet, statusRequest.URL, nil)
+func getSiteStatusTask(ctx context.Context, statusRequest *SiteStatusRequest) (SiteStatus, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, statusRequest.URL, nil)
 
 	if err != nil {
 		return SiteStatus{
@@ -28,16 +32,16 @@ func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
 		}, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	ctx, cancel := context.WithTimeout(ctx, time.Second*3)
 	defer cancel()
 	request = request.WithContext(ctx)
 	requestSentAt := time.Now()
 	var response *http.Response
 
 	if !statusRequest.AllowInsecure {
-		response, err = defaultClient.Do(request)
+		response, err = defaultClient().Do(request)
 	} else {
-		response, err = defaultInsecureClient.Do(request)
+		response, err = defaultInsecureClient().Do(request)
 	}
 
 	status := SiteStatus{ResponseTime: time.Since(requestSentAt)}
@@ -55,11 +59,21 @@ func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
 
 	status.Code = response.StatusCode
 
+	if statusRequest.CheckCertificate {
+		status.CertificateExpiresAt, _ = CertificateExpiry(response)
+	}
+
 	return status, nil
 }
 
-func FetchStatusForSites(requests []*SiteStatusRequest) ([]SiteStatus, error) {
-	job := newJob(getSiteStatusTask, requests).withWorkers(20)
+func FetchStatusForSites(ctx context.Context, requests []*SiteStatusRequest) ([]SiteStatus, error) {
+	return FetchStatusForSitesWithWorkers(ctx, requests, 20)
+}
+
+// FetchStatusForSitesWithWorkers is the same as FetchStatusForSites but with
+// a limit on how many sites are checked at the same time
+func FetchStatusForSitesWithWorkers(ctx context.Context, requests []*SiteStatusRequest, workers int) ([]SiteStatus, error) {
+	job := newJobWithContext(getSiteStatusTask, requests).withWorkers(workers).withContext(ctx)
 	results, _, err := workerPoolDo(job)
 
 	if err != nil {
@@ -0,0 +1,29 @@
+package feed
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var ErrRecentlyNotFound = errors.New("resource recently responded with 404")
+
+// URLs which recently responded with a 404, so that widgets pointed at
+// something which doesn't exist don't keep requesting it on every update,
+// disabled unless a TTL is set
+var notFoundResponses = newFailureCache(0)
+
+// SetNotFoundCacheTTL changes how long a 404 response is remembered for,
+// a TTL of 0 disables the cache
+func SetNotFoundCacheTTL(ttl time.Duration) {
+	notFoundResponses.setTTL(ttl)
+}
+
+func notFoundCacheKey(request *http.Request) (string, bool) {
+	// other methods may well succeed on a URL which 404s for a GET
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return "", false
+	}
+
+	return request.Method + " " + request.URL.String(), true
+}
@@ -0,0 +1,319 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type NotificationPriority int
+
+const (
+	NotificationPriorityLow NotificationPriority = iota
+	NotificationPriorityNormal
+	NotificationPriorityHigh
+	NotificationPriorityUrgent
+)
+
+type Notification struct {
+	ID       string
+	Title    string
+	Message  string
+	Priority NotificationPriority
+	Time     time.Time
+	Source   string
+	// the index of the source within the request, used for acknowledging
+	SourceIndex int
+}
+
+type Notifications []Notification
+
+func (n Notifications) SortByNewest() Notifications {
+	sort.SliceStable(n, func(i, j int) bool {
+		return n[i].Time.After(n[j].Time)
+	})
+
+	return n
+}
+
+type NotificationSource struct {
+	// either ntfy or gotify
+	Type   string
+	URL    string
+	Title  string
+	Token  string
+	Topics []string
+	AppID  int
+}
+
+type ntfyMessageJson struct {
+	ID       string `json:"id"`
+	Event    string `json:"event"`
+	Time     int64  `json:"time"`
+	Topic    string `json:"topic"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// ntfy priorities go from 1 (min) to 5 (max) with 3 being the default
+func ntfyPriority(priority int) NotificationPriority {
+	switch {
+	case priority == 0 || priority == 3:
+		return NotificationPriorityNormal
+	case priority < 3:
+		return NotificationPriorityLow
+	case priority == 4:
+		return NotificationPriorityHigh
+	default:
+		return NotificationPriorityUrgent
+	}
+}
+
+func fetchNotificationsFromNtfy(ctx context.Context, source *NotificationSource, since time.Duration) (Notifications, error) {
+	sinceParam := "all"
+
+	if since > 0 {
+		sinceParam = strconv.FormatInt(time.Now().Add(-since).Unix(), 10)
+	}
+
+	topics := make([]string, len(source.Topics))
+
+	for i := range source.Topics {
+		topics[i] = url.PathEscape(source.Topics[i])
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf("%s/%s/json?poll=1&since=%s", strings.TrimRight(source.URL, "/"), strings.Join(topics, ","), sinceParam),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+source.Token)
+	}
+
+	_, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(Notifications, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxResponseBodySize)
+
+	// the poll endpoint responds with one JSON object per line
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		var message ntfyMessageJson
+
+		if err := json.Unmarshal(line, &message); err != nil {
+			return nil, fmt.Errorf("could not parse ntfy message: %w", err)
+		}
+
+		if message.Event != "message" {
+			continue
+		}
+
+		notifications = append(notifications, Notification{
+			ID:       message.ID,
+			Title:    message.Title,
+			Message:  message.Message,
+			Priority: ntfyPriority(message.Priority),
+			Time:     time.Unix(message.Time, 0),
+			Source:   message.Topic,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+type gotifyMessagesResponseJson struct {
+	Messages []struct {
+		ID       int       `json:"id"`
+		AppID    int       `json:"appid"`
+		Title    string    `json:"title"`
+		Message  string    `json:"message"`
+		Priority int       `json:"priority"`
+		Date     time.Time `json:"date"`
+	} `json:"messages"`
+}
+
+// gotify priorities go from 0 to 10, grouped by the clients as 0 (no notification),
+// 1-3 (silent), 4-7 (sound) and 8-10 (interrupting)
+func gotifyPriority(priority int) NotificationPriority {
+	switch {
+	case priority < 4:
+		return NotificationPriorityLow
+	case priority < 8:
+		return NotificationPriorityNormal
+	case priority < 10:
+		return NotificationPriorityHigh
+	default:
+		return NotificationPriorityUrgent
+	}
+}
+
+func fetchNotificationsFromGotify(ctx context.Context, source *NotificationSource, limit int) (Notifications, error) {
+	endpoint := strings.TrimRight(source.URL, "/") + "/message"
+
+	if source.AppID > 0 {
+		endpoint = fmt.Sprintf("%s/application/%d/message", strings.TrimRight(source.URL, "/"), source.AppID)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s?limit=%d", endpoint, limit), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-Gotify-Key", source.Token)
+
+	response, err := decodeJsonFromRequest[gotifyMessagesResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(Notifications, 0, len(response.Messages))
+
+	for _, message := range response.Messages {
+		notifications = append(notifications, Notification{
+			ID:       strconv.Itoa(message.ID),
+			Title:    message.Title,
+			Message:  message.Message,
+			Priority: gotifyPriority(message.Priority),
+			Time:     message.Date,
+			Source:   source.Title,
+		})
+	}
+
+	return notifications, nil
+}
+
+// FetchNotifications fetches and merges the most recent notifications from
+// every source, leaving out the ones older than hideOlderThan if it's set
+func FetchNotifications(ctx context.Context, sources []NotificationSource, limit int, hideOlderThan time.Duration) (Notifications, error) {
+	indexes := make([]int, len(sources))
+
+	for i := range sources {
+		indexes[i] = i
+	}
+
+	task := func(i int) (Notifications, error) {
+		var notifications Notifications
+		var err error
+
+		switch sources[i].Type {
+		case "ntfy":
+			notifications, err = fetchNotificationsFromNtfy(ctx, &sources[i], hideOlderThan)
+		case "gotify":
+			notifications, err = fetchNotificationsFromGotify(ctx, &sources[i], limit)
+		default:
+			err = fmt.Errorf("unknown notification source type: %s", sources[i].Type)
+		}
+
+		for j := range notifications {
+			notifications[j].SourceIndex = i
+
+			if sources[i].Title != "" {
+				notifications[j].Source = sources[i].Title
+			}
+		}
+
+		return notifications, err
+	}
+
+	job := newJob(task, indexes).withWorkers(len(sources))
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(Notifications, 0)
+
+	for i := range results {
+		if errs[i] != nil {
+			slog.Error("Failed to fetch notifications", "type", sources[i].Type, "url", sources[i].URL, "error", errs[i])
+			continue
+		}
+
+		notifications = append(notifications, results[i]...)
+	}
+
+	if hideOlderThan > 0 {
+		cutoff := time.Now().Add(-hideOlderThan)
+		recent := notifications[:0]
+
+		for i := range notifications {
+			if notifications[i].Time.After(cutoff) {
+				recent = append(recent, notifications[i])
+			}
+		}
+
+		notifications = recent
+	}
+
+	notifications.SortByNewest()
+
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+
+	if err = mergeErr(errs); err != nil {
+		return notifications, err
+	}
+
+	return notifications, nil
+}
+
+var ErrNotificationNotAcknowledgeable = errors.New("notification source does not support acknowledging")
+
+// AcknowledgeNotification deletes the message from the source, only gotify supports this
+func AcknowledgeNotification(source *NotificationSource, id string) error {
+	if source.Type != "gotify" {
+		return ErrNotificationNotAcknowledgeable
+	}
+
+	if _, err := strconv.Atoi(id); err != nil {
+		return fmt.Errorf("invalid gotify message id: %s", id)
+	}
+
+	request, err := http.NewRequest("DELETE", strings.TrimRight(source.URL, "/")+"/message/"+id, nil)
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("X-Gotify-Key", source.Token)
+
+	_, _, err = fetchBodyFromRequest(defaultClient(), request)
+
+	return err
+}
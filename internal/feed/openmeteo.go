@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"net/http"
@@ -83,11 +84,11 @@ func parsePlaceName(name string) (string, string) {
 	return parts[0] + ", " + expandCountryAbbreviations(parts[2]), strings.TrimSpace(parts[1])
 }
 
-func FetchPlaceFromName(location string) (*PlaceJson, error) {
+func FetchPlaceFromName(ctx context.Context, location string) (*PlaceJson, error) {
 	location, area := parsePlaceName(location)
 	requestUrl := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=10&language=en&format=json", url.QueryEscape(location))
-	request, _ := http.NewRequest("GET", requestUrl, nil)
-	responseJson, err := decodeJsonFromRequest[PlacesResponseJson](defaultClient, request)
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+	responseJson, err := decodeJsonFromRequest[PlacesResponseJson](defaultClient(), request)
 
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch places data: %v", err)
@@ -132,7 +133,7 @@ func barIndexFromHour(h int) int {
 }
 
 // TODO: bunch of spaget, refactor
-func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
+func FetchWeatherForPlace(ctx context.Context, place *PlaceJson, units string) (*Weather, error) {
 	query := url.Values{}
 	var temperatureUnit string
 
@@ -153,8 +154,8 @@ func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
 	query.Add("temperature_unit", temperatureUnit)
 
 	requestUrl := "https://api.open-meteo.com/v1/forecast?" + query.Encode()
-	request, _ := http.NewRequest("GET", requestUrl, nil)
-	responseJson, err := decodeJsonFromRequest[WeatherResponseJson](defaultClient, request)
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+	responseJson, err := decodeJsonFromRequest[WeatherResponseJson](defaultClient(), request)
 
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
@@ -0,0 +1,265 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrNoPaginationInfo = errors.New("response contains no pagination info")
+var ErrPaginationLoop = errors.New("next page was already fetched")
+var ErrTooManyPages = errors.New("too many pages")
+
+// the most pages FetchAllPages fetches when it's not given a lower limit
+const maxPaginatedPages = 1000
+
+type PaginationInfo struct {
+	Total      int
+	Page       int
+	PerPage    int
+	TotalPages int
+	HasNext    bool
+	HasPrev    bool
+	NextURL    string
+}
+
+var contentRangePattern = regexp.MustCompile(`^(\w+)\s+(\d+)-(\d+)/(\d+|\*)$`)
+var linkHeaderPattern = regexp.MustCompile(`<([^>]*)>\s*((?:;\s*[^;,]+)*)`)
+
+// ExtractPaginationInfo reads pagination metadata from the response headers,
+// supporting the Link header (RFC 8288), X-Total-Count/X-Total, X-Page, X-Per-Page,
+// X-Total-Pages (GitHub/GitLab style), Pagination-Count/Page/Limit and
+// Content-Range (RFC 7233). When several schemes are present they're applied
+// in that order of precedence, with later schemes only filling in values the
+// earlier ones didn't provide.
+func ExtractPaginationInfo(headers http.Header) (*PaginationInfo, error) {
+	info := &PaginationInfo{}
+	found := false
+	hasNextFromLink := false
+
+	if links := headers.Values("Link"); len(links) > 0 {
+		parsed := parseLinkHeader(strings.Join(links, ","))
+
+		if next, ok := parsed["next"]; ok {
+			info.NextURL = next
+			info.HasNext = true
+		}
+
+		_, info.HasPrev = parsed["prev"]
+
+		if len(parsed) > 0 {
+			found = true
+			hasNextFromLink = true
+		}
+	}
+
+	headerSchemes := [][3]string{
+		{"X-Total-Count", "X-Page", "X-Per-Page"},
+		{"X-Total", "X-Page", "X-Per-Page"},
+		{"Pagination-Count", "Pagination-Page", "Pagination-Limit"},
+	}
+
+	for _, scheme := range headerSchemes {
+		for i, target := range []*int{&info.Total, &info.Page, &info.PerPage} {
+			value := headers.Get(scheme[i])
+
+			if value == "" || *target != 0 {
+				continue
+			}
+
+			parsed, err := strconv.Atoi(strings.TrimSpace(value))
+
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("invalid %s header: %q", scheme[i], value)
+			}
+
+			*target = parsed
+			found = true
+		}
+	}
+
+	if value := headers.Get("X-Total-Pages"); value != "" {
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid X-Total-Pages header: %q", value)
+		}
+
+		info.TotalPages = parsed
+		found = true
+	}
+
+	if value := headers.Get("Content-Range"); value != "" {
+		matches := contentRangePattern.FindStringSubmatch(strings.TrimSpace(value))
+
+		if matches == nil {
+			return nil, fmt.Errorf("invalid Content-Range header: %q", value)
+		}
+
+		// bytes ranges are used for partial downloads rather than pagination
+		if matches[1] != "bytes" {
+			start, _ := strconv.Atoi(matches[2])
+			end, _ := strconv.Atoi(matches[3])
+
+			if end < start {
+				return nil, fmt.Errorf("invalid Content-Range header: %q", value)
+			}
+
+			if info.PerPage == 0 {
+				info.PerPage = end - start + 1
+			}
+
+			if info.Page == 0 {
+				info.Page = start/info.PerPage + 1
+			}
+
+			if info.Total == 0 && matches[4] != "*" {
+				info.Total, _ = strconv.Atoi(matches[4])
+			}
+
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, ErrNoPaginationInfo
+	}
+
+	if info.TotalPages == 0 && info.Total > 0 && info.PerPage > 0 {
+		info.TotalPages = (info.Total + info.PerPage - 1) / info.PerPage
+	}
+
+	if !hasNextFromLink && info.Page > 0 {
+		info.HasPrev = info.Page > 1
+		info.HasNext = info.TotalPages > 0 && info.Page < info.TotalPages
+	}
+
+	return info, nil
+}
+
+func parseLinkHeader(header string) map[string]string {
+	links := make(map[string]string)
+
+	for _, match := range linkHeaderPattern.FindAllStringSubmatch(header, -1) {
+		for _, param := range strings.Split(match[2], ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+
+			if !ok || strings.TrimSpace(key) != "rel" {
+				continue
+			}
+
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+				links[rel] = match[1]
+			}
+		}
+	}
+
+	return links
+}
+
+// FetchAllPages decodes every page of a paginated JSON array endpoint, following
+// the next link when the server provides one and otherwise incrementing the
+// page query parameter. It stops once the pagination info says there are no
+// more pages, a page comes back empty or maxPages have been fetched, and fails
+// with ErrPaginationLoop if a page links back to one already fetched or with
+// ErrTooManyPages past 1000 pages when maxPages is 0 or less. Headers which may
+// hold credentials aren't sent to next pages on another scheme or host, the same
+// way as http.Client does with redirects.
+func FetchAllPages[T any](client RequestDoer, request *http.Request, maxPages int) ([]T, error) {
+	results := make([]T, 0)
+	current := request
+	visited := map[string]bool{request.URL.String(): true}
+
+	if maxPages <= 0 || maxPages > maxPaginatedPages {
+		maxPages = maxPaginatedPages
+	}
+
+	for page := 1; ; page++ {
+		response, body, err := fetchBodyFromRequest(client, current)
+
+		if err != nil {
+			return results, err
+		}
+
+		var items []T
+
+		if err = json.Unmarshal(body, &items); err != nil {
+			return results, err
+		}
+
+		results = append(results, items...)
+
+		if len(items) == 0 {
+			break
+		}
+
+		info, err := ExtractPaginationInfo(response.Header)
+
+		if err != nil || !info.HasNext {
+			break
+		}
+
+		next, err := nextPageURL(current.URL, info)
+
+		if err != nil {
+			return results, err
+		}
+
+		if visited[next.String()] {
+			return results, fmt.Errorf("%w: %s", ErrPaginationLoop, next)
+		}
+
+		if page == maxPages {
+			if maxPages == maxPaginatedPages {
+				return results, fmt.Errorf("%w: stopped after %d", ErrTooManyPages, page)
+			}
+
+			break
+		}
+
+		visited[next.String()] = true
+		current = nextPageRequest(current, next)
+	}
+
+	return results, nil
+}
+
+func nextPageRequest(current *http.Request, next *url.URL) *http.Request {
+	request := current.Clone(current.Context())
+	request.URL = next
+	request.Host = next.Host
+
+	if next.Scheme != current.URL.Scheme || next.Host != current.URL.Host {
+		for name := range request.Header {
+			if isSensitiveHeader(name) {
+				request.Header.Del(name)
+			}
+		}
+	}
+
+	return request
+}
+
+func nextPageURL(current *url.URL, info *PaginationInfo) (*url.URL, error) {
+	if info.NextURL != "" {
+		next, err := current.Parse(info.NextURL)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page URL: %w", err)
+		}
+
+		return next, nil
+	}
+
+	next := *current
+	query := next.Query()
+	query.Set("page", strconv.Itoa(info.Page+1))
+	next.RawQuery = query.Encode()
+
+	return &next, nil
+}
@@ -0,0 +1,264 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func headersOf(pairs ...string) http.Header {
+	headers := make(http.Header)
+
+	for i := 0; i < len(pairs); i += 2 {
+		headers.Add(pairs[i], pairs[i+1])
+	}
+
+	return headers
+}
+
+func TestExtractPaginationInfoSchemes(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  http.Header
+		expected PaginationInfo
+	}{
+		{
+			name:     "github style",
+			headers:  headersOf("X-Total-Count", "95", "X-Page", "2", "X-Per-Page", "25"),
+			expected: PaginationInfo{Total: 95, Page: 2, PerPage: 25, TotalPages: 4, HasNext: true, HasPrev: true},
+		},
+		{
+			name:     "gitlab style",
+			headers:  headersOf("X-Total", "50", "X-Page", "2", "X-Per-Page", "25", "X-Total-Pages", "2"),
+			expected: PaginationInfo{Total: 50, Page: 2, PerPage: 25, TotalPages: 2, HasNext: false, HasPrev: true},
+		},
+		{
+			name:     "pagination headers",
+			headers:  headersOf("Pagination-Count", "30", "Pagination-Page", "1", "Pagination-Limit", "10"),
+			expected: PaginationInfo{Total: 30, Page: 1, PerPage: 10, TotalPages: 3, HasNext: true, HasPrev: false},
+		},
+		{
+			name:     "content range",
+			headers:  headersOf("Content-Range", "items 0-24/100"),
+			expected: PaginationInfo{Total: 100, Page: 1, PerPage: 25, TotalPages: 4, HasNext: true, HasPrev: false},
+		},
+		{
+			name:     "content range with unknown total",
+			headers:  headersOf("Content-Range", "items 25-49/*"),
+			expected: PaginationInfo{Page: 2, PerPage: 25, HasPrev: true},
+		},
+		{
+			name:     "link header",
+			headers:  headersOf("Link", `<https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=1>; rel="prev"`),
+			expected: PaginationInfo{HasNext: true, HasPrev: true, NextURL: "https://api.example.com/items?page=3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := ExtractPaginationInfo(test.headers)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *info != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, *info)
+			}
+		})
+	}
+}
+
+func TestExtractPaginationInfoConflictingSchemes(t *testing.T) {
+	// the Link header decides whether there's a next page and X-Total-Count
+	// takes precedence over the total from Content-Range
+	info, err := ExtractPaginationInfo(headersOf(
+		"Link", `<https://api.example.com/items?page=2>; rel="next"`,
+		"X-Total-Count", "10",
+		"X-Per-Page", "10",
+		"X-Page", "1",
+		"Content-Range", "items 0-49/500",
+	))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Total != 10 || info.PerPage != 10 || info.Page != 1 {
+		t.Errorf("expected the X- headers to take precedence, got %+v", *info)
+	}
+
+	if !info.HasNext || info.NextURL != "https://api.example.com/items?page=2" {
+		t.Errorf("expected the Link header to decide the next page, got %+v", *info)
+	}
+}
+
+func TestExtractPaginationInfoErrors(t *testing.T) {
+	if _, err := ExtractPaginationInfo(http.Header{}); !errors.Is(err, ErrNoPaginationInfo) {
+		t.Errorf("expected ErrNoPaginationInfo, got %v", err)
+	}
+
+	if _, err := ExtractPaginationInfo(headersOf("Content-Range", "bytes 0-99/1000")); !errors.Is(err, ErrNoPaginationInfo) {
+		t.Errorf("expected byte ranges to be ignored, got %v", err)
+	}
+
+	for _, headers := range []http.Header{
+		headersOf("X-Total-Count", "many"),
+		headersOf("X-Page", "-1"),
+		headersOf("Content-Range", "items 10-5/100"),
+		headersOf("Content-Range", "nonsense"),
+	} {
+		if _, err := ExtractPaginationInfo(headers); err == nil || errors.Is(err, ErrNoPaginationInfo) {
+			t.Errorf("expected an invalid header error for %v, got %v", headers, err)
+		}
+	}
+}
+
+func TestFetchAllPagesStopsWhenThereIsNoNextPage(t *testing.T) {
+	requests := 0
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		if page == 0 {
+			page = 1
+		}
+
+		w.Header().Set("X-Total-Count", "5")
+		w.Header().Set("X-Per-Page", "2")
+		w.Header().Set("X-Page", strconv.Itoa(page))
+
+		switch page {
+		case 1:
+			fmt.Fprint(w, `[1,2]`)
+		case 2:
+			fmt.Fprint(w, `[3,4]`)
+		case 3:
+			fmt.Fprint(w, `[5]`)
+		default:
+			t.Errorf("requested page %d past the last one", page)
+			fmt.Fprint(w, `[]`)
+		}
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	items, err := FetchAllPages[int](server.Client(), request, 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 5 || items[4] != 5 {
+		t.Errorf("expected items 1 to 5, got %v", items)
+	}
+
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+}
+
+func TestFetchAllPagesFollowsLinkHeader(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("Link", `</items?cursor=abc>; rel="next"`)
+			fmt.Fprint(w, `["a"]`)
+			return
+		}
+
+		fmt.Fprint(w, `["b"]`)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	items, err := FetchAllPages[string](server.Client(), request, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 2 || items[0] != "a" || items[1] != "b" {
+		t.Errorf("expected [a b], got %v", items)
+	}
+}
+
+func TestFetchAllPagesStopsOnLoops(t *testing.T) {
+	requests := 0
+
+	// every page links to the next one, with the third linking back to the first
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		w.Header().Set("Link", fmt.Sprintf(`</items?cursor=%d>; rel="next"`, (page+1)%3))
+		fmt.Fprint(w, `[1]`)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/items?cursor=0", nil)
+	items, err := FetchAllPages[int](server.Client(), request, 0)
+
+	if !errors.Is(err, ErrPaginationLoop) {
+		t.Fatalf("expected ErrPaginationLoop, got %v", err)
+	}
+
+	if len(items) != 3 || requests != 3 {
+		t.Errorf("expected the 3 pages before the loop, got %v from %d requests", items, requests)
+	}
+}
+
+func TestFetchAllPagesHardLimit(t *testing.T) {
+	requests := 0
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Link", fmt.Sprintf(`</items?cursor=%d>; rel="next"`, requests))
+		fmt.Fprint(w, `[1]`)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+
+	if _, err := FetchAllPages[int](server.Client(), request, 0); !errors.Is(err, ErrTooManyPages) {
+		t.Errorf("expected ErrTooManyPages, got %v", err)
+	}
+
+	if requests != maxPaginatedPages {
+		t.Errorf("expected %d requests, got %d", maxPaginatedPages, requests)
+	}
+}
+
+func TestFetchAllPagesDropsCredentialsForOtherHosts(t *testing.T) {
+	received := make(chan http.Header, 1)
+
+	other := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		fmt.Fprint(w, `[2]`)
+	})
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "<"+other.URL+`/items?page=2>; rel="next"`)
+		fmt.Fprint(w, `[1]`)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("X-Api-Key", "secret")
+	request.Header.Set("Accept", "application/json")
+
+	items, err := FetchAllPages[int](server.Client(), request, 0)
+
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected both pages, got %v, %v", items, err)
+	}
+
+	headers := <-received
+
+	for _, name := range []string{"Authorization", "X-Api-Key"} {
+		if headers.Get(name) != "" {
+			t.Errorf("expected %s to not be sent to another host, got %q", name, headers.Get(name))
+		}
+	}
+
+	if headers.Get("Accept") != "application/json" {
+		t.Errorf("expected the other headers to be kept, got %q", headers.Get("Accept"))
+	}
+}
@@ -0,0 +1,61 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// PanicError is returned as the error of a worker pool task which panicked,
+// so that a single misbehaving task doesn't take down the whole process
+type PanicError struct {
+	Value any
+	Stack []byte
+	// the input the task was called with
+	Input any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Unwrap allows errors.Is and errors.As to see through panics with an error value
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func IsPanicError(err error) bool {
+	_, ok := ExtractPanicError(err)
+	return ok
+}
+
+func ExtractPanicError(err error) (*PanicError, bool) {
+	var panicErr *PanicError
+
+	if errors.As(err, &panicErr) {
+		return panicErr, true
+	}
+
+	return nil, false
+}
+
+func runTaskRecoveringPanic[I any, O any](task func(I) (O, error), input I) (output O, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			stack := debug.Stack()
+			slog.Error("Recovered from panic in task", "panic", value, "stack", string(stack))
+
+			var zero O
+			output = zero
+			err = &PanicError{
+				Value: value,
+				Stack: stack,
+				Input: input,
+			}
+		}
+	}()
+
+	return task(input)
+}
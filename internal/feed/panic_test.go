@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWorkerPoolRecoversFromPanickingTask(t *testing.T) {
+	errSentinel := errors.New("sentinel")
+
+	task := func(n int) (int, error) {
+		switch n {
+		case 2:
+			panic("broken widget")
+		case 3:
+			panic(fmt.Errorf("wrapped: %w", errSentinel))
+		}
+
+		return n * 10, nil
+	}
+
+	// a single worker has to carry on with the remaining tasks after a panic
+	results, errs, err := workerPoolDo(newJob(task, []int{1, 2, 3, 4}).withWorkers(1))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0] != 10 || results[3] != 40 || errs[0] != nil || errs[3] != nil {
+		t.Errorf("expected the other tasks to complete, got %v, %v", results, errs)
+	}
+
+	panicErr, ok := ExtractPanicError(errs[1])
+
+	if !ok || !IsPanicError(errs[1]) {
+		t.Fatalf("expected a PanicError, got %v", errs[1])
+	}
+
+	if panicErr.Value != "broken widget" || panicErr.Input != 2 || len(panicErr.Stack) == 0 {
+		t.Errorf("unexpected panic error: value %v, input %v, %d bytes of stack", panicErr.Value, panicErr.Input, len(panicErr.Stack))
+	}
+
+	if results[1] != 0 {
+		t.Errorf("expected a zero value for the panicked task, got %d", results[1])
+	}
+
+	if !IsPanicError(errs[2]) || !errors.Is(errs[2], errSentinel) {
+		t.Errorf("expected a PanicError wrapping the panic value, got %v", errs[2])
+	}
+
+	if IsPanicError(errSentinel) {
+		t.Error("expected a regular error to not be a PanicError")
+	}
+}
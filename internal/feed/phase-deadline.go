@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+var (
+	ErrConnectBudgetExceeded = errors.New("connect budget exceeded")
+	ErrReadBudgetExceeded    = errors.New("read budget exceeded")
+)
+
+// phaseDeadlineRoundTripper gives each phase of a request its own time budget
+// rather than one timeout for all of it. The connect budget covers everything up
+// until a connection is ready, which includes DNS, dialing and the TLS handshake.
+// The read budget starts once the connection is ready and covers the wait for the
+// response, then starts over when the first byte of the response arrives and
+// covers reading the body, so both the server and the transfer get the budget.
+type phaseDeadlineRoundTripper struct {
+	next          http.RoundTripper
+	connectBudget time.Duration
+	readBudget    time.Duration
+}
+
+type phaseDeadline struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel context.CancelCauseFunc
+	// set once the timer of a phase fires
+	exceeded error
+}
+
+func (d *phaseDeadline) start(budget time.Duration, cause error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.exceeded != nil {
+		return
+	}
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	d.timer = time.AfterFunc(budget, func() {
+		err := fmt.Errorf("%w after %s", cause, budget)
+
+		d.mu.Lock()
+		d.exceeded = err
+		d.mu.Unlock()
+
+		d.cancel(err)
+	})
+}
+
+func (d *phaseDeadline) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	d.cancel(nil)
+}
+
+func (d *phaseDeadline) err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.exceeded
+}
+
+func (rt *phaseDeadlineRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(request.Context())
+	deadline := &phaseDeadline{cancel: cancel}
+
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			deadline.start(rt.readBudget, ErrReadBudgetExceeded)
+		},
+		GotFirstResponseByte: func() {
+			deadline.start(rt.readBudget, ErrReadBudgetExceeded)
+		},
+	})
+
+	deadline.start(rt.connectBudget, ErrConnectBudgetExceeded)
+
+	response, err := rt.next.RoundTrip(request.WithContext(ctx))
+
+	if err != nil {
+		deadline.stop()
+
+		if exceeded := deadline.err(); exceeded != nil {
+			return nil, exceeded
+		}
+
+		return nil, err
+	}
+
+	response.Body = &phaseDeadlineBody{ReadCloser: response.Body, deadline: deadline}
+
+	return response, nil
+}
+
+type phaseDeadlineBody struct {
+	io.ReadCloser
+	deadline *phaseDeadline
+}
+
+func (b *phaseDeadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	if err != nil && err != io.EOF {
+		if exceeded := b.deadline.err(); exceeded != nil {
+			return n, exceeded
+		}
+	}
+
+	return n, err
+}
+
+func (b *phaseDeadlineBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.deadline.stop()
+
+	return err
+}
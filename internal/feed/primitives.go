@@ -25,6 +25,7 @@ type Calendar struct {
 	CurrentDay        int
 	CurrentWeekNumber int
 	CurrentMonthName  string
+	CurrentMonth      time.Month
 	CurrentYear       int
 	Days              []int
 }
@@ -42,6 +43,7 @@ type Weather struct {
 type AppRelease struct {
 	Name         string
 	Version      string
+	Tag          string
 	NotesUrl     string
 	TimeReleased time.Time
 	Downvotes    int
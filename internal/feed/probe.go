@@ -0,0 +1,206 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type ProbeResult struct {
+	Reachable  bool
+	StatusCode int
+	Latency    time.Duration
+	Error      error
+}
+
+type ProbeResults map[string]ProbeResult
+
+// HealthCheckResult is the same as ProbeResult along with the URL it's for,
+// for when the results have to stay in the order the URLs were given in
+type HealthCheckResult struct {
+	URL string
+	ProbeResult
+}
+
+type probeOptions struct {
+	// empty sends a HEAD request and falls back to GET for servers which don't support HEAD
+	method          string
+	timeout         time.Duration
+	expectedCodes   []int
+	followRedirects bool
+}
+
+type ProbeOption func(*probeOptions)
+
+func WithProbeMethod(method string) ProbeOption {
+	return func(o *probeOptions) {
+		o.method = method
+	}
+}
+
+func WithProbeTimeout(d time.Duration) ProbeOption {
+	return func(o *probeOptions) {
+		o.timeout = d
+	}
+}
+
+// WithExpectedStatusCodes sets the status codes which count as reachable,
+// by default any 2xx or 3xx status does
+func WithExpectedStatusCodes(codes ...int) ProbeOption {
+	return func(o *probeOptions) {
+		o.expectedCodes = codes
+	}
+}
+
+// WithProbeFollowRedirects sets whether redirects are followed, in which case the
+// result is that of the page redirected to. Redirects are followed by default.
+// Only applies when the client is an *http.Client.
+func WithProbeFollowRedirects(follow bool) ProbeOption {
+	return func(o *probeOptions) {
+		o.followRedirects = follow
+	}
+}
+
+func (o *probeOptions) isExpected(statusCode int) bool {
+	if len(o.expectedCodes) == 0 {
+		return statusCode >= 200 && statusCode < 400
+	}
+
+	return slices.Contains(o.expectedCodes, statusCode)
+}
+
+func newProbeOptions(opts []ProbeOption) *probeOptions {
+	options := &probeOptions{
+		timeout:         defaultClientTimeout,
+		followRedirects: true,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+func (o *probeOptions) client(client RequestDoer) RequestDoer {
+	httpClient, ok := client.(*http.Client)
+
+	if o.followRedirects || !ok {
+		return client
+	}
+
+	noRedirects := *httpClient
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &noRedirects
+}
+
+func probeURL(ctx context.Context, client RequestDoer, options *probeOptions, url string) ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	method := options.method
+
+	if method == "" {
+		method = http.MethodHead
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+
+	if err != nil {
+		return ProbeResult{Error: err}
+	}
+
+	startedAt := time.Now()
+	response, err := client.Do(request)
+
+	if err == nil && options.method == "" &&
+		(response.StatusCode == http.StatusMethodNotAllowed || response.StatusCode == http.StatusNotImplemented) {
+		response.Body.Close()
+
+		request, _ = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		startedAt = time.Now()
+		response, err = client.Do(request)
+	}
+
+	latency := time.Since(startedAt)
+
+	if err != nil {
+		return ProbeResult{Latency: latency, Error: err}
+	}
+
+	response.Body.Close()
+
+	result := ProbeResult{
+		StatusCode: response.StatusCode,
+		Latency:    latency,
+		Reachable:  options.isExpected(response.StatusCode),
+	}
+
+	if !result.Reachable {
+		result.Error = fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return result
+}
+
+// ProbeURLs checks which of the URLs are reachable in parallel, the same way as
+// CheckURLHealth except that only a HEAD request is sent unless the method is set
+// with WithProbeMethod
+func ProbeURLs(ctx context.Context, client RequestDoer, urls []string, opts ...ProbeOption) ProbeResults {
+	checked := CheckURLHealth(ctx, client, urls, append([]ProbeOption{WithProbeMethod(http.MethodHead)}, opts...)...)
+	results := make(ProbeResults, len(urls))
+
+	for i := range checked {
+		results[checked[i].URL] = checked[i].ProbeResult
+	}
+
+	return results
+}
+
+// CheckURLHealth checks the URLs in parallel, the body of the responses is never
+// read. A HEAD request is retried as a GET request when the server doesn't allow
+// HEAD, unless the method is set with WithProbeMethod. The results are in the
+// same order as the URLs.
+func CheckURLHealth(ctx context.Context, client RequestDoer, urls []string, opts ...ProbeOption) []HealthCheckResult {
+	if client == nil {
+		client = defaultClient()
+	}
+
+	options := newProbeOptions(opts)
+	client = options.client(client)
+
+	indexes := make([]int, len(urls))
+	probed := make([]bool, len(urls))
+
+	probe := func(i int) (ProbeResult, error) {
+		result := probeURL(ctx, client, options, urls[i])
+		probed[i] = true
+
+		return result, nil
+	}
+
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	job := newJob(probe, indexes).withWorkers(20).withContext(ctx)
+	results, _, err := workerPoolDo(job)
+	checked := make([]HealthCheckResult, len(urls))
+
+	for i := range urls {
+		checked[i] = HealthCheckResult{URL: urls[i], ProbeResult: results[i]}
+
+		// cancelling the context stops the remaining URLs from being probed,
+		// report the reason for them instead
+		if !probed[i] {
+			checked[i].Error = err
+		}
+	}
+
+	return checked
+}
@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newProbeTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// an address which refuses connections, from a server which was closed right away
+func unreachableURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.URL
+}
+
+func TestProbeURLsReachableAndUnreachable(t *testing.T) {
+	server := newProbeTestServer(t)
+	unreachable := unreachableURL()
+
+	urls := []string{server.URL + "/ok", server.URL + "/error", server.URL + "/missing", unreachable}
+	results := ProbeURLs(context.Background(), server.Client(), urls)
+
+	if len(results) != len(urls) {
+		t.Fatalf("expected %d results, got %d", len(urls), len(results))
+	}
+
+	if ok := results[server.URL+"/ok"]; !ok.Reachable || ok.StatusCode != http.StatusOK || ok.Error != nil || ok.Latency <= 0 {
+		t.Errorf("unexpected result for the reachable URL: %+v", ok)
+	}
+
+	for _, url := range []string{server.URL + "/error", server.URL + "/missing"} {
+		if result := results[url]; result.Reachable || result.StatusCode == 0 || result.Error == nil {
+			t.Errorf("expected %s to be unreachable with its status code, got %+v", url, result)
+		}
+	}
+
+	if result := results[unreachable]; result.Reachable || result.StatusCode != 0 || result.Error == nil {
+		t.Errorf("expected the closed server to be unreachable with an error, got %+v", result)
+	}
+}
+
+func TestProbeURLsOptions(t *testing.T) {
+	server := newProbeTestServer(t)
+
+	results := ProbeURLs(
+		context.Background(),
+		server.Client(),
+		[]string{server.URL + "/created", server.URL + "/ok", server.URL + "/slow"},
+		WithExpectedStatusCodes(http.StatusCreated),
+		WithProbeTimeout(50*time.Millisecond),
+		WithProbeMethod(http.MethodGet),
+	)
+
+	if !results[server.URL+"/created"].Reachable {
+		t.Errorf("expected 201 to count as reachable, got %+v", results[server.URL+"/created"])
+	}
+
+	if results[server.URL+"/ok"].Reachable {
+		t.Errorf("expected 200 to not count as reachable, got %+v", results[server.URL+"/ok"])
+	}
+
+	if slow := results[server.URL+"/slow"]; slow.Reachable || slow.Error == nil {
+		t.Errorf("expected the slow URL to time out, got %+v", slow)
+	}
+}
+
+func TestProbeURLsCancelledContext(t *testing.T) {
+	server := newProbeTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := ProbeURLs(ctx, server.Client(), []string{server.URL + "/ok", server.URL + "/created"})
+
+	for url, result := range results {
+		if result.Reachable || result.Error == nil {
+			t.Errorf("expected %s to report the cancelled context, got %+v", url, result)
+		}
+	}
+}
+
+func TestCheckURLHealthFallsBackToGet(t *testing.T) {
+	server := newProbeTestServer(t)
+	urls := []string{server.URL + "/no-head", server.URL + "/ok", server.URL + "/missing"}
+
+	results := CheckURLHealth(context.Background(), server.Client(), urls)
+
+	for i := range urls {
+		if results[i].URL != urls[i] {
+			t.Fatalf("expected the results in the order of the URLs, got %s at %d", results[i].URL, i)
+		}
+	}
+
+	if !results[0].Reachable || results[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the HEAD request to be retried as GET, got %+v", results[0].ProbeResult)
+	}
+
+	if !results[1].Reachable || results[2].Reachable {
+		t.Errorf("unexpected results: %+v, %+v", results[1].ProbeResult, results[2].ProbeResult)
+	}
+
+	// ProbeURLs only ever sends HEAD
+	if probed := ProbeURLs(context.Background(), server.Client(), urls[:1]); probed[urls[0]].StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected HEAD to not be retried by ProbeURLs, got %+v", probed[urls[0]])
+	}
+}
+
+func TestCheckURLHealthCancelledContext(t *testing.T) {
+	server := newProbeTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	urls := []string{server.URL + "/ok", server.URL + "/created", server.URL + "/error"}
+
+	for _, result := range CheckURLHealth(ctx, server.Client(), urls) {
+		if result.Reachable || result.Error == nil {
+			t.Errorf("expected %s to report the cancelled context, got %+v", result.URL, result.ProbeResult)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"io"
+	"time"
+)
+
+type progressOptions struct {
+	throttle time.Duration
+}
+
+type ProgressOption func(*progressOptions)
+
+// WithProgressThrottle calls the callback at most once per interval, apart
+// from the final call once everything has been read which is always made
+func WithProgressThrottle(interval time.Duration) ProgressOption {
+	return func(o *progressOptions) {
+		o.throttle = interval
+	}
+}
+
+type ProgressReader struct {
+	reader    io.Reader
+	total     int64
+	read      int64
+	callback  func(bytesRead, total int64)
+	options   progressOptions
+	lastCall  time.Time
+	completed bool
+}
+
+// NewProgressReader wraps r, calling callback with the number of bytes read so far
+// after each read. Pass a total of -1 if it isn't known ahead of time, in which
+// case the final call is made once r returns io.EOF.
+func NewProgressReader(r io.Reader, total int64, callback func(bytesRead, total int64), opts ...ProgressOption) *ProgressReader {
+	reader := &ProgressReader{
+		reader:   r,
+		total:    total,
+		callback: callback,
+	}
+
+	for _, opt := range opts {
+		opt(&reader.options)
+	}
+
+	return reader
+}
+
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	finished := err == io.EOF || (r.total >= 0 && r.read >= r.total)
+
+	if finished {
+		// only report completion once even if Read keeps getting called after EOF
+		if !r.completed {
+			r.completed = true
+			r.callback(r.read, r.total)
+		}
+
+		return n, err
+	}
+
+	if n == 0 {
+		return n, err
+	}
+
+	if r.options.throttle > 0 {
+		now := time.Now()
+
+		if now.Sub(r.lastCall) < r.options.throttle {
+			return n, err
+		}
+
+		r.lastCall = now
+	}
+
+	r.callback(r.read, r.total)
+
+	return n, err
+}
+
+type progressRequestBody struct {
+	*ProgressReader
+	closer io.Closer
+}
+
+func (b *progressRequestBody) Close() error {
+	if b.closer != nil {
+		return b.closer.Close()
+	}
+
+	return nil
+}
+
+// NewProgressRequestBody is a NewProgressReader for use as the body of a request,
+// closing it closes r if it's an io.Closer. Set the ContentLength of the request
+// to total since the transport can't know it otherwise.
+func NewProgressRequestBody(r io.Reader, total int64, callback func(bytesRead, total int64), opts ...ProgressOption) io.ReadCloser {
+	closer, _ := r.(io.Closer)
+
+	return &progressRequestBody{
+		ProgressReader: NewProgressReader(r, total, callback, opts...),
+		closer:         closer,
+	}
+}
@@ -0,0 +1,127 @@
+package feed
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readInChunks(t *testing.T, r io.Reader, size int) {
+	t.Helper()
+
+	buffer := make([]byte, size)
+
+	for {
+		_, err := r.Read(buffer)
+
+		if err == io.EOF {
+			return
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProgressReaderReportsByteCounts(t *testing.T) {
+	var calls []int64
+	reader := NewProgressReader(strings.NewReader(strings.Repeat("x", 25)), 25, func(bytesRead, total int64) {
+		if total != 25 {
+			t.Errorf("expected a total of 25, got %d", total)
+		}
+
+		calls = append(calls, bytesRead)
+	})
+
+	readInChunks(t, reader, 10)
+
+	if expected := []int64{10, 20, 25}; !slices.Equal(calls, expected) {
+		t.Errorf("expected callbacks at %v, got %v", expected, calls)
+	}
+
+	// reading past the end doesn't report completion again
+	reader.Read(make([]byte, 10))
+
+	if len(calls) != 3 {
+		t.Errorf("expected no more callbacks after completion, got %v", calls)
+	}
+}
+
+func TestProgressReaderUnknownTotal(t *testing.T) {
+	var calls []int64
+	reader := NewProgressReader(strings.NewReader(strings.Repeat("x", 15)), -1, func(bytesRead, total int64) {
+		calls = append(calls, bytesRead)
+	})
+
+	readInChunks(t, reader, 10)
+
+	if len(calls) == 0 || calls[len(calls)-1] != 15 {
+		t.Errorf("expected the last callback once EOF was reached to be at 15, got %v", calls)
+	}
+}
+
+func TestProgressReaderThrottle(t *testing.T) {
+	var calls []int64
+	reader := NewProgressReader(strings.NewReader(strings.Repeat("x", 25)), 25, func(bytesRead, total int64) {
+		calls = append(calls, bytesRead)
+	}, WithProgressThrottle(time.Hour))
+
+	readInChunks(t, reader, 5)
+
+	if expected := []int64{5, 25}; !slices.Equal(calls, expected) {
+		t.Errorf("expected the first and the final callback only, got %v", calls)
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestProgressRequestBody(t *testing.T) {
+	payload := bytes.Repeat([]byte("y"), 64*1024)
+	var received int
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = len(body)
+	})
+
+	var last int64
+	source := &closeRecorder{Reader: bytes.NewReader(payload)}
+	body := NewProgressRequestBody(source, int64(len(payload)), func(bytesRead, total int64) {
+		if bytesRead < last || bytesRead > total {
+			t.Errorf("unexpected progress %d of %d after %d", bytesRead, total, last)
+		}
+
+		last = bytesRead
+	})
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL, body)
+	request.ContentLength = int64(len(payload))
+	response, err := server.Client().Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response.Body.Close()
+
+	if received != len(payload) || last != int64(len(payload)) {
+		t.Errorf("expected the whole payload to be sent and reported, got %d received and %d reported", received, last)
+	}
+
+	if !source.closed {
+		t.Error("expected closing the body to close the underlying reader")
+	}
+}
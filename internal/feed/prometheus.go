@@ -0,0 +1,184 @@
+package feed
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+type PrometheusSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+type PrometheusMetric struct {
+	Name string
+	Help string
+	// one of counter, gauge, summary, histogram or untyped
+	Type    string
+	Samples []PrometheusSample
+}
+
+// PrometheusMetrics holds metrics by name. Summaries and histograms have a sample
+// for each quantile or bucket under their own name, with a quantile or le label,
+// while their sums and counts are under the name with a _sum and _count suffix,
+// same as they appear in the exposition format.
+type PrometheusMetrics map[string]*PrometheusMetric
+
+// Value returns the value of the first sample of the metric which has all of the
+// given labels, other labels of the sample are ignored so nil matches any sample
+func (m PrometheusMetrics) Value(name string, labels map[string]string) (float64, bool) {
+	metric, ok := m[name]
+
+	if !ok {
+		return 0, false
+	}
+
+samples:
+	for i := range metric.Samples {
+		for label, value := range labels {
+			if metric.Samples[i].Labels[label] != value {
+				continue samples
+			}
+		}
+
+		return metric.Samples[i].Value, true
+	}
+
+	return 0, false
+}
+
+func (m PrometheusMetrics) addSample(name, help, metricType string, labels map[string]string, value float64) {
+	metric, ok := m[name]
+
+	if !ok {
+		metric = &PrometheusMetric{Name: name, Help: help, Type: metricType}
+		m[name] = metric
+	}
+
+	metric.Samples = append(metric.Samples, PrometheusSample{Labels: labels, Value: value})
+}
+
+func prometheusLabels(metric *dto.Metric, extra ...string) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel())+len(extra)/2)
+
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels[extra[i]] = extra[i+1]
+	}
+
+	return labels
+}
+
+func formatPrometheusFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// ParsePrometheusMetrics parses metrics in the Prometheus text exposition format,
+// including their HELP and TYPE lines
+func ParsePrometheusMetrics(text string) (PrometheusMetrics, error) {
+	var parser expfmt.TextParser
+
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make(PrometheusMetrics, len(families))
+
+	for name, family := range families {
+		help := family.GetHelp()
+		metricType := strings.ToLower(family.GetType().String())
+
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				metrics.addSample(name, help, metricType, prometheusLabels(metric), metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				metrics.addSample(name, help, metricType, prometheusLabels(metric), metric.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				metrics.addSample(name, help, metricType, prometheusLabels(metric), metric.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+
+				for _, quantile := range summary.GetQuantile() {
+					labels := prometheusLabels(metric, "quantile", formatPrometheusFloat(quantile.GetQuantile()))
+					metrics.addSample(name, help, metricType, labels, quantile.GetValue())
+				}
+
+				metrics.addSample(name+"_sum", help, metricType, prometheusLabels(metric), summary.GetSampleSum())
+				metrics.addSample(name+"_count", help, metricType, prometheusLabels(metric), float64(summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+
+				for _, bucket := range histogram.GetBucket() {
+					labels := prometheusLabels(metric, "le", formatPrometheusFloat(bucket.GetUpperBound()))
+					metrics.addSample(name, help, metricType, labels, float64(bucket.GetCumulativeCount()))
+				}
+
+				metrics.addSample(name+"_sum", help, metricType, prometheusLabels(metric), histogram.GetSampleSum())
+				metrics.addSample(name+"_count", help, metricType, prometheusLabels(metric), float64(histogram.GetSampleCount()))
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+func decodePrometheusFromRequest(client RequestDoer, request *http.Request) (_ PrometheusMetrics, err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", "text/plain;version=0.0.4")
+	}
+
+	text, err := decodeTextFromRequest(client, request, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := ParsePrometheusMetrics(text)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse metrics from %s: %w", request.URL, err)
+	}
+
+	return metrics, nil
+}
+
+// FetchPrometheusMetrics scrapes the metrics endpoint at url, such as that of
+// node_exporter, without going through a Prometheus server
+func FetchPrometheusMetrics(client RequestDoer, url string) (PrometheusMetrics, error) {
+	if client == nil {
+		client = defaultClient()
+	}
+
+	request, err := http.NewRequest("GET", url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := decodePrometheusFromRequest(client, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	return metrics, nil
+}
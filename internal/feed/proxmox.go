@@ -0,0 +1,163 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ProxmoxNode struct {
+	Name   string
+	Online bool
+	// percentage of all of the node's cores
+	CPU         float64
+	CPUs        int
+	MemoryUsed  uint64
+	MemoryTotal uint64
+	Uptime      time.Duration
+}
+
+func (n *ProxmoxNode) MemoryPercent() float64 {
+	if n.MemoryTotal == 0 {
+		return 0
+	}
+
+	return float64(n.MemoryUsed) / float64(n.MemoryTotal) * 100
+}
+
+type ProxmoxGuest struct {
+	ID   int
+	Name string
+	Node string
+	// either VM or LXC
+	Type       string
+	Running    bool
+	CPU        float64
+	MemoryUsed uint64
+	Uptime     time.Duration
+}
+
+type ProxmoxGuests []ProxmoxGuest
+
+func (g ProxmoxGuests) SortByCPU() ProxmoxGuests {
+	sort.SliceStable(g, func(i, j int) bool {
+		return g[i].CPU > g[j].CPU
+	})
+
+	return g
+}
+
+type ProxmoxCluster struct {
+	Nodes             []ProxmoxNode
+	Guests            ProxmoxGuests
+	RunningVMs        int
+	StoppedVMs        int
+	RunningContainers int
+	StoppedContainers int
+}
+
+type proxmoxClusterResourcesResponseJson struct {
+	Data []struct {
+		Type     string  `json:"type"`
+		Node     string  `json:"node"`
+		VMID     int     `json:"vmid"`
+		Name     string  `json:"name"`
+		Status   string  `json:"status"`
+		Template int     `json:"template"`
+		CPU      float64 `json:"cpu"`
+		MaxCPU   float64 `json:"maxcpu"`
+		Mem      uint64  `json:"mem"`
+		MaxMem   uint64  `json:"maxmem"`
+		Uptime   int64   `json:"uptime"`
+	} `json:"data"`
+}
+
+// FetchProxmoxCluster gets the nodes and guests of a Proxmox VE cluster, which
+// works the same for a single node that isn't part of a cluster. The token is
+// in the form of USER@REALM!TOKENID=SECRET. Only the resources the token has
+// access to are returned, guests it can't audit are left out instead of
+// causing an error. Guests which are templates aren't included.
+func FetchProxmoxCluster(ctx context.Context, client RequestDoer, instanceURL, token string) (*ProxmoxCluster, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(instanceURL, "/")+"/api2/json/cluster/resources", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request.Header.Set("Authorization", "PVEAPIToken="+token)
+
+	response, err := decodeJsonFromRequest[proxmoxClusterResourcesResponseJson](client, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	cluster := &ProxmoxCluster{
+		Nodes:  make([]ProxmoxNode, 0),
+		Guests: make(ProxmoxGuests, 0),
+	}
+
+	for _, resource := range response.Data {
+		switch resource.Type {
+		case "node":
+			cluster.Nodes = append(cluster.Nodes, ProxmoxNode{
+				Name:        resource.Node,
+				Online:      resource.Status == "online",
+				CPU:         resource.CPU * 100,
+				CPUs:        int(resource.MaxCPU),
+				MemoryUsed:  resource.Mem,
+				MemoryTotal: resource.MaxMem,
+				Uptime:      time.Duration(resource.Uptime) * time.Second,
+			})
+		case "qemu", "lxc":
+			// guests shown with only some of their details are ones which the
+			// token can see but doesn't have the permission to audit
+			if resource.Template == 1 || resource.Status == "" {
+				continue
+			}
+
+			guest := ProxmoxGuest{
+				ID:         resource.VMID,
+				Name:       resource.Name,
+				Node:       resource.Node,
+				Running:    resource.Status == "running",
+				CPU:        resource.CPU * 100,
+				MemoryUsed: resource.Mem,
+				Uptime:     time.Duration(resource.Uptime) * time.Second,
+			}
+
+			if resource.Type == "qemu" {
+				guest.Type = "VM"
+
+				if guest.Running {
+					cluster.RunningVMs++
+				} else {
+					cluster.StoppedVMs++
+				}
+			} else {
+				guest.Type = "LXC"
+
+				if guest.Running {
+					cluster.RunningContainers++
+				} else {
+					cluster.StoppedContainers++
+				}
+			}
+
+			cluster.Guests = append(cluster.Guests, guest)
+		}
+	}
+
+	if len(cluster.Nodes) == 0 {
+		return nil, fmt.Errorf("%w: no nodes found, the token may be missing the Sys.Audit permission", ErrNoContent)
+	}
+
+	sort.Slice(cluster.Nodes, func(i, j int) bool {
+		return cluster.Nodes[i].Name < cluster.Nodes[j].Name
+	})
+
+	return cluster, nil
+}
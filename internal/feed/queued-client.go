@@ -0,0 +1,165 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is the same error returned by the global request queue, so
+// errors.Is works the same no matter which queue turned the request away
+var ErrQueueFull = ErrRequestQueueFull
+
+var ErrQueueTimeout = errors.New("timed out waiting in request queue")
+
+// QueuedClient sends requests through base one at a time, or a few at a time
+// with WithQueueConcurrency, queueing the rest in the order they arrived. A
+// request counts as in flight until its response body is closed. Once
+// maxPending requests are waiting, further requests fail with ErrQueueFull
+// instead of piling up while the upstream is unreachable.
+type QueuedClient struct {
+	base    RequestDoer
+	queue   *requestQueue
+	timeout time.Duration
+
+	mu          sync.Mutex
+	outstanding int
+	idle        chan struct{}
+}
+
+type QueuedClientOption func(*QueuedClient)
+
+// WithQueueConcurrency sets how many requests are sent at once, defaults to 1
+func WithQueueConcurrency(n int) QueuedClientOption {
+	return func(c *QueuedClient) {
+		if n > 0 {
+			c.queue.limit = n
+		}
+	}
+}
+
+// WithQueueTimeout fails requests with ErrQueueTimeout if they've been
+// waiting for longer than d without being sent, the time it takes to get a
+// response once the request's been sent isn't counted
+func WithQueueTimeout(d time.Duration) QueuedClientOption {
+	return func(c *QueuedClient) {
+		c.timeout = d
+	}
+}
+
+// NewQueuedClient wraps base in a queue, a maxPending of 0 or less lets any
+// number of requests wait
+func NewQueuedClient(base RequestDoer, maxPending int, opts ...QueuedClientOption) *QueuedClient {
+	if base == nil {
+		base = defaultClient()
+	}
+
+	client := &QueuedClient{
+		base:  base,
+		queue: &requestQueue{limit: 1, maxQueued: max(maxPending, 0)},
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+func (c *QueuedClient) Do(request *http.Request) (*http.Response, error) {
+	c.begin()
+
+	ctx := request.Context()
+	cancel := func() {}
+
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, c.timeout, ErrQueueTimeout)
+	}
+
+	release, err := c.queue.acquire(ctx)
+	cancel()
+
+	if err != nil {
+		c.end()
+
+		if errors.Is(context.Cause(ctx), ErrQueueTimeout) && request.Context().Err() == nil {
+			return nil, fmt.Errorf("%w after %s", ErrQueueTimeout, c.timeout)
+		}
+
+		return nil, err
+	}
+
+	done := sync.OnceFunc(func() {
+		release()
+		c.end()
+	})
+
+	response, err := c.base.Do(request)
+
+	if err != nil {
+		done()
+		return nil, err
+	}
+
+	response.Body = &releaseOnCloseBody{ReadCloser: response.Body, release: done}
+
+	return response, nil
+}
+
+func (c *QueuedClient) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.outstanding == 0 {
+		c.idle = make(chan struct{})
+	}
+
+	c.outstanding++
+}
+
+func (c *QueuedClient) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.outstanding--
+
+	if c.outstanding == 0 {
+		close(c.idle)
+	}
+}
+
+// Drain waits until every request which is queued or in flight has finished,
+// requests made while draining are waited for as well
+func (c *QueuedClient) Drain(ctx context.Context) error {
+	c.mu.Lock()
+
+	if c.outstanding == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+
+	idle := c.idle
+	c.mu.Unlock()
+
+	select {
+	case <-idle:
+		// more requests may have come in right after the last one finished
+		return c.Drain(ctx)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// QueueDepth returns the number of requests waiting to be sent
+func (c *QueuedClient) QueueDepth() int {
+	return c.queue.depth()
+}
+
+// InFlight returns the number of requests which have been sent and
+// whose response body hasn't been closed yet
+func (c *QueuedClient) InFlight() int {
+	return c.queue.inFlight()
+}
@@ -0,0 +1,186 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newBlockingTestServer holds every request until the returned function is called
+func newBlockingTestServer(t *testing.T) (*httptest.Server, func()) {
+	t.Helper()
+
+	unblocked := make(chan struct{})
+	unblock := sync.OnceFunc(func() { close(unblocked) })
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-unblocked
+	})
+
+	t.Cleanup(unblock)
+
+	return server, unblock
+}
+
+func waitForCondition(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func sendQueuedRequest(client *QueuedClient, url string) error {
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	response, err := client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+func TestQueuedClientRejectsRequestsOnceFull(t *testing.T) {
+	server, unblock := newBlockingTestServer(t)
+	client := NewQueuedClient(server.Client(), 2)
+
+	errs := make(chan error, 3)
+
+	for range 3 {
+		go func() { errs <- sendQueuedRequest(client, server.URL) }()
+	}
+
+	waitForCondition(t, func() bool { return client.InFlight() == 1 && client.QueueDepth() == 2 })
+
+	startedAt := time.Now()
+
+	if err := sendQueuedRequest(client, server.URL); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	if elapsed := time.Since(startedAt); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the excess request to fail right away, took %v", elapsed)
+	}
+
+	unblock()
+
+	for range 3 {
+		if err := <-errs; err != nil {
+			t.Errorf("expected the queued requests to succeed, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := client.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.InFlight() != 0 || client.QueueDepth() != 0 {
+		t.Errorf("expected an empty queue, got %d in flight and %d queued", client.InFlight(), client.QueueDepth())
+	}
+}
+
+func TestQueuedClientTimeout(t *testing.T) {
+	server, _ := newBlockingTestServer(t)
+	client := NewQueuedClient(server.Client(), 0, WithQueueTimeout(50*time.Millisecond))
+
+	go sendQueuedRequest(client, server.URL)
+	waitForCondition(t, func() bool { return client.InFlight() == 1 })
+
+	if err := sendQueuedRequest(client, server.URL); !errors.Is(err, ErrQueueTimeout) {
+		t.Errorf("expected ErrQueueTimeout, got %v", err)
+	}
+}
+
+func TestQueuedClientDrainWaitsForInFlightRequests(t *testing.T) {
+	server, unblock := newBlockingTestServer(t)
+	client := NewQueuedClient(server.Client(), 0)
+
+	go sendQueuedRequest(client, server.URL)
+	waitForCondition(t, func() bool { return client.InFlight() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := client.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected draining to time out while a request is in flight, got %v", err)
+	}
+
+	unblock()
+
+	if err := client.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestQueueEvictsCancelledRequests(t *testing.T) {
+	queue := newRequestQueue(1, 1)
+	release, err := queue.acquire(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evictedBefore := GetRequestQueueStats().Evicted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+
+	go func() {
+		_, err := queue.acquire(ctx)
+		cancelled <- err
+	}()
+
+	waitForCondition(t, func() bool { return queue.depth() == 1 })
+
+	// the queue is full while the request is waiting
+	if _, err := queue.acquire(context.Background()); !errors.Is(err, ErrRequestQueueFull) {
+		t.Fatalf("expected ErrRequestQueueFull, got %v", err)
+	}
+
+	cancel()
+
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled request to give up, got %v", err)
+	}
+
+	if queue.depth() != 0 || GetRequestQueueStats().Evicted != evictedBefore+1 {
+		t.Errorf("expected the cancelled request to be evicted, depth %d", queue.depth())
+	}
+
+	// the freed space is used by the next request, which gets the slot once it's released
+	acquired := make(chan error, 1)
+
+	go func() {
+		next, err := queue.acquire(context.Background())
+
+		if err == nil {
+			next()
+		}
+
+		acquired <- err
+	}()
+
+	waitForCondition(t, func() bool { return queue.depth() == 1 })
+	release()
+
+	if err := <-acquired; err != nil {
+		t.Fatal(err)
+	}
+
+	if queue.inFlight() != 0 {
+		t.Errorf("expected no requests in flight, got %d", queue.inFlight())
+	}
+}
@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type workerPoolSuccess[I any, O any] struct {
+	// index of the input in the data given to the job
+	Index  int
+	Input  I
+	Output O
+}
+
+// workerPoolDoUntil runs task for each of data until n of them succeed, stopping the
+// rest the way withMinResults does. The successes are returned in the order of data
+// along with the inputs which produced them, with ErrPartialContent if fewer than n
+// succeeded or ErrNoContent if none did.
+func workerPoolDoUntil[I any, O any](
+	ctx context.Context,
+	task func(context.Context, I) (O, error),
+	data []I,
+	n int,
+	workers int,
+) ([]workerPoolSuccess[I, O], error) {
+	job := newJobWithContext(task, data).withWorkers(workers).withContext(ctx).withMinResults(n)
+	results, errs, err := workerPoolDo(job)
+
+	successes := make([]workerPoolSuccess[I, O], 0, min(n, len(data)))
+
+	for i := range results {
+		if errs[i] == nil {
+			successes = append(successes, workerPoolSuccess[I, O]{Index: i, Input: data[i], Output: results[i]})
+		}
+	}
+
+	if len(successes) >= n {
+		return successes, nil
+	}
+
+	if len(successes) == 0 {
+		return successes, fmt.Errorf("%w: no task succeeded: %v", ErrNoContent, firstTaskErr(errs, err))
+	}
+
+	return successes, fmt.Errorf("%w: %d of the %d needed tasks succeeded", ErrPartialContent, len(successes), n)
+}
+
+func firstTaskErr(errs []error, jobErr error) error {
+	for i := range errs {
+		if errs[i] != nil && !errors.Is(errs[i], errWorkerPoolTaskSkipped) {
+			return errs[i]
+		}
+	}
+
+	return jobErr
+}
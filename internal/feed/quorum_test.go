@@ -0,0 +1,98 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolDoUntilReturnsOnceEnoughSucceed(t *testing.T) {
+	var started, cancelled atomic.Int32
+
+	// the even inputs succeed right away while the odd ones only return once cancelled
+	task := func(ctx context.Context, n int) (int, error) {
+		if n%2 == 0 {
+			return n * 10, nil
+		}
+
+		started.Add(1)
+
+		select {
+		case <-ctx.Done():
+			cancelled.Add(1)
+			return 0, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return n * 10, nil
+		}
+	}
+
+	startedAt := time.Now()
+	successes, err := workerPoolDoUntil(context.Background(), task, []int{1, 2, 3, 4, 5, 6}, 2, 6)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(startedAt) > time.Second {
+		t.Errorf("expected to return once 2 tasks succeeded, took %v", time.Since(startedAt))
+	}
+
+	if len(successes) < 2 {
+		t.Fatalf("expected at least 2 successes, got %+v", successes)
+	}
+
+	for i, success := range successes {
+		if success.Input%2 != 0 || success.Output != success.Input*10 || success.Index != success.Input-1 {
+			t.Errorf("success %d doesn't match its input: %+v", i, success)
+		}
+
+		if i > 0 && successes[i-1].Index > success.Index {
+			t.Errorf("expected the successes in the order of the inputs, got %+v", successes)
+		}
+	}
+
+	waitForCondition(t, func() bool {
+		return started.Load() > 0 && cancelled.Load() == started.Load()
+	})
+}
+
+func TestWorkerPoolDoUntilNotEnoughSuccesses(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			return 0, nil
+		}
+
+		return 0, errFailed
+	}
+
+	successes, err := workerPoolDoUntil(context.Background(), task, []int{0, 1, 2}, 2, 1)
+
+	if !errors.Is(err, ErrPartialContent) || len(successes) != 1 || successes[0].Index != 0 {
+		t.Errorf("expected ErrPartialContent with 1 success, got %+v, %v", successes, err)
+	}
+
+	successes, err = workerPoolDoUntil(context.Background(), task, []int{1, 2}, 1, 2)
+
+	if !errors.Is(err, ErrNoContent) || len(successes) != 0 {
+		t.Errorf("expected ErrNoContent with the error of a task, got %+v, %v", successes, err)
+	}
+}
+
+func TestWorkerPoolDoUntilCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task := func(ctx context.Context, n int) (int, error) {
+		return n, ctx.Err()
+	}
+
+	successes, err := workerPoolDoUntil(ctx, task, []int{1, 2, 3}, 1, 1)
+
+	if !errors.Is(err, ErrNoContent) || len(successes) != 0 {
+		t.Errorf("expected ErrNoContent for the cancelled context, got %+v, %v", successes, err)
+	}
+}
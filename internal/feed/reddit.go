@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"net/http"
@@ -30,7 +31,7 @@ type subredditResponseJson struct {
 	} `json:"data"`
 }
 
-func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate, requestUrlTemplate string) (ForumPosts, error) {
+func FetchSubredditPosts(ctx context.Context, subreddit, sort, topPeriod, search, commentsUrlTemplate, requestUrlTemplate string) (ForumPosts, error) {
 	query := url.Values{}
 	var requestUrl string
 
@@ -53,7 +54,7 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 		requestUrl = strings.ReplaceAll(requestUrlTemplate, "{REQUEST-URL}", requestUrl)
 	}
 
-	request, err := http.NewRequest("GET", requestUrl, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 
 	if err != nil {
 		return nil, err
@@ -61,7 +62,7 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 
 	// Required to increase rate limit, otherwise Reddit randomly returns 429 even after just 2 requests
 	addBrowserUserAgentHeader(request)
-	responseJson, err := decodeJsonFromRequest[subredditResponseJson](defaultClient, request)
+	responseJson, err := decodeJsonFromRequest[subredditResponseJson](defaultClient(), request)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,140 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestError is returned by the decode functions when fetching or decoding
+// a response fails, so that callers can tell which request it was about. It
+// wraps the original error, so errors.Is keeps working as before.
+type RequestError struct {
+	Method string
+	// with any password removed
+	URL string
+	// the headers the request was sent with, with the values of those which
+	// may contain credentials redacted
+	Header  http.Header
+	Elapsed time.Duration
+	Err     error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("fetching %s: %v", e.URL, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func ExtractRequestError(err error) (*RequestError, bool) {
+	var requestErr *RequestError
+
+	if errors.As(err, &requestErr) {
+		return requestErr, true
+	}
+
+	return nil, false
+}
+
+// HTTPError is returned when a response has a status code which isn't accepted,
+// so that callers can tell a missing resource apart from an authentication failure
+// without having to parse the message. It ends up wrapped in a RequestError when
+// returned by the decode functions, errors.As finds it either way.
+type HTTPError struct {
+	StatusCode int
+	// with any password removed
+	URL string
+	// the start of the response body, for showing what the server had to say
+	Body string
+	// the error extracted from the body by the parser given through
+	// WithErrorBodyParser, if there was one and it recognized the body
+	Detail error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Detail != nil {
+		return fmt.Sprintf("unexpected status code %d for %s: %v", e.StatusCode, e.URL, e.Detail)
+	}
+
+	return fmt.Sprintf("unexpected status code %d for %s, response: %s", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Detail
+}
+
+func ExtractHTTPError(err error) (*HTTPError, bool) {
+	var httpErr *HTTPError
+
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+
+	return nil, false
+}
+
+// HasStatusCode reports whether err was caused by a response with the given status code
+func HasStatusCode(err error, statusCode int) bool {
+	httpErr, ok := ExtractHTTPError(err)
+	return ok && httpErr.StatusCode == statusCode
+}
+
+var sensitiveHeaderNames = []string{"auth", "cookie", "token", "key", "secret", "password", "session"}
+
+// isSensitiveHeader reports whether the header may contain credentials, such as
+// Authorization, Cookie or the X-Api-Key used by many APIs
+func isSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+
+	for _, part := range sensitiveHeaderNames {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sanitizeHeaders(header http.Header) http.Header {
+	sanitized := make(http.Header, len(header))
+
+	for name, values := range header {
+		if !isSensitiveHeader(name) {
+			sanitized[name] = append([]string(nil), values...)
+			continue
+		}
+
+		sanitized[name] = make([]string, len(values))
+
+		for i := range values {
+			sanitized[name][i] = "[REDACTED]"
+		}
+	}
+
+	return sanitized
+}
+
+// wrapRequestError is deferred by the decode functions with a pointer to their
+// error, errors which already carry the context of a request are left as is so
+// that decode functions calling each other don't wrap twice
+func wrapRequestError(request *http.Request, startedAt time.Time, err *error) {
+	if *err == nil {
+		return
+	}
+
+	if _, ok := ExtractRequestError(*err); ok {
+		return
+	}
+
+	*err = &RequestError{
+		Method:  request.Method,
+		URL:     request.URL.Redacted(),
+		Header:  sanitizeHeaders(request.Header),
+		Elapsed: time.Since(startedAt),
+		Err:     *err,
+	}
+}
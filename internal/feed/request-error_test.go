@@ -0,0 +1,105 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newRequestErrorTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.Error(w, "no such thing", http.StatusNotFound)
+		case "/invalid":
+			w.Write([]byte(`{"broken":`))
+		default:
+			w.Write([]byte(`<item></item>`))
+		}
+	})
+}
+
+func TestRequestErrorPreservesRequestContext(t *testing.T) {
+	server := newRequestErrorTestServer(t)
+
+	target, _ := url.Parse(server.URL + "/missing?page=2")
+	target.User = url.UserPassword("user", "hunter2")
+
+	request, _ := http.NewRequest(http.MethodGet, target.String(), nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	request.Header.Set("X-Api-Key", "secret-key")
+	request.Header.Set("Accept", "application/json")
+
+	_, err := decodeJsonFromRequest[map[string]any](server.Client(), request)
+	requestErr, ok := ExtractRequestError(err)
+
+	if !ok {
+		t.Fatalf("expected a RequestError, got %v", err)
+	}
+
+	if requestErr.Method != http.MethodGet || requestErr.URL != target.Redacted() || requestErr.Elapsed <= 0 {
+		t.Errorf("unexpected request context: %s %s after %v", requestErr.Method, requestErr.URL, requestErr.Elapsed)
+	}
+
+	if strings.Contains(err.Error(), "hunter2") || !strings.HasPrefix(err.Error(), "fetching "+target.Redacted()+": ") {
+		t.Errorf("expected the message to start with the redacted URL, got %q", err.Error())
+	}
+
+	if requestErr.Header.Get("Authorization") != "[REDACTED]" || requestErr.Header.Get("X-Api-Key") != "[REDACTED]" {
+		t.Errorf("expected credentials to be redacted, got %v", requestErr.Header)
+	}
+
+	if requestErr.Header.Get("Accept") != "application/json" {
+		t.Errorf("expected other headers to be kept, got %v", requestErr.Header)
+	}
+
+	if request.Header.Get("Authorization") != "Bearer secret-token" {
+		t.Error("expected the headers of the request itself to be left alone")
+	}
+
+	if !HasStatusCode(err, http.StatusNotFound) {
+		t.Errorf("expected the HTTPError to be reachable through the RequestError, got %v", err)
+	}
+
+	if _, nested := ExtractRequestError(requestErr.Err); nested {
+		t.Error("expected the error to only be wrapped once")
+	}
+}
+
+func TestRequestErrorKeepsUnderlyingErrors(t *testing.T) {
+	server := newRequestErrorTestServer(t)
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/invalid", nil)
+	_, err := decodeJsonFromRequest[map[string]any](server.Client(), request)
+
+	if requestErr, ok := ExtractRequestError(err); !ok || requestErr.URL != server.URL+"/invalid" {
+		t.Errorf("expected a RequestError for the invalid body, got %v", err)
+	}
+
+	var syntaxErr *json.SyntaxError
+
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the decoding error to be kept, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	request, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/item", nil)
+	_, err = decodeXmlFromRequest[struct{}](server.Client(), request)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected errors.Is to see through the RequestError, got %v", err)
+	}
+
+	if requestErr, ok := ExtractRequestError(err); !ok || requestErr.URL != server.URL+"/item" {
+		t.Errorf("expected a RequestError for the XML request, got %v", err)
+	}
+}
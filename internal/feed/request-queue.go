@@ -0,0 +1,173 @@
+package feed
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrRequestQueueFull = errors.New("too many requests waiting to be sent")
+
+// requestQueue limits how many requests can be in flight at once, requests
+// over the limit wait in order of arrival. Waiting requests whose context has
+// been cancelled or whose deadline has passed are evicted before they're given
+// a slot, so that during a refresh spike capacity isn't spent on requests that
+// nobody is waiting for anymore.
+type requestQueue struct {
+	mu        sync.Mutex
+	limit     int
+	maxQueued int
+	active    int
+	waiting   list.List
+}
+
+type queuedRequest struct {
+	ctx     context.Context
+	ready   chan struct{}
+	evicted bool
+}
+
+var requestQueueStats struct {
+	queued  atomic.Int64
+	evicted atomic.Uint64
+}
+
+type RequestQueueStats struct {
+	// the number of requests currently waiting for a slot in any queue
+	Depth int64
+	// the number of requests which gave up or expired while waiting
+	Evicted uint64
+}
+
+func GetRequestQueueStats() RequestQueueStats {
+	return RequestQueueStats{
+		Depth:   requestQueueStats.queued.Load(),
+		Evicted: requestQueueStats.evicted.Load(),
+	}
+}
+
+// a limit of 0 means no limit, in which case nil is returned
+func newRequestQueue(limit, maxQueued int) *requestQueue {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &requestQueue{limit: limit, maxQueued: maxQueued}
+}
+
+func isQueuedRequestStale(ctx context.Context, now time.Time) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	return ok && !now.Before(deadline)
+}
+
+// acquire blocks until the request can be sent, the returned function must be
+// called once it's done and is safe to call more than once
+func (q *requestQueue) acquire(ctx context.Context) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	q.mu.Lock()
+	q.evictStale()
+
+	if q.active < q.limit && q.waiting.Len() == 0 {
+		q.active++
+		q.mu.Unlock()
+
+		return sync.OnceFunc(q.release), nil
+	}
+
+	if q.maxQueued > 0 && q.waiting.Len() >= q.maxQueued {
+		q.mu.Unlock()
+		return nil, ErrRequestQueueFull
+	}
+
+	request := &queuedRequest{ctx: ctx, ready: make(chan struct{})}
+	element := q.waiting.PushBack(request)
+	requestQueueStats.queued.Add(1)
+	q.mu.Unlock()
+
+	select {
+	case <-request.ready:
+		return sync.OnceFunc(q.release), nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-request.ready:
+		// the slot was handed over at the same time as the context
+		// got cancelled, pass it on to the next request instead
+		q.releaseLocked()
+	default:
+		if !request.evicted {
+			q.waiting.Remove(element)
+			requestQueueStats.queued.Add(-1)
+			requestQueueStats.evicted.Add(1)
+		}
+	}
+
+	return nil, ctx.Err()
+}
+
+func (q *requestQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.releaseLocked()
+}
+
+func (q *requestQueue) releaseLocked() {
+	q.active--
+	q.evictStale()
+
+	if front := q.waiting.Front(); front != nil && q.active < q.limit {
+		q.waiting.Remove(front)
+		requestQueueStats.queued.Add(-1)
+		q.active++
+		close(front.Value.(*queuedRequest).ready)
+	}
+}
+
+// evictStale drops waiting requests which are no longer worth sending, they
+// return on their own once their context is done so they're only unlinked here
+func (q *requestQueue) evictStale() {
+	now := time.Now()
+
+	for element := q.waiting.Front(); element != nil; {
+		next := element.Next()
+
+		if request := element.Value.(*queuedRequest); isQueuedRequestStale(request.ctx, now) {
+			request.evicted = true
+			q.waiting.Remove(element)
+			requestQueueStats.queued.Add(-1)
+			requestQueueStats.evicted.Add(1)
+		}
+
+		element = next
+	}
+}
+
+func (q *requestQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.waiting.Len()
+}
+
+func (q *requestQueue) inFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.active
+}
@@ -1,38 +1,107 @@
 package feed
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
 )
 
 const defaultClientTimeout = 5 * time.Second
 
-var (
-	insecureClientTransport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
+type defaultClientSet struct {
+	transport         *http.Transport
+	insecureTransport *http.Transport
+	client            *http.Client
+	insecureClient    *http.Client
+}
 
-	defaultTransport = &http.Transport{}
+func newDefaultClientSet() *defaultClientSet {
+	set := &defaultClientSet{
+		transport: &http.Transport{
+			TLSClientConfig: &tls.Config{ClientSessionCache: tlsSessionCacheFor("")},
+			DialContext:     dialContextWithDNSFailureCache(newDefaultDialer().DialContext),
+		},
+		insecureTransport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ClientSessionCache: tlsSessionCacheFor("")},
+			DialContext:     dialContextWithDNSFailureCache(newDefaultDialer().DialContext),
+		},
+	}
 
-	defaultClient = &http.Client{
+	set.client = &http.Client{
 		Timeout:   defaultClientTimeout,
-		Transport: defaultTransport,
+		Transport: withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(set.transport))),
 	}
 
-	defaultInsecureClient = &http.Client{
+	set.insecureClient = &http.Client{
 		Timeout:   defaultClientTimeout,
-		Transport: insecureClientTransport,
+		Transport: withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(set.insecureTransport))),
 	}
 
+	return set
+}
+
+// created on first use rather than when the package is loaded, see ResetDefaultClients
+var defaultClients = sync.OnceValue(newDefaultClientSet)
+
+func defaultTransport() *http.Transport {
+	return defaultClients().transport
+}
+
+func insecureClientTransport() *http.Transport {
+	return defaultClients().insecureTransport
+}
+
+func defaultClient() *http.Client {
+	return defaultClients().client
+}
+
+func defaultInsecureClient() *http.Client {
+	return defaultClients().insecureClient
+}
+
+// ResetDefaultClients replaces the default clients with new ones as they are
+// before SetProxy or EnableHTTPDebugLogging are called and forgets the clients
+// created by GetClient and GetClientWithOptions, so that tests which change
+// them don't affect one another. It must not be called while requests are
+// being made.
+func ResetDefaultClients() {
+	defaultClients = sync.OnceValue(newDefaultClientSet)
+	globalProxyURL = nil
+	httpDebugLogOutput = nil
+	httpDebugLogOptions = nil
+
+	clientCache.Range(func(key, _ any) bool {
+		clientCache.Delete(key)
+		return true
+	})
+
+	torIsolatedClients.Lock()
+	torIsolatedClients.options = nil
+	torIsolatedClients.Unlock()
+}
+
+var (
 	clientCache = sync.Map{}
+
+	// set through SetProxy, needed for applying the proxy to transports
+	// created later on which can't be cloned from the default ones
+	globalProxyURL *url.URL
 )
 
 type RequestDoer interface {
@@ -46,28 +115,42 @@ func GetClient(proxyURL string, insecure bool) (*http.Client, error) {
 		return client.(*http.Client), nil
 	}
 
+	transport, err := newProxyTransport(proxyURL, insecure, newDefaultDialer())
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout:   defaultClientTimeout,
+		Transport: withHTTPDebugLogging(withHostConcurrencyLimit(withContentDecoding(withWireBandwidthMetrics(transport)))),
+	}
+
+	clientCache.Store(proxyURL, client)
+	return client, nil
+}
+
+func newProxyTransport(proxyURL string, insecure bool, dialer *net.Dialer) (*http.Transport, error) {
 	proxyURLParsed, err := url.Parse(proxyURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid proxy URL: %w", err)
 	}
 
 	transport := &http.Transport{
-		Proxy: http.ProxyURL(proxyURLParsed),
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: insecure,
+			ClientSessionCache: tlsSessionCacheFor(""),
 		},
+		DialContext:         dialContextWithDNSFailureCache(dialer.DialContext),
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 100,
 		IdleConnTimeout:     90 * time.Second,
 	}
 
-	client := &http.Client{
-		Timeout:   defaultClientTimeout,
-		Transport: transport,
+	if err = setTransportProxy(transport, proxyURLParsed, dialer); err != nil {
+		return nil, err
 	}
 
-	clientCache.Store(proxyURL, client)
-	return client, nil
+	return transport, nil
 }
 
 func SetProxy(proxyURL string) error {
@@ -76,18 +159,27 @@ func SetProxy(proxyURL string) error {
 		return fmt.Errorf("invalid proxy URL: %w", err)
 	}
 
-	setupTransport := func(transport *http.Transport, insecureSkipVerify bool) {
-		transport.Proxy = http.ProxyURL(proxyURLParsed)
+	globalProxyURL = proxyURLParsed
+
+	setupTransport := func(transport *http.Transport, insecureSkipVerify bool) error {
+		if err := setTransportProxy(transport, proxyURLParsed, newDefaultDialer()); err != nil {
+			return err
+		}
 		if insecureSkipVerify {
 			if transport.TLSClientConfig == nil {
 				transport.TLSClientConfig = &tls.Config{}
 			}
 			transport.TLSClientConfig.InsecureSkipVerify = true
 		}
+		return nil
 	}
 
-	setupTransport(defaultTransport, false)
-	setupTransport(insecureClientTransport, true)
+	if err = setupTransport(defaultTransport(), false); err != nil {
+		return err
+	}
+	if err = setupTransport(insecureClientTransport(), true); err != nil {
+		return err
+	}
 
 	_, err = GetClient(proxyURL, false)
 	if err != nil {
@@ -115,70 +207,304 @@ func truncateString(s string, maxLen int) string {
 	return s
 }
 
-func decodeJsonFromRequest[T any](client RequestDoer, request *http.Request) (T, error) {
+const maxResponseBodySize = 10 * 1024 * 1024
+
+var ErrResponseTooLarge = errors.New("response body exceeds size limit")
+
+// readResponseBodyInto reads the body into buffer, which avoids the repeated
+// growing and copying that io.ReadAll does when the buffer comes from the pool
+func readResponseBodyInto(buffer *bytes.Buffer, response *http.Response) error {
+	if response.ContentLength > 0 && response.ContentLength <= maxResponseBodySize {
+		buffer.Grow(int(response.ContentLength) + bytes.MinRead)
+	}
+
+	if _, err := buffer.ReadFrom(io.LimitReader(response.Body, maxResponseBodySize+1)); err != nil {
+		return err
+	}
+
+	if buffer.Len() > maxResponseBodySize {
+		return fmt.Errorf("%w of %d bytes", ErrResponseTooLarge, maxResponseBodySize)
+	}
+
+	return nil
+}
+
+func isStatusOK(statusCode int) bool {
+	return statusCode == http.StatusOK
+}
+
+func fetchBodyFromRequest(client RequestDoer, request *http.Request) (*http.Response, []byte, error) {
+	return fetchBodyFromRequestWithStatus(client, request, isStatusOK)
+}
+
+func fetchBodyFromRequestWithStatus(client RequestDoer, request *http.Request, isAccepted func(int) bool) (*http.Response, []byte, error) {
+	buffer := getBodyBuffer()
+	defer putBodyBuffer(buffer)
+
+	response, err := fetchBodyIntoBuffer(client, request, isAccepted, buffer)
+
+	if response == nil || (err != nil && buffer.Len() == 0) {
+		return response, nil, err
+	}
+
+	return response, bytes.Clone(buffer.Bytes()), err
+}
+
+// fetchBodyIntoBuffer is like fetchBodyFromRequestWithStatus except that the
+// body is read into buffer, which callers that don't hold on to the body
+// can take from the pool and put back once they're done decoding it
+func fetchBodyIntoBuffer(client RequestDoer, request *http.Request, isAccepted func(int) bool, buffer *bytes.Buffer) (*http.Response, error) {
+	done, err := beginWork()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer done()
+
+	request, cancel := withShutdownCancellation(request)
+	defer cancel()
+
+	cacheKey, cacheable := notFoundCacheKey(request)
+
+	if cacheable {
+		if cachedErr, ok := notFoundResponses.get(cacheKey); ok {
+			return nil, fmt.Errorf("%w: %w", ErrRecentlyNotFound, cachedErr)
+		}
+	}
+
 	response, err := client.Do(request)
-	var result T
 
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 
 	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	err = readResponseBodyInto(buffer, response)
+	recordBandwidth(request, buffer.Len())
+
+	if err != nil {
+		buffer.Reset()
+		return response, err
+	}
+
+	if !isAccepted(response.StatusCode) {
+		body := buffer.Bytes()
+
+		err = &HTTPError{
+			StatusCode: response.StatusCode,
+			URL:        request.URL.Redacted(),
+			// a rune is at most 4 bytes, so only that much has to be converted
+			Body: truncateString(string(body[:min(len(body), 256*utf8.UTFMax)]), 256),
+		}
+
+		if response.StatusCode == http.StatusNotFound && cacheable {
+			notFoundResponses.add(cacheKey, err)
+		}
+
+		return response, err
+	}
+
+	return response, nil
+}
+
+var ErrInvalidResponse = errors.New("response failed validation")
+
+type decodeOptions struct {
+	validators           []func(any) error
+	responseChecks       []func(*http.Response) error
+	resolveRelationships bool
+	apiVersionHeader     string
+	retryPolicy          *RetryPolicy
+	isAcceptedStatus     func(int) bool
+	keyConvention        *NamingConvention
+	jsonMode             JSONMode
+	errorBodyParser      func(statusCode int, body []byte) error
+	lastGoodMaxAge       time.Duration
+	lastGoodKeyPart      string
+	softDeadline         time.Duration
+	// the validators of the response being decoded, kept along with its value
+	etag         string
+	lastModified string
+}
+
+type DecodeOption func(*decodeOptions)
+
+// WithValidation runs validate on the decoded response, allowing responses which
+// decoded fine but are semantically wrong (such as {"ok": false} or an empty list
+// where data is expected) to be turned into an error
+func WithValidation[T any](validate func(T) error) DecodeOption {
+	return func(o *decodeOptions) {
+		o.validators = append(o.validators, func(value any) error {
+			// validators for a different type than the one being decoded don't apply
+			if typed, ok := value.(T); ok {
+				return validate(typed)
+			}
+
+			return nil
+		})
+	}
+}
+
+// WithAcceptedStatus replaces the default of only treating 200 as a successful
+// response, for APIs which respond with something like 202 or 203 instead
+func WithAcceptedStatus(isAccepted func(statusCode int) bool) DecodeOption {
+	return func(o *decodeOptions) {
+		o.isAcceptedStatus = isAccepted
+	}
+}
+
+func WithAcceptedStatusCodes(statusCodes ...int) DecodeOption {
+	return WithAcceptedStatus(func(statusCode int) bool {
+		return slices.Contains(statusCodes, statusCode)
+	})
+}
+
+func newDecodeOptions(opts []DecodeOption) *decodeOptions {
+	options := &decodeOptions{
+		isAcceptedStatus: isStatusOK,
+		lastGoodMaxAge:   time.Duration(lastGoodMaxAgeByDefault.Load()),
+		softDeadline:     time.Duration(softDeadlineByDefault.Load()),
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+func (o *decodeOptions) fetch(client RequestDoer, request *http.Request) (*http.Response, []byte, error) {
+	buffer := getBodyBuffer()
+	defer putBodyBuffer(buffer)
+
+	response, err := o.fetchIntoBuffer(client, request, buffer)
+
+	if response == nil || (err != nil && buffer.Len() == 0) {
+		return response, nil, err
+	}
+
+	return response, bytes.Clone(buffer.Bytes()), err
+}
+
+func (o *decodeOptions) fetchIntoBuffer(client RequestDoer, request *http.Request, buffer *bytes.Buffer) (*http.Response, error) {
+	request = o.addConditionalHeaders(withAPIVersionHeader(request, o.apiVersionHeader))
+
+	var response *http.Response
+	var err error
+
+	if o.retryPolicy != nil {
+		response, err = fetchBodyIntoBufferWithRetry(client, request, *o.retryPolicy, o.isAcceptedStatus, buffer)
+	} else {
+		response, err = fetchBodyIntoBuffer(client, request, o.isAcceptedStatus, buffer)
+	}
+
+	if err != nil {
+		return response, o.parseErrorBody(err, buffer.Bytes())
+	}
+
+	o.recordValidators(response)
+
+	for _, check := range o.responseChecks {
+		if err = check(response); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+func (o *decodeOptions) validate(value any, request *http.Request) error {
+	for _, validate := range o.validators {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("%w for %s: %w", ErrInvalidResponse, request.URL, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeJsonFromRequest[T any](client RequestDoer, request *http.Request, opts ...DecodeOption) (T, error) {
+	options := newDecodeOptions(opts)
+
+	return decodeWithSoftDeadline(options, request, func(request *http.Request) (T, error) {
+		return decodeJsonWithOptions[T](client, request, options)
+	})
+}
+
+func decodeJsonWithOptions[T any](client RequestDoer, request *http.Request, options *decodeOptions) (result T, err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+	defer useLastGood(options, request, &result, &err)
+
+	// the body is only needed until it's been unmarshaled, which copies
+	// everything it keeps, so the buffer can go straight back to the pool
+	buffer := getBodyBuffer()
+	defer putBodyBuffer(buffer)
+
+	response, err := options.fetchIntoBuffer(client, request, buffer)
 
 	if err != nil {
 		return result, err
 	}
 
-	if response.StatusCode != http.StatusOK {
-		return result, fmt.Errorf(
-			"unexpected status code %d for %s, response: %s",
-			response.StatusCode,
-			request.URL,
-			truncateString(string(body), 256),
-		)
+	return decodeJsonBody[T](options, request, response, buffer.Bytes())
+}
+
+func decodeJsonBody[T any](options *decodeOptions, request *http.Request, response *http.Response, body []byte) (result T, err error) {
+	// statuses such as 202 and 204 which may have been accepted usually come without a body
+	if len(body) == 0 && response.StatusCode != http.StatusOK {
+		return result, options.validate(result, request)
 	}
 
-	err = json.Unmarshal(body, &result)
+	if body, err = options.normalizeKeys(options.prepareJSON(body)); err != nil {
+		return result, err
+	}
+
+	err = options.unmarshalJSON(body, &result)
 
 	if err != nil {
 		return result, err
 	}
 
+	if err = options.validate(result, request); err != nil {
+		return result, err
+	}
+
 	return result, nil
 }
 
-func decodeJsonFromRequestTask[T any](client RequestDoer) func(*http.Request) (T, error) {
+func decodeJsonFromRequestTask[T any](client RequestDoer, opts ...DecodeOption) func(*http.Request) (T, error) {
 	return func(request *http.Request) (T, error) {
-		return decodeJsonFromRequest[T](client, request)
+		return decodeJsonFromRequest[T](client, request, opts...)
 	}
 }
 
-// TODO: tidy up, these are a copy of the above but with a line changed
-func decodeXmlFromRequest[T any](client RequestDoer, request *http.Request) (T, error) {
-	response, err := client.Do(request)
-	var result T
+func decodeXmlFromRequest[T any](client RequestDoer, request *http.Request, opts ...DecodeOption) (T, error) {
+	options := newDecodeOptions(opts)
 
-	if err != nil {
-		return result, err
-	}
+	return decodeWithSoftDeadline(options, request, func(request *http.Request) (T, error) {
+		return decodeXmlWithOptions[T](client, request, options)
+	})
+}
 
-	defer response.Body.Close()
+func decodeXmlWithOptions[T any](client RequestDoer, request *http.Request, options *decodeOptions) (result T, err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+	defer useLastGood(options, request, &result, &err)
+
+	buffer := getBodyBuffer()
+	defer putBodyBuffer(buffer)
 
-	body, err := io.ReadAll(response.Body)
+	response, err := options.fetchIntoBuffer(client, request, buffer)
 
 	if err != nil {
 		return result, err
 	}
 
-	if response.StatusCode != http.StatusOK {
-		return result, fmt.Errorf(
-			"unexpected status code %d for %s, response: %s",
-			response.StatusCode,
-			request.URL,
-			truncateString(string(body), 256),
-		)
+	body := buffer.Bytes()
+
+	if len(body) == 0 && response.StatusCode != http.StatusOK {
+		return result, options.validate(result, request)
 	}
 
 	err = xml.Unmarshal(body, &result)
@@ -187,12 +513,65 @@ func decodeXmlFromRequest[T any](client RequestDoer, request *http.Request) (T,
 		return result, err
 	}
 
+	if err = options.validate(result, request); err != nil {
+		return result, err
+	}
+
 	return result, nil
 }
 
-func decodeXmlFromRequestTask[T any](client RequestDoer) func(*http.Request) (T, error) {
+func decodeXmlFromRequestTask[T any](client RequestDoer, opts ...DecodeOption) func(*http.Request) (T, error) {
 	return func(request *http.Request) (T, error) {
-		return decodeXmlFromRequest[T](client, request)
+		return decodeXmlFromRequest[T](client, request, opts...)
+	}
+}
+
+// decodeTextFromRequest returns the body of the response transcoded to UTF-8.
+// The charset declared by the server in the Content-Type header always takes
+// precedence, charsetOverride is used for servers that don't declare one and
+// if neither is available the charset is detected from the contents.
+func decodeTextFromRequest(client RequestDoer, request *http.Request, charsetOverride string) (_ string, err error) {
+	defer wrapRequestError(request, time.Now(), &err)
+
+	response, body, err := fetchBodyFromRequest(client, request)
+
+	if err != nil {
+		return "", err
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	label := charsetOverride
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		label = params["charset"]
+	}
+
+	if label == "" {
+		if utf8.Valid(body) {
+			return string(body), nil
+		}
+
+		_, label, _ = charset.DetermineEncoding(body, contentType)
+	}
+
+	encoding, _ := charset.Lookup(label)
+
+	if encoding == nil {
+		return "", fmt.Errorf("unsupported charset %q for %s", label, request.URL)
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(body)
+
+	if err != nil {
+		return "", fmt.Errorf("could not decode response as %s: %w", label, err)
+	}
+
+	return string(decoded), nil
+}
+
+func decodeTextFromRequestTask(client RequestDoer, charsetOverride string) func(*http.Request) (string, error) {
+	return func(request *http.Request) (string, error) {
+		return decodeTextFromRequest(client, request, charsetOverride)
 	}
 }
 
@@ -204,17 +583,45 @@ type workerPoolTask[I any, O any] struct {
 }
 
 type workerPoolJob[I any, O any] struct {
-	data    []I
-	workers int
-	task    func(I) (O, error)
-	ctx     context.Context
+	data       []I
+	workers    int
+	task       func(context.Context, I) (O, error)
+	ctx        context.Context
+	minResults int
 }
 
-const defaultNumWorkers = 10
+var errWorkerPoolTaskSkipped = errors.New("task skipped since enough results were collected")
+
+// how many tasks a job runs at once unless it sets its own number of workers
+var defaultNumWorkers atomic.Int64
+
+func init() {
+	defaultNumWorkers.Store(10)
+}
+
+// SetDefaultWorkerCount changes how many tasks jobs run at once when they don't
+// set their own number of workers, clamped to at least 1. Jobs mostly wait on
+// requests, so this can be well above the number of CPUs, with the limit usually
+// being what the APIs being queried are fine with rather than the machine.
+func SetDefaultWorkerCount(n int) {
+	defaultNumWorkers.Store(int64(max(n, 1)))
+}
+
+// SetDefaultWorkerCountFromCPU sets the default number of workers to the number of
+// CPUs times multiplier, clamped to at least 1. A multiplier of 1 suits jobs which
+// are mostly busy with the CPU, such as parsing, while ones which mostly wait on
+// the network can use several times more workers than there are CPUs.
+func SetDefaultWorkerCountFromCPU(multiplier float64) {
+	SetDefaultWorkerCount(int(float64(runtime.NumCPU()) * multiplier))
+}
+
+func DefaultWorkerCount() int {
+	return int(defaultNumWorkers.Load())
+}
 
 func (job *workerPoolJob[I, O]) withWorkers(workers int) *workerPoolJob[I, O] {
 	if workers == 0 {
-		job.workers = defaultNumWorkers
+		job.workers = DefaultWorkerCount()
 	} else if workers > len(job.data) {
 		job.workers = len(job.data)
 	} else {
@@ -224,17 +631,38 @@ func (job *workerPoolJob[I, O]) withWorkers(workers int) *workerPoolJob[I, O] {
 	return job
 }
 
-// func (job *workerPoolJob[I, O]) withContext(ctx context.Context) *workerPoolJob[I, O] {
-// 	if ctx != nil {
-// 		job.ctx = ctx
-// 	}
+func (job *workerPoolJob[I, O]) withContext(ctx context.Context) *workerPoolJob[I, O] {
+	if ctx != nil {
+		job.ctx = ctx
+	}
+
+	return job
+}
+
+// withMinResults has workerPoolDo return as soon as n tasks have succeeded rather
+// than waiting for all of them. Tasks which haven't started yet are skipped and
+// the ones already running are no longer waited on, their results are dropped and
+// the context given to them by newJobWithContext is cancelled.
+func (job *workerPoolJob[I, O]) withMinResults(n int) *workerPoolJob[I, O] {
+	job.minResults = max(n, 0)
+	return job
+}
 
-// 	return job
-// }
+func (job *workerPoolJob[I, O]) withFirstSuccess() *workerPoolJob[I, O] {
+	return job.withMinResults(1)
+}
 
 func newJob[I any, O any](task func(I) (O, error), data []I) *workerPoolJob[I, O] {
+	return newJobWithContext(func(_ context.Context, input I) (O, error) {
+		return task(input)
+	}, data)
+}
+
+// newJobWithContext is like newJob except that task is given a context derived from
+// the one of the job, which is cancelled once workerPoolDo returns
+func newJobWithContext[I any, O any](task func(context.Context, I) (O, error), data []I) *workerPoolJob[I, O] {
 	return &workerPoolJob[I, O]{
-		workers: defaultNumWorkers,
+		workers: DefaultWorkerCount(),
 		task:    task,
 		data:    data,
 		ctx:     context.Background(),
@@ -249,8 +677,18 @@ func workerPoolDo[I any, O any](job *workerPoolJob[I, O]) ([]O, []error, error)
 		return results, errs, nil
 	}
 
+	done, err := beginWork()
+
+	if err != nil {
+		return results, errs, err
+	}
+
+	ctx, cancel := context.WithCancel(job.ctx)
+	defer cancel()
+
 	tasksQueue := make(chan *workerPoolTask[I, O])
 	resultsQueue := make(chan *workerPoolTask[I, O])
+	stop := make(chan struct{})
 
 	var wg sync.WaitGroup
 
@@ -260,26 +698,30 @@ func workerPoolDo[I any, O any](job *workerPoolJob[I, O]) ([]O, []error, error)
 			defer wg.Done()
 
 			for t := range tasksQueue {
-				t.output, t.err = job.task(t.input)
+				t.output, t.err = runTaskRecoveringPanic(func(input I) (O, error) {
+					return job.task(ctx, input)
+				}, t.input)
 				resultsQueue <- t
 			}
 		}()
 	}
 
-	var err error
-
 	go func() {
 	loop:
 		for i := range job.data {
 			select {
-			default:
-				tasksQueue <- &workerPoolTask[I, O]{
-					index: i,
-					input: job.data[i],
-				}
+			case tasksQueue <- &workerPoolTask[I, O]{
+				index: i,
+				input: job.data[i],
+			}:
 			case <-job.ctx.Done():
 				err = job.ctx.Err()
 				break loop
+			case <-lifecycle.ctx.Done():
+				err = ErrShuttingDown
+				break loop
+			case <-stop:
+				break loop
 			}
 		}
 
@@ -288,9 +730,57 @@ func workerPoolDo[I any, O any](job *workerPoolJob[I, O]) ([]O, []error, error)
 		close(resultsQueue)
 	}()
 
+	completed := make([]bool, len(job.data))
+	collected, succeeded := 0, 0
+
 	for task := range resultsQueue {
 		errs[task.index] = task.err
 		results[task.index] = task.output
+		completed[task.index] = true
+		collected++
+
+		if task.err == nil {
+			succeeded++
+		}
+
+		if job.minResults == 0 || succeeded < job.minResults || collected == len(job.data) {
+			continue
+		}
+
+		close(stop)
+		cancel()
+
+		// the workers which are still running have to be able to hand off their
+		// results, the job isn't done as far as Shutdown is concerned until they have
+		go func() {
+			for range resultsQueue {
+			}
+
+			done()
+		}()
+
+		for i := range completed {
+			if !completed[i] {
+				errs[i] = errWorkerPoolTaskSkipped
+			}
+		}
+
+		return results, errs, fmt.Errorf(
+			"%w: returned after %d of %d tasks completed",
+			ErrPartialContent, collected, len(job.data),
+		)
+	}
+
+	done()
+
+	// tasks which were never started because the job was cancelled or the
+	// package is shutting down would otherwise look like they succeeded
+	if err != nil {
+		for i := range completed {
+			if !completed[i] {
+				errs[i] = err
+			}
+		}
 	}
 
 	return results, errs, err
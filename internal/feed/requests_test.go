@@ -0,0 +1,252 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolDoReturnsOnceMinResultsAreReached(t *testing.T) {
+	var started, cancelled atomic.Int32
+
+	// the first 2 tasks succeed right away while the others only return once cancelled
+	task := func(ctx context.Context, n int) (int, error) {
+		if n < 2 {
+			return n + 100, nil
+		}
+
+		started.Add(1)
+
+		select {
+		case <-ctx.Done():
+			cancelled.Add(1)
+			return 0, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return n + 100, nil
+		}
+	}
+
+	job := newJobWithContext(task, []int{0, 1, 2, 3, 4, 5, 6}).withWorkers(5).withMinResults(2)
+
+	startedAt := time.Now()
+	results, errs, err := workerPoolDo(job)
+
+	if elapsed := time.Since(startedAt); elapsed > time.Second {
+		t.Fatalf("expected an early return, took %v", elapsed)
+	}
+
+	if !errors.Is(err, ErrPartialContent) {
+		t.Fatalf("expected ErrPartialContent, got %v", err)
+	}
+
+	if results[0] != 100 || results[1] != 101 || errs[0] != nil || errs[1] != nil {
+		t.Errorf("expected the 2 successful results, got %v, %v", results[:2], errs[:2])
+	}
+
+	for i := 2; i < len(errs); i++ {
+		if !errors.Is(errs[i], errWorkerPoolTaskSkipped) {
+			t.Errorf("expected task %d to be marked as skipped, got %v", i, errs[i])
+		}
+	}
+
+	// how many of the slow tasks the other workers got to before enough results
+	// came in depends on scheduling, but each of them has to be cancelled
+	waitForCondition(t, func() bool {
+		return cancelled.Load() == started.Load()
+	})
+}
+
+func TestWorkerPoolDoFirstSuccess(t *testing.T) {
+	job := newJob(func(n int) (int, error) {
+		if n == 0 {
+			return 0, errors.New("failed")
+		}
+
+		return n, nil
+	}, []int{0, 1, 2, 3}).withWorkers(1).withFirstSuccess()
+
+	results, errs, err := workerPoolDo(job)
+
+	if !errors.Is(err, ErrPartialContent) {
+		t.Fatalf("expected ErrPartialContent, got %v", err)
+	}
+
+	if errs[0] == nil || results[1] != 1 || errs[1] != nil {
+		t.Errorf("expected the failure and the first success, got %v, %v", results, errs)
+	}
+
+	if !errors.Is(errs[3], errWorkerPoolTaskSkipped) {
+		t.Errorf("expected the last task to be skipped, got %v", errs[3])
+	}
+}
+
+func TestWorkerPoolDoWaitsForAllTasksWithoutMinResults(t *testing.T) {
+	var contexts []context.Context
+
+	// a single worker runs the tasks one after the other
+	job := newJobWithContext(func(ctx context.Context, n int) (int, error) {
+		contexts = append(contexts, ctx)
+		return n * 2, ctx.Err()
+	}, []int{1, 2, 3}).withWorkers(1)
+
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []int{2, 4, 6} {
+		if results[i] != expected || errs[i] != nil {
+			t.Errorf("task %d: expected %d, got %d, %v", i, expected, results[i], errs[i])
+		}
+	}
+
+	// the context is only cancelled once the job is over
+	for _, ctx := range contexts {
+		if ctx.Err() == nil {
+			t.Error("expected the context of the tasks to be cancelled after returning")
+		}
+	}
+}
+
+func TestDefaultWorkerCount(t *testing.T) {
+	previous := DefaultWorkerCount()
+	t.Cleanup(func() { SetDefaultWorkerCount(previous) })
+
+	tests := []struct {
+		name     string
+		set      func()
+		expected int
+	}{
+		{name: "explicit count", set: func() { SetDefaultWorkerCount(25) }, expected: 25},
+		{name: "zero is clamped", set: func() { SetDefaultWorkerCount(0) }, expected: 1},
+		{name: "negative is clamped", set: func() { SetDefaultWorkerCount(-3) }, expected: 1},
+		{name: "twice the CPUs", set: func() { SetDefaultWorkerCountFromCPU(2.0) }, expected: 2 * runtime.NumCPU()},
+		{name: "fraction of the CPUs is clamped", set: func() { SetDefaultWorkerCountFromCPU(0.0001) }, expected: 1},
+		{name: "negative multiplier is clamped", set: func() { SetDefaultWorkerCountFromCPU(-1) }, expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.set()
+
+			if count := DefaultWorkerCount(); count != test.expected {
+				t.Errorf("expected %d workers, got %d", test.expected, count)
+			}
+		})
+	}
+}
+
+func TestJobsUseDefaultWorkerCount(t *testing.T) {
+	previous := DefaultWorkerCount()
+	t.Cleanup(func() { SetDefaultWorkerCount(previous) })
+	SetDefaultWorkerCount(3)
+
+	data := make([]int, 10)
+	identity := func(n int) (int, error) { return n, nil }
+
+	if workers := newJob(identity, data).workers; workers != 3 {
+		t.Errorf("expected new jobs to use 3 workers, got %d", workers)
+	}
+
+	if workers := newJob(identity, data).withWorkers(5).withWorkers(0).workers; workers != 3 {
+		t.Errorf("expected withWorkers(0) to go back to the default, got %d", workers)
+	}
+
+	// changing the default doesn't affect jobs which were already created
+	job := newJob(identity, data)
+	SetDefaultWorkerCount(7)
+
+	if job.workers != 3 {
+		t.Errorf("expected the job to keep 3 workers, got %d", job.workers)
+	}
+}
+
+// newHTTPProxyTestServer answers requests sent to it as a proxy itself, counting them
+func newHTTPProxyTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var proxied atomic.Int32
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.IsAbs() {
+			proxied.Add(1)
+		}
+
+		w.Write([]byte("from proxy"))
+	})
+
+	return server, &proxied
+}
+
+func TestResetDefaultClientsIsolatesProxySettings(t *testing.T) {
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("direct"))
+	})
+
+	proxy, proxied := newHTTPProxyTestServer(t)
+
+	get := func(t *testing.T, client RequestDoer) string {
+		t.Helper()
+
+		request, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+		body, err := getBody(client, request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return body
+	}
+
+	t.Run("with a proxy", func(t *testing.T) {
+		t.Cleanup(ResetDefaultClients)
+
+		if err := SetProxy(proxy.URL); err != nil {
+			t.Fatal(err)
+		}
+
+		if body := get(t, defaultClient()); body != "from proxy" {
+			t.Errorf("expected the default client to use the proxy, got %q", body)
+		}
+
+		client, err := GetClientWithOptions(WithTimeout(time.Minute))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if body := get(t, client); body != "from proxy" {
+			t.Errorf("expected clients created after SetProxy to use the proxy, got %q", body)
+		}
+	})
+
+	t.Run("after resetting", func(t *testing.T) {
+		t.Cleanup(ResetDefaultClients)
+		before := proxied.Load()
+
+		if body := get(t, defaultClient()); body != "direct" {
+			t.Errorf("expected the proxy to not carry over to the default client, got %q", body)
+		}
+
+		// the client with the same options is created again rather than reused
+		client, err := GetClientWithOptions(WithTimeout(time.Minute))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if body := get(t, client); body != "direct" {
+			t.Errorf("expected the proxy to not carry over to cached clients, got %q", body)
+		}
+
+		if proxied.Load() != before {
+			t.Errorf("expected no requests through the proxy, got %d", proxied.Load()-before)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package feed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxRetries   int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	// MaxRetryAfterDuration bounds how long the server can ask us to wait
+	// through the Retry-After header, longer waits are shortened to it.
+	// MaxDelay is used instead when it's not set.
+	MaxRetryAfterDuration time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:            2,
+	InitialDelay:          500 * time.Millisecond,
+	MaxDelay:              5 * time.Second,
+	MaxRetryAfterDuration: 30 * time.Second,
+}
+
+// WithRetry retries requests which failed due to a network error, a rate limit
+// or a server error, waiting for an exponentially increasing delay between
+// attempts unless the server says how long to wait through Retry-After
+func WithRetry(policy RetryPolicy) DecodeOption {
+	return func(o *decodeOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+func decodeJsonFromRequestWithRetry[T any](client RequestDoer, request *http.Request, policy RetryPolicy, opts ...DecodeOption) (T, error) {
+	return decodeJsonFromRequest[T](client, request, append(opts, WithRetry(policy))...)
+}
+
+func fetchBodyIntoBufferWithRetry(client RequestDoer, request *http.Request, policy RetryPolicy, isAccepted func(int) bool, buffer *bytes.Buffer) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		buffer.Reset()
+		response, err := fetchBodyIntoBuffer(client, request, isAccepted, buffer)
+
+		if err == nil || attempt >= policy.MaxRetries || !isRetryableResponse(request, response, err) {
+			return response, err
+		}
+
+		// requests with a body can only be retried if it can be read again
+		if request.Body != nil && request.Body != http.NoBody {
+			if request.GetBody == nil {
+				return response, err
+			}
+
+			request = request.Clone(request.Context())
+			request.Body, _ = request.GetBody()
+		}
+
+		delay := policy.delayForAttempt(attempt)
+
+		if response != nil {
+			if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+				delay = policy.boundRetryAfter(retryAfter)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-request.Context().Done():
+			timer.Stop()
+			return response, fmt.Errorf("%w (gave up retrying: %w)", err, request.Context().Err())
+		}
+	}
+}
+
+func isRetryableResponse(request *http.Request, response *http.Response, err error) bool {
+	if errors.Is(err, ErrRecentlyNotFound) {
+		return false
+	}
+
+	// network errors are worth retrying unless the caller gave up on the request
+	if response == nil {
+		return request.Context().Err() == nil
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func (p RetryPolicy) delayForAttempt(attempt int) time.Duration {
+	delay := p.InitialDelay
+
+	for range attempt {
+		delay *= 2
+
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	if p.MaxDelay > 0 {
+		return min(delay, p.MaxDelay)
+	}
+
+	return delay
+}
+
+// boundRetryAfter falls back to MaxDelay for policies which don't set
+// MaxRetryAfterDuration so that they still follow what the server asked for
+func (p RetryPolicy) boundRetryAfter(retryAfter time.Duration) time.Duration {
+	if p.MaxRetryAfterDuration > 0 {
+		return min(retryAfter, p.MaxRetryAfterDuration)
+	}
+
+	if p.MaxDelay > 0 {
+		return min(retryAfter, p.MaxDelay)
+	}
+
+	return retryAfter
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can either
+// be a number of seconds or an HTTP date (RFC 9110 section 10.2.3)
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.November, 15, 8, 12, 1, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "30", expected: 30 * time.Second, ok: true},
+		{value: " 0 ", expected: 0, ok: true},
+		{value: "Fri, 15 Nov 2024 08:12:31 GMT", expected: 30 * time.Second, ok: true},
+		{value: "Fri, 15 Nov 2024 08:00:00 GMT", expected: 0, ok: true},
+		{value: "-5", ok: false},
+		{value: "soon", ok: false},
+		{value: "", ok: false},
+	}
+
+	for _, test := range tests {
+		delay, ok := parseRetryAfter(test.value, now)
+
+		if ok != test.ok || delay != test.expected {
+			t.Errorf("%q: expected %v, %v, got %v, %v", test.value, test.expected, test.ok, delay, ok)
+		}
+	}
+}
+
+func TestRetryPolicyBoundsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxDelay: 5 * time.Second, MaxRetryAfterDuration: 30 * time.Second}
+
+	if delay := policy.boundRetryAfter(time.Hour); delay != 30*time.Second {
+		t.Errorf("expected the wait to be bounded to MaxRetryAfterDuration, got %v", delay)
+	}
+
+	if delay := policy.boundRetryAfter(10 * time.Second); delay != 10*time.Second {
+		t.Errorf("expected a reasonable wait to be kept, got %v", delay)
+	}
+
+	policy.MaxRetryAfterDuration = 0
+
+	if delay := policy.boundRetryAfter(time.Hour); delay != 5*time.Second {
+		t.Errorf("expected MaxDelay to bound the wait when there's no MaxRetryAfterDuration, got %v", delay)
+	}
+}
+
+// newRetryAfterTestServer responds with 503 and retryAfter once, then succeeds
+func newRetryAfterTestServer(t *testing.T, retryAfter func() string) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests == 1 {
+			w.Header().Set("Retry-After", retryAfter())
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+
+	return server, &requests
+}
+
+func TestRetryWaitsForRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 1, InitialDelay: time.Millisecond, MaxRetryAfterDuration: 5 * time.Second}
+
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		minWait    time.Duration
+	}{
+		{name: "delta seconds", retryAfter: func() string { return "1" }, minWait: time.Second},
+		{
+			name: "http date",
+			// the date only has a precision of seconds, so it's at least a second away
+			retryAfter: func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) },
+			minWait:    time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := newRetryAfterTestServer(t, test.retryAfter)
+			request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+			startedAt := time.Now()
+			result, err := decodeJsonFromRequestWithRetry[map[string]bool](server.Client(), request, policy)
+			elapsed := time.Since(startedAt)
+
+			if err != nil || !result["ok"] {
+				t.Fatalf("expected the retry to succeed, got %v, %v", result, err)
+			}
+
+			if *requests != 2 {
+				t.Errorf("expected 2 requests, got %d", *requests)
+			}
+
+			if elapsed < test.minWait {
+				t.Errorf("expected to wait at least %v as the server asked, waited %v", test.minWait, elapsed)
+			}
+		})
+	}
+}
+
+func TestRetryBoundsLongRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 1, InitialDelay: time.Millisecond, MaxRetryAfterDuration: 50 * time.Millisecond}
+	server, _ := newRetryAfterTestServer(t, func() string { return "3600" })
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	startedAt := time.Now()
+
+	if _, err := decodeJsonFromRequestWithRetry[map[string]bool](server.Client(), request, policy); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(startedAt); elapsed >= time.Second {
+		t.Errorf("expected the wait to be bounded to 50ms, waited %v", elapsed)
+	}
+}
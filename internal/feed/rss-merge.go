@@ -0,0 +1,132 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type RSSFeedSourceError struct {
+	URL   string
+	Error error
+}
+
+// tracking parameters commonly added by feeds which otherwise make
+// the same article from different sources look like different links
+var trackingQueryParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"ref":    true,
+	"source": true,
+}
+
+func canonicalizeFeedItemLink(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+
+	if err != nil || parsed.Host == "" {
+		return strings.TrimSpace(link)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := parsed.Query()
+
+	for key := range query {
+		if strings.HasPrefix(key, "utm_") || trackingQueryParams[key] {
+			query.Del(key)
+		}
+	}
+
+	// scheme is ignored since the same article is often linked over both
+	canonical := host + strings.TrimSuffix(parsed.EscapedPath(), "/")
+
+	// Encode sorts the keys so the order of the parameters doesn't matter
+	if encoded := query.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+
+	return canonical
+}
+
+func normalizeFeedItemTitle(title string) string {
+	return sequentialWhitespacePattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(title)), " ")
+}
+
+// FetchMergedRSSFeeds fetches all feeds concurrently and merges their items into
+// a single list sorted by newest, with items that appear in more than one feed
+// only being included once. Items are considered the same if their links point
+// to the same page, or if they have no link, if their titles are the same. The
+// earliest published copy is kept since it's most likely to be the original.
+// Errors are reported for each feed that couldn't be fetched.
+func FetchMergedRSSFeeds(ctx context.Context, requests []RSSFeedRequest) (RSSFeedItems, []RSSFeedSourceError, error) {
+	job := newJobWithContext(getItemsFromRSSFeedTask, requests).withWorkers(10).withContext(ctx)
+	feeds, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	sourceErrors := make([]RSSFeedSourceError, 0)
+	failed := 0
+	indexes := make(map[string]int)
+	merged := make(RSSFeedItems, 0, len(feeds)*10)
+
+	for i := range feeds {
+		if errs[i] != nil {
+			sourceErrors = append(sourceErrors, RSSFeedSourceError{URL: requests[i].Url, Error: errs[i]})
+
+			if !errors.Is(errs[i], ErrPartialContent) {
+				failed++
+				slog.Error("failed to get rss feed", "error", errs[i], "url", requests[i].Url)
+				continue
+			}
+		}
+
+		for _, item := range feeds[i] {
+			var key string
+
+			if item.Link != "" {
+				key = "link:" + canonicalizeFeedItemLink(item.Link)
+			} else {
+				key = "title:" + normalizeFeedItemTitle(item.Title)
+			}
+
+			if existing, ok := indexes[key]; ok {
+				if item.PublishedAt.Before(merged[existing].PublishedAt) {
+					merged[existing] = item
+				}
+
+				continue
+			}
+
+			indexes[key] = len(merged)
+			merged = append(merged, item)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, sourceErrors, ErrNoContent
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].PublishedAt.After(merged[j].PublishedAt)
+	})
+
+	if failed > 0 {
+		return merged, sourceErrors, fmt.Errorf("%w: missing %d RSS feeds", ErrPartialContent, failed)
+	}
+
+	if len(sourceErrors) > 0 {
+		return merged, sourceErrors, fmt.Errorf("%w: %d RSS feeds contain malformed items", ErrPartialContent, len(sourceErrors))
+	}
+
+	return merged, sourceErrors, nil
+}
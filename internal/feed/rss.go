@@ -1,10 +1,13 @@
 package feed
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"regexp"
 	"sort"
@@ -61,20 +64,45 @@ func (f RSSFeedItems) SortByNewest() RSSFeedItems {
 	return f
 }
 
-func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
+func getItemsFromRSSFeedTask(ctx context.Context, request RSSFeedRequest) ([]RSSFeedItem, error) {
 
 	var feedParser = gofeed.NewParser()
-	feedParser.Client = defaultClient
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
 
-	feed, err := feedParser.ParseURLWithContext(request.Url, ctx)
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, request.Url, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
+	httpRequest.Header.Set("User-Agent", feedParser.UserAgent)
+	_, body, err := fetchBodyFromRequest(defaultClient(), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := feedParser.Parse(bytes.NewReader(body))
+	skippedItems := 0
+
+	// a single malformed item shouldn't make the whole feed unavailable
+	if err != nil {
+		repaired, skipped, repairErr := repairFeedXML(body)
+
+		if repairErr != nil {
+			return nil, err
+		}
+
+		if feed, repairErr = feedParser.Parse(bytes.NewReader(repaired)); repairErr != nil {
+			return nil, err
+		}
+
+		skippedItems = skipped
+		slog.Warn("Skipped malformed items in rss feed", "url", request.Url, "skipped", skipped, "error", err)
+	}
+
 	items := make(RSSFeedItems, 0, len(feed.Items))
 
 	for i := range feed.Items {
@@ -89,7 +117,7 @@ func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
 			rssItem.Link = request.ItemLinkPrefix + item.Link
 		} else if strings.HasPrefix(item.Link, "http://") || strings.HasPrefix(item.Link, "https://") {
 			rssItem.Link = item.Link
-		} else {
+		} else if item.Link != "" {
 			parsedUrl, err := url.Parse(feed.Link)
 
 			if err != nil {
@@ -160,11 +188,15 @@ func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
 		items = append(items, rssItem)
 	}
 
+	if skippedItems > 0 {
+		return items, fmt.Errorf("%w: skipped %d malformed items", ErrPartialContent, skippedItems)
+	}
+
 	return items, nil
 }
 
-func GetItemsFromRSSFeeds(requests []RSSFeedRequest) (RSSFeedItems, error) {
-	job := newJob(getItemsFromRSSFeedTask, requests).withWorkers(10)
+func GetItemsFromRSSFeeds(ctx context.Context, requests []RSSFeedRequest) (RSSFeedItems, error) {
+	job := newJobWithContext(getItemsFromRSSFeedTask, requests).withWorkers(10).withContext(ctx)
 	feeds, errs, err := workerPoolDo(job)
 
 	if err != nil {
@@ -172,11 +204,14 @@ func GetItemsFromRSSFeeds(requests []RSSFeedRequest) (RSSFeedItems, error) {
 	}
 
 	failed := 0
+	malformed := 0
 
 	entries := make(RSSFeedItems, 0, len(feeds)*10)
 
 	for i := range feeds {
-		if errs[i] != nil {
+		if errors.Is(errs[i], ErrPartialContent) {
+			malformed++
+		} else if errs[i] != nil {
 			failed++
 			slog.Error("failed to get rss feed", "error", errs[i], "url", requests[i].Url)
 			continue
@@ -195,5 +230,9 @@ func GetItemsFromRSSFeeds(requests []RSSFeedRequest) (RSSFeedItems, error) {
 		return entries, fmt.Errorf("%w: missing %d RSS feeds", ErrPartialContent, failed)
 	}
 
+	if malformed > 0 {
+		return entries, fmt.Errorf("%w: %d RSS feeds contain malformed items", ErrPartialContent, malformed)
+	}
+
 	return entries, nil
 }
@@ -0,0 +1,238 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html/charset"
+)
+
+type ScrapeSelector struct {
+	Label    string         `yaml:"label"`
+	Selector string         `yaml:"selector"`
+	Attr     string         `yaml:"attr"`
+	Regex    string         `yaml:"regex"`
+	Link     bool           `yaml:"link"`
+	pattern  *regexp.Regexp `yaml:"-"`
+}
+
+func (s *ScrapeSelector) Compile() error {
+	if s.Selector == "" {
+		return fmt.Errorf("no selector specified for %q", s.Label)
+	}
+
+	// goquery silently matches nothing for invalid selectors so
+	// they're validated upfront to give a useful error instead
+	if _, err := cascadia.ParseGroup(s.Selector); err != nil {
+		return fmt.Errorf("invalid selector %q: %v", s.Selector, err)
+	}
+
+	if s.Regex == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(s.Regex)
+
+	if err != nil {
+		return fmt.Errorf("invalid regex for %q: %v", s.Label, err)
+	}
+
+	s.pattern = pattern
+
+	return nil
+}
+
+type ScrapeRequest struct {
+	URL          string
+	Headers      map[string]string
+	Fields       []ScrapeSelector
+	ListSelector string
+	ListLimit    int
+	ListFields   []ScrapeSelector
+}
+
+type ScrapedValue struct {
+	Label   string
+	Value   string
+	Missing bool
+}
+
+type ScrapedItem struct {
+	Link   string
+	Values []ScrapedValue
+}
+
+type ScrapeResult struct {
+	Values []ScrapedValue
+	Items  []ScrapedItem
+}
+
+func FetchScrapedValues(ctx context.Context, options ScrapeRequest) (*ScrapeResult, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", options.URL, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %v", ErrNoContent, err)
+	}
+
+	addBrowserUserAgentHeader(request)
+
+	for key, value := range options.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, body, err := fetchBodyFromRequest(defaultClient(), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	reader, err := charset.NewReader(bytes.NewReader(body), response.Header.Get("Content-Type"))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not determine charset: %v", ErrNoContent, err)
+	}
+
+	document, err := goquery.NewDocumentFromReader(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not parse HTML: %v", ErrNoContent, err)
+	}
+
+	result := &ScrapeResult{}
+	unmatched := make([]string, 0)
+
+	result.Values = extractScrapedValues(document.Selection, options.Fields, &unmatched)
+
+	if len(options.ListFields) > 0 {
+		nodes := document.Find(options.ListSelector)
+
+		if nodes.Length() == 0 {
+			return nil, fmt.Errorf("%w: list selector %q matched nothing", ErrNoContent, options.ListSelector)
+		}
+
+		if options.ListLimit > 0 && nodes.Length() > options.ListLimit {
+			nodes = nodes.Slice(0, options.ListLimit)
+		}
+
+		nodes.Each(func(_ int, node *goquery.Selection) {
+			item := ScrapedItem{}
+			fields := make([]ScrapeSelector, 0, len(options.ListFields))
+
+			for i := range options.ListFields {
+				if !options.ListFields[i].Link {
+					fields = append(fields, options.ListFields[i])
+					continue
+				}
+
+				if link, ok := extractScrapedValue(node, &options.ListFields[i]); ok {
+					item.Link = resolveScrapedLink(response.Request.URL, link)
+				}
+			}
+
+			item.Values = extractScrapedValues(node, fields, &unmatched)
+			result.Items = append(result.Items, item)
+		})
+	}
+
+	if len(unmatched) > 0 && len(unmatched) == len(options.Fields)+len(result.Items)*len(options.ListFields) {
+		return nil, fmt.Errorf("%w: no selectors matched anything: %s", ErrNoContent, strings.Join(unmatched, ", "))
+	}
+
+	if len(unmatched) > 0 {
+		return result, fmt.Errorf("%w: selectors matched nothing: %s", ErrPartialContent, strings.Join(unique(unmatched), ", "))
+	}
+
+	return result, nil
+}
+
+func extractScrapedValues(root *goquery.Selection, fields []ScrapeSelector, unmatched *[]string) []ScrapedValue {
+	values := make([]ScrapedValue, len(fields))
+
+	for i := range fields {
+		values[i].Label = fields[i].Label
+		value, ok := extractScrapedValue(root, &fields[i])
+
+		if !ok {
+			values[i].Missing = true
+			*unmatched = append(*unmatched, fmt.Sprintf("%q", fields[i].Selector))
+			continue
+		}
+
+		values[i].Value = value
+	}
+
+	return values
+}
+
+func extractScrapedValue(root *goquery.Selection, field *ScrapeSelector) (string, bool) {
+	node := root.Find(field.Selector).First()
+
+	if node.Length() == 0 {
+		return "", false
+	}
+
+	var value string
+
+	if field.Attr != "" {
+		attr, exists := node.Attr(field.Attr)
+
+		if !exists {
+			return "", false
+		}
+
+		value = attr
+	} else {
+		value = node.Text()
+	}
+
+	value = strings.TrimSpace(sequentialWhitespacePattern.ReplaceAllString(value, " "))
+
+	if field.pattern == nil {
+		return value, true
+	}
+
+	matches := field.pattern.FindStringSubmatch(value)
+
+	if matches == nil {
+		return "", false
+	}
+
+	if len(matches) > 1 {
+		return matches[1], true
+	}
+
+	return matches[0], true
+}
+
+func resolveScrapedLink(base *url.URL, link string) string {
+	parsed, err := base.Parse(link)
+
+	if err != nil {
+		return link
+	}
+
+	return parsed.String()
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+
+		seen[value] = true
+		result = append(result, value)
+	}
+
+	return result
+}
@@ -0,0 +1,265 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type serviceAccountTokenClient struct {
+	base      RequestDoer
+	tokenPath string
+
+	watchOnce sync.Once
+	mu        sync.RWMutex
+	token     string
+}
+
+// NewServiceAccountTokenClient sends requests through base with the token in tokenPath
+// as a bearer token, such as the one Kubernetes mounts into pods for their service
+// account. The token is read on the first request and kept in memory, being read
+// again whenever the file changes, which covers the token being rotated.
+func NewServiceAccountTokenClient(base RequestDoer, tokenPath string) RequestDoer {
+	return &serviceAccountTokenClient{base: base, tokenPath: tokenPath}
+}
+
+func (c *serviceAccountTokenClient) Do(request *http.Request) (*http.Response, error) {
+	token, err := c.currentToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := request.Clone(request.Context())
+	authenticated.Header.Set("Authorization", "Bearer "+token)
+
+	return c.base.Do(authenticated)
+}
+
+// currentToken reads the token if it hasn't been yet, failing requests until
+// it can be read rather than for good since it may only be mounted later
+func (c *serviceAccountTokenClient) currentToken() (string, error) {
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+
+	if token != "" {
+		return token, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	token, err := readTokenFile(c.tokenPath)
+
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.watchOnce.Do(c.watch)
+
+	return token, nil
+}
+
+// watch reloads the token when the file changes until Shutdown is called. The
+// directory is watched rather than the file since Kubernetes replaces the file
+// by swapping a symlink, which a watch on the file itself wouldn't notice.
+func (c *serviceAccountTokenClient) watch() {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err == nil {
+		err = watcher.Add(filepath.Dir(c.tokenPath))
+	}
+
+	if err != nil {
+		slog.Warn("Could not watch service account token for changes", "path", c.tokenPath, "error", err)
+
+		if watcher != nil {
+			watcher.Close()
+		}
+
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-lifecycle.ctx.Done():
+				return
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+
+				token, err := readTokenFile(c.tokenPath)
+
+				// the file can be briefly missing or empty while being replaced,
+				// in which case the next event picks up the new one
+				if err != nil {
+					continue
+				}
+
+				c.mu.Lock()
+				c.token = token
+				c.mu.Unlock()
+			}
+		}
+	}()
+}
+
+func readTokenFile(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", fmt.Errorf("reading service account token: %v", err)
+	}
+
+	token := strings.TrimSpace(string(contents))
+
+	if token == "" {
+		return "", fmt.Errorf("service account token in %s is empty", path)
+	}
+
+	return token, nil
+}
+
+var ErrVaultLogin = errors.New("vault approle login failed")
+
+type vaultTokenClient struct {
+	base RequestDoer
+	// what the login requests are sent through, the same as base unless set separately
+	login    RequestDoer
+	loginURL string
+	roleID   string
+	secretID string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewVaultTokenClient sends requests through base with a Vault token obtained by
+// logging in with an AppRole, for widgets which read from Vault. The token is
+// kept until shortly before its lease runs out and a new one is requested once
+// it does or when Vault rejects it.
+func NewVaultTokenClient(base RequestDoer, vaultAddr, roleID, secretID string) RequestDoer {
+	return NewVaultTokenClientWithLoginClient(base, base, vaultAddr, roleID, secretID)
+}
+
+// NewVaultTokenClientWithLoginClient is like NewVaultTokenClient but logs in through
+// login rather than base, for networks where Vault has to be reached through a
+// different proxy than the API that the token is for. A client from
+// GetClientWithOptions can be used for either, being shared with every other
+// client created with the same options.
+func NewVaultTokenClientWithLoginClient(base, login RequestDoer, vaultAddr, roleID, secretID string) RequestDoer {
+	return &vaultTokenClient{
+		base:     base,
+		login:    login,
+		loginURL: strings.TrimRight(vaultAddr, "/") + "/v1/auth/approle/login",
+		roleID:   roleID,
+		secretID: secretID,
+	}
+}
+
+type vaultLoginResponseJson struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+func (c *vaultTokenClient) Do(request *http.Request) (*http.Response, error) {
+	token, err := c.currentToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := request.Clone(request.Context())
+	authenticated.Header.Set("X-Vault-Token", token)
+
+	response, err := c.base.Do(authenticated)
+
+	// the token may have been revoked before its lease ran out
+	if err == nil && response.StatusCode == http.StatusForbidden {
+		c.mu.Lock()
+		if c.token == token {
+			c.token = ""
+		}
+		c.mu.Unlock()
+	}
+
+	return response, err
+}
+
+func (c *vaultTokenClient) currentToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"role_id": c.roleID, "secret_id": c.secretID})
+
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequest("POST", c.loginURL, bytes.NewReader(body))
+
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := decodeJsonFromRequest[vaultLoginResponseJson](c.login, request)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrVaultLogin, err)
+	}
+
+	if response.Auth.ClientToken == "" {
+		return "", fmt.Errorf("%w: response has no token", ErrVaultLogin)
+	}
+
+	lease := time.Duration(response.Auth.LeaseDuration) * time.Second
+
+	// renewed a little early so that requests don't go out with a token about to expire,
+	// tokens without a lease don't expire
+	if lease <= 0 {
+		c.expiresAt = time.Now().Add(100 * 365 * 24 * time.Hour)
+	} else {
+		c.expiresAt = time.Now().Add(lease - min(lease/10, time.Minute))
+	}
+
+	c.token = response.Auth.ClientToken
+
+	return c.token, nil
+}
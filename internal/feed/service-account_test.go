@@ -0,0 +1,207 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newHeaderEchoTestServer responds with the value of the header it's given
+func newHeaderEchoTestServer(t *testing.T, header string) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(header)))
+	})
+}
+
+// replaceTokenFile swaps in a new file the way tokens mounted by Kubernetes are rotated
+func replaceTokenFile(t *testing.T, path string, token string) {
+	t.Helper()
+
+	replacement := path + ".new"
+
+	if err := os.WriteFile(replacement, []byte(token+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceAccountTokenClientPicksUpReplacedToken(t *testing.T) {
+	server := newHeaderEchoTestServer(t, "Authorization")
+	path := filepath.Join(t.TempDir(), "token")
+	replaceTokenFile(t, path, "first-token")
+
+	client := NewServiceAccountTokenClient(server.Client(), path)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if body, err := getBody(client, request); err != nil || body != "Bearer first-token" {
+		t.Fatalf("expected the token from the file, got %q, %v", body, err)
+	}
+
+	if request.Header.Get("Authorization") != "" {
+		t.Error("expected the request given to the client to be left unchanged")
+	}
+
+	replaceTokenFile(t, path, "second-token")
+
+	waitForCondition(t, func() bool {
+		body, err := getBody(client, request)
+		return err == nil && body == "Bearer second-token"
+	})
+
+	// the token is kept in memory, so it's still sent while the file is missing
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if body, err := getBody(client, request); err != nil || body != "Bearer second-token" {
+		t.Errorf("expected the last token read, got %q, %v", body, err)
+	}
+}
+
+func TestServiceAccountTokenClientWaitsForTokenFile(t *testing.T) {
+	server := newHeaderEchoTestServer(t, "Authorization")
+	path := filepath.Join(t.TempDir(), "token")
+	client := NewServiceAccountTokenClient(server.Client(), path)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	if _, err := getBody(client, request); err == nil {
+		t.Fatal("expected an error while the token file is missing")
+	}
+
+	os.WriteFile(path, []byte("  \n"), 0600)
+
+	if _, err := getBody(client, request); err == nil {
+		t.Fatal("expected an error while the token file is empty")
+	}
+
+	os.WriteFile(path, []byte("mounted-token"), 0600)
+
+	if body, err := getBody(client, request); err != nil || body != "Bearer mounted-token" {
+		t.Errorf("expected the token once the file exists, got %q, %v", body, err)
+	}
+}
+
+type vaultTestServer struct {
+	*httptest.Server
+	logins     atomic.Int32
+	leaseSecs  int
+	revokeNext atomic.Bool
+}
+
+// newVaultTestServer hands out a new token for each AppRole login and responds
+// with the token requests are sent with, rejecting it once revokeNext is set
+func newVaultTestServer(t *testing.T, leaseSecs int) *vaultTestServer {
+	t.Helper()
+
+	server := &vaultTestServer{leaseSecs: leaseSecs}
+	server.Server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var credentials map[string]string
+			json.NewDecoder(r.Body).Decode(&credentials)
+
+			if r.Method != http.MethodPost || credentials["role_id"] != "role" || credentials["secret_id"] != "secret" {
+				http.Error(w, `{"errors":["invalid role or secret ID"]}`, http.StatusBadRequest)
+				return
+			}
+
+			login := server.logins.Add(1)
+			json.NewEncoder(w).Encode(map[string]any{
+				"auth": map[string]any{"client_token": fmt.Sprintf("token-%d", login), "lease_duration": server.leaseSecs},
+			})
+			return
+		}
+
+		if server.revokeNext.CompareAndSwap(true, false) {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		w.Write([]byte(r.Header.Get("X-Vault-Token")))
+	})
+
+	return server
+}
+
+func TestVaultTokenClientReusesToken(t *testing.T) {
+	vault := newVaultTestServer(t, 3600)
+	client := NewVaultTokenClient(vault.Client(), vault.URL+"/", "role", "secret")
+	request, _ := http.NewRequest(http.MethodGet, vault.URL+"/v1/secret/data/glance", nil)
+
+	for range 3 {
+		if body, err := getBody(client, request); err != nil || body != "token-1" {
+			t.Fatalf("expected the token from the login, got %q, %v", body, err)
+		}
+	}
+
+	if logins := vault.logins.Load(); logins != 1 {
+		t.Errorf("expected a single login, got %d", logins)
+	}
+
+	if request.Header.Get("X-Vault-Token") != "" {
+		t.Error("expected the request given to the client to be left unchanged")
+	}
+}
+
+func TestVaultTokenClientLogsInAgain(t *testing.T) {
+	vault := newVaultTestServer(t, 3600)
+	client := NewVaultTokenClient(vault.Client(), vault.URL, "role", "secret")
+	request, _ := http.NewRequest(http.MethodGet, vault.URL+"/v1/secret/data/glance", nil)
+
+	getBody(client, request)
+
+	// a rejected token is dropped, with the next request logging in again
+	vault.revokeNext.Store(true)
+
+	response, err := client.Do(request)
+
+	if err != nil || response.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the rejection to be passed on, got %v", err)
+	}
+
+	response.Body.Close()
+
+	if body, err := getBody(client, request); err != nil || body != "token-2" {
+		t.Errorf("expected a new token after the rejection, got %q, %v", body, err)
+	}
+
+	// as is one whose lease is about to run out
+	client.(*vaultTokenClient).expiresAt = time.Now()
+
+	if body, err := getBody(client, request); err != nil || body != "token-3" {
+		t.Errorf("expected a new token once the lease ran out, got %q, %v", body, err)
+	}
+}
+
+func TestVaultTokenClientLeaseRenewedEarly(t *testing.T) {
+	vault := newVaultTestServer(t, 600)
+	client := NewVaultTokenClient(vault.Client(), vault.URL, "role", "secret").(*vaultTokenClient)
+	request, _ := http.NewRequest(http.MethodGet, vault.URL, nil)
+	getBody(client, request)
+
+	// a tenth of the lease, at most a minute
+	if remaining := time.Until(client.expiresAt); remaining > 9*time.Minute || remaining < 9*time.Minute-time.Second {
+		t.Errorf("expected the token to be renewed a minute early, %v remaining", remaining)
+	}
+}
+
+func TestVaultTokenClientLoginFailure(t *testing.T) {
+	vault := newVaultTestServer(t, 3600)
+	client := NewVaultTokenClient(vault.Client(), vault.URL, "role", "wrong secret")
+	request, _ := http.NewRequest(http.MethodGet, vault.URL, nil)
+
+	if _, err := client.Do(request); !errors.Is(err, ErrVaultLogin) || !HasStatusCode(err, http.StatusBadRequest) {
+		t.Errorf("expected ErrVaultLogin with the status of the login, got %v", err)
+	}
+}
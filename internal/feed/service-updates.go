@@ -0,0 +1,179 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type serviceVersionAdapter struct {
+	// appended to the URL of the service
+	endpoint string
+	// where the version is in the response, the whole response when empty
+	versionPath string
+	repository  string
+}
+
+var serviceVersionAdapters = map[string]serviceVersionAdapter{
+	"vaultwarden": {endpoint: "/api/version", repository: "dani-garcia/vaultwarden"},
+	"bitwarden":   {endpoint: "/api/config", versionPath: "version", repository: "bitwarden/server"},
+	"jellyfin":    {endpoint: "/System/Info/Public", versionPath: "Version", repository: "jellyfin/jellyfin"},
+	"gitea":       {endpoint: "/api/v1/version", versionPath: "version", repository: "go-gitea/gitea"},
+}
+
+// IsKnownServiceType reports whether the running version of serviceType can be
+// fetched without having to specify where it is, custom services need a URL
+// pointing to the endpoint reporting the version along with its path
+func IsKnownServiceType(serviceType string) bool {
+	_, ok := serviceVersionAdapters[serviceType]
+	return ok
+}
+
+type ServiceUpdateRequest struct {
+	Type string
+	URL  string
+	// the GitHub repository to compare against, defaults to that of the service type
+	Repository  string
+	VersionPath string
+	Client      RequestDoer
+}
+
+type ServiceUpdate struct {
+	Running  string
+	Latest   AppRelease
+	Outdated bool
+	Error    error
+}
+
+func (r *ServiceUpdateRequest) repository() string {
+	if r.Repository != "" {
+		return r.Repository
+	}
+
+	return serviceVersionAdapters[r.Type].repository
+}
+
+func fetchRunningServiceVersion(ctx context.Context, request *ServiceUpdateRequest) (string, error) {
+	url := request.URL
+	versionPath := request.VersionPath
+
+	if adapter, ok := serviceVersionAdapters[request.Type]; ok {
+		url = strings.TrimRight(url, "/") + adapter.endpoint
+		versionPath = adapter.versionPath
+	}
+
+	client := request.Client
+
+	if client == nil {
+		client = defaultClient()
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	response, err := decodeJsonFromRequest[any](client, httpRequest)
+
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := ResolveJSONPath(response, versionPath)
+
+	if !ok {
+		return "", fmt.Errorf("no version at %q in response from %s", versionPath, url)
+	}
+
+	switch version := value.(type) {
+	case string:
+		return version, nil
+	case float64:
+		return strconv.FormatFloat(version, 'f', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("version at %q in response from %s is not a string", versionPath, url)
+}
+
+// FetchServiceUpdates compares the running version of each service with its latest
+// release on GitHub, releases are only fetched once for services sharing a repository
+func FetchServiceUpdates(ctx context.Context, requests []*ServiceUpdateRequest, token string) ([]ServiceUpdate, error) {
+	updates := make([]ServiceUpdate, len(requests))
+
+	if len(requests) == 0 {
+		return updates, nil
+	}
+
+	job := newJobWithContext(fetchRunningServiceVersion, requests).withWorkers(10).withContext(ctx)
+	versions, versionErrs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repositories := make([]string, 0, len(requests))
+	repositoryIndexes := make(map[string]int, len(requests))
+
+	for _, request := range requests {
+		if _, ok := repositoryIndexes[request.repository()]; !ok {
+			repositoryIndexes[request.repository()] = len(repositories)
+			repositories = append(repositories, request.repository())
+		}
+	}
+
+	releaseRequests := make([]*http.Request, len(repositories))
+
+	for i, repository := range repositories {
+		releaseRequests[i] = newGithubReleasesRequest(ctx, repository, token)
+	}
+
+	releasesJob := newJob(decodeJsonFromRequestTask[[]githubReleaseResponseJson](defaultClient()), releaseRequests).withWorkers(10)
+	releases, releaseErrs, err := workerPoolDo(releasesJob)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i, request := range requests {
+		update := &updates[i]
+		index := repositoryIndexes[request.repository()]
+
+		if versionErrs[i] != nil {
+			update.Error = fmt.Errorf("could not get running version: %w", versionErrs[i])
+		} else if releaseErrs[index] != nil {
+			update.Error = fmt.Errorf("could not get latest release: %w", releaseErrs[index])
+		} else {
+			update.Running = versions[i]
+			update.Latest, update.Error = liveGithubRelease(request.repository(), releases[index])
+		}
+
+		if update.Error == nil {
+			var result int
+
+			if result, update.Error = CompareVersions(update.Running, update.Latest.Version); update.Error == nil {
+				update.Outdated = result < 0
+			}
+		}
+
+		if update.Error != nil {
+			failed++
+			slog.Error("Failed to check for service update", "error", update.Error, "url", request.URL, "repository", request.repository())
+		}
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return updates, fmt.Errorf("%w: could not check %d services for updates", ErrPartialContent, failed)
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,91 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+var lifecycle = func() *lifecycleState {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycleState{ctx: ctx, cancel: cancel}
+}()
+
+type lifecycleState struct {
+	// cancelled once the grace period of Shutdown has run out
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	closing  bool
+	inFlight sync.WaitGroup
+}
+
+// beginWork registers a job or request which Shutdown has to wait for,
+// failing once a shutdown has started so that nothing new gets going
+func beginWork() (done func(), err error) {
+	lifecycle.mu.Lock()
+	defer lifecycle.mu.Unlock()
+
+	if lifecycle.closing {
+		return nil, ErrShuttingDown
+	}
+
+	lifecycle.inFlight.Add(1)
+
+	return lifecycle.inFlight.Done, nil
+}
+
+// withShutdownCancellation returns the request with a context which is cancelled
+// when the grace period of Shutdown runs out, along with a function which must be
+// called once the response has been read
+func withShutdownCancellation(request *http.Request) (*http.Request, func()) {
+	ctx, cancel := context.WithCancel(request.Context())
+	stop := context.AfterFunc(lifecycle.ctx, cancel)
+
+	return request.WithContext(ctx), func() {
+		stop()
+		cancel()
+	}
+}
+
+// Shutdown stops new jobs and requests from starting, giving the ones already
+// running up to grace to finish before cancelling them. It returns once all of
+// them have stopped and idle connections have been closed. Requests made after
+// it's been called fail with ErrShuttingDown.
+func Shutdown(grace time.Duration) {
+	lifecycle.mu.Lock()
+	lifecycle.closing = true
+	lifecycle.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		lifecycle.inFlight.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+	case <-timer.C:
+		lifecycle.cancel()
+		<-drained
+	}
+
+	lifecycle.cancel()
+
+	defaultTransport().CloseIdleConnections()
+	insecureClientTransport().CloseIdleConnections()
+
+	clientCache.Range(func(_, value any) bool {
+		value.(*http.Client).CloseIdleConnections()
+		return true
+	})
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// setTransportProxy routes requests made through transport via proxyURL. SOCKS
+// proxies are dialed directly rather than through Transport.Proxy since it
+// always leaves resolving hostnames to the proxy, whereas socks5:// is meant
+// to resolve them locally and only socks5h:// should hand them to the proxy.
+// The connection to a SOCKS proxy is made through forward.
+func setTransportProxy(transport *http.Transport, proxyURL *url.URL, forward *net.Dialer) error {
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+	default:
+		transport.Proxy = http.ProxyURL(proxyURL)
+		return nil
+	}
+
+	var auth *proxy.Auth
+
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, forward)
+
+	if err != nil {
+		return fmt.Errorf("invalid SOCKS proxy: %w", err)
+	}
+
+	socksDial := dialer.(proxy.ContextDialer).DialContext
+
+	transport.Proxy = nil
+
+	if proxyURL.Scheme == "socks5h" {
+		transport.DialContext = socksDial
+	} else {
+		transport.DialContext = dialContextWithDNSFailureCache(dialContextResolvingLocally(socksDial))
+	}
+
+	return nil
+}
+
+// dialContextResolvingLocally resolves the hostname before handing the address
+// to dial, so that only IP addresses are ever sent to the proxy
+func dialContextResolvingLocally(dial dialContextFunc) dialContextFunc {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, address)
+		}
+
+		addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+
+		for _, ip := range addresses {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+
+			if err == nil {
+				return conn, nil
+			}
+
+			errs = append(errs, err)
+		}
+
+		if len(errs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+		}
+
+		return nil, errors.Join(errs...)
+	}
+}
@@ -0,0 +1,222 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type socksTestRequest struct {
+	username string
+	// the address as it was sent to the proxy, either a hostname or an IP
+	host       string
+	isHostname bool
+}
+
+// socksTestServer is a minimal SOCKS5 proxy which records the requests it
+// receives and connects every one of them to target
+type socksTestServer struct {
+	listener net.Listener
+	target   string
+
+	mu       sync.Mutex
+	requests []socksTestRequest
+}
+
+func newSOCKSTestServer(t *testing.T, target string) *socksTestServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &socksTestServer{listener: listener, target: target}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *socksTestServer) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *socksTestServer) received() []socksTestRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]socksTestRequest(nil), s.requests...)
+}
+
+func (s *socksTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	readBytes := func(n int) []byte {
+		b := make([]byte, n)
+
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return nil
+		}
+
+		return b
+	}
+
+	greeting := readBytes(2)
+
+	if greeting == nil || greeting[0] != 5 {
+		return
+	}
+
+	methods := readBytes(int(greeting[1]))
+	method := byte(0)
+
+	for _, m := range methods {
+		if m == 2 {
+			method = 2
+		}
+	}
+
+	conn.Write([]byte{5, method})
+
+	var request socksTestRequest
+
+	if method == 2 {
+		header := readBytes(2)
+
+		if header == nil {
+			return
+		}
+
+		request.username = string(readBytes(int(header[1])))
+		readBytes(int(readBytes(1)[0]))
+		conn.Write([]byte{1, 0})
+	}
+
+	header := readBytes(4)
+
+	if header == nil {
+		return
+	}
+
+	switch header[3] {
+	case 1:
+		request.host = net.IP(readBytes(4)).String()
+	case 3:
+		request.host = string(readBytes(int(readBytes(1)[0])))
+		request.isHostname = true
+	case 4:
+		request.host = net.IP(readBytes(16)).String()
+	}
+
+	// the port is ignored since everything is connected to the target
+	readBytes(2)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", s.target)
+
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+
+	defer upstream.Close()
+
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestSOCKSProxyHostnameResolution(t *testing.T) {
+	t.Cleanup(ResetDefaultClients)
+
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+
+	tests := []struct {
+		scheme     string
+		url        string
+		isHostname bool
+		host       string
+	}{
+		// .invalid can never be resolved, so this only works if it's left to the proxy
+		{scheme: "socks5h", url: "http://glance-test.invalid/", isHostname: true, host: "glance-test.invalid"},
+		{scheme: "socks5", url: "http://localhost/", isHostname: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			socks := newSOCKSTestServer(t, upstream.Listener.Addr().String())
+			client, err := GetClientWithOptions(WithProxy(test.scheme + "://" + socks.address()))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response, err := client.Get(test.url)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response.Body.Close()
+			requests := socks.received()
+
+			if len(requests) != 1 {
+				t.Fatalf("expected 1 request through the proxy, got %d", len(requests))
+			}
+
+			if requests[0].isHostname != test.isHostname {
+				t.Errorf("expected the proxy to receive a hostname: %v, got %+v", test.isHostname, requests[0])
+			}
+
+			if test.isHostname && requests[0].host != test.host {
+				t.Errorf("expected the proxy to receive %s, got %s", test.host, requests[0].host)
+			}
+
+			if !test.isHostname && net.ParseIP(requests[0].host) == nil {
+				t.Errorf("expected the proxy to receive an IP address, got %s", requests[0].host)
+			}
+		})
+	}
+}
+
+func TestSOCKS5ResolvesLocally(t *testing.T) {
+	t.Cleanup(ResetDefaultClients)
+
+	socks := newSOCKSTestServer(t, strings.TrimPrefix(unreachableURL(), "http://"))
+	client, err := GetClientWithOptions(WithProxy("socks5://" + socks.address()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.Get("http://glance-test.invalid/"); err == nil {
+		t.Fatal("expected the local lookup of an unresolvable hostname to fail")
+	}
+
+	if requests := socks.received(); len(requests) != 0 {
+		t.Errorf("expected nothing to reach the proxy, got %+v", requests)
+	}
+}
@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSoftDeadlineExceeded is what a StaleContentError holds when the kept value
+// was returned because the request took longer than its soft deadline
+var ErrSoftDeadlineExceeded = errors.New("still updating in the background")
+
+// 0 for only requests decoded with WithSoftDeadline having one
+var softDeadlineByDefault atomic.Int64
+
+// SetSoftDeadlineByDefault sets WithSoftDeadline for every request decoded by the
+// decode functions, 0 or less disabling it again
+func SetSoftDeadlineByDefault(d time.Duration) {
+	softDeadlineByDefault.Store(int64(max(d, 0)))
+}
+
+// WithSoftDeadline returns the value kept by WithLastGoodFallback when the request
+// takes longer than d, along with a StaleContentError holding ErrSoftDeadlineExceeded,
+// instead of waiting on it until the timeout of the client. The request carries on in
+// the background and the value it gets replaces the kept one, so that it's there for
+// the next time. Requests without a kept value, or with one older than the max age of
+// the fallback, wait as usual. Has no effect without WithLastGoodFallback.
+func WithSoftDeadline(d time.Duration) DecodeOption {
+	return func(o *decodeOptions) {
+		o.softDeadline = d
+	}
+}
+
+// softDeadlineFetch is a request which carries on in the background after its
+// soft deadline, shared by every decode of the same kept value until it's done
+type softDeadlineFetch struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+var softDeadlineFetches = struct {
+	sync.Mutex
+	inFlight map[string]*softDeadlineFetch
+}{inFlight: make(map[string]*softDeadlineFetch)}
+
+// softDeadlineFetchFor returns the fetch in flight for key, starting one with
+// decode if there's none
+func softDeadlineFetchFor(key string, decode func() (any, error)) *softDeadlineFetch {
+	softDeadlineFetches.Lock()
+	defer softDeadlineFetches.Unlock()
+
+	if fetch, ok := softDeadlineFetches.inFlight[key]; ok {
+		return fetch
+	}
+
+	fetch := &softDeadlineFetch{done: make(chan struct{})}
+	softDeadlineFetches.inFlight[key] = fetch
+
+	go func() {
+		fetch.value, fetch.err = decode()
+
+		softDeadlineFetches.Lock()
+		delete(softDeadlineFetches.inFlight, key)
+		softDeadlineFetches.Unlock()
+		close(fetch.done)
+	}()
+
+	return fetch
+}
+
+// detachedRequest returns the request with a context which isn't cancelled along
+// with its own, so that it can outlive the caller, but which still ends at its
+// deadline or after the timeout of the default clients if it doesn't have one
+func detachedRequest(request *http.Request) (*http.Request, context.CancelFunc) {
+	timeout := defaultClientTimeout
+
+	if deadline, ok := request.Context().Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(request.Context()), timeout)
+
+	return request.WithContext(ctx), cancel
+}
+
+// decodeWithSoftDeadline calls decode, which has to keep what it decodes through
+// useLastGood, and returns the kept value if it's still running once the soft
+// deadline is up. Only one request is made at a time for each kept value, with
+// the others waiting on the same one. The options must not be used by anything
+// else once passed in.
+func decodeWithSoftDeadline[T any](options *decodeOptions, request *http.Request, decode func(*http.Request) (T, error)) (result T, err error) {
+	if options.softDeadline <= 0 || options.lastGoodMaxAge <= 0 {
+		return decode(request)
+	}
+
+	key := options.lastGoodKey(request)
+	entry, ok := getLastGood(key)
+	kept, isType := entry.value.(T)
+
+	if !ok || !isType || time.Since(entry.fetchedAt) > options.lastGoodMaxAge {
+		return decode(request)
+	}
+
+	startedAt := time.Now()
+	fetch := softDeadlineFetchFor(key, func() (any, error) {
+		detached, cancel := detachedRequest(request)
+		defer cancel()
+
+		return decode(detached)
+	})
+
+	timer := time.NewTimer(options.softDeadline)
+	defer timer.Stop()
+
+	select {
+	case <-fetch.done:
+		// the fetch may have been started by a decode into another type
+		// under the same key, in which case this one makes its own
+		if value, ok := fetch.value.(T); ok || fetch.err != nil {
+			return value, fetch.err
+		}
+
+		return decode(request)
+	case <-timer.C:
+		err = &StaleContentError{FetchedAt: entry.fetchedAt, Err: ErrSoftDeadlineExceeded}
+	case <-request.Context().Done():
+		err = &StaleContentError{FetchedAt: entry.fetchedAt, Err: request.Context().Err()}
+	}
+
+	wrapRequestError(request, startedAt, &err)
+
+	return kept, err
+}
@@ -0,0 +1,119 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type softDeadlineTestResponse struct {
+	Version int `json:"version"`
+}
+
+// newSlowVersionTestServer responds with how many requests it got,
+// taking 200ms for every request after the first
+func newSlowVersionTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		version := requests.Add(1)
+
+		if version > 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		w.Write([]byte(`{"version":` + strconv.Itoa(int(version)) + `}`))
+	})
+
+	return server, requests
+}
+
+var softDeadlineTestOptions = []DecodeOption{WithLastGoodFallback(time.Hour), WithSoftDeadline(100 * time.Millisecond)}
+
+func fetchSoftDeadlineTestVersion(ctx context.Context, server *httptest.Server) (int, error) {
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	response, err := decodeJsonFromRequest[softDeadlineTestResponse](server.Client(), request, softDeadlineTestOptions...)
+
+	return response.Version, err
+}
+
+func keptSoftDeadlineTestVersion(server *httptest.Server) int {
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	entry, _ := getLastGood(newDecodeOptions(softDeadlineTestOptions).lastGoodKey(request))
+	kept, _ := entry.value.(softDeadlineTestResponse)
+
+	return kept.Version
+}
+
+func primeSoftDeadlineTestServer(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	if version, err := fetchSoftDeadlineTestVersion(context.Background(), server); err != nil || version != 1 {
+		t.Fatalf("expected the first version to be fetched, got %d, %v", version, err)
+	}
+}
+
+func TestSoftDeadlineReturnsKeptValueAndUpdatesInBackground(t *testing.T) {
+	server, _ := newSlowVersionTestServer(t)
+	primeSoftDeadlineTestServer(t, server)
+
+	// the background request has to outlive the context of the caller
+	ctx, cancel := context.WithCancel(context.Background())
+	startedAt := time.Now()
+	version, err := fetchSoftDeadlineTestVersion(ctx, server)
+	elapsed := time.Since(startedAt)
+	cancel()
+
+	if version != 1 || !errors.Is(err, ErrSoftDeadlineExceeded) {
+		t.Fatalf("expected the kept version with ErrSoftDeadlineExceeded, got %d, %v", version, err)
+	}
+
+	var stale *StaleContentError
+
+	if !errors.As(err, &stale) {
+		t.Errorf("expected a StaleContentError, got %v", err)
+	}
+
+	if elapsed >= 200*time.Millisecond {
+		t.Errorf("expected the kept version to be returned at the soft deadline, took %v", elapsed)
+	}
+
+	waitForCondition(t, func() bool { return keptSoftDeadlineTestVersion(server) == 2 })
+}
+
+func TestSoftDeadlineSharesBackgroundRequest(t *testing.T) {
+	server, requests := newSlowVersionTestServer(t)
+	primeSoftDeadlineTestServer(t, server)
+
+	var wg sync.WaitGroup
+	versions := make([]int, 5)
+
+	for i := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			versions[i], _ = fetchSoftDeadlineTestVersion(context.Background(), server)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, version := range versions {
+		if version != 1 {
+			t.Errorf("request %d: expected the kept version, got %d", i, version)
+		}
+	}
+
+	waitForCondition(t, func() bool { return keptSoftDeadlineTestVersion(server) == 2 })
+
+	if requests.Load() != 2 {
+		t.Errorf("expected a single background request, got %d requests", requests.Load())
+	}
+}
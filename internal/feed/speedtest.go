@@ -0,0 +1,261 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnknownSpeedtestOutput = errors.New("unrecognized speedtest output")
+
+type SpeedtestResult struct {
+	// in megabits per second
+	Download float64 `json:"download"`
+	Upload   float64 `json:"upload"`
+	// in milliseconds
+	Ping       float64   `json:"ping"`
+	Jitter     float64   `json:"jitter,omitempty"`
+	Server     string    `json:"server,omitempty"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+type SpeedtestResults []SpeedtestResult
+
+func (r SpeedtestResults) Latest() *SpeedtestResult {
+	if len(r) == 0 {
+		return nil
+	}
+
+	return &r[len(r)-1]
+}
+
+type ooklaSpeedtestJson struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Ping      struct {
+		Jitter  float64 `json:"jitter"`
+		Latency float64 `json:"latency"`
+	} `json:"ping"`
+	Download struct {
+		// bytes per second
+		Bandwidth float64 `json:"bandwidth"`
+	} `json:"download"`
+	Upload struct {
+		Bandwidth float64 `json:"bandwidth"`
+	} `json:"upload"`
+	Server struct {
+		Name     string `json:"name"`
+		Location string `json:"location"`
+	} `json:"server"`
+}
+
+type speedtestCLIJson struct {
+	// bits per second
+	Download  float64 `json:"download"`
+	Upload    float64 `json:"upload"`
+	Ping      float64 `json:"ping"`
+	Timestamp string  `json:"timestamp"`
+	Server    struct {
+		Sponsor string `json:"sponsor"`
+		Name    string `json:"name"`
+	} `json:"server"`
+}
+
+type librespeedCLIJson struct {
+	Timestamp string `json:"timestamp"`
+	Server    struct {
+		Name string `json:"name"`
+	} `json:"server"`
+	// already in megabits per second
+	Download float64 `json:"download"`
+	Upload   float64 `json:"upload"`
+	Ping     float64 `json:"ping"`
+	Jitter   float64 `json:"jitter"`
+}
+
+func parseSpeedtestTimestamp(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t
+	}
+
+	return time.Now()
+}
+
+// ParseSpeedtestOutput reads the JSON output of one of the common speedtest
+// command line tools, which one it is gets detected from the shape of the output:
+// the official Ookla CLI (speedtest --format=json), speedtest-cli (--json)
+// and librespeed-cli (--json)
+func ParseSpeedtestOutput(output []byte) (*SpeedtestResult, error) {
+	output = []byte(strings.TrimSpace(string(output)))
+
+	if len(output) == 0 {
+		return nil, fmt.Errorf("%w: command produced no output", ErrUnknownSpeedtestOutput)
+	}
+
+	// librespeed-cli outputs an array with one result per server
+	if output[0] == '[' {
+		var results []librespeedCLIJson
+
+		if err := json.Unmarshal(output, &results); err != nil {
+			return nil, err
+		}
+
+		if len(results) == 0 {
+			return nil, fmt.Errorf("%w: no results", ErrUnknownSpeedtestOutput)
+		}
+
+		result := results[len(results)-1]
+
+		return &SpeedtestResult{
+			Download:   result.Download,
+			Upload:     result.Upload,
+			Ping:       result.Ping,
+			Jitter:     result.Jitter,
+			Server:     result.Server.Name,
+			MeasuredAt: parseSpeedtestTimestamp(result.Timestamp),
+		}, nil
+	}
+
+	var generic map[string]json.RawMessage
+
+	if err := json.Unmarshal(output, &generic); err != nil {
+		return nil, err
+	}
+
+	// the fields of the Ookla CLI are objects while speedtest-cli has numbers
+	if _, ok := generic["type"]; ok {
+		var result ooklaSpeedtestJson
+
+		if err := json.Unmarshal(output, &result); err != nil {
+			return nil, err
+		}
+
+		if result.Type != "result" {
+			return nil, fmt.Errorf("%w: type is %q", ErrUnknownSpeedtestOutput, result.Type)
+		}
+
+		server := result.Server.Name
+
+		if result.Server.Location != "" {
+			server += " (" + result.Server.Location + ")"
+		}
+
+		return &SpeedtestResult{
+			Download:   result.Download.Bandwidth * 8 / 1e6,
+			Upload:     result.Upload.Bandwidth * 8 / 1e6,
+			Ping:       result.Ping.Latency,
+			Jitter:     result.Ping.Jitter,
+			Server:     server,
+			MeasuredAt: parseSpeedtestTimestamp(result.Timestamp),
+		}, nil
+	}
+
+	if _, ok := generic["download"]; ok {
+		var result speedtestCLIJson
+
+		if err := json.Unmarshal(output, &result); err != nil {
+			return nil, err
+		}
+
+		server := result.Server.Sponsor
+
+		if result.Server.Name != "" {
+			server += " (" + result.Server.Name + ")"
+		}
+
+		return &SpeedtestResult{
+			Download:   result.Download / 1e6,
+			Upload:     result.Upload / 1e6,
+			Ping:       result.Ping,
+			Server:     strings.TrimSpace(server),
+			MeasuredAt: parseSpeedtestTimestamp(result.Timestamp),
+		}, nil
+	}
+
+	return nil, ErrUnknownSpeedtestOutput
+}
+
+type speedtestTrackerResultsResponseJson struct {
+	Data []struct {
+		Ping         float64 `json:"ping"`
+		DownloadBits float64 `json:"download_bits"`
+		UploadBits   float64 `json:"upload_bits"`
+		Status       string  `json:"status"`
+		CreatedAt    string  `json:"created_at"`
+		Data         struct {
+			Ping struct {
+				Jitter float64 `json:"jitter"`
+			} `json:"ping"`
+			Server struct {
+				Name string `json:"name"`
+			} `json:"server"`
+		} `json:"data"`
+	} `json:"data"`
+}
+
+// FetchSpeedtestTrackerResults gets the latest results measured by a
+// Speedtest Tracker (https://github.com/alexjustesen/speedtest-tracker)
+// instance, oldest first. Failed measurements are skipped.
+func FetchSpeedtestTrackerResults(ctx context.Context, baseURL string, token string, limit int) (SpeedtestResults, error) {
+	query := url.Values{}
+	query.Set("page[size]", strconv.Itoa(limit))
+	query.Set("sort", "-created_at")
+
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+"/api/v1/results?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := decodeJsonFromRequest[speedtestTrackerResultsResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	results := make(SpeedtestResults, 0, len(response.Data))
+
+	// the response is newest first
+	for i := len(response.Data) - 1; i >= 0; i-- {
+		result := response.Data[i]
+
+		if result.Status != "" && result.Status != "completed" {
+			continue
+		}
+
+		measuredAt, err := time.Parse(time.RFC3339Nano, result.CreatedAt)
+
+		if err != nil {
+			// older versions don't include the time zone
+			measuredAt, err = time.ParseInLocation(time.DateTime, result.CreatedAt, time.UTC)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		results = append(results, SpeedtestResult{
+			Download:   result.DownloadBits / 1e6,
+			Upload:     result.UploadBits / 1e6,
+			Ping:       result.Ping,
+			Jitter:     result.Data.Ping.Jitter,
+			Server:     result.Data.Server.Name,
+			MeasuredAt: measuredAt,
+		})
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no completed results", ErrNoContent)
+	}
+
+	return results, nil
+}
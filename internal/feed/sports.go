@@ -0,0 +1,442 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SportsMatchStatus int
+
+const (
+	SportsMatchScheduled SportsMatchStatus = iota
+	SportsMatchLive
+	SportsMatchFinished
+)
+
+type SportsTeam struct {
+	Name         string
+	Abbreviation string
+	Logo         string
+}
+
+type SportsMatch struct {
+	Home        SportsTeam
+	Away        SportsTeam
+	HomeScore   int
+	AwayScore   int
+	Status      SportsMatchStatus
+	Kickoff     time.Time
+	Competition string
+	// provider specific description of the state of the match, such as the
+	// minute of play or the quarter and time left in it
+	Detail string
+	// whether the team being followed is the home team
+	FollowedIsHome bool
+}
+
+func (m *SportsMatch) Team() SportsTeam {
+	if m.FollowedIsHome {
+		return m.Home
+	}
+
+	return m.Away
+}
+
+func (m *SportsMatch) Opponent() SportsTeam {
+	if m.FollowedIsHome {
+		return m.Away
+	}
+
+	return m.Home
+}
+
+// Result returns W, D or L from the perspective of the followed team
+func (m *SportsMatch) Result() string {
+	team, opponent := m.HomeScore, m.AwayScore
+
+	if !m.FollowedIsHome {
+		team, opponent = opponent, team
+	}
+
+	switch {
+	case team > opponent:
+		return "W"
+	case team < opponent:
+		return "L"
+	default:
+		return "D"
+	}
+}
+
+type SportsMatches []SportsMatch
+
+func (m SportsMatches) SortByKickoff() SportsMatches {
+	sort.SliceStable(m, func(i, j int) bool {
+		return m[i].Kickoff.Before(m[j].Kickoff)
+	})
+
+	return m
+}
+
+type TeamSchedule struct {
+	Team SportsTeam
+	Last *SportsMatch
+	Live *SportsMatch
+	Next *SportsMatch
+}
+
+// Schedule picks out the most recent result, the match in progress if there
+// is one and the next match that hasn't started yet
+func (m SportsMatches) Schedule() *TeamSchedule {
+	schedule := &TeamSchedule{}
+	m.SortByKickoff()
+
+	for i := range m {
+		match := &m[i]
+
+		switch match.Status {
+		case SportsMatchFinished:
+			schedule.Last = match
+		case SportsMatchLive:
+			if schedule.Live == nil {
+				schedule.Live = match
+			}
+		case SportsMatchScheduled:
+			if schedule.Next == nil && match.Kickoff.After(time.Now()) {
+				schedule.Next = match
+			}
+		}
+	}
+
+	for _, match := range []*SportsMatch{schedule.Live, schedule.Next, schedule.Last} {
+		if match != nil {
+			schedule.Team = match.Team()
+			break
+		}
+	}
+
+	return schedule
+}
+
+// SportsProvider fetches the matches of a team within a league, league and team
+// are in whatever format the provider identifies them by
+type SportsProvider interface {
+	FetchTeamMatches(ctx context.Context, league, team string) (SportsMatches, error)
+}
+
+var sportsProviders = map[string]func(apiKey string) SportsProvider{
+	"espn": func(string) SportsProvider {
+		return &espnSportsProvider{}
+	},
+	"football-data": func(apiKey string) SportsProvider {
+		return &footballDataSportsProvider{apiKey: apiKey}
+	},
+}
+
+func IsSportsProviderSupported(name string) bool {
+	_, ok := sportsProviders[name]
+	return ok
+}
+
+func NewSportsProvider(name, apiKey string) (SportsProvider, error) {
+	newProvider, ok := sportsProviders[name]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sports provider: %s", name)
+	}
+
+	return newProvider(apiKey), nil
+}
+
+func FetchTeamSchedule(ctx context.Context, provider SportsProvider, league, team string) (*TeamSchedule, error) {
+	matches, err := provider.FetchTeamMatches(ctx, league, team)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no matches found for team %s", ErrNoContent, team)
+	}
+
+	return matches.Schedule(), nil
+}
+
+type espnSportsProvider struct{}
+
+// the scoreboard endpoint returns scores as strings while the
+// schedule endpoints return them as objects, accept both
+type espnScore int
+
+func (s *espnScore) UnmarshalJSON(data []byte) error {
+	var text string
+
+	if err := json.Unmarshal(data, &text); err == nil {
+		value, _ := strconv.Atoi(text)
+		*s = espnScore(value)
+		return nil
+	}
+
+	var object struct {
+		Value float64 `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	*s = espnScore(object.Value)
+
+	return nil
+}
+
+type espnTeamJson struct {
+	ID           string `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Abbreviation string `json:"abbreviation"`
+	Logo         string `json:"logo"`
+}
+
+type espnScoreboardResponseJson struct {
+	Events []struct {
+		Date         string `json:"date"`
+		Competitions []struct {
+			Competitors []struct {
+				HomeAway string       `json:"homeAway"`
+				Score    espnScore    `json:"score"`
+				Team     espnTeamJson `json:"team"`
+			} `json:"competitors"`
+		} `json:"competitions"`
+		Status struct {
+			DisplayClock string `json:"displayClock"`
+			Type         struct {
+				State       string `json:"state"`
+				ShortDetail string `json:"shortDetail"`
+			} `json:"type"`
+		} `json:"status"`
+	} `json:"events"`
+	Leagues []struct {
+		Name string `json:"name"`
+	} `json:"leagues"`
+}
+
+func (t *espnTeamJson) matches(team string) bool {
+	return t.ID == team || strings.EqualFold(t.Abbreviation, team) || strings.EqualFold(t.DisplayName, team)
+}
+
+func parseESPNDate(date string) (time.Time, error) {
+	// dates usually come without seconds, such as 2024-08-16T19:00Z
+	if parsed, err := time.Parse("2006-01-02T15:04Z07:00", date); err == nil {
+		return parsed, nil
+	}
+
+	return time.Parse(time.RFC3339, date)
+}
+
+func (p *espnSportsProvider) FetchTeamMatches(ctx context.Context, league, team string) (SportsMatches, error) {
+	now := time.Now().UTC()
+	query := url.Values{}
+	query.Set("dates", now.AddDate(0, 0, -21).Format("20060102")+"-"+now.AddDate(0, 0, 45).Format("20060102"))
+	query.Set("limit", "1000")
+
+	request, err := http.NewRequestWithContext(ctx, "GET", "https://site.api.espn.com/apis/site/v2/sports/"+strings.Trim(league, "/")+"/scoreboard?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := decodeJsonFromRequest[espnScoreboardResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	competition := ""
+
+	if len(response.Leagues) > 0 {
+		competition = response.Leagues[0].Name
+	}
+
+	matches := make(SportsMatches, 0)
+
+	for i := range response.Events {
+		event := &response.Events[i]
+
+		if len(event.Competitions) == 0 {
+			continue
+		}
+
+		match := SportsMatch{Competition: competition, Detail: event.Status.Type.ShortDetail}
+		followed := false
+
+		for _, competitor := range event.Competitions[0].Competitors {
+			side := SportsTeam{
+				Name:         competitor.Team.DisplayName,
+				Abbreviation: competitor.Team.Abbreviation,
+				Logo:         competitor.Team.Logo,
+			}
+			isFollowed := competitor.Team.matches(team)
+
+			if competitor.HomeAway == "home" {
+				match.Home, match.HomeScore = side, int(competitor.Score)
+				match.FollowedIsHome = isFollowed
+			} else {
+				match.Away, match.AwayScore = side, int(competitor.Score)
+			}
+
+			followed = followed || isFollowed
+		}
+
+		if !followed {
+			continue
+		}
+
+		match.Kickoff, err = parseESPNDate(event.Date)
+
+		if err != nil {
+			continue
+		}
+
+		switch event.Status.Type.State {
+		case "in":
+			match.Status = SportsMatchLive
+		case "post":
+			match.Status = SportsMatchFinished
+		default:
+			match.Status = SportsMatchScheduled
+		}
+
+		if match.Status == SportsMatchLive && event.Status.DisplayClock != "" && match.Detail == "" {
+			match.Detail = event.Status.DisplayClock
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+type footballDataSportsProvider struct {
+	apiKey string
+}
+
+type footballDataTeamJson struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	ShortName string `json:"shortName"`
+	TLA       string `json:"tla"`
+	Crest     string `json:"crest"`
+}
+
+func (t *footballDataTeamJson) toTeam() SportsTeam {
+	name := t.ShortName
+
+	if name == "" {
+		name = t.Name
+	}
+
+	return SportsTeam{Name: name, Abbreviation: t.TLA, Logo: t.Crest}
+}
+
+type footballDataMatchesResponseJson struct {
+	Matches []struct {
+		UTCDate     time.Time            `json:"utcDate"`
+		Status      string               `json:"status"`
+		HomeTeam    footballDataTeamJson `json:"homeTeam"`
+		AwayTeam    footballDataTeamJson `json:"awayTeam"`
+		Competition struct {
+			Name string `json:"name"`
+		} `json:"competition"`
+		Score struct {
+			FullTime struct {
+				Home *int `json:"home"`
+				Away *int `json:"away"`
+			} `json:"fullTime"`
+		} `json:"score"`
+	} `json:"matches"`
+}
+
+var errMissingFootballDataAPIKey = errors.New("football-data.org requires an API key")
+
+func (p *footballDataSportsProvider) FetchTeamMatches(ctx context.Context, competition, team string) (SportsMatches, error) {
+	if p.apiKey == "" {
+		return nil, errMissingFootballDataAPIKey
+	}
+
+	teamID, err := strconv.Atoi(team)
+
+	if err != nil {
+		return nil, fmt.Errorf("football-data.org team must be a numeric ID, got %q", team)
+	}
+
+	query := url.Values{}
+
+	if competition != "" {
+		query.Set("competitions", competition)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.football-data.org/v4/teams/%d/matches?%s", teamID, query.Encode()), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-Auth-Token", p.apiKey)
+
+	response, err := decodeJsonFromRequest[footballDataMatchesResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(SportsMatches, 0, len(response.Matches))
+
+	for i := range response.Matches {
+		m := &response.Matches[i]
+
+		match := SportsMatch{
+			Home:           m.HomeTeam.toTeam(),
+			Away:           m.AwayTeam.toTeam(),
+			Kickoff:        m.UTCDate,
+			Competition:    m.Competition.Name,
+			FollowedIsHome: m.HomeTeam.ID == teamID,
+		}
+
+		switch m.Status {
+		case "IN_PLAY", "LIVE":
+			match.Status = SportsMatchLive
+			match.Detail = "Live"
+		case "PAUSED":
+			match.Status = SportsMatchLive
+			match.Detail = "HT"
+		case "FINISHED", "AWARDED":
+			match.Status = SportsMatchFinished
+			match.Detail = "FT"
+		case "SCHEDULED", "TIMED":
+			match.Status = SportsMatchScheduled
+		default:
+			// postponed, suspended or cancelled
+			continue
+		}
+
+		if m.Score.FullTime.Home != nil {
+			match.HomeScore = *m.Score.FullTime.Home
+		}
+
+		if m.Score.FullTime.Away != nil {
+			match.AwayScore = *m.Score.FullTime.Away
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
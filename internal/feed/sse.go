@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// lines longer than this end the stream with an error rather than being buffered forever
+const maxSSELineSize = 1024 * 1024
+
+// SSEEvent is an event received from a server-sent events stream
+type SSEEvent struct {
+	// empty for events which didn't set one, which are message events
+	Event string
+	// the data lines of the event joined with newlines
+	Data string
+	// the last id set by the stream, which carries over to the following events
+	ID string
+	// how long the server asks clients to wait before reconnecting, 0 if it didn't say
+	Retry time.Duration
+}
+
+// ConsumeSSE sends the request and calls handler for every event of the
+// server-sent events stream it returns, until the stream ends, ctx is done or
+// handler returns an error, which is then returned. The stream ending on its own
+// returns nil. Since the response is read for as long as the stream lasts, the
+// client shouldn't have a timeout, or the stream ends with an error once it's hit.
+func ConsumeSSE(ctx context.Context, client RequestDoer, request *http.Request, handler func(SSEEvent) error) error {
+	request = request.Clone(ctx)
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set("Cache-Control", "no-cache")
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 256*utf8.UTFMax))
+
+		return &HTTPError{
+			StatusCode: response.StatusCode,
+			URL:        request.URL.Redacted(),
+			Body:       truncateString(string(body), 256),
+		}
+	}
+
+	err = readSSEEvents(response.Body, handler)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// readSSEEvents parses the stream as described in the HTML spec, with events
+// which weren't ended by a blank line before the stream ended being dropped
+func readSSEEvents(body io.Reader, handler func(SSEEvent) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxSSELineSize)
+
+	var event SSEEvent
+	var data strings.Builder
+	hasData := false
+	first := true
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if line == "" {
+			if hasData {
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+
+				if err := handler(event); err != nil {
+					return err
+				}
+			}
+
+			// the id and retry carry over to the events which follow
+			event = SSEEvent{ID: event.ID, Retry: event.Retry}
+			data.Reset()
+			hasData = false
+			continue
+		}
+
+		// comments, usually sent to keep the connection open
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				event.ID = value
+			}
+		case "retry":
+			if milliseconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+				event.Retry = time.Duration(milliseconds) * time.Millisecond
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// SSEJSONDecodeError is returned by ConsumeSSEJSON when the data of an event isn't valid JSON
+type SSEJSONDecodeError struct {
+	// the data of the event as it was received
+	Data string
+	Err  error
+}
+
+func (e *SSEJSONDecodeError) Error() string {
+	return fmt.Sprintf("decoding data of event as JSON: %v, data: %s", e.Err, truncateString(e.Data, 256))
+}
+
+func (e *SSEJSONDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ConsumeSSEJSON is like ConsumeSSE for streams whose events have JSON as their
+// data, which is decoded into T before being passed to handler. Data split over
+// several data lines is joined first, so JSON spread over lines works as well.
+func ConsumeSSEJSON[T any](ctx context.Context, client RequestDoer, request *http.Request, handler func(T) error) error {
+	return ConsumeSSE(ctx, client, request, func(event SSEEvent) error {
+		var value T
+
+		if err := json.Unmarshal([]byte(event.Data), &value); err != nil {
+			return &SSEJSONDecodeError{Data: event.Data, Err: err}
+		}
+
+		return handler(value)
+	})
+}
@@ -0,0 +1,278 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collectSSEEvents(t *testing.T, stream string) []SSEEvent {
+	t.Helper()
+
+	var events []SSEEvent
+
+	err := readSSEEvents(strings.NewReader(stream), func(event SSEEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+func TestReadSSEEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		expected []SSEEvent
+	}{
+		{
+			name:     "single data line",
+			stream:   "data: hello\n\n",
+			expected: []SSEEvent{{Data: "hello"}},
+		},
+		{
+			name:     "multi-line data",
+			stream:   "data: first\ndata: second\ndata:third\n\n",
+			expected: []SSEEvent{{Data: "first\nsecond\nthird"}},
+		},
+		{
+			name:     "empty data lines are kept",
+			stream:   "data\ndata: after\ndata:\n\n",
+			expected: []SSEEvent{{Data: "\nafter\n"}},
+		},
+		{
+			name:     "event and id fields",
+			stream:   "event: update\nid: 42\ndata: {}\n\n",
+			expected: []SSEEvent{{Event: "update", ID: "42", Data: "{}"}},
+		},
+		{
+			name:   "event type resets while the id carries over",
+			stream: "event: update\nid: 1\ndata: a\n\ndata: b\n\nid: 2\ndata: c\n\n",
+			expected: []SSEEvent{
+				{Event: "update", ID: "1", Data: "a"},
+				{ID: "1", Data: "b"},
+				{ID: "2", Data: "c"},
+			},
+		},
+		{
+			name:     "id with a null character is ignored",
+			stream:   "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			expected: []SSEEvent{{ID: "1", Data: "a"}, {ID: "1", Data: "b"}},
+		},
+		{
+			name:     "empty id resets it",
+			stream:   "id: 1\ndata: a\n\nid\ndata: b\n\n",
+			expected: []SSEEvent{{ID: "1", Data: "a"}, {Data: "b"}},
+		},
+		{
+			name:     "retry",
+			stream:   "retry: 3000\ndata: a\n\nretry: soon\ndata: b\n\n",
+			expected: []SSEEvent{{Retry: 3 * time.Second, Data: "a"}, {Retry: 3 * time.Second, Data: "b"}},
+		},
+		{
+			name:     "comments and unknown fields",
+			stream:   ": keep-alive\nfoo: bar\ndata: a\n\n:\n\n",
+			expected: []SSEEvent{{Data: "a"}},
+		},
+		{
+			name:     "events without data aren't dispatched",
+			stream:   "event: ping\n\ndata: a\n\n",
+			expected: []SSEEvent{{Data: "a"}},
+		},
+		{
+			name:     "byte order mark and CRLF line endings",
+			stream:   "\ufeffdata: a\r\ndata: b\r\n\r\n",
+			expected: []SSEEvent{{Data: "a\nb"}},
+		},
+		{
+			name:     "unfinished event at the end is dropped",
+			stream:   "data: a\n\ndata: b\n",
+			expected: []SSEEvent{{Data: "a"}},
+		},
+		{
+			name:     "only the first space after the colon is removed",
+			stream:   "data:  indented\n\n",
+			expected: []SSEEvent{{Data: " indented"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := collectSSEEvents(t, test.stream)
+
+			if len(events) != len(test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, events)
+			}
+
+			for i := range events {
+				if events[i] != test.expected[i] {
+					t.Errorf("event %d: expected %+v, got %+v", i, test.expected[i], events[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadSSEEventsStopsOnHandlerError(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+
+	err := readSSEEvents(strings.NewReader("data: a\n\ndata: b\n\n"), func(SSEEvent) error {
+		calls++
+		return errStop
+	})
+
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("expected the handler error after the first event, got %v after %d calls", err, calls)
+	}
+}
+
+// newSSETestServer writes stream and then keeps the connection open until the
+// request is cancelled when hold is set
+func newSSETestServer(t *testing.T, stream string, hold bool) *httptest.Server {
+	t.Helper()
+
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "expected an event stream to be accepted", http.StatusNotAcceptable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(stream))
+		w.(http.Flusher).Flush()
+
+		if hold {
+			<-r.Context().Done()
+		}
+	})
+}
+
+func TestConsumeSSE(t *testing.T) {
+	server := newSSETestServer(t, "event: status\nid: 7\ndata: line one\ndata: line two\n\ndata: next\n\n", false)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	var events []SSEEvent
+
+	err := ConsumeSSE(context.Background(), server.Client(), request, func(event SSEEvent) error {
+		events = append(events, event)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SSEEvent{{Event: "status", ID: "7", Data: "line one\nline two"}, {ID: "7", Data: "next"}}
+
+	if len(events) != 2 || events[0] != expected[0] || events[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, events)
+	}
+}
+
+func TestConsumeSSEStatusError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "subscription expired", http.StatusUnauthorized)
+	})
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	err := ConsumeSSE(context.Background(), server.Client(), request, func(SSEEvent) error { return nil })
+
+	if httpErr, ok := ExtractHTTPError(err); !ok || httpErr.StatusCode != http.StatusUnauthorized || !strings.Contains(httpErr.Body, "subscription expired") {
+		t.Errorf("expected an HTTPError with the body, got %v", err)
+	}
+}
+
+func TestConsumeSSEStopsWhenCancelled(t *testing.T) {
+	server := newSSETestServer(t, "data: first\n\n", true)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	received := 0
+
+	err := ConsumeSSE(ctx, server.Client(), request, func(SSEEvent) error {
+		received++
+		// the stream stays open, so it only ends through the context
+		cancel()
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) || received != 1 {
+		t.Errorf("expected the stream to end with the context after 1 event, got %v after %d", err, received)
+	}
+}
+
+type sseTestMessage struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+func TestConsumeSSEJSON(t *testing.T) {
+	stream := "data: {\"name\": \"single\", \"count\": 1}\n\n" +
+		"event: update\nid: 2\n" +
+		"data: {\n" +
+		"data:   \"name\": \"multi-line\",\n" +
+		"data:   \"count\": 2,\n" +
+		"data:   \"tags\": [\"a\",\n" +
+		"data:     \"b\"]\n" +
+		"data: }\n\n"
+
+	server := newSSETestServer(t, stream, false)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	var messages []sseTestMessage
+
+	err := ConsumeSSEJSON(context.Background(), server.Client(), request, func(message sseTestMessage) error {
+		messages = append(messages, message)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %+v", messages)
+	}
+
+	if messages[0].Name != "single" || messages[0].Count != 1 {
+		t.Errorf("unexpected first message: %+v", messages[0])
+	}
+
+	if messages[1].Name != "multi-line" || messages[1].Count != 2 || len(messages[1].Tags) != 2 || messages[1].Tags[1] != "b" {
+		t.Errorf("expected the data lines to be joined before decoding, got %+v", messages[1])
+	}
+}
+
+func TestConsumeSSEJSONDecodeError(t *testing.T) {
+	server := newSSETestServer(t, "data: {\"name\": \"ok\"}\n\ndata: {\"name\":\ndata: oops}\n\ndata: {}\n\n", false)
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	received := 0
+
+	err := ConsumeSSEJSON(context.Background(), server.Client(), request, func(sseTestMessage) error {
+		received++
+		return nil
+	})
+
+	var decodeErr *SSEJSONDecodeError
+
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected an SSEJSONDecodeError, got %v", err)
+	}
+
+	if decodeErr.Data != "{\"name\":\noops}" || decodeErr.Err == nil {
+		t.Errorf("expected the raw data and the decode error, got %+v", decodeErr)
+	}
+
+	if received != 1 {
+		t.Errorf("expected the stream to stop at the invalid event, got %d messages", received)
+	}
+}
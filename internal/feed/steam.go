@@ -0,0 +1,451 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SteamFriend struct {
+	Name       string
+	AvatarURL  string
+	ProfileURL string
+	Game       string
+}
+
+type SteamRecentGame struct {
+	Name            string
+	IconURL         string
+	StoreURL        string
+	Playtime2Weeks  time.Duration
+	PlaytimeForever time.Duration
+}
+
+type SteamWishlistDiscount struct {
+	Name            string
+	StoreURL        string
+	DiscountPercent int
+	InitialPrice    string
+	FinalPrice      string
+}
+
+type SteamProfile struct {
+	FriendsInGame []SteamFriend
+	// set when the friend list or recently played games aren't visible with
+	// the given key, usually because the profile or its game details are private
+	FriendsPrivate     bool
+	RecentGames        []SteamRecentGame
+	RecentGamesPrivate bool
+	WishlistDiscounts  []SteamWishlistDiscount
+}
+
+type SteamRequest struct {
+	APIKey      string
+	SteamID     string
+	Friends     bool
+	RecentGames bool
+	Wishlist    bool
+	CountryCode string
+	Limit       int
+}
+
+// the store API is rate limited far more aggressively than the Web API,
+// to around 200 requests every 5 minutes, shared by every steam widget
+var steamStoreThrottle = &requestThrottle{interval: 1500 * time.Millisecond}
+
+type requestThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (t *requestThrottle) wait() {
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+
+	if at.Before(now) {
+		at = now
+	}
+
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+const steamWebAPIURL = "https://api.steampowered.com"
+
+func newSteamWebAPIRequest(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", steamWebAPIURL+path+"?"+query.Encode(), nil)
+}
+
+type steamFriendListResponseJson struct {
+	FriendsList struct {
+		Friends []struct {
+			SteamID string `json:"steamid"`
+		} `json:"friends"`
+	} `json:"friendslist"`
+}
+
+type steamPlayerSummariesResponseJson struct {
+	Response struct {
+		Players []struct {
+			PersonaName   string `json:"personaname"`
+			AvatarMedium  string `json:"avatarmedium"`
+			ProfileURL    string `json:"profileurl"`
+			GameExtraInfo string `json:"gameextrainfo"`
+		} `json:"players"`
+	} `json:"response"`
+}
+
+// fetchSteamFriendsInGame returns false instead of an error when the friend
+// list isn't visible, which is the case for private profiles
+func fetchSteamFriendsInGame(ctx context.Context, apiKey, steamID string) ([]SteamFriend, bool, error) {
+	request, err := newSteamWebAPIRequest(ctx, "/ISteamUser/GetFriendList/v1/", url.Values{
+		"key":          {apiKey},
+		"steamid":      {steamID},
+		"relationship": {"friend"},
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	response, body, err := fetchBodyFromRequestWithStatus(defaultClient(), request, func(status int) bool {
+		return status == http.StatusOK || status == http.StatusUnauthorized || status == http.StatusForbidden
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+
+	var friendList steamFriendListResponseJson
+
+	if err = json.Unmarshal(body, &friendList); err != nil {
+		return nil, false, err
+	}
+
+	ids := make([]string, len(friendList.FriendsList.Friends))
+
+	for i := range friendList.FriendsList.Friends {
+		ids[i] = friendList.FriendsList.Friends[i].SteamID
+	}
+
+	friends := make([]SteamFriend, 0)
+
+	// the summaries endpoint takes up to 100 IDs at a time
+	for start := 0; start < len(ids); start += 100 {
+		end := min(start+100, len(ids))
+
+		request, err := newSteamWebAPIRequest(ctx, "/ISteamUser/GetPlayerSummaries/v2/", url.Values{
+			"key":      {apiKey},
+			"steamids": {strings.Join(ids[start:end], ",")},
+		})
+
+		if err != nil {
+			return nil, true, err
+		}
+
+		summaries, err := decodeJsonFromRequest[steamPlayerSummariesResponseJson](defaultClient(), request)
+
+		if err != nil {
+			return nil, true, err
+		}
+
+		for _, player := range summaries.Response.Players {
+			if player.GameExtraInfo == "" {
+				continue
+			}
+
+			friends = append(friends, SteamFriend{
+				Name:       player.PersonaName,
+				AvatarURL:  player.AvatarMedium,
+				ProfileURL: player.ProfileURL,
+				Game:       player.GameExtraInfo,
+			})
+		}
+	}
+
+	sort.Slice(friends, func(i, j int) bool {
+		return strings.ToLower(friends[i].Name) < strings.ToLower(friends[j].Name)
+	})
+
+	return friends, true, nil
+}
+
+type steamRecentlyPlayedResponseJson struct {
+	Response struct {
+		// missing when the game details of the profile are private
+		TotalCount *int `json:"total_count"`
+		Games      []struct {
+			AppID           int    `json:"appid"`
+			Name            string `json:"name"`
+			Playtime2Weeks  int    `json:"playtime_2weeks"`
+			PlaytimeForever int    `json:"playtime_forever"`
+			IconHash        string `json:"img_icon_url"`
+		} `json:"games"`
+	} `json:"response"`
+}
+
+func fetchSteamRecentGames(ctx context.Context, apiKey, steamID string, limit int) ([]SteamRecentGame, bool, error) {
+	request, err := newSteamWebAPIRequest(ctx, "/IPlayerService/GetRecentlyPlayedGames/v1/", url.Values{
+		"key":     {apiKey},
+		"steamid": {steamID},
+		"count":   {strconv.Itoa(limit)},
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	response, err := decodeJsonFromRequest[steamRecentlyPlayedResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if response.Response.TotalCount == nil {
+		return nil, false, nil
+	}
+
+	games := make([]SteamRecentGame, 0, len(response.Response.Games))
+
+	for _, game := range response.Response.Games {
+		recentGame := SteamRecentGame{
+			Name:            game.Name,
+			StoreURL:        fmt.Sprintf("https://store.steampowered.com/app/%d/", game.AppID),
+			Playtime2Weeks:  time.Duration(game.Playtime2Weeks) * time.Minute,
+			PlaytimeForever: time.Duration(game.PlaytimeForever) * time.Minute,
+		}
+
+		if game.IconHash != "" {
+			recentGame.IconURL = fmt.Sprintf("https://media.steampowered.com/steamcommunity/public/images/apps/%d/%s.jpg", game.AppID, game.IconHash)
+		}
+
+		games = append(games, recentGame)
+	}
+
+	return games, true, nil
+}
+
+type steamWishlistResponseJson struct {
+	Response struct {
+		Items []struct {
+			AppID int `json:"appid"`
+		} `json:"items"`
+	} `json:"response"`
+}
+
+type steamAppDetailsJson struct {
+	Success bool `json:"success"`
+	// an empty array rather than an object when there's nothing to
+	// return for the requested filters, such as for free games
+	Data json.RawMessage `json:"data"`
+}
+
+type steamAppPriceJson struct {
+	PriceOverview *struct {
+		DiscountPercent  int    `json:"discount_percent"`
+		InitialFormatted string `json:"initial_formatted"`
+		FinalFormatted   string `json:"final_formatted"`
+	} `json:"price_overview"`
+}
+
+// names don't change so they're kept around to spare the store API
+var steamAppNames sync.Map
+
+func fetchSteamStoreAppDetails(ctx context.Context, appIDs []int, filter string, countryCode string) (map[string]steamAppDetailsJson, error) {
+	ids := make([]string, len(appIDs))
+
+	for i := range appIDs {
+		ids[i] = strconv.Itoa(appIDs[i])
+	}
+
+	query := url.Values{
+		"appids":  {strings.Join(ids, ",")},
+		"filters": {filter},
+	}
+
+	if countryCode != "" {
+		query.Set("cc", countryCode)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", "https://store.steampowered.com/api/appdetails?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	steamStoreThrottle.wait()
+
+	return decodeJsonFromRequest[map[string]steamAppDetailsJson](defaultClient(), request)
+}
+
+func fetchSteamAppName(ctx context.Context, appID int) (string, error) {
+	if name, ok := steamAppNames.Load(appID); ok {
+		return name.(string), nil
+	}
+
+	details, err := fetchSteamStoreAppDetails(ctx, []int{appID}, "basic", "")
+
+	if err != nil {
+		return "", err
+	}
+
+	var basic struct {
+		Name string `json:"name"`
+	}
+
+	app, ok := details[strconv.Itoa(appID)]
+
+	if !ok || !app.Success || json.Unmarshal(app.Data, &basic) != nil || basic.Name == "" {
+		return "", fmt.Errorf("no name found for app %d", appID)
+	}
+
+	steamAppNames.Store(appID, basic.Name)
+
+	return basic.Name, nil
+}
+
+func fetchSteamWishlistDiscounts(ctx context.Context, steamID, countryCode string, limit int) ([]SteamWishlistDiscount, error) {
+	request, err := newSteamWebAPIRequest(ctx, "/IWishlistService/GetWishlist/v1/", url.Values{
+		"steamid": {steamID},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// private wishlists come back empty rather than with an error
+	wishlist, err := decodeJsonFromRequest[steamWishlistResponseJson](defaultClient(), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]int, len(wishlist.Response.Items))
+
+	for i := range wishlist.Response.Items {
+		appIDs[i] = wishlist.Response.Items[i].AppID
+	}
+
+	type discountedApp struct {
+		appID int
+		SteamWishlistDiscount
+	}
+
+	discounted := make([]discountedApp, 0)
+
+	// multiple apps can only be requested at once when just asking for prices
+	for start := 0; start < len(appIDs); start += 100 {
+		end := min(start+100, len(appIDs))
+		details, err := fetchSteamStoreAppDetails(ctx, appIDs[start:end], "price_overview", countryCode)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, appID := range appIDs[start:end] {
+			app, ok := details[strconv.Itoa(appID)]
+
+			if !ok || !app.Success {
+				continue
+			}
+
+			var price steamAppPriceJson
+
+			if json.Unmarshal(app.Data, &price) != nil || price.PriceOverview == nil || price.PriceOverview.DiscountPercent <= 0 {
+				continue
+			}
+
+			discounted = append(discounted, discountedApp{
+				appID: appID,
+				SteamWishlistDiscount: SteamWishlistDiscount{
+					StoreURL:        fmt.Sprintf("https://store.steampowered.com/app/%d/", appID),
+					DiscountPercent: price.PriceOverview.DiscountPercent,
+					InitialPrice:    price.PriceOverview.InitialFormatted,
+					FinalPrice:      price.PriceOverview.FinalFormatted,
+				},
+			})
+		}
+	}
+
+	sort.SliceStable(discounted, func(i, j int) bool {
+		return discounted[i].DiscountPercent > discounted[j].DiscountPercent
+	})
+
+	if len(discounted) > limit {
+		discounted = discounted[:limit]
+	}
+
+	discounts := make([]SteamWishlistDiscount, 0, len(discounted))
+
+	for i := range discounted {
+		name, err := fetchSteamAppName(ctx, discounted[i].appID)
+
+		if err != nil {
+			slog.Warn("Failed to fetch Steam app name", "appid", discounted[i].appID, "error", err)
+			name = "App " + strconv.Itoa(discounted[i].appID)
+		}
+
+		discounted[i].Name = name
+		discounts = append(discounts, discounted[i].SteamWishlistDiscount)
+	}
+
+	return discounts, nil
+}
+
+func FetchSteamProfile(ctx context.Context, request SteamRequest) (*SteamProfile, error) {
+	profile := &SteamProfile{}
+	var errs []error
+	var err error
+
+	if request.Friends {
+		var visible bool
+		profile.FriendsInGame, visible, err = fetchSteamFriendsInGame(ctx, request.APIKey, request.SteamID)
+		profile.FriendsPrivate = err == nil && !visible
+		errs = append(errs, err)
+	}
+
+	if request.RecentGames {
+		var visible bool
+		profile.RecentGames, visible, err = fetchSteamRecentGames(ctx, request.APIKey, request.SteamID, request.Limit)
+		profile.RecentGamesPrivate = err == nil && !visible
+		errs = append(errs, err)
+	}
+
+	if request.Wishlist {
+		profile.WishlistDiscounts, err = fetchSteamWishlistDiscounts(ctx, request.SteamID, request.CountryCode, request.Limit)
+		errs = append(errs, err)
+	}
+
+	for i := range errs {
+		if errs[i] != nil {
+			slog.Error("Failed to fetch Steam data", "error", errs[i])
+		}
+	}
+
+	if err = mergeErr(errs); err != nil {
+		if errors.Is(err, ErrNoContent) {
+			return nil, err
+		}
+
+		return profile, err
+	}
+
+	return profile, nil
+}
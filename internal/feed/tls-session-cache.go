@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"crypto/tls"
+	"sync"
+)
+
+const defaultTLSSessionCacheSize = 64
+
+// sharedTLSSessionCache holds the TLS sessions of every client so that a new
+// connection to a host can resume the session of an earlier one, even when it
+// was made by a different widget, instead of doing a full handshake. The
+// underlying cache is swapped out rather than resized when the size changes.
+type sharedTLSSessionCache struct {
+	mu    sync.RWMutex
+	cache tls.ClientSessionCache
+}
+
+var tlsSessionCache = &sharedTLSSessionCache{
+	cache: tls.NewLRUClientSessionCache(defaultTLSSessionCacheSize),
+}
+
+// SetTLSSessionCacheSize changes how many TLS sessions are kept across all
+// clients, a size of 0 disables session resumption
+func SetTLSSessionCacheSize(size int) {
+	tlsSessionCache.mu.Lock()
+	defer tlsSessionCache.mu.Unlock()
+
+	if size <= 0 {
+		tlsSessionCache.cache = nil
+		return
+	}
+
+	tlsSessionCache.cache = tls.NewLRUClientSessionCache(size)
+}
+
+func (c *sharedTLSSessionCache) get(key string) (*tls.ClientSessionState, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cache == nil {
+		return nil, false
+	}
+
+	return c.cache.Get(key)
+}
+
+func (c *sharedTLSSessionCache) put(key string, session *tls.ClientSessionState) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.cache != nil {
+		c.cache.Put(key, session)
+	}
+}
+
+// tlsSessionCacheView is a tls.ClientSessionCache over the shared cache with its
+// own key prefix. Resumed sessions skip verifying the certificate chain again, so
+// clients which trust different CAs mustn't resume each other's sessions.
+type tlsSessionCacheView struct {
+	prefix string
+}
+
+func (v tlsSessionCacheView) Get(key string) (*tls.ClientSessionState, bool) {
+	return tlsSessionCache.get(v.prefix + "\x00" + key)
+}
+
+func (v tlsSessionCacheView) Put(key string, session *tls.ClientSessionState) {
+	tlsSessionCache.put(v.prefix+"\x00"+key, session)
+}
+
+// tlsSessionCacheFor returns the view of the shared cache for clients trusting
+// the CAs in caCertFile, or the system ones when it's empty
+func tlsSessionCacheFor(caCertFile string) tls.ClientSessionCache {
+	return tlsSessionCacheView{prefix: caCertFile}
+}
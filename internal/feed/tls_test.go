@@ -0,0 +1,196 @@
+package feed
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var testCertificateSerial int64
+
+// newTestCertificate creates a certificate signed by parent, or a self-signed
+// CA when parent is nil, valid for 127.0.0.1 until notAfter
+func newTestCertificate(t *testing.T, name string, parent *testCertificate, notAfter time.Time) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCertificateSerial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(testCertificateSerial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	signer, signerKey := template, key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCertificate{cert: cert, key: key}
+}
+
+// newTestTLSServer serves over TLS with leaf and the extra certificates sent after it
+func newTestTLSServer(t *testing.T, leaf *testCertificate, extra ...*testCertificate) *httptest.Server {
+	t.Helper()
+
+	chain := tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+
+	for _, cert := range extra {
+		chain.Certificate = append(chain.Certificate, cert.cert.Raw)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{chain}}
+	// rejected handshakes are expected, don't log them
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func writeTestCACertFile(t *testing.T, ca *testCertificate) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func getWithOptions(t *testing.T, url string, opts ...ClientOption) error {
+	t.Helper()
+
+	client, err := GetClientWithOptions(opts...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.Get(url)
+
+	if err == nil {
+		response.Body.Close()
+	}
+
+	return err
+}
+
+func TestCertificatePinning(t *testing.T) {
+	ca := newTestCertificate(t, "test CA", nil, time.Now().Add(time.Hour))
+	leaf := newTestCertificate(t, "server", ca, time.Now().Add(time.Hour))
+	unrelated := newTestCertificate(t, "unrelated CA", nil, time.Now().Add(time.Hour))
+
+	server := newTestTLSServer(t, leaf, ca)
+	caFile := writeTestCACertFile(t, ca)
+
+	tests := []struct {
+		name     string
+		pins     []string
+		insecure bool
+		matches  bool
+	}{
+		{name: "pin of the server certificate", pins: []string{ComputeCertPin(leaf.cert)}, matches: true},
+		{name: "pin of the CA", pins: []string{ComputeCertPin(ca.cert)}, matches: true},
+		{name: "one of several pins", pins: []string{ComputeCertPin(unrelated.cert), ComputeCertPin(leaf.cert)}, matches: true},
+		{name: "non-matching pin", pins: []string{ComputeCertPin(unrelated.cert)}, matches: false},
+		{name: "pin of the server certificate without verification", pins: []string{ComputeCertPin(leaf.cert)}, insecure: true, matches: true},
+		{name: "pin of the CA without verification", pins: []string{ComputeCertPin(ca.cert)}, insecure: true, matches: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := []ClientOption{WithCertificatePin(test.pins...)}
+
+			if test.insecure {
+				opts = append(opts, WithInsecureSkipVerify(true))
+			} else {
+				opts = append(opts, WithCACertFile(caFile))
+			}
+
+			err := getWithOptions(t, server.URL, opts...)
+
+			if test.matches && err != nil {
+				t.Errorf("expected the request to succeed, got %v", err)
+			}
+
+			if !test.matches && !errors.Is(err, ErrCertificatePinMismatch) {
+				t.Errorf("expected ErrCertificatePinMismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCertificatePinningIgnoresAppendedCertificates(t *testing.T) {
+	pinnedCA := newTestCertificate(t, "pinned CA", nil, time.Now().Add(time.Hour))
+	pinnedLeaf := newTestCertificate(t, "pinned server", pinnedCA, time.Now().Add(time.Hour))
+	attackerCA := newTestCertificate(t, "attacker CA", nil, time.Now().Add(time.Hour))
+	attackerLeaf := newTestCertificate(t, "attacker", attackerCA, time.Now().Add(time.Hour))
+
+	// the attacker's own certificate followed by the pinned ones, which aren't part of its chain
+	server := newTestTLSServer(t, attackerLeaf, pinnedLeaf, pinnedCA)
+
+	for _, pinned := range []*testCertificate{pinnedLeaf, pinnedCA} {
+		pin := WithCertificatePin(ComputeCertPin(pinned.cert))
+
+		if err := getWithOptions(t, server.URL, pin, WithInsecureSkipVerify(true)); !errors.Is(err, ErrCertificatePinMismatch) {
+			t.Errorf("%s without verification: expected ErrCertificatePinMismatch, got %v", pinned.cert.Subject.CommonName, err)
+		}
+
+		// the chain verifies against the attacker CA, which doesn't include the pinned certificate
+		if err := getWithOptions(t, server.URL, pin, WithCACertFile(writeTestCACertFile(t, attackerCA))); !errors.Is(err, ErrCertificatePinMismatch) {
+			t.Errorf("%s with verification: expected ErrCertificatePinMismatch, got %v", pinned.cert.Subject.CommonName, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !tor
+
+package feed
+
+import (
+	"errors"
+	"net/http"
+)
+
+var ErrTorNotSupported = errors.New("tor support is not included in this build, build with -tags tor to enable it")
+
+func newTorTransport(base *http.Transport, options clientOptions) (*http.Transport, error) {
+	return nil, ErrTorNotSupported
+}
+
+func withTorCircuitInfo(transport http.RoundTripper, base *http.Transport, options clientOptions) http.RoundTripper {
+	return transport
+}
@@ -0,0 +1,196 @@
+//go:build tor
+
+package feed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrTorControl = errors.New("tor control port error")
+
+const torControlTimeout = 5 * time.Second
+
+// TorCircuitInfo describes the circuit a request was sent through. Requests
+// with the same IsolationID share circuits, while requests with different
+// ones are never sent through the same circuit.
+type TorCircuitInfo struct {
+	IsolationID  string
+	SOCKSAddress string
+}
+
+type torCircuitInfoContextKey struct{}
+
+// TorCircuitInfoFromResponse returns the circuit information for a response
+// received through a client created with WithTorIsolation
+func TorCircuitInfoFromResponse(response *http.Response) (TorCircuitInfo, bool) {
+	if response == nil || response.Request == nil {
+		return TorCircuitInfo{}, false
+	}
+
+	info, ok := response.Request.Context().Value(torCircuitInfoContextKey{}).(TorCircuitInfo)
+	return info, ok
+}
+
+type torControlConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialTorControl(controlPort int, password string) (*torControlConn, error) {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", controlPort), torControlTimeout)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTorControl, err)
+	}
+
+	conn.SetDeadline(time.Now().Add(torControlTimeout))
+	control := &torControlConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if _, err = control.command(fmt.Sprintf("AUTHENTICATE %q", password)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return control, nil
+}
+
+// command sends a command and returns the lines of a successful reply
+// with the status code stripped from them
+func (c *torControlConn) command(command string) ([]string, error) {
+	if _, err := fmt.Fprintf(c.conn, "%s\r\n", command); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTorControl, err)
+	}
+
+	lines := make([]string, 0, 1)
+
+	for {
+		line, err := c.reader.ReadString('\n')
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTorControl, err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if len(line) < 4 {
+			return nil, fmt.Errorf("%w: malformed reply %q", ErrTorControl, line)
+		}
+
+		if line[:3] != "250" {
+			return nil, fmt.Errorf("%w: %s", ErrTorControl, line)
+		}
+
+		lines = append(lines, line[4:])
+
+		// a space after the status code marks the last line of the reply
+		if line[3] == ' ' {
+			return lines, nil
+		}
+	}
+}
+
+func (c *torControlConn) close() {
+	c.command("QUIT")
+	c.conn.Close()
+}
+
+func (c *torControlConn) socksAddress() (string, error) {
+	lines, err := c.command("GETINFO net/listeners/socks")
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range lines {
+		value, ok := strings.CutPrefix(line, "net/listeners/socks=")
+
+		if !ok {
+			continue
+		}
+
+		if listener := strings.Trim(strings.Fields(value + " ")[0], `"`); listener != "" {
+			return listener, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no SOCKS listener configured", ErrTorControl)
+}
+
+// RenewTorCircuits asks Tor to use new circuits for all new connections
+func RenewTorCircuits(controlPort int, password string) error {
+	control, err := dialTorControl(controlPort, password)
+
+	if err != nil {
+		return err
+	}
+
+	defer control.close()
+
+	_, err = control.command("SIGNAL NEWNYM")
+
+	return err
+}
+
+// newTorTransport routes requests through the SOCKS listener of Tor using a
+// username unique to this client, since Tor isolates streams with different
+// SOCKS credentials (IsolateSOCKSAuth, enabled by default) this gives every
+// client its own circuits
+func newTorTransport(base *http.Transport, options clientOptions) (*http.Transport, error) {
+	control, err := dialTorControl(options.torControlPort, options.torControlPassword)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer control.close()
+
+	address, err := control.socksAddress()
+
+	if err != nil {
+		return nil, err
+	}
+
+	proxyURL := &url.URL{
+		Scheme: "socks5",
+		User:   url.UserPassword(options.torIsolationID, "glance"),
+		Host:   address,
+	}
+
+	transport := base.Clone()
+	// hostnames are resolved by Tor, which is required for .onion addresses
+	// and keeps lookups from leaking outside of it
+	transport.Proxy = http.ProxyURL(proxyURL)
+
+	return transport, nil
+}
+
+type torCircuitInfoRoundTripper struct {
+	next http.RoundTripper
+	info TorCircuitInfo
+}
+
+func (rt *torCircuitInfoRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.WithContext(context.WithValue(request.Context(), torCircuitInfoContextKey{}, rt.info))
+
+	return rt.next.RoundTrip(request)
+}
+
+func withTorCircuitInfo(transport http.RoundTripper, base *http.Transport, options clientOptions) http.RoundTripper {
+	proxyURL, _ := base.Proxy(nil)
+
+	return &torCircuitInfoRoundTripper{
+		next: transport,
+		info: TorCircuitInfo{
+			IsolationID:  options.torIsolationID,
+			SOCKSAddress: proxyURL.Host,
+		},
+	}
+}
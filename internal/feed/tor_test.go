@@ -0,0 +1,201 @@
+//go:build tor
+
+package feed
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newTorControlTestServer mocks the control port of a Tor instance with its
+// SOCKS listener at socksAddress, returning the port it listens on
+func newTorControlTestServer(t *testing.T, password string, socksAddress string) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+
+				for {
+					line, err := reader.ReadString('\n')
+
+					if err != nil {
+						return
+					}
+
+					command := strings.TrimRight(line, "\r\n")
+
+					switch {
+					case command == fmt.Sprintf("AUTHENTICATE %q", password):
+						fmt.Fprint(conn, "250 OK\r\n")
+					case strings.HasPrefix(command, "AUTHENTICATE"):
+						fmt.Fprint(conn, "515 Authentication failed\r\n")
+						return
+					case command == "GETINFO net/listeners/socks":
+						fmt.Fprintf(conn, "250-net/listeners/socks=\"%s\"\r\n250 OK\r\n", socksAddress)
+					case command == "SIGNAL NEWNYM":
+						fmt.Fprint(conn, "250 OK\r\n")
+					case command == "QUIT":
+						fmt.Fprint(conn, "250 closing connection\r\n")
+						return
+					default:
+						fmt.Fprint(conn, "510 Unrecognized command\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func newTorTestSetup(t *testing.T) (*socksTestServer, int) {
+	t.Helper()
+	t.Cleanup(ResetDefaultClients)
+
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+
+	socks := newSOCKSTestServer(t, upstream.Listener.Addr().String())
+
+	return socks, newTorControlTestServer(t, "secret", socks.address())
+}
+
+func TestTorIsolationClientPerKey(t *testing.T) {
+	socks, controlPort := newTorTestSetup(t)
+
+	first, err := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-1"))
+	second, _ := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-2"))
+
+	if first != again {
+		t.Error("expected the same key to reuse its client")
+	}
+
+	if first == second {
+		t.Error("expected different keys to get different clients")
+	}
+
+	for _, test := range []struct {
+		client *http.Client
+		key    string
+	}{{first, "widget-1"}, {second, "widget-2"}} {
+		response, err := test.client.Get("http://example.onion/")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response.Body.Close()
+		info, ok := TorCircuitInfoFromResponse(response)
+
+		if !ok || info.IsolationID != torIsolationIDFromKey(test.key) || info.SOCKSAddress != socks.address() {
+			t.Errorf("unexpected circuit info for %s: %+v", test.key, info)
+		}
+	}
+
+	requests := socks.received()
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests through the proxy, got %d", len(requests))
+	}
+
+	if requests[0].username == requests[1].username {
+		t.Errorf("expected different SOCKS usernames for different keys, got %q for both", requests[0].username)
+	}
+
+	for _, request := range requests {
+		if !request.isHostname || request.host != "example.onion" {
+			t.Errorf("expected the hostname to be resolved by Tor, got %+v", request)
+		}
+	}
+}
+
+func TestTorIsolationKeyRequiresTor(t *testing.T) {
+	_, controlPort := newTorTestSetup(t)
+
+	if client, _ := GetClientWithOptions(WithTorIsolationKey("widget-1")); client != defaultClient() {
+		t.Error("expected an isolation key without WithTorIsolation to not change the client")
+	}
+
+	unkeyed, err := GetClientWithOptions(WithTorIsolation(controlPort, "secret"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyed, _ := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-1")); keyed == unkeyed {
+		t.Error("expected clients without a key to not share circuits with keyed ones")
+	}
+}
+
+func TestTorIsolationEvictsOldestClients(t *testing.T) {
+	_, controlPort := newTorTestSetup(t)
+
+	oldest, err := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-0"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= maxTorIsolatedClients; i++ {
+		if _, err := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey(fmt.Sprintf("widget-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cached := 0
+
+	clientCache.Range(func(key, _ any) bool {
+		if options, ok := key.(clientOptions); ok && options.torIsolationID != "" {
+			cached++
+		}
+
+		return true
+	})
+
+	if cached != maxTorIsolatedClients {
+		t.Errorf("expected %d cached clients, got %d", maxTorIsolatedClients, cached)
+	}
+
+	if recreated, _ := GetClientWithOptions(WithTorIsolation(controlPort, "secret"), WithTorIsolationKey("widget-0")); recreated == oldest {
+		t.Error("expected the oldest client to have been evicted")
+	}
+}
+
+func TestTorIsolationWrongPassword(t *testing.T) {
+	_, controlPort := newTorTestSetup(t)
+
+	_, err := GetClientWithOptions(WithTorIsolation(controlPort, "wrong"))
+
+	if !errors.Is(err, ErrTorControl) {
+		t.Errorf("expected ErrTorControl, got %v", err)
+	}
+}
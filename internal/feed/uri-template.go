@@ -0,0 +1,165 @@
+package feed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type uriTemplateOperator struct {
+	first         string
+	separator     string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+var uriTemplateOperators = map[byte]uriTemplateOperator{
+	'+': {first: "", separator: ",", allowReserved: true},
+	'#': {first: "#", separator: ",", allowReserved: true},
+	'.': {first: ".", separator: "."},
+	'/': {first: "/", separator: "/"},
+	';': {first: ";", separator: ";", named: true},
+	'?': {first: "?", separator: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", separator: "&", named: true, ifEmpty: "="},
+}
+
+// expandURITemplate expands a URI template (RFC 6570) up to level 4, with the
+// exception of list and associative array values since every variable is a
+// string. Variables missing from vars are undefined and left out along with
+// their separator, as the RFC describes.
+func expandURITemplate(template string, vars map[string]string) (string, error) {
+	var result strings.Builder
+
+	for {
+		start := strings.IndexByte(template, '{')
+
+		if start == -1 {
+			result.WriteString(template)
+			break
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+
+		if end == -1 {
+			return "", fmt.Errorf("unclosed expression in uri template at position %d", start)
+		}
+
+		result.WriteString(template[:start])
+
+		if err := expandURITemplateExpression(&result, template[start+1:start+end], vars); err != nil {
+			return "", err
+		}
+
+		template = template[start+end+1:]
+	}
+
+	return result.String(), nil
+}
+
+func expandURITemplateExpression(result *strings.Builder, expression string, vars map[string]string) error {
+	if expression == "" {
+		return fmt.Errorf("empty expression in uri template")
+	}
+
+	operator := uriTemplateOperator{separator: ","}
+
+	if op, ok := uriTemplateOperators[expression[0]]; ok {
+		operator = op
+		expression = expression[1:]
+	}
+
+	defined := 0
+
+	for _, spec := range strings.Split(expression, ",") {
+		// explode only changes how lists and maps are expanded
+		name := strings.TrimSuffix(spec, "*")
+		prefix := -1
+
+		if i := strings.IndexByte(name, ':'); i != -1 {
+			length, err := strconv.Atoi(name[i+1:])
+
+			if err != nil || length <= 0 || length >= 10000 {
+				return fmt.Errorf("invalid prefix modifier in uri template: %s", spec)
+			}
+
+			name, prefix = name[:i], length
+		}
+
+		if name == "" {
+			return fmt.Errorf("missing variable name in uri template expression: %s", expression)
+		}
+
+		value, ok := vars[name]
+
+		if !ok {
+			continue
+		}
+
+		if defined == 0 {
+			result.WriteString(operator.first)
+		} else {
+			result.WriteString(operator.separator)
+		}
+
+		defined++
+
+		if prefix != -1 {
+			if runes := []rune(value); len(runes) > prefix {
+				value = string(runes[:prefix])
+			}
+		}
+
+		if operator.named {
+			result.WriteString(name)
+
+			if value == "" {
+				result.WriteString(operator.ifEmpty)
+				continue
+			}
+
+			result.WriteByte('=')
+		}
+
+		result.WriteString(encodeURITemplateValue(value, operator.allowReserved))
+	}
+
+	return nil
+}
+
+func isURITemplateUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isURITemplateReserved(c byte) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) != -1
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func encodeURITemplateValue(value string, allowReserved bool) string {
+	var result strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		if isURITemplateUnreserved(c) || allowReserved && isURITemplateReserved(c) {
+			result.WriteByte(c)
+			continue
+		}
+
+		// reserved expansion passes through values which are already encoded
+		if allowReserved && c == '%' && i+2 < len(value) && isHexDigit(value[i+1]) && isHexDigit(value[i+2]) {
+			result.WriteString(value[i : i+3])
+			i += 2
+			continue
+		}
+
+		fmt.Fprintf(&result, "%%%02X", c)
+	}
+
+	return result.String()
+}
@@ -0,0 +1,121 @@
+package feed
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed version number which can be compared with others,
+// mostly following semver but without requiring exactly three components
+// so that versions such as 1.2 and date-based ones such as 2024.10.1 work too
+type Version struct {
+	Original   string
+	Components []int
+	// dot separated identifiers after the -, empty for stable versions
+	PreRelease []string
+}
+
+var dashedDateVersionPattern = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})`)
+
+// ParseVersion accepts a leading v, as in v1.2.3, and dates separated with dashes,
+// as in 2024-10-01, anything after a + is build metadata and is ignored
+func ParseVersion(version string) (Version, error) {
+	parsed := Version{Original: version}
+
+	version = strings.TrimSpace(version)
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	version, _, _ = strings.Cut(version, "+")
+	version = dashedDateVersionPattern.ReplaceAllString(version, "$1.$2.$3")
+
+	core, preRelease, _ := strings.Cut(version, "-")
+
+	if core == "" {
+		return Version{}, fmt.Errorf("invalid version: %q", parsed.Original)
+	}
+
+	for _, component := range strings.Split(core, ".") {
+		number, err := strconv.Atoi(component)
+
+		if err != nil || number < 0 {
+			return Version{}, fmt.Errorf("invalid version: %q", parsed.Original)
+		}
+
+		parsed.Components = append(parsed.Components, number)
+	}
+
+	if preRelease != "" {
+		parsed.PreRelease = strings.Split(preRelease, ".")
+	}
+
+	return parsed, nil
+}
+
+// Compare returns -1 if v is older than other, 1 if it's newer and 0 if they're the same,
+// missing components count as 0 so that 1.2 and 1.2.0 are the same version
+func (v Version) Compare(other Version) int {
+	for i := range max(len(v.Components), len(other.Components)) {
+		var a, b int
+
+		if i < len(v.Components) {
+			a = v.Components[i]
+		}
+
+		if i < len(other.Components) {
+			b = other.Components[i]
+		}
+
+		if a != b {
+			return cmp.Compare(a, b)
+		}
+	}
+
+	// a pre-release comes before the stable version
+	if len(v.PreRelease) == 0 || len(other.PreRelease) == 0 {
+		return cmp.Compare(len(other.PreRelease), len(v.PreRelease))
+	}
+
+	for i := range min(len(v.PreRelease), len(other.PreRelease)) {
+		if result := comparePreReleaseIdentifiers(v.PreRelease[i], other.PreRelease[i]); result != 0 {
+			return result
+		}
+	}
+
+	return cmp.Compare(len(v.PreRelease), len(other.PreRelease))
+}
+
+// numeric identifiers are compared as numbers and come before alphanumeric ones
+func comparePreReleaseIdentifiers(a, b string) int {
+	aNumber, aErr := strconv.Atoi(a)
+	bNumber, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(aNumber, bNumber)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// CompareVersions parses both versions and compares them as in Version.Compare
+func CompareVersions(a, b string) (int, error) {
+	aVersion, err := ParseVersion(a)
+
+	if err != nil {
+		return 0, err
+	}
+
+	bVersion, err := ParseVersion(b)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return aVersion.Compare(bVersion), nil
+}
@@ -0,0 +1,223 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+var errNothingToRepair = errors.New("document could not be repaired")
+
+// repairFeedXML rebuilds an RSS or Atom document token by token, dropping any
+// item or entry that can't be parsed instead of rejecting the whole document.
+// After a syntax error inside an item the parsing resumes at the next item,
+// anything that's broken outside of items ends the document at that point.
+// The result is always UTF-8 and is returned along with the number of items
+// which had to be dropped.
+func repairFeedXML(body []byte) ([]byte, int, error) {
+	var out bytes.Buffer
+	outerStack := make([]string, 0, 4)
+	skipped := 0
+	kept := 0
+
+	newDecoder := func(offset int64) *xml.Decoder {
+		decoder := xml.NewDecoder(bytes.NewReader(body[offset:]))
+		decoder.Strict = false
+		decoder.Entity = xml.HTMLEntity
+		decoder.CharsetReader = charset.NewReaderLabel
+		return decoder
+	}
+
+	decoder := newDecoder(0)
+	base := int64(0)
+
+	var item bytes.Buffer
+	itemStack := make([]string, 0, 8)
+	itemBroken := false
+
+	finishItem := func() {
+		if itemBroken {
+			skipped++
+		} else {
+			out.Write(item.Bytes())
+			kept++
+		}
+
+		item.Reset()
+		itemStack = itemStack[:0]
+		itemBroken = false
+	}
+
+	for {
+		token, err := decoder.RawToken()
+
+		if err == io.EOF {
+			if len(itemStack) > 0 {
+				skipped++
+			}
+
+			break
+		}
+
+		if err != nil {
+			if len(itemStack) == 0 {
+				break
+			}
+
+			itemBroken = true
+			finishItem()
+
+			next := nextFeedItemOffset(body, base+decoder.InputOffset())
+
+			if next < 0 {
+				break
+			}
+
+			base = next
+			decoder = newDecoder(next)
+			continue
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := rawXMLName(t.Name)
+
+			if len(itemStack) > 0 || t.Name.Local == "item" || t.Name.Local == "entry" {
+				itemStack = append(itemStack, name)
+				writeXMLToken(&item, t)
+				continue
+			}
+
+			outerStack = append(outerStack, name)
+			writeXMLToken(&out, t)
+		case xml.EndElement:
+			name := rawXMLName(t.Name)
+
+			if len(itemStack) > 0 {
+				// RawToken doesn't check that elements are closed in the right
+				// order so mismatches are caught here, the item is still
+				// consumed up to its end so that parsing can carry on after it
+				open := slices.Index(itemStack, name)
+
+				if open < 0 {
+					itemBroken = true
+					continue
+				}
+
+				if open != len(itemStack)-1 {
+					itemBroken = true
+				}
+
+				itemStack = itemStack[:open]
+				writeXMLToken(&item, t)
+
+				if len(itemStack) == 0 {
+					finishItem()
+				}
+
+				continue
+			}
+
+			if len(outerStack) > 0 && outerStack[len(outerStack)-1] == name {
+				outerStack = outerStack[:len(outerStack)-1]
+				writeXMLToken(&out, t)
+			}
+		case xml.ProcInst, xml.Directive:
+			// the declaration is dropped since the output is always UTF-8
+			continue
+		default:
+			if len(itemStack) > 0 {
+				writeXMLToken(&item, t)
+			} else if len(outerStack) > 0 {
+				writeXMLToken(&out, t)
+			}
+			// whatever is outside of the root element is dropped, the whitespace
+			// there would otherwise be escaped into text the document can't have
+		}
+	}
+
+	if kept == 0 {
+		return nil, skipped, errNothingToRepair
+	}
+
+	for i := len(outerStack) - 1; i >= 0; i-- {
+		out.WriteString("</" + outerStack[i] + ">")
+	}
+
+	return out.Bytes(), skipped, nil
+}
+
+func nextFeedItemOffset(body []byte, from int64) int64 {
+	if from >= int64(len(body)) {
+		return -1
+	}
+
+	rest := body[from:]
+	next := -1
+
+	for _, tag := range [][]byte{[]byte("<item"), []byte("<entry")} {
+		for start := 0; ; {
+			i := bytes.Index(rest[start:], tag)
+
+			if i < 0 {
+				break
+			}
+
+			i += start
+			end := i + len(tag)
+
+			// make sure it's not just a tag starting with the same name
+			if end < len(rest) && (rest[end] == '>' || rest[end] == ' ' || rest[end] == '\t' || rest[end] == '\n' || rest[end] == '\r') {
+				if next < 0 || i < next {
+					next = i
+				}
+
+				break
+			}
+
+			start = end
+		}
+	}
+
+	if next < 0 {
+		return -1
+	}
+
+	return from + int64(next)
+}
+
+func rawXMLName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func writeXMLToken(w *bytes.Buffer, token xml.Token) {
+	switch t := token.(type) {
+	case xml.StartElement:
+		w.WriteString("<" + rawXMLName(t.Name))
+
+		for _, attr := range t.Attr {
+			w.WriteString(" " + rawXMLName(attr.Name) + `="`)
+			xml.EscapeText(w, []byte(attr.Value))
+			w.WriteString(`"`)
+		}
+
+		w.WriteString(">")
+	case xml.EndElement:
+		w.WriteString("</" + rawXMLName(t.Name) + ">")
+	case xml.CharData:
+		xml.EscapeText(w, t)
+	case xml.Comment:
+		if !bytes.Contains(t, []byte("--")) {
+			w.WriteString("<!--" + strings.ToValidUTF8(string(t), "") + "-->")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func readBrokenFeedFixture(t *testing.T) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile("testdata/rss-broken-trailing-item.xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestRepairFeedXMLDropsBrokenTrailingItem(t *testing.T) {
+	body := readBrokenFeedFixture(t)
+
+	if _, err := gofeed.NewParser().Parse(bytes.NewReader(body)); err == nil {
+		t.Fatal("expected the fixture to be rejected without repairing it")
+	}
+
+	repaired, skipped, err := repairFeedXML(body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if skipped != 1 {
+		t.Errorf("expected 1 skipped item, got %d", skipped)
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(repaired))
+
+	if err != nil {
+		t.Fatalf("expected the repaired document to parse, got %v", err)
+	}
+
+	if feed.Title != "Example feed" || len(feed.Items) != 2 {
+		t.Fatalf("expected the channel and its 2 valid items, got %q with %d items", feed.Title, len(feed.Items))
+	}
+
+	if feed.Items[0].Title != "First post" || feed.Items[1].Title != "Second post" {
+		t.Errorf("unexpected items: %q, %q", feed.Items[0].Title, feed.Items[1].Title)
+	}
+}
+
+func TestRepairFeedXMLWithoutValidItems(t *testing.T) {
+	_, _, err := repairFeedXML([]byte(`<rss><channel><item><title>a</b></title></item></channel></rss>`))
+
+	if !errors.Is(err, errNothingToRepair) {
+		t.Errorf("expected errNothingToRepair, got %v", err)
+	}
+}
+
+func TestGetItemsFromRSSFeedTaskKeepsValidItems(t *testing.T) {
+	body := readBrokenFeedFixture(t)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write(body)
+	})
+
+	items, err := getItemsFromRSSFeedTask(context.Background(), RSSFeedRequest{Url: server.URL})
+
+	if !errors.Is(err, ErrPartialContent) {
+		t.Errorf("expected ErrPartialContent for the skipped item, got %v", err)
+	}
+
+	if len(items) != 2 || items[0].Link != "https://example.com/first" {
+		t.Errorf("expected the 2 valid items, got %+v", items)
+	}
+}
@@ -1,6 +1,7 @@
 package glance
 
 import (
+	"errors"
 	"flag"
 	"os"
 )
@@ -10,11 +11,18 @@ type CliIntent uint8
 const (
 	CliIntentServe       CliIntent = iota
 	CliIntentCheckConfig           = iota
+	CliIntentExportState           = iota
+	CliIntentImportState           = iota
+	CliIntentRender                = iota
 )
 
 type CliOptions struct {
 	Intent     CliIntent
 	ConfigPath string
+	// where state is exported to or imported from, - or empty for stdout or stdin
+	StatePath    string
+	RenderPage   string
+	RenderOutput string
 }
 
 func ParseCliOptions() (*CliOptions, error) {
@@ -22,21 +30,45 @@ func ParseCliOptions() (*CliOptions, error) {
 
 	checkConfig := flags.Bool("check-config", false, "Check whether the config is valid")
 	configPath := flags.String("config", "glance.yml", "Set config path")
+	renderPage := flags.String("page", "", "Set the slug of the page to render, the first page if empty")
+	renderOutput := flags.String("output", "", "Set the file the rendered page is written to, stdout if empty")
 
-	err := flags.Parse(os.Args[1:])
+	intent := CliIntentServe
+	args := os.Args[1:]
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "export-state":
+			intent = CliIntentExportState
+			args = args[1:]
+		case "import-state":
+			intent = CliIntentImportState
+			args = args[1:]
+		case "render":
+			intent = CliIntentRender
+			args = args[1:]
+		}
+	}
+
+	err := flags.Parse(args)
 
 	if err != nil {
 		return nil, err
 	}
 
-	intent := CliIntentServe
-
 	if *checkConfig {
 		intent = CliIntentCheckConfig
 	}
 
+	if intent == CliIntentImportState && flags.NArg() == 0 {
+		return nil, errors.New("import-state requires the path of the file to import, or - for stdin")
+	}
+
 	return &CliOptions{
-		Intent:     intent,
-		ConfigPath: *configPath,
+		Intent:       intent,
+		ConfigPath:   *configPath,
+		StatePath:    flags.Arg(0),
+		RenderPage:   *renderPage,
+		RenderOutput: *renderOutput,
 	}, nil
 }
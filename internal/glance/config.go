@@ -3,14 +3,20 @@ package glance
 import (
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/glanceapp/glance/internal/widget"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server Server `yaml:"server"`
-	Theme  Theme  `yaml:"theme"`
-	Pages  []Page `yaml:"pages"`
+	Server     Server                  `yaml:"server"`
+	Theme      Theme                   `yaml:"theme"`
+	Pages      []Page                  `yaml:"pages"`
+	AllowExec  bool                    `yaml:"allow-exec"`
+	Language   string                  `yaml:"language"`
+	QuietHours *widget.QuietHoursField `yaml:"quiet-hours"`
 }
 
 func NewConfigFromYml(contents io.Reader) (*Config, error) {
@@ -41,11 +47,76 @@ func NewConfig() *Config {
 	config.Server.Host = ""
 	config.Server.Port = 8080
 	config.Server.ProxyURL = ""
+	config.Server.HTTPDebugLog.MaxSize = 10 * 1024 * 1024
+	config.Server.HTTPDebugLog.MaxBackups = 3
+	config.Server.HTTPDebugLog.SampleRate = 1
+	config.Server.HTTPDebugLog.ErrorSummaryInterval = widget.DurationField(time.Minute)
+	config.Server.DNSFailureCacheTTL = widget.DurationField(30 * time.Second)
+	config.Server.TLSSessionCache = 64
+	config.Server.ShutdownTimeout = widget.DurationField(10 * time.Second)
+	config.Server.RenderTimeout = widget.DurationField(3 * time.Second)
+	config.Server.MaxWidgetUpdates = 10
+	config.Server.Prefetch = PrefetchFirstPage
+	config.Server.ImageProxy.CacheDir = "glance-image-cache"
+	config.Server.ImageProxy.MaxCacheSize = 200 * 1024 * 1024
+	config.Server.DataFile = "glance-data.json"
 
 	return config
 }
 
 func configIsValid(config *Config) error {
+	if config.Server.HostConcurrency.Default < 0 {
+		return fmt.Errorf("max-concurrent-requests-per-host default can't be negative")
+	}
+
+	if config.Server.MaxConcurrent < 0 {
+		return fmt.Errorf("max-concurrent-requests can't be negative")
+	}
+
+	if config.Server.SoftDeadline > 0 && config.Server.StaleFallback == 0 {
+		return fmt.Errorf("soft-deadline requires stale-fallback to be set")
+	}
+
+	if rate := config.Server.HTTPDebugLog.SampleRate; rate < 0 || rate > 1 {
+		return fmt.Errorf("http-debug-log sample-rate must be between 0 and 1, got %g", rate)
+	}
+
+	if config.Server.TLSSessionCache < 0 {
+		return fmt.Errorf("tls-session-cache-size can't be negative")
+	}
+
+	if config.Server.ImageProxy.Enabled && config.Server.ImageProxy.MaxCacheSize <= 0 {
+		return fmt.Errorf("image-proxy max-cache-size must be positive")
+	}
+
+	if config.Server.ImageProxy.Enabled && config.Server.ImageProxy.CacheDir == "" {
+		return fmt.Errorf("image-proxy cache-dir can't be empty")
+	}
+
+	if config.Server.MaxWidgetUpdates < 0 {
+		return fmt.Errorf("max-concurrent-widget-updates can't be negative")
+	}
+
+	if !isValidPrefetchMode(config.Server.Prefetch) {
+		return fmt.Errorf("prefetch must be one of %s, %s or %s, got %q", PrefetchNone, PrefetchFirstPage, PrefetchAll, config.Server.Prefetch)
+	}
+
+	if config.Server.MaxQueued < 0 {
+		return fmt.Errorf("max-queued-requests can't be negative")
+	}
+
+	if config.Server.Timezone != "" {
+		if _, err := time.LoadLocation(config.Server.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone '%s': %v", config.Server.Timezone, err)
+		}
+	}
+
+	for host, limit := range config.Server.HostConcurrency.Hosts {
+		if limit < 0 {
+			return fmt.Errorf("max-concurrent-requests-per-host for %s can't be negative", host)
+		}
+	}
+
 	for i := range config.Pages {
 		if config.Pages[i].Title == "" {
 			return fmt.Errorf("Page %d has no title", i+1)
@@ -74,6 +145,16 @@ func configIsValid(config *Config) error {
 		if full > 2 || full == 0 {
 			return fmt.Errorf("Page %d must have either 1 or 2 full width columns", i+1)
 		}
+
+		if !config.AllowExec {
+			for j := range config.Pages[i].Columns {
+				for _, w := range config.Pages[i].Columns[j].Widgets {
+					if w.GetType() == "exec" {
+						return fmt.Errorf("Page %d uses an exec widget but allow-exec is not enabled", i+1)
+					}
+				}
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,50 @@
+package glance
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+func hasFreeGamesWidget(pages []Page) bool {
+	for p := range pages {
+		for c := range pages[p].Columns {
+			for _, w := range pages[p].Columns[c].Widgets {
+				if _, ok := w.(*widget.FreeGames); ok {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (a *Application) HandleFreeGameClaimRequest(w http.ResponseWriter, r *http.Request) {
+	if !a.isAuthorizedAPIRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	if !a.hasFreeGames {
+		writeJSONError(w, http.StatusNotFound, "widget not found")
+		return
+	}
+
+	err := widget.SetFreeGameClaimed(r.PathValue("game"), r.Method == http.MethodPut)
+
+	if errors.Is(err, widget.ErrFreeGameInvalid) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to save claimed game", "game", r.PathValue("game"), "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "could not save claimed game")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
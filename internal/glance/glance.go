@@ -3,13 +3,18 @@ package glance
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
@@ -22,9 +27,14 @@ var buildVersion = "dev"
 var sequentialWhitespacePattern = regexp.MustCompile(`\s+`)
 
 type Application struct {
-	Version    string
-	Config     Config
-	slugToPage map[string]*Page
+	Version              string
+	Config               Config
+	Token                string
+	slugToPage           map[string]*Page
+	frameSources         string
+	todoLists            map[string]*widget.Todo
+	notificationsWidgets map[string]notificationsWidget
+	hasFreeGames         bool
 }
 
 type Theme struct {
@@ -39,11 +49,52 @@ type Theme struct {
 }
 
 type Server struct {
-	Host       string    `yaml:"host"`
-	Port       uint16    `yaml:"port"`
-	AssetsPath string    `yaml:"assets-path"`
-	StartedAt  time.Time `yaml:"-"`
-	ProxyURL   string    `yaml:"proxy-url"`
+	Host               string               `yaml:"host"`
+	Port               uint16               `yaml:"port"`
+	AssetsPath         string               `yaml:"assets-path"`
+	LocalesPath        string               `yaml:"locales-path"`
+	StartedAt          time.Time            `yaml:"-"`
+	ProxyURL           string               `yaml:"proxy-url"`
+	HTTPDebugLog       HTTPDebugLog         `yaml:"http-debug-log"`
+	BandwidthStats     bool                 `yaml:"bandwidth-stats"`
+	DisableCompression bool                 `yaml:"disable-compression"`
+	ImageProxy         ImageProxy           `yaml:"image-proxy"`
+	DNSFailureCacheTTL widget.DurationField `yaml:"dns-failure-cache-ttl"`
+	NotFoundCacheTTL   widget.DurationField `yaml:"not-found-cache-ttl"`
+	TLSSessionCache    int                  `yaml:"tls-session-cache-size"`
+	ShutdownTimeout    widget.DurationField `yaml:"shutdown-timeout"`
+	RenderTimeout      widget.DurationField `yaml:"render-timeout"`
+	StaleFallback      widget.DurationField `yaml:"stale-fallback"`
+	SoftDeadline       widget.DurationField `yaml:"soft-deadline"`
+	DataFile           string               `yaml:"data-file"`
+	StateToken         string               `yaml:"state-token"`
+	HostConcurrency    HostConcurrency      `yaml:"max-concurrent-requests-per-host"`
+	MaxConcurrent      int                  `yaml:"max-concurrent-requests"`
+	MaxQueued          int                  `yaml:"max-queued-requests"`
+	MaxWidgetUpdates   int                  `yaml:"max-concurrent-widget-updates"`
+	Prefetch           string               `yaml:"prefetch"`
+	Timezone           string               `yaml:"timezone"`
+}
+
+type HostConcurrency struct {
+	Default int            `yaml:"default"`
+	Hosts   map[string]int `yaml:"hosts"`
+}
+
+type HTTPDebugLog struct {
+	Path                 string               `yaml:"path"`
+	MaxSize              int64                `yaml:"max-size"`
+	MaxBackups           int                  `yaml:"max-backups"`
+	SampleRate           float64              `yaml:"sample-rate"`
+	AlwaysLogErrors      bool                 `yaml:"always-log-errors"`
+	ErrorSummaryInterval widget.DurationField `yaml:"error-summary-interval"`
+}
+
+type ImageProxy struct {
+	Enabled      bool   `yaml:"enabled"`
+	CacheDir     string `yaml:"cache-dir"`
+	MaxCacheSize int64  `yaml:"max-cache-size"`
+	Secret       string `yaml:"secret"`
 }
 
 type Column struct {
@@ -60,33 +111,119 @@ type Page struct {
 	Title            string   `yaml:"name"`
 	Slug             string   `yaml:"slug"`
 	ShowMobileHeader bool     `yaml:"show-mobile-header"`
+	HighlightNew     bool     `yaml:"highlight-new-items"`
 	Columns          []Column `yaml:"columns"`
 	mu               sync.Mutex
+	// widgets which are being updated, by ID, each with a channel closed once done
+	updating map[uint64]chan struct{}
+	// whether the page was requested since startup
+	viewed bool
 }
 
-func (p *Page) UpdateOutdatedWidgets() {
+// startOutdatedWidgetUpdates starts updating the widgets of the page which are outdated
+// and not already being updated, returning a channel which is closed once every widget
+// of the page has finished updating. The updates are made with ctx, which is shared by
+// everything waiting on them. Must be called with the lock of the page held.
+func (p *Page) startOutdatedWidgetUpdates(ctx context.Context) <-chan struct{} {
 	now := time.Now()
 
-	var wg sync.WaitGroup
-	context := context.Background()
+	if p.updating == nil {
+		p.updating = make(map[uint64]chan struct{})
+	}
 
 	for c := range p.Columns {
 		for w := range p.Columns[c].Widgets {
 			widget := p.Columns[c].Widgets[w]
 
-			if !widget.RequiresUpdate(&now) {
+			if _, updating := p.updating[widget.GetID()]; updating || !widget.RequiresUpdate(&now) {
 				continue
 			}
 
-			wg.Add(1)
+			done := make(chan struct{})
+			p.updating[widget.GetID()] = done
+
 			go func() {
-				defer wg.Done()
-				widget.Update(context)
+				release := acquireWidgetUpdateSlot()
+				widget.Update(feed.WithWidgetID(ctx, widget.GetID()))
+				release()
+
+				p.mu.Lock()
+				delete(p.updating, widget.GetID())
+				p.mu.Unlock()
+				close(done)
 			}()
 		}
 	}
 
-	wg.Wait()
+	pending := make([]chan struct{}, 0, len(p.updating))
+
+	for _, done := range p.updating {
+		pending = append(pending, done)
+	}
+
+	allDone := make(chan struct{})
+
+	go func() {
+		for _, done := range pending {
+			<-done
+		}
+
+		close(allDone)
+	}()
+
+	return allDone
+}
+
+func (p *Page) UpdateOutdatedWidgets(ctx context.Context) {
+	p.mu.Lock()
+	done := p.startOutdatedWidgetUpdates(ctx)
+	p.mu.Unlock()
+
+	<-done
+}
+
+// widgetUpdate returns a channel which is closed once the widget is done
+// updating, nil if it isn't updating. Must be called with the lock held.
+func (p *Page) widgetUpdate(id uint64) chan struct{} {
+	return p.updating[id]
+}
+
+// RenderWidget renders a placeholder in place of widgets which are still updating,
+// to be replaced with the widget once it's done. Must be called with the lock held.
+func (p *Page) RenderWidget(w widget.Widget) template.HTML {
+	if p.widgetUpdate(w.GetID()) == nil {
+		return w.Render()
+	}
+
+	var placeholder bytes.Buffer
+
+	if err := assets.WidgetPlaceholderTemplate.Execute(&placeholder, w); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+
+	return template.HTML(placeholder.String())
+}
+
+func (p *Page) findWidget(id uint64) widget.Widget {
+	for c := range p.Columns {
+		for _, w := range p.Columns[c].Widgets {
+			if w.GetID() == id {
+				return w
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *Application) findWidget(id uint64) (*Page, widget.Widget) {
+	for i := range a.Config.Pages {
+		if w := a.Config.Pages[i].findWidget(id); w != nil {
+			return &a.Config.Pages[i], w
+		}
+	}
+
+	return nil, nil
 }
 
 // TODO: fix, currently very simple, lots of uncovered edge cases
@@ -103,9 +240,22 @@ func NewApplication(config *Config) (*Application, error) {
 		return nil, fmt.Errorf("no pages configured")
 	}
 
+	if config.Server.LocalesPath != "" {
+		if err := assets.LoadLocales(config.Server.LocalesPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Language != "" {
+		if err := assets.SetLanguage(config.Language); err != nil {
+			return nil, err
+		}
+	}
+
 	app := &Application{
 		Version:    buildVersion,
 		Config:     *config,
+		Token:      newAPIToken(),
 		slugToPage: make(map[string]*Page),
 	}
 
@@ -119,9 +269,60 @@ func NewApplication(config *Config) (*Application, error) {
 		app.slugToPage[config.Pages[i].Slug] = &config.Pages[i]
 	}
 
+	app.frameSources = collectIFrameOrigins(config.Pages)
+	app.todoLists = collectTodoLists(config.Pages)
+	app.notificationsWidgets = collectNotificationsWidgets(config.Pages)
+	app.hasFreeGames = hasFreeGamesWidget(config.Pages)
+
 	return app, nil
 }
 
+func collectIFrameOrigins(pages []Page) string {
+	origins := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for p := range pages {
+		for c := range pages[p].Columns {
+			for _, w := range pages[p].Columns[c].Widgets {
+				iframe, ok := w.(*widget.IFrame)
+
+				if !ok || iframe.Origin() == "" || seen[iframe.Origin()] {
+					continue
+				}
+
+				seen[iframe.Origin()] = true
+				origins = append(origins, iframe.Origin())
+			}
+		}
+	}
+
+	return strings.Join(origins, " ")
+}
+
+// the data file is only read when there's a widget which keeps its state in it
+func requiresWidgetStorage(pages []Page) bool {
+	for p := range pages {
+		for c := range pages[p].Columns {
+			for _, w := range pages[p].Columns[c].Widgets {
+				switch w := w.(type) {
+				case *widget.Todo:
+					if w.UsesStorage() {
+						return true
+					}
+				case *widget.FreeGames:
+					return true
+				case *widget.Speedtest:
+					if w.Source == "command" {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func (a *Application) HandlePageRequest(w http.ResponseWriter, r *http.Request) {
 	page, exists := a.slugToPage[r.PathValue("page")]
 
@@ -144,6 +345,12 @@ func (a *Application) HandlePageRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// only restrict frames when there are iframe widgets so that
+	// embeds from other widgets such as html keep working otherwise
+	if a.frameSources != "" {
+		w.Header().Set("Content-Security-Policy", "frame-src 'self' "+a.frameSources)
+	}
+
 	w.Write(responseBytes.Bytes())
 }
 
@@ -159,9 +366,45 @@ func (a *Application) HandlePageContentRequest(w http.ResponseWriter, r *http.Re
 		Page: page,
 	}
 
+	page.mu.Lock()
+	page.viewed = true
+	// what's fetched is shown to everyone viewing the page, so nothing from
+	// the request which started the update, such as its headers, is used for it
+	done := page.startOutdatedWidgetUpdates(context.Background())
+	page.mu.Unlock()
+
+	// widgets which take longer than this are filled in later so that they don't hold up the rest of the page
+	if timeout := time.Duration(a.Config.Server.RenderTimeout); timeout > 0 {
+		timer := time.NewTimer(timeout)
+
+		select {
+		case <-done:
+		case <-timer.C:
+		case <-r.Context().Done():
+		}
+
+		timer.Stop()
+	} else {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}
+
 	page.mu.Lock()
 	defer page.mu.Unlock()
-	page.UpdateOutdatedWidgets()
+
+	if page.HighlightNew {
+		lastVisit := lastVisitForRequest(w, r, time.Now())
+
+		for c := range page.Columns {
+			for _, pageWidget := range page.Columns[c].Widgets {
+				if page.widgetUpdate(pageWidget.GetID()) == nil {
+					widget.SetLastVisit(pageWidget, lastVisit)
+				}
+			}
+		}
+	}
 
 	var responseBytes bytes.Buffer
 	err := assets.PageContentTemplate.Execute(&responseBytes, pageData)
@@ -191,20 +434,129 @@ func FileServerWithCache(fs http.FileSystem, cacheDuration time.Duration) http.H
 	})
 }
 
-func (a *Application) Serve() error {
-	// TODO: add gzip support, static files must have their gzipped contents cached
-	// TODO: add HTTPS support
+// setUp applies the parts of the config which are used by widgets when they update,
+// the returned function releasing what's been opened
+func (a *Application) setUp() (func(), error) {
+	closeLog := func() {}
+
 	if a.Config.Server.ProxyURL != "" {
 		slog.Info("Setting proxy", "url", a.Config.Server.ProxyURL)
 		if err := feed.SetProxy(a.Config.Server.ProxyURL); err != nil {
-			return err
+			return nil, err
 		}
 	}
+
+	feed.SetDNSFailureCacheTTL(time.Duration(a.Config.Server.DNSFailureCacheTTL))
+	feed.SetNotFoundCacheTTL(time.Duration(a.Config.Server.NotFoundCacheTTL))
+	feed.SetTLSSessionCacheSize(a.Config.Server.TLSSessionCache)
+	feed.SetIdentityEncodingByDefault(a.Config.Server.DisableCompression)
+	feed.SetLastGoodFallbackByDefault(time.Duration(a.Config.Server.StaleFallback))
+	feed.SetSoftDeadlineByDefault(time.Duration(a.Config.Server.SoftDeadline))
+	feed.SetHostConcurrencyLimits(a.Config.Server.HostConcurrency.Default, a.Config.Server.HostConcurrency.Hosts)
+	feed.SetConcurrencyLimit(a.Config.Server.MaxConcurrent, a.Config.Server.MaxQueued)
+
+	if a.Config.Server.Timezone != "" {
+		location, err := time.LoadLocation(a.Config.Server.Timezone)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+
+		widget.SetTimezone(location)
+	}
+
+	widget.SetQuietHours(a.Config.QuietHours)
+
+	if a.Config.Server.HTTPDebugLog.Path != "" {
+		logger, err := feed.NewRotatingFileLogger(
+			a.Config.Server.HTTPDebugLog.Path,
+			a.Config.Server.HTTPDebugLog.MaxSize,
+			a.Config.Server.HTTPDebugLog.MaxBackups,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not set up http debug log: %w", err)
+		}
+
+		closeLog = func() { logger.Close() }
+
+		loggingOptions := []feed.LoggingOption{feed.WithResponseSampling(a.Config.Server.HTTPDebugLog.SampleRate)}
+
+		if a.Config.Server.HTTPDebugLog.AlwaysLogErrors {
+			loggingOptions = append(loggingOptions, feed.WithAlwaysLogErrors())
+		}
+
+		if interval := time.Duration(a.Config.Server.HTTPDebugLog.ErrorSummaryInterval); interval > 0 {
+			loggingOptions = append(loggingOptions, feed.WithErrorThrottling(interval))
+		}
+
+		slog.Info("Logging outgoing requests", "path", a.Config.Server.HTTPDebugLog.Path)
+		feed.EnableHTTPDebugLogging(logger, loggingOptions...)
+	}
+
+	if a.Config.Server.BandwidthStats {
+		feed.EnableBandwidthStats()
+	}
+
+	if requiresWidgetStorage(a.Config.Pages) || a.Config.Server.StateToken != "" {
+		storage, err := widget.OpenStorage(a.Config.Server.DataFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Storing widget data", "path", a.Config.Server.DataFile)
+		widget.SetStorage(storage)
+	}
+
+	return closeLog, nil
+}
+
+func (a *Application) Serve() error {
+	// TODO: add gzip support, static files must have their gzipped contents cached
+	// TODO: add HTTPS support
+	closeLog, err := a.setUp()
+
+	if err != nil {
+		return err
+	}
+
+	defer closeLog()
+
 	mux := http.NewServeMux()
 
+	if a.Config.Server.ImageProxy.Enabled {
+		proxy, err := newImageProxy(
+			a.Config.Server.ImageProxy.Secret,
+			a.Config.Server.ImageProxy.CacheDir,
+			a.Config.Server.ImageProxy.MaxCacheSize,
+		)
+
+		if err != nil {
+			return fmt.Errorf("could not set up image proxy: %w", err)
+		}
+
+		slog.Info("Proxying images", "cache-dir", a.Config.Server.ImageProxy.CacheDir)
+		assets.SetImageProxy(proxy.url)
+		mux.Handle("GET "+imageProxyPath, proxy)
+	}
+
 	mux.HandleFunc("GET /{$}", a.HandlePageRequest)
 	mux.HandleFunc("GET /{page}", a.HandlePageRequest)
 	mux.HandleFunc("GET /api/pages/{page}/content/{$}", a.HandlePageContentRequest)
+	mux.HandleFunc("GET /api/pages/{page}/content/widgets/{widget}", a.HandleWidgetContentRequest)
+	mux.HandleFunc("GET /api/todo/{list}", a.HandleTodoRequest)
+	mux.HandleFunc("POST /api/todo/{list}/items", a.HandleTodoRequest)
+	mux.HandleFunc("POST /api/todo/{list}/items/{item}/toggle", a.HandleTodoRequest)
+	mux.HandleFunc("DELETE /api/todo/{list}/items/{item}", a.HandleTodoRequest)
+	mux.HandleFunc("PUT /api/todo/{list}/order", a.HandleTodoRequest)
+	mux.HandleFunc("DELETE /api/notifications/{widget}/{source}/{id}", a.HandleNotificationAcknowledgeRequest)
+	mux.HandleFunc("PUT /api/free-games/claimed/{game}", a.HandleFreeGameClaimRequest)
+	mux.HandleFunc("DELETE /api/free-games/claimed/{game}", a.HandleFreeGameClaimRequest)
+	mux.HandleFunc("GET /api/stats/bandwidth", a.HandleBandwidthStatsRequest)
+	mux.HandleFunc("GET /api/metrics", a.HandleMetricsRequest)
+	mux.HandleFunc("GET /api/state", a.HandleStateRequest)
+	mux.HandleFunc("POST /api/state", a.HandleStateRequest)
 	mux.Handle("GET /static/{path...}", http.StripPrefix("/static/", FileServerWithCache(http.FS(assets.PublicFS), 2*time.Hour)))
 
 	if a.Config.Server.AssetsPath != "" {
@@ -226,6 +578,50 @@ func (a *Application) Serve() error {
 
 	a.Config.Server.StartedAt = time.Now()
 
-	slog.Info("Starting server", "host", a.Config.Server.Host, "port", a.Config.Server.Port)
-	return server.ListenAndServe()
+	setMaxConcurrentWidgetUpdates(a.Config.Server.MaxWidgetUpdates)
+	go a.prefetchWidgets(a.Config.Server.Prefetch)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if a.Config.QuietHours != nil {
+		go a.catchUpAfterQuietHours(ctx)
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		slog.Info("Starting server", "host", a.Config.Server.Host, "port", a.Config.Server.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := time.Duration(a.Config.Server.ShutdownTimeout)
+	slog.Info("Shutting down", "timeout", timeout)
+
+	// fetches have to be cancelled at the same time as the server stops waiting for requests,
+	// some of which may be waiting on them, so both are given the same amount of time
+	fetchesStopped := make(chan struct{})
+
+	go func() {
+		feed.Shutdown(timeout)
+		close(fetchesStopped)
+	}()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	<-fetchesStopped
+
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,76 @@
+package glance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestApplication(t *testing.T, config string) *Application {
+	t.Helper()
+
+	parsed, err := NewConfigFromYml(strings.NewReader(config))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := NewApplication(parsed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+func TestPageContentDoesNotForwardViewerHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var received []http.Header
+
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		mu.Unlock()
+		w.Write([]byte("extension"))
+	})
+
+	app := newTestApplication(t, `
+pages:
+  - name: Home
+    columns:
+      - size: full
+        widgets:
+          - type: extension
+            url: `+upstream.URL)
+
+	for _, viewer := range []string{"first", "second"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/pages/home/content/", nil)
+		request.SetPathValue("page", "home")
+		request.Header.Set("X-Request-Id", viewer)
+		request.Header.Set("Cookie", "session="+viewer)
+		recorder := httptest.NewRecorder()
+
+		app.HandlePageContentRequest(recorder, request)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s viewer: expected status 200, got %d", viewer, recorder.Code)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// the second viewer gets what was fetched for the first one
+	if len(received) != 1 {
+		t.Fatalf("expected a single upstream request, got %d", len(received))
+	}
+
+	for _, name := range []string{"X-Request-Id", "Cookie"} {
+		if value := received[0].Get(name); value != "" {
+			t.Errorf("expected %s of the viewer to not be forwarded, got %q", name, value)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package glance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer starts a server with the handler, which is closed once the test is done
+func newTestServer(t testing.TB, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
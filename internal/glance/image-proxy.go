@@ -0,0 +1,447 @@
+package glance
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/glanceapp/glance/internal/feed"
+	"golang.org/x/image/draw"
+
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	imageProxyPath     = "/api/image-proxy"
+	maxProxiedWidth    = 2000
+	maxProxiedPixels   = 50_000_000
+	proxiedImageMaxAge = 365 * 24 * time.Hour
+)
+
+// imageProxy serves external images from glance so that browsers don't request
+// them from dozens of third parties, optionally scaled down to the width they're
+// displayed at. Only URLs signed with the secret are served, otherwise anyone
+// could use it to fetch whatever they want through the server.
+type imageProxy struct {
+	secret []byte
+	cache  *imageCache
+
+	mu       sync.Mutex
+	inFlight map[string]*imageProxyCall
+}
+
+// imageProxyCall is a fetch of an image which other requests for the same
+// image and width wait for and share the result of
+type imageProxyCall struct {
+	done        chan struct{}
+	image       []byte
+	contentType string
+	err         error
+}
+
+func newImageProxy(secret string, cacheDir string, maxCacheSize int64) (*imageProxy, error) {
+	key := []byte(secret)
+
+	// without a configured secret, URLs stay valid until the next restart
+	if len(key) == 0 {
+		key = make([]byte, 32)
+
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("could not generate image proxy secret: %w", err)
+		}
+	}
+
+	cache, err := newImageCache(cacheDir, maxCacheSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageProxy{
+		secret:   key,
+		cache:    cache,
+		inFlight: make(map[string]*imageProxyCall),
+	}, nil
+}
+
+func (p *imageProxy) sign(source string, width int) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(strconv.Itoa(width) + ":" + source))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *imageProxy) url(source string, width int) string {
+	query := url.Values{}
+	query.Set("url", source)
+	query.Set("sig", p.sign(source, width))
+
+	if width > 0 {
+		query.Set("w", strconv.Itoa(width))
+	}
+
+	return imageProxyPath + "?" + query.Encode()
+}
+
+// the key is derived from the signature rather than the URL since its length is
+// fixed and it can't be guessed without knowing the secret
+func imageCacheKey(signature string) string {
+	return signature[:32]
+}
+
+func (p *imageProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	source := query.Get("url")
+	width := 0
+
+	if value := query.Get("w"); value != "" {
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed <= 0 || parsed > maxProxiedWidth {
+			http.Error(w, "invalid width", http.StatusBadRequest)
+			return
+		}
+
+		width = parsed
+	}
+
+	signature := query.Get("sig")
+
+	if !hmac.Equal([]byte(signature), []byte(p.sign(source, width))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	image, contentType, err := p.get(imageCacheKey(signature), source, width)
+
+	if err != nil {
+		slog.Error("Failed to proxy image", "url", source, "error", err)
+		http.Error(w, "could not get image", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(proxiedImageMaxAge.Seconds())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Write(image)
+}
+
+// get returns the image from the cache or fetches it, with concurrent requests
+// for the same image and width waiting for the first one and getting its result
+// rather than fetching it again
+func (p *imageProxy) get(key string, source string, width int) ([]byte, string, error) {
+	if image, contentType, ok := p.cache.get(key); ok {
+		return image, contentType, nil
+	}
+
+	callKey := strconv.Itoa(width) + ":" + source
+
+	p.mu.Lock()
+
+	if call, ok := p.inFlight[callKey]; ok {
+		p.mu.Unlock()
+		<-call.done
+
+		return call.image, call.contentType, call.err
+	}
+
+	call := &imageProxyCall{done: make(chan struct{})}
+	p.inFlight[callKey] = call
+	p.mu.Unlock()
+
+	call.image, call.contentType, call.err = p.fetch(key, source, width)
+
+	p.mu.Lock()
+	delete(p.inFlight, callKey)
+	p.mu.Unlock()
+	close(call.done)
+
+	return call.image, call.contentType, call.err
+}
+
+func (p *imageProxy) fetch(key string, source string, width int) ([]byte, string, error) {
+	image, contentType, err := feed.FetchImage(source)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if width > 0 {
+		if resized, resizedType, ok := resizeImage(image, contentType, width); ok {
+			image, contentType = resized, resizedType
+		}
+	}
+
+	if err := p.cache.put(key, contentType, image); err != nil {
+		slog.Warn("Could not cache proxied image", "url", source, "error", err)
+	}
+
+	return image, contentType, nil
+}
+
+// resizeImage scales the image down to width, keeping its aspect ratio, and encodes
+// it as a WebP. The encoder is lossless only, which is smaller than a PNG but
+// usually not than a JPEG for photos, so opaque images are also encoded as a JPEG
+// and whichever is smaller is used. Images which are already narrow enough, are
+// animated or can't be decoded are served as they are.
+func resizeImage(data []byte, contentType string, width int) ([]byte, string, bool) {
+	if contentType == "image/gif" {
+		return nil, "", false
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil || config.Width <= width || config.Width*config.Height > maxProxiedPixels {
+		return nil, "", false
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, "", false
+	}
+
+	bounds := source.Bounds()
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), source, bounds, draw.Src, nil)
+
+	var output bytes.Buffer
+
+	if err := nativewebp.Encode(&output, resized, nil); err != nil {
+		return nil, "", false
+	}
+
+	outputType := "image/webp"
+
+	if opaque, ok := source.(interface{ Opaque() bool }); !ok || opaque.Opaque() {
+		var jpegOutput bytes.Buffer
+
+		if err := jpeg.Encode(&jpegOutput, resized, &jpeg.Options{Quality: 82}); err == nil && jpegOutput.Len() < output.Len() {
+			output, outputType = jpegOutput, "image/jpeg"
+		}
+	}
+
+	// scaling down an image which was already well compressed can make it larger
+	if output.Len() >= len(data) {
+		return nil, "", false
+	}
+
+	return output.Bytes(), outputType, true
+}
+
+type imageCacheEntry struct {
+	key  string
+	size int64
+}
+
+// imageCache keeps images on disk, evicting the least recently used ones once
+// their total size goes past the limit. The order of use survives restarts
+// through the modification times of the files, which are updated when read.
+// Each file starts with the content type of the image followed by a newline
+// since sniffing can't tell every format apart.
+type imageCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newImageCache(dir string, maxSize int64) (*imageCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create image cache directory: %w", err)
+	}
+
+	files, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read image cache directory: %w", err)
+	}
+
+	type cachedFile struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+
+	cached := make([]cachedFile, 0, len(files))
+
+	for _, file := range files {
+		info, err := file.Info()
+
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		// left behind by a write which didn't finish
+		if strings.HasPrefix(file.Name(), ".tmp-") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+
+		cached = append(cached, cachedFile{name: file.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+
+	slices.SortFunc(cached, func(a, b cachedFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+
+	cache := &imageCache{
+		dir:     dir,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, len(cached)),
+	}
+
+	for _, file := range cached {
+		cache.entries[file.name] = cache.order.PushBack(&imageCacheEntry{key: file.name, size: file.size})
+		cache.size += file.size
+	}
+
+	cache.mu.Lock()
+	cache.evict()
+	cache.mu.Unlock()
+
+	return cache, nil
+}
+
+func (c *imageCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func (c *imageCache) get(key string) ([]byte, string, bool) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+
+	if ok {
+		c.order.MoveToFront(element)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, "", false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	var contentType []byte
+
+	if err == nil {
+		var found bool
+		contentType, data, found = bytes.Cut(data, []byte("\n"))
+
+		if !found {
+			err = errors.New("missing content type")
+		}
+	}
+
+	if err != nil {
+		c.mu.Lock()
+		c.remove(key)
+		c.mu.Unlock()
+
+		return nil, "", false
+	}
+
+	now := time.Now()
+	os.Chtimes(c.path(key), now, now)
+
+	return data, string(contentType), true
+}
+
+func (c *imageCache) put(key string, contentType string, data []byte) error {
+	size := int64(len(contentType) + 1 + len(data))
+
+	if size > c.maxSize {
+		return errors.New("image is larger than the cache")
+	}
+
+	// written to a temporary file first so that a crash can't leave a partial image behind
+	temp, err := os.CreateTemp(c.dir, ".tmp-*")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = temp.WriteString(contentType + "\n")
+
+	if err == nil {
+		_, err = temp.Write(data)
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), c.path(key))
+	}
+
+	if err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.size -= element.Value.(*imageCacheEntry).size
+		element.Value.(*imageCacheEntry).size = size
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[key] = c.order.PushFront(&imageCacheEntry{key: key, size: size})
+	}
+
+	c.size += size
+	c.evict()
+
+	return nil
+}
+
+// must be called with the lock held
+func (c *imageCache) remove(key string) {
+	element, ok := c.entries[key]
+
+	if !ok {
+		return
+	}
+
+	c.order.Remove(element)
+	delete(c.entries, key)
+	c.size -= element.Value.(*imageCacheEntry).size
+}
+
+// must be called with the lock held
+func (c *imageCache) evict() {
+	for c.size > c.maxSize && c.order.Len() > 0 {
+		entry := c.order.Back().Value.(*imageCacheEntry)
+		c.remove(entry.key)
+		os.Remove(c.path(entry.key))
+	}
+}
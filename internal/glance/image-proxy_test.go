@@ -0,0 +1,348 @@
+package glance
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPNG(t *testing.T, width, height int, transparent bool) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		for x := range width {
+			alpha := uint8(255)
+
+			if transparent && x < width/2 {
+				alpha = 0
+			}
+
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: alpha})
+		}
+	}
+
+	var output bytes.Buffer
+
+	if err := png.Encode(&output, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return output.Bytes()
+}
+
+// newImageTestServer serves data as a PNG after delay, counting the requests it gets
+func newImageTestServer(t *testing.T, data []byte, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	})
+
+	return server, requests
+}
+
+func newTestImageProxy(t *testing.T) *imageProxy {
+	t.Helper()
+
+	proxy, err := newImageProxy("test secret", t.TempDir(), 10<<20)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return proxy
+}
+
+func serveImageProxy(proxy *imageProxy, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	proxy.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return recorder
+}
+
+func TestImageProxySignatureCheck(t *testing.T) {
+	server, requests := newImageTestServer(t, newTestPNG(t, 8, 8, false), 0)
+	proxy := newTestImageProxy(t)
+	source := server.URL + "/image.png"
+
+	signed, err := url.Parse(proxy.url(source, 0))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tamperedURL := signed.Query()
+	tamperedURL.Set("url", server.URL+"/other.png")
+
+	tamperedWidth := signed.Query()
+	tamperedWidth.Set("w", "4")
+
+	badSignature := signed.Query()
+	badSignature.Set("sig", proxy.sign(source, 4))
+
+	otherSecret, err := newImageProxy("other secret", t.TempDir(), 10<<20)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+	}{
+		{name: "valid signature", target: signed.String(), expected: http.StatusOK},
+		{name: "tampered URL", target: imageProxyPath + "?" + tamperedURL.Encode(), expected: http.StatusForbidden},
+		{name: "tampered width", target: imageProxyPath + "?" + tamperedWidth.Encode(), expected: http.StatusForbidden},
+		{name: "signature for another width", target: imageProxyPath + "?" + badSignature.Encode(), expected: http.StatusForbidden},
+		{name: "signed with another secret", target: otherSecret.url(source, 0), expected: http.StatusForbidden},
+		{name: "missing signature", target: imageProxyPath + "?url=" + url.QueryEscape(source), expected: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := serveImageProxy(proxy, test.target)
+
+			if response.Code != test.expected {
+				t.Errorf("expected status %d, got %d", test.expected, response.Code)
+			}
+		})
+	}
+
+	if requests.Load() != 1 {
+		t.Errorf("expected only the signed URL to be fetched, got %d requests", requests.Load())
+	}
+}
+
+func TestImageProxyResponseHeaders(t *testing.T) {
+	server, _ := newImageTestServer(t, newTestPNG(t, 8, 8, false), 0)
+	proxy := newTestImageProxy(t)
+
+	response := serveImageProxy(proxy, proxy.url(server.URL, 0))
+
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", response.Code)
+	}
+
+	if response.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("expected the content type of the source, got %q", response.Header().Get("Content-Type"))
+	}
+
+	if response.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("unexpected Cache-Control: %q", response.Header().Get("Cache-Control"))
+	}
+
+	if response.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected nosniff, got %q", response.Header().Get("X-Content-Type-Options"))
+	}
+}
+
+func TestImageProxyInvalidWidth(t *testing.T) {
+	proxy := newTestImageProxy(t)
+
+	for _, width := range []string{"0", "-1", "abc", strconv.Itoa(maxProxiedWidth + 1)} {
+		response := serveImageProxy(proxy, imageProxyPath+"?url=https://example.com/a.png&sig=x&w="+width)
+
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("width %s: expected status 400, got %d", width, response.Code)
+		}
+	}
+}
+
+func TestImageProxyResizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		transparent bool
+	}{
+		{name: "opaque"},
+		{name: "transparent", transparent: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := newImageTestServer(t, newTestPNG(t, 400, 200, test.transparent), 0)
+			proxy := newTestImageProxy(t)
+
+			response := serveImageProxy(proxy, proxy.url(server.URL, 100))
+
+			if response.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", response.Code)
+			}
+
+			config, format, err := image.DecodeConfig(response.Body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.Width != 100 || config.Height != 50 {
+				t.Errorf("expected 100x50, got %dx%d", config.Width, config.Height)
+			}
+
+			if response.Header().Get("Content-Type") != "image/"+format {
+				t.Errorf("content type %q doesn't match the format %s", response.Header().Get("Content-Type"), format)
+			}
+
+			if test.transparent && format != "webp" {
+				t.Errorf("expected the transparent image to be encoded as a WebP, got %s", format)
+			}
+		})
+	}
+}
+
+func TestImageProxyFetchesOncePerImageAndWidth(t *testing.T) {
+	server, requests := newImageTestServer(t, newTestPNG(t, 400, 200, false), 100*time.Millisecond)
+	proxy := newTestImageProxy(t)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// half of them are for a different width, which is fetched separately
+			codes[i] = serveImageProxy(proxy, proxy.url(server.URL, 100+i%2*100)).Code
+		}()
+	}
+
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d: expected status 200, got %d", i, code)
+		}
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("expected 2 upstream requests, got %d", requests.Load())
+	}
+}
+
+func TestImageProxySharesFailures(t *testing.T) {
+	proxy := newTestImageProxy(t)
+	source := "https://example.invalid/image.png"
+
+	call := &imageProxyCall{done: make(chan struct{}), err: os.ErrNotExist}
+	proxy.inFlight["0:"+source] = call
+	close(call.done)
+
+	if _, _, err := proxy.get(imageCacheKey(proxy.sign(source, 0)), source, 0); err != os.ErrNotExist {
+		t.Errorf("expected the error of the call in flight, got %v", err)
+	}
+}
+
+func newTestImageCache(t *testing.T, dir string, maxSize int64) *imageCache {
+	t.Helper()
+
+	cache, err := newImageCache(dir, maxSize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cache
+}
+
+func putTestImages(t *testing.T, cache *imageCache, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		// 10 bytes with the content type
+		if err := cache.put(key, "image/x", []byte("aa")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func cachedKeys(cache *imageCache, keys ...string) []string {
+	cached := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		cache.mu.Lock()
+		_, ok := cache.entries[key]
+		cache.mu.Unlock()
+
+		if ok {
+			cached = append(cached, key)
+		}
+	}
+
+	return cached
+}
+
+func TestImageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache := newTestImageCache(t, dir, 30)
+
+	putTestImages(t, cache, "a", "b", "c")
+
+	if _, _, ok := cache.get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	putTestImages(t, cache, "d")
+
+	if cached := cachedKeys(cache, "a", "b", "c", "d"); len(cached) != 3 || cached[0] != "a" || cached[1] != "c" {
+		t.Fatalf("expected b to be evicted after a was read, got %v", cached)
+	}
+
+	if _, err := os.Stat(cache.path("b")); !os.IsNotExist(err) {
+		t.Errorf("expected the evicted file to be removed, got %v", err)
+	}
+
+	if cache.size != 30 {
+		t.Errorf("expected a size of 30, got %d", cache.size)
+	}
+
+	if data, contentType, ok := cache.get("d"); !ok || contentType != "image/x" || string(data) != "aa" {
+		t.Errorf("unexpected cached image: %q %q %v", data, contentType, ok)
+	}
+
+	if err := cache.put("e", "image/x", make([]byte, 40)); err == nil {
+		t.Error("expected an image larger than the cache to be rejected")
+	}
+}
+
+func TestImageCacheKeepsOrderAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	cache := newTestImageCache(t, dir, 30)
+	putTestImages(t, cache, "a", "b", "c")
+
+	// the order is restored from the modification times
+	for i, key := range []string{"b", "c", "a"} {
+		modTime := time.Now().Add(time.Duration(i-3) * time.Minute)
+
+		if err := os.Chtimes(cache.path(key), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.WriteFile(cache.path(".tmp-123"), []byte("partial"), 0644)
+
+	restarted := newTestImageCache(t, dir, 20)
+
+	if cached := cachedKeys(restarted, "a", "b", "c"); len(cached) != 2 || cached[0] != "a" || cached[1] != "c" {
+		t.Errorf("expected the oldest image to be evicted when the limit is lowered, got %v", cached)
+	}
+
+	if _, err := os.Stat(cache.path(".tmp-123")); !os.IsNotExist(err) {
+		t.Errorf("expected the leftover temporary file to be removed, got %v", err)
+	}
+}
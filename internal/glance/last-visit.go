@@ -0,0 +1,104 @@
+package glance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const lastVisitCookieName = "last-visit"
+
+// requests for the content of a page which are closer together than this are
+// considered part of the same visit, so that items stay marked as new when
+// reloading the page or coming back to it shortly after rather than only
+// being marked the very first time the page is loaded
+const visitTimeout = 30 * time.Minute
+
+type lastVisitCookie struct {
+	// when the visit before the current one ended, zero if there was none
+	previous time.Time
+	// when the page was last loaded as part of the current visit
+	current time.Time
+}
+
+func parseLastVisitCookie(value string) (lastVisitCookie, bool) {
+	var previous, current int64
+
+	if _, err := fmt.Sscanf(value, "%d.%d", &previous, &current); err != nil || previous < 0 || current <= 0 {
+		return lastVisitCookie{}, false
+	}
+
+	cookie := lastVisitCookie{current: time.Unix(current, 0)}
+
+	if previous > 0 {
+		cookie.previous = time.Unix(previous, 0)
+	}
+
+	return cookie, true
+}
+
+func (c lastVisitCookie) String() string {
+	var previous int64
+
+	if !c.previous.IsZero() {
+		previous = c.previous.Unix()
+	}
+
+	return fmt.Sprintf("%d.%d", previous, c.current.Unix())
+}
+
+// lastVisitForRequest returns when the visit before the current one to the page
+// ended and moves the visit forward through a cookie scoped to the content of the
+// page. The time is zero without a cookie, meaning that nothing should be marked.
+func lastVisitForRequest(w http.ResponseWriter, r *http.Request, now time.Time) time.Time {
+	var visit lastVisitCookie
+
+	if cookie, err := r.Cookie(lastVisitCookieName); err == nil {
+		visit, _ = parseLastVisitCookie(cookie.Value)
+	}
+
+	// the cookie only ever holds times from the clock of the server, but it may
+	// have been set back since, in which case items would never be marked as new
+	if visit.current.After(now) {
+		visit.current = now
+	}
+
+	if visit.previous.After(visit.current) {
+		visit.previous = visit.current
+	}
+
+	if !visit.current.IsZero() && now.Sub(visit.current) >= visitTimeout {
+		visit.previous = visit.current
+	}
+
+	visit.current = now
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     lastVisitCookieName,
+		Value:    visit.String(),
+		Path:     r.URL.Path,
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return visit.previous
+}
+
+// previousVisitFromRequest returns the same time as the last call to lastVisitForRequest
+// for the page made during the current visit, without moving the visit forward
+func previousVisitFromRequest(r *http.Request, now time.Time) time.Time {
+	cookie, err := r.Cookie(lastVisitCookieName)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	visit, _ := parseLastVisitCookie(cookie.Value)
+
+	if visit.previous.After(now) {
+		return now
+	}
+
+	return visit.previous
+}
@@ -28,6 +28,27 @@ func Main() int {
 		return 1
 	}
 
+	if options.Intent == CliIntentExportState || options.Intent == CliIntentImportState {
+		if err := runStateCommand(config, options); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	if options.Intent == CliIntentRender {
+		app, err := NewApplication(config)
+
+		if err != nil {
+			fmt.Printf("failed creating application: %v\n", err)
+			return 1
+		}
+
+		if err := app.RenderStatic(options.RenderPage, options.RenderOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "failed rendering page: %v\n", err)
+			return 1
+		}
+	}
+
 	if options.Intent == CliIntentServe {
 		app, err := NewApplication(config)
 
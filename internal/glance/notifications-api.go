@@ -0,0 +1,71 @@
+package glance
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+type notificationsWidget struct {
+	widget *widget.Notifications
+	page   *Page
+}
+
+func collectNotificationsWidgets(pages []Page) map[string]notificationsWidget {
+	widgets := make(map[string]notificationsWidget)
+
+	for p := range pages {
+		for c := range pages[p].Columns {
+			for _, w := range pages[p].Columns[c].Widgets {
+				if notifications, ok := w.(*widget.Notifications); ok {
+					widgets[notifications.ID] = notificationsWidget{widget: notifications, page: &pages[p]}
+				}
+			}
+		}
+	}
+
+	return widgets
+}
+
+func (a *Application) HandleNotificationAcknowledgeRequest(w http.ResponseWriter, r *http.Request) {
+	if !a.isAuthorizedAPIRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	target, exists := a.notificationsWidgets[r.PathValue("widget")]
+
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "widget not found")
+		return
+	}
+
+	sourceIndex, err := strconv.Atoi(r.PathValue("source"))
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid source")
+		return
+	}
+
+	// the page lock keeps the notification from being removed while the widget is updating or rendering
+	target.page.mu.Lock()
+	err = target.widget.Acknowledge(sourceIndex, r.PathValue("id"))
+	target.page.mu.Unlock()
+
+	if errors.Is(err, feed.ErrNotificationNotAcknowledgeable) {
+		writeJSONError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to acknowledge notification", "error", err)
+		writeJSONError(w, http.StatusBadGateway, "could not acknowledge notification")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package glance
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+const (
+	PrefetchNone      = "none"
+	PrefetchFirstPage = "first-page"
+	PrefetchAll       = "all"
+)
+
+// limits how many widgets update at once across all pages, nil meaning no limit
+var widgetUpdateSlots chan struct{}
+
+func setMaxConcurrentWidgetUpdates(limit int) {
+	if limit <= 0 {
+		widgetUpdateSlots = nil
+		return
+	}
+
+	widgetUpdateSlots = make(chan struct{}, limit)
+}
+
+func acquireWidgetUpdateSlot() func() {
+	if widgetUpdateSlots == nil {
+		return func() {}
+	}
+
+	widgetUpdateSlots <- struct{}{}
+
+	return func() { <-widgetUpdateSlots }
+}
+
+func isValidPrefetchMode(mode string) bool {
+	return mode == PrefetchNone || mode == PrefetchFirstPage || mode == PrefetchAll
+}
+
+// prefetchWidgets updates widgets before their page is first requested so that it
+// doesn't have to wait on them. The first page goes first since it's the one that
+// gets opened, with the others updating together once it's done. Pages requested
+// while they're being prefetched wait on the widgets which are still updating
+// like they would on their own updates.
+func (a *Application) prefetchWidgets(mode string) {
+	if mode == PrefetchNone {
+		return
+	}
+
+	start := time.Now()
+	pages := a.Config.Pages
+
+	pages[0].UpdateOutdatedWidgets(context.Background())
+	slog.Info("Prefetched first page", "page", pages[0].Title, "took", time.Since(start).Round(time.Millisecond))
+
+	if mode != PrefetchAll || len(pages) == 1 {
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 1; i < len(pages); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pages[i].UpdateOutdatedWidgets(context.Background())
+		}()
+	}
+
+	wg.Wait()
+	slog.Info("Prefetched all pages", "pages", len(pages), "took", time.Since(start).Round(time.Millisecond))
+}
+
+// how long to wait between pages when catching up after quiet hours
+const quietHoursCatchUpStagger = 5 * time.Second
+
+// catchUpAfterQuietHours updates the widgets which were due during quiet hours once
+// they end, rather than leaving them out of date until their page is next requested.
+func (a *Application) catchUpAfterQuietHours(ctx context.Context) {
+	for {
+		end := widget.QuietHoursEnd(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(end)):
+		}
+
+		start := time.Now()
+		updated := a.catchUpViewedPages(ctx, quietHoursCatchUpStagger)
+		slog.Info("Caught up after quiet hours", "pages", updated, "took", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// catchUpViewedPages updates the outdated widgets of the pages which were requested
+// since startup, since widgets on pages nobody opens shouldn't make requests, and
+// returns how many pages were updated. Pages are updated one after the other, stagger
+// apart, so that every widget doesn't make its requests at the same moment.
+func (a *Application) catchUpViewedPages(ctx context.Context, stagger time.Duration) int {
+	updated := 0
+
+	for i := range a.Config.Pages {
+		page := &a.Config.Pages[i]
+
+		page.mu.Lock()
+		viewed := page.viewed
+		page.mu.Unlock()
+
+		if !viewed {
+			continue
+		}
+
+		if updated > 0 {
+			select {
+			case <-ctx.Done():
+				return updated
+			case <-time.After(stagger):
+			}
+		}
+
+		if ctx.Err() != nil {
+			return updated
+		}
+
+		page.UpdateOutdatedWidgets(ctx)
+		updated++
+	}
+
+	return updated
+}
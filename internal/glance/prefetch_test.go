@@ -0,0 +1,69 @@
+package glance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func newCountingExtensionServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("extension"))
+	})
+
+	return server, requests
+}
+
+func newCatchUpTestApplication(t *testing.T) (*Application, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+
+	viewed, viewedRequests := newCountingExtensionServer(t)
+	unviewed, unviewedRequests := newCountingExtensionServer(t)
+
+	app := newTestApplication(t, `
+pages:
+  - name: Viewed
+    columns:
+      - size: full
+        widgets:
+          - type: extension
+            url: `+viewed.URL+`
+  - name: Unviewed
+    columns:
+      - size: full
+        widgets:
+          - type: extension
+            url: `+unviewed.URL)
+
+	app.Config.Pages[0].viewed = true
+
+	return app, viewedRequests, unviewedRequests
+}
+
+func TestCatchUpOnlyUpdatesViewedPages(t *testing.T) {
+	app, viewedRequests, unviewedRequests := newCatchUpTestApplication(t)
+
+	if updated := app.catchUpViewedPages(context.Background(), 0); updated != 1 {
+		t.Errorf("expected 1 page to be updated, got %d", updated)
+	}
+
+	if viewedRequests.Load() != 1 || unviewedRequests.Load() != 0 {
+		t.Errorf("expected only the viewed page to be updated, got %d and %d requests", viewedRequests.Load(), unviewedRequests.Load())
+	}
+}
+
+func TestCatchUpStopsOnShutdown(t *testing.T) {
+	app, viewedRequests, _ := newCatchUpTestApplication(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if updated := app.catchUpViewedPages(ctx, 0); updated != 0 || viewedRequests.Load() != 0 {
+		t.Errorf("expected nothing to be updated once shutting down, got %d pages and %d requests", updated, viewedRequests.Load())
+	}
+}
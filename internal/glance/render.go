@@ -0,0 +1,232 @@
+package glance
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+// images larger than this, even once scaled down, are left to be loaded from
+// where they are rather than making the file larger than it's worth
+const maxEmbeddedImageSize = 256 * 1024
+
+var ErrWidgetsFailed = errors.New("some widgets failed to update")
+
+type staticPageData struct {
+	templateData
+	CSS     template.CSS
+	Content template.HTML
+}
+
+// RenderStatic updates the widgets of the page once and writes it to output as
+// a single HTML file, with the styles and images embedded so that it can be
+// opened without glance running. The file is still written when widgets fail
+// to update, with the error being ErrWidgetsFailed.
+func (a *Application) RenderStatic(slug string, output string) error {
+	page, exists := a.slugToPage[slug]
+
+	if !exists {
+		return fmt.Errorf("page %s does not exist", slug)
+	}
+
+	closeLog, err := a.setUp()
+
+	if err != nil {
+		return err
+	}
+
+	defer closeLog()
+
+	assets.SetImageProxy(newImageEmbedder().url)
+
+	start := time.Now()
+	page.UpdateOutdatedWidgets(context.Background())
+	slog.Info("Updated widgets", "page", page.Title, "took", time.Since(start).Round(time.Millisecond))
+
+	var failed []string
+
+	page.mu.Lock()
+
+	for c := range page.Columns {
+		for _, w := range page.Columns[c].Widgets {
+			if err := widget.UpdateError(w); err != nil {
+				slog.Error("Widget failed to update", "type", w.GetType(), "error", err)
+				failed = append(failed, w.GetType())
+			}
+		}
+	}
+
+	var content bytes.Buffer
+	err = assets.PageContentTemplate.Execute(&content, templateData{Page: page})
+	page.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("could not render page: %w", err)
+	}
+
+	css, err := a.staticPageCSS()
+
+	if err != nil {
+		return err
+	}
+
+	var document bytes.Buffer
+
+	err = assets.StaticPageTemplate.Execute(&document, staticPageData{
+		templateData: templateData{App: a, Page: page},
+		CSS:          template.CSS(css),
+		Content:      template.HTML(fillRelativeTimes(content.String())),
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not render page: %w", err)
+	}
+
+	if output == "" || output == "-" {
+		_, err = os.Stdout.Write(document.Bytes())
+	} else {
+		err = os.WriteFile(output, document.Bytes(), 0644)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not write page: %w", err)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrWidgetsFailed, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+var staticCSSURLPattern = regexp.MustCompile(`url\('([^':]+)'\)`)
+
+// staticPageCSS returns the bundled styles, with the fonts they reference embedded,
+// followed by the custom CSS file when it's one that's served from the assets path
+func (a *Application) staticPageCSS() (string, error) {
+	mainCSS, err := fs.ReadFile(assets.PublicFS, "main.css")
+
+	if err != nil {
+		return "", err
+	}
+
+	css := staticCSSURLPattern.ReplaceAllStringFunc(string(mainCSS), func(match string) string {
+		name := staticCSSURLPattern.FindStringSubmatch(match)[1]
+		contents, err := fs.ReadFile(assets.PublicFS, name)
+
+		if err != nil {
+			return match
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		return "url('" + dataURI(contentType, contents) + "')"
+	})
+
+	customCSS := a.Config.Theme.CustomCSSFile
+
+	if customCSS == "" {
+		return css, nil
+	}
+
+	// files from elsewhere can't be read without the server
+	name, fromAssets := strings.CutPrefix(customCSS, "/assets/")
+
+	if !fromAssets || a.Config.Server.AssetsPath == "" {
+		slog.Warn("Custom CSS file can't be embedded, it has to be in the assets path", "file", customCSS)
+		return css, nil
+	}
+
+	contents, err := os.ReadFile(filepath.Join(a.Config.Server.AssetsPath, filepath.FromSlash(name)))
+
+	if err != nil {
+		return "", fmt.Errorf("could not read custom CSS file: %w", err)
+	}
+
+	return css + "\n" + string(contents), nil
+}
+
+var dynamicRelativeTimePattern = regexp.MustCompile(`(data-dynamic-relative-time="(\d+)"[^>]*>)(</)`)
+
+// the relative times which are otherwise filled in by the script
+func fillRelativeTimes(html string) string {
+	return dynamicRelativeTimePattern.ReplaceAllStringFunc(html, func(match string) string {
+		groups := dynamicRelativeTimePattern.FindStringSubmatch(match)
+		timestamp, err := strconv.ParseInt(groups[2], 10, 64)
+
+		if err != nil {
+			return match
+		}
+
+		return groups[1] + assets.RelativeTime(time.Unix(timestamp, 0)) + groups[3]
+	})
+}
+
+func dataURI(contentType string, data []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// imageEmbedder takes the place of the image proxy when rendering a static page,
+// replacing the images with data URIs of them scaled down the same way
+type imageEmbedder struct {
+	mu     sync.Mutex
+	images map[string]string
+}
+
+func newImageEmbedder() *imageEmbedder {
+	return &imageEmbedder{images: make(map[string]string)}
+}
+
+func (e *imageEmbedder) url(source string, width int) string {
+	key := strconv.Itoa(width) + ":" + source
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if embedded, ok := e.images[key]; ok {
+		return embedded
+	}
+
+	embedded := source
+	image, contentType, err := feed.FetchImage(source)
+
+	if err != nil {
+		slog.Warn("Could not embed image", "url", source, "error", err)
+	} else {
+		if width > 0 {
+			if resized, resizedType, ok := resizeImage(image, contentType, width); ok {
+				image, contentType = resized, resizedType
+			}
+		}
+
+		if len(image) <= maxEmbeddedImageSize {
+			embedded = dataURI(contentType, image)
+		}
+	}
+
+	e.images[key] = embedded
+
+	return embedded
+}
@@ -0,0 +1,122 @@
+package glance
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+const maxStateRequestBodySize = 16 * 1024 * 1024
+
+// unlike the other API endpoints, which are only used by the page itself, these
+// are meant to be used by scripts and so are authorized by a configured token
+func (a *Application) isAuthorizedStateRequest(r *http.Request) bool {
+	return subtle.ConstantTimeCompare(
+		[]byte(r.Header.Get("Authorization")),
+		[]byte("Bearer "+a.Config.Server.StateToken),
+	) == 1
+}
+
+func (a *Application) HandleStateRequest(w http.ResponseWriter, r *http.Request) {
+	if a.Config.Server.StateToken == "" {
+		writeJSONError(w, http.StatusNotFound, "state-token is not set")
+		return
+	}
+
+	if !a.isAuthorizedStateRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="glance-state.json"`)
+
+		if err := widget.ExportState(w); err != nil {
+			slog.Error("Failed to export state", "error", err)
+		}
+
+		return
+	}
+
+	summary, err := widget.ImportState(http.MaxBytesReader(w, r.Body, maxStateRequestBodySize))
+
+	if errors.Is(err, widget.ErrInvalidState) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to import state", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "could not import state")
+		return
+	}
+
+	slog.Info("Imported state", "imported", summary.Imported, "skipped", summary.Skipped)
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// runStateCommand exports or imports the data file set in the config. Importing
+// into the data file of an instance which is running gets overwritten the next
+// time it saves something, the endpoint has to be used for those instead.
+func runStateCommand(config *Config, options *CliOptions) error {
+	if config.Server.DataFile == "" {
+		return errors.New("data-file is not set, there's no state to export or import")
+	}
+
+	storage, err := widget.OpenStorage(config.Server.DataFile)
+
+	if err != nil {
+		return err
+	}
+
+	usesStd := options.StatePath == "" || options.StatePath == "-"
+
+	if options.Intent == CliIntentExportState {
+		if usesStd {
+			return storage.Export(os.Stdout)
+		}
+
+		file, err := os.Create(options.StatePath)
+
+		if err != nil {
+			return fmt.Errorf("could not create state file: %w", err)
+		}
+
+		if err = storage.Export(file); err != nil {
+			file.Close()
+			return fmt.Errorf("could not write state file: %w", err)
+		}
+
+		return file.Close()
+	}
+
+	var input io.Reader = os.Stdin
+
+	if !usesStd {
+		file, err := os.Open(options.StatePath)
+
+		if err != nil {
+			return fmt.Errorf("could not open state file: %w", err)
+		}
+
+		defer file.Close()
+		input = file
+	}
+
+	summary, err := storage.Import(input)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d values into %s, skipped %d which weren't newer than the stored ones\n", summary.Imported, config.Server.DataFile, summary.Skipped)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package glance
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/glanceapp/glance/internal/feed"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// the stats are requested by the page like the other API endpoints, or by scripts
+// and scrapers with the state token, which unlike the API token doesn't change
+func (a *Application) isAuthorizedStatsRequest(r *http.Request) bool {
+	return a.isAuthorizedAPIRequest(r) || (a.Config.Server.StateToken != "" && a.isAuthorizedStateRequest(r))
+}
+
+type widgetBandwidthStats struct {
+	ID   uint64 `json:"id"`
+	Type string `json:"type"`
+	Page string `json:"page"`
+	feed.WidgetBandwidthStats
+}
+
+type bandwidthStatsResponse struct {
+	Hosts   []feed.BandwidthStats  `json:"hosts"`
+	Widgets []widgetBandwidthStats `json:"widgets"`
+}
+
+func (a *Application) HandleBandwidthStatsRequest(w http.ResponseWriter, r *http.Request) {
+	if !a.Config.Server.BandwidthStats {
+		writeJSONError(w, http.StatusNotFound, "bandwidth stats are disabled")
+		return
+	}
+
+	if !a.isAuthorizedStatsRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	widgets := feed.GetWidgetBandwidthStats()
+	response := bandwidthStatsResponse{
+		Hosts:   feed.GetBandwidthStats(),
+		Widgets: make([]widgetBandwidthStats, 0, len(widgets)),
+	}
+
+	for _, stats := range widgets {
+		widget := widgetBandwidthStats{ID: stats.WidgetID, WidgetBandwidthStats: stats}
+
+		if page, pageWidget := a.findWidget(stats.WidgetID); pageWidget != nil {
+			widget.Type, widget.Page = pageWidget.GetType(), page.Slug
+		}
+
+		response.Widgets = append(response.Widgets, widget)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleMetricsRequest exposes the bytes sent to and received from each host in the
+// Prometheus text format, as they went over the wire before being decompressed
+func (a *Application) HandleMetricsRequest(w http.ResponseWriter, r *http.Request) {
+	if !a.Config.Server.BandwidthStats {
+		writeJSONError(w, http.StatusNotFound, "bandwidth stats are disabled")
+		return
+	}
+
+	if !a.isAuthorizedStatsRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	hosts := feed.WireBandwidthMetrics().Hosts()
+
+	sent := newCounterFamily("glance_http_bytes_sent_total", "Bytes of request bodies sent to each host.")
+	received := newCounterFamily("glance_http_bytes_received_total", "Bytes of response bodies received from each host, before decompression.")
+
+	for _, host := range hosts {
+		sent.Metric = append(sent.Metric, newHostCounter(host.Host, host.BytesSent))
+		received.Metric = append(received.Metric, newHostCounter(host.Host, host.BytesReceived))
+	}
+
+	widgetReceived := newCounterFamily("glance_widget_http_bytes_received_total", "Bytes of response bodies received for each widget, after decompression.")
+
+	for _, stats := range feed.GetWidgetBandwidthStats() {
+		widgetReceived.Metric = append(widgetReceived.Metric, newCounter("widget", strconv.FormatUint(stats.WidgetID, 10), stats.BytesReceived))
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+
+	for _, family := range []*dto.MetricFamily{sent, received, widgetReceived} {
+		if _, err := expfmt.MetricFamilyToText(w, family); err != nil {
+			return
+		}
+	}
+}
+
+func newCounterFamily(name, help string) *dto.MetricFamily {
+	counter := dto.MetricType_COUNTER
+
+	return &dto.MetricFamily{Name: &name, Help: &help, Type: &counter}
+}
+
+func newHostCounter(host string, value int64) *dto.Metric {
+	return newCounter("host", host, value)
+}
+
+func newCounter(label string, labelValue string, value int64) *dto.Metric {
+	asFloat := float64(value)
+
+	return &dto.Metric{
+		Label:   []*dto.LabelPair{{Name: &label, Value: &labelValue}},
+		Counter: &dto.Counter{Value: &asFloat},
+	}
+}
@@ -0,0 +1,180 @@
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+	"gopkg.in/yaml.v3"
+)
+
+func newStatsTestApplication(stateToken string) *Application {
+	config := NewConfig()
+	config.Server.BandwidthStats = true
+	config.Server.StateToken = stateToken
+
+	return &Application{Config: *config, Token: "api token"}
+}
+
+func TestStatsEndpointsRequireToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		stateToken string
+		headers    map[string]string
+		expected   int
+	}{
+		{name: "no token", expected: http.StatusForbidden},
+		{name: "wrong API token", headers: map[string]string{"X-Glance-Token": "wrong"}, expected: http.StatusForbidden},
+		{name: "API token", headers: map[string]string{"X-Glance-Token": "api token"}, expected: http.StatusOK},
+		{name: "state token", stateToken: "state", headers: map[string]string{"Authorization": "Bearer state"}, expected: http.StatusOK},
+		{name: "wrong state token", stateToken: "state", headers: map[string]string{"Authorization": "Bearer wrong"}, expected: http.StatusForbidden},
+		{name: "empty bearer without a state token", headers: map[string]string{"Authorization": "Bearer "}, expected: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		app := newStatsTestApplication(test.stateToken)
+
+		for _, handler := range []http.HandlerFunc{app.HandleBandwidthStatsRequest, app.HandleMetricsRequest} {
+			request := httptest.NewRequest(http.MethodGet, "/api/stats/bandwidth", nil)
+
+			for name, value := range test.headers {
+				request.Header.Set(name, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			if recorder.Code != test.expected {
+				t.Errorf("%s: expected status %d, got %d", test.name, test.expected, recorder.Code)
+			}
+		}
+	}
+}
+
+func TestBandwidthStatsResponse(t *testing.T) {
+	app := newStatsTestApplication("")
+	request := httptest.NewRequest(http.MethodGet, "/api/stats/bandwidth", nil)
+	request.Header.Set("X-Glance-Token", "api token")
+	recorder := httptest.NewRecorder()
+
+	app.HandleBandwidthStatsRequest(recorder, request)
+
+	var response map[string]json.RawMessage
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := response["hosts"]; !ok {
+		t.Errorf("expected the hosts to be included, got %s", recorder.Body.String())
+	}
+
+	if widgets := strings.TrimSpace(string(response["widgets"])); widgets != "[]" {
+		t.Errorf("expected an empty list of widgets, got %s", widgets)
+	}
+}
+
+func TestStatsEndpointsDisabled(t *testing.T) {
+	app := newStatsTestApplication("")
+	app.Config.Server.BandwidthStats = false
+
+	request := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	request.Header.Set("X-Glance-Token", "api token")
+	recorder := httptest.NewRecorder()
+
+	app.HandleMetricsRequest(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", recorder.Code)
+	}
+}
+
+func TestMetricsResponse(t *testing.T) {
+	app := newStatsTestApplication("")
+	feed.EnableBandwidthStats()
+	feed.WireBandwidthMetrics().Reset()
+	t.Cleanup(feed.WireBandwidthMetrics().Reset)
+
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strings.Repeat("a", 1234)))
+	})
+
+	client, err := feed.GetClientWithOptions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.Post(upstream.URL, "text/plain", strings.NewReader("hello world"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/metrics", nil)
+	request.Header.Set("X-Glance-Token", "api token")
+	recorder := httptest.NewRecorder()
+
+	app.HandleMetricsRequest(recorder, request)
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("expected the Prometheus text format, got %q", recorder.Header().Get("Content-Type"))
+	}
+
+	host, _ := url.Parse(upstream.URL)
+	body := recorder.Body.String()
+
+	for _, expected := range []string{
+		"# TYPE glance_http_bytes_sent_total counter",
+		fmt.Sprintf(`glance_http_bytes_sent_total{host="%s"} 11`, host.Host),
+		"# TYPE glance_http_bytes_received_total counter",
+		fmt.Sprintf(`glance_http_bytes_received_total{host="%s"} 1234`, host.Host),
+	} {
+		if !strings.Contains(body, expected+"\n") {
+			t.Errorf("expected %q in the response:\n%s", expected, body)
+		}
+	}
+}
+
+func TestBandwidthStatsCountedForWidgetUpdates(t *testing.T) {
+	feed.EnableBandwidthStats()
+
+	upstream := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1234)))
+	})
+
+	var widgets widget.Widgets
+
+	if err := yaml.Unmarshal([]byte("- type: extension\n  url: "+upstream.URL), &widgets); err != nil {
+		t.Fatal(err)
+	}
+
+	extension := widgets[0]
+	extension.Update(feed.WithWidgetID(context.Background(), extension.GetID()))
+
+	for _, stats := range feed.GetWidgetBandwidthStats() {
+		if stats.WidgetID != extension.GetID() {
+			continue
+		}
+
+		if stats.Requests != 1 || stats.BytesReceived != 1234 {
+			t.Errorf("expected 1 request receiving 1234 bytes, got %+v", stats)
+		}
+
+		return
+	}
+
+	t.Errorf("expected the bandwidth of widget %d to be counted", extension.GetID())
+}
@@ -0,0 +1,143 @@
+package glance
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+const maxTodoRequestBodySize = 64 * 1024
+
+func newAPIToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func collectTodoLists(pages []Page) map[string]*widget.Todo {
+	lists := make(map[string]*widget.Todo)
+
+	for p := range pages {
+		for c := range pages[p].Columns {
+			for _, w := range pages[p].Columns[c].Widgets {
+				todo, ok := w.(*widget.Todo)
+
+				if !ok {
+					continue
+				}
+
+				// widgets sharing an id share a list, the first
+				// one's limits are the ones which get enforced
+				if _, exists := lists[todo.ListID]; !exists {
+					lists[todo.ListID] = todo
+				}
+			}
+		}
+	}
+
+	return lists
+}
+
+// the token is only handed out through the page itself, which keeps other
+// sites from making changes on behalf of someone who has the dashboard open
+func (a *Application) isAuthorizedAPIRequest(r *http.Request) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Glance-Token")), []byte(a.Token)) == 1
+}
+
+func (a *Application) HandleTodoRequest(w http.ResponseWriter, r *http.Request) {
+	if !a.isAuthorizedAPIRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	todo, exists := a.todoLists[r.PathValue("list")]
+
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "list not found")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		list, err := todo.GetList(r.Context())
+
+		if err != nil {
+			slog.Error("Failed to load todo list", "list", todo.ListID, "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "could not load list")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	op := widget.TodoOperation{ItemID: r.PathValue("item")}
+
+	switch {
+	case r.Method == http.MethodPost && op.ItemID == "":
+		op.Kind = "add"
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/toggle"):
+		op.Kind = "toggle"
+	case r.Method == http.MethodDelete:
+		op.Kind = "delete"
+	case r.Method == http.MethodPut:
+		op.Kind = "reorder"
+	}
+
+	if op.Kind == "add" || op.Kind == "reorder" {
+		body := struct {
+			Text  string   `json:"text"`
+			Order []string `json:"order"`
+		}{}
+
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTodoRequestBodySize)).Decode(&body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		op.Text = body.Text
+		op.Order = body.Order
+	}
+
+	version, err := strconv.Atoi(strings.Trim(r.Header.Get("If-Match"), `"`))
+
+	if err != nil {
+		writeJSONError(w, http.StatusPreconditionRequired, "missing or invalid If-Match header")
+		return
+	}
+
+	op.Version = version
+	list, err := todo.ApplyOperation(r.Context(), op)
+
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, list)
+	case errors.Is(err, widget.ErrTodoVersionConflict):
+		// send back the current list so that the client can reconcile
+		writeJSON(w, http.StatusConflict, list)
+	case errors.Is(err, widget.ErrTodoItemNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, widget.ErrTodoInvalid):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.Error("Failed to update todo list", "list", todo.ListID, "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "could not save list")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,120 @@
+package glance
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+// newCalDAVTodoTestServer serves a collection holding a single to-do, which it
+// replaces whenever it's written to with the ETag it was fetched with
+func newCalDAVTodoTestServer(t *testing.T) (*httptest.Server, func() string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	etag := 1
+	data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:a\r\nSUMMARY:Buy milk\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == "REPORT":
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:response>` +
+				`<d:href>/tasks/a.ics</d:href><d:propstat><d:prop><d:getetag>"` + strconv.Itoa(etag) + `"</d:getetag>` +
+				`<c:calendar-data>` + html.EscapeString(data) + `</c:calendar-data></d:prop>` +
+				`<d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response></d:multistatus>`))
+		case r.Method == http.MethodPut && r.URL.Path == "/tasks/a.ics":
+			if r.Header.Get("If-Match") != `"`+strconv.Itoa(etag)+`"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			data = string(body)
+			etag++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	return server, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return data
+	}
+}
+
+func sendTodoTestRequest(t *testing.T, app *Application, method, path string, version int) (int, widget.TodoList) {
+	t.Helper()
+
+	request := httptest.NewRequest(method, path, nil)
+	request.SetPathValue("list", "chores")
+	request.Header.Set("X-Glance-Token", app.Token)
+
+	if parts := strings.Split(path, "/"); len(parts) > 5 {
+		request.SetPathValue("item", parts[5])
+	}
+
+	if method != http.MethodGet {
+		request.Header.Set("If-Match", strconv.Itoa(version))
+	}
+
+	recorder := httptest.NewRecorder()
+	app.HandleTodoRequest(recorder, request)
+
+	var list widget.TodoList
+	json.NewDecoder(recorder.Body).Decode(&list)
+
+	return recorder.Code, list
+}
+
+func TestTodoListOnCalDAVServer(t *testing.T) {
+	server, stored := newCalDAVTodoTestServer(t)
+	app := newTestApplication(t, `
+pages:
+  - name: Home
+    columns:
+      - size: full
+        widgets:
+          - type: todo
+            id: chores
+            caldav:
+              url: `+server.URL+`/tasks/`)
+
+	status, list := sendTodoTestRequest(t, app, http.MethodGet, "/api/todo/chores", 0)
+
+	if status != http.StatusOK || len(list.Items) != 1 || list.Items[0].Text != "Buy milk" || list.Items[0].Done {
+		t.Fatalf("expected the to-do from the server, got %d %+v", status, list)
+	}
+
+	toggle := "/api/todo/chores/items/" + list.Items[0].ID + "/toggle"
+	status, toggled := sendTodoTestRequest(t, app, http.MethodPost, toggle, list.Version)
+
+	if status != http.StatusOK || len(toggled.Items) != 1 || !toggled.Items[0].Done {
+		t.Fatalf("expected the to-do to be checked off, got %d %+v", status, toggled)
+	}
+
+	if !strings.Contains(stored(), "STATUS:COMPLETED") {
+		t.Errorf("expected the to-do to be completed on the server, got %q", stored())
+	}
+
+	if toggled.Version == list.Version {
+		t.Errorf("expected the version to change along with the to-do")
+	}
+
+	if status, _ = sendTodoTestRequest(t, app, http.MethodPost, toggle, list.Version); status != http.StatusConflict {
+		t.Errorf("expected a change based on an old version to conflict, got %d", status)
+	}
+}
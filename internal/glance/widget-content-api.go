@@ -0,0 +1,56 @@
+package glance
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+// HandleWidgetContentRequest renders a single widget of a page once it's done updating,
+// for filling in the placeholders of widgets which weren't ready when the page was
+func (a *Application) HandleWidgetContentRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+
+	if !exists {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("widget"), 10, 64)
+	var pageWidget widget.Widget
+
+	if err == nil {
+		pageWidget = page.findWidget(id)
+	}
+
+	if pageWidget == nil {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	page.mu.Lock()
+
+	// the widget may have started updating again by the time the lock is acquired
+	for done := page.widgetUpdate(id); done != nil; done = page.widgetUpdate(id) {
+		page.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-r.Context().Done():
+			return
+		}
+
+		page.mu.Lock()
+	}
+
+	defer page.mu.Unlock()
+
+	// the cookie was already moved forward by the request for the page
+	if page.HighlightNew {
+		widget.SetLastVisit(pageWidget, previousVisitFromRequest(r, time.Now()))
+	}
+
+	w.Write([]byte(pageWidget.Render()))
+}
@@ -0,0 +1,87 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type Analytics struct {
+	widgetBase `yaml:",inline"`
+	Provider   string                 `yaml:"provider"`
+	URL        string                 `yaml:"url"`
+	Token      OptionalEnvString      `yaml:"token"`
+	SiteID     string                 `yaml:"site-id"`
+	TopPages   int                    `yaml:"top-pages"`
+	Summary    *feed.AnalyticsSummary `yaml:"-"`
+}
+
+func (widget *Analytics) Initialize() error {
+	widget.withTitle("Analytics").withCacheDuration(10 * time.Minute)
+
+	switch widget.Provider {
+	case "plausible":
+		if widget.URL == "" {
+			widget.URL = "https://plausible.io"
+		}
+	case "umami":
+		if widget.URL == "" {
+			widget.URL = "https://api.umami.is/v1"
+		}
+	default:
+		return fmt.Errorf("provider for analytics widget must be either plausible or umami: %s", widget.Provider)
+	}
+
+	widget.URL = strings.TrimRight(widget.URL, "/")
+
+	if widget.Token == "" {
+		return errors.New("token is required for analytics widget")
+	}
+
+	if widget.SiteID == "" {
+		return errors.New("site-id is required for analytics widget")
+	}
+
+	if widget.TopPages == 0 {
+		widget.TopPages = 5
+	}
+
+	return nil
+}
+
+func (widget *Analytics) Update(ctx context.Context) {
+	summary, err := feed.FetchAnalyticsSummary(ctx, feed.AnalyticsRequest{
+		Provider: widget.Provider,
+		URL:      widget.URL,
+		Token:    string(widget.Token),
+		SiteID:   widget.SiteID,
+		Pages:    max(widget.TopPages, 0),
+		Location: timezone,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Summary = summary
+}
+
+func (widget *Analytics) ChartPoints() string {
+	values := make([]float64, len(widget.Summary.DailyVisitors))
+
+	for i := range widget.Summary.DailyVisitors {
+		values[i] = float64(widget.Summary.DailyVisitors[i])
+	}
+
+	return sparklinePoints(values)
+}
+
+func (widget *Analytics) Render() template.HTML {
+	return widget.render(widget, assets.AnalyticsTemplate)
+}
@@ -1,11 +1,20 @@
 package widget
 
 import (
+	"context"
+	"fmt"
 	"html/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
 )
 
+// keep the number of simultaneous checks low since bookmarks
+// can easily number in the dozens unlike monitor sites
+const bookmarksCheckWorkers = 5
+
 type Bookmarks struct {
 	widgetBase `yaml:",inline"`
 	cachedHTML template.HTML `yaml:"-"`
@@ -13,12 +22,18 @@ type Bookmarks struct {
 		Title string         `yaml:"title"`
 		Color *HSLColorField `yaml:"color"`
 		Links []struct {
-			Title        string `yaml:"title"`
-			URL          string `yaml:"url"`
-			Icon         string `yaml:"icon"`
-			IsSimpleIcon bool   `yaml:"-"`
-			SameTab      bool   `yaml:"same-tab"`
-			HideArrow    bool   `yaml:"hide-arrow"`
+			Title        string           `yaml:"title"`
+			URL          string           `yaml:"url"`
+			Icon         string           `yaml:"icon"`
+			IsSimpleIcon bool             `yaml:"-"`
+			SameTab      bool             `yaml:"same-tab"`
+			HideArrow    bool             `yaml:"hide-arrow"`
+			Shortcut     string           `yaml:"shortcut"`
+			Check        bool             `yaml:"check"`
+			Status       *feed.SiteStatus `yaml:"-"`
+			StatusStyle  string           `yaml:"-"`
+			AutoIcon     template.URL     `yaml:"-"`
+			autoIcon     bool             `yaml:"-"`
 		} `yaml:"links"`
 	} `yaml:"groups"`
 	Style string `yaml:"style"`
@@ -27,22 +42,109 @@ type Bookmarks struct {
 func (widget *Bookmarks) Initialize() error {
 	widget.withTitle("Bookmarks").withError(nil)
 
+	shortcuts := make(map[string]string)
+	requiresUpdate := false
+
 	for g := range widget.Groups {
 		for l := range widget.Groups[g].Links {
-			if widget.Groups[g].Links[l].Icon == "" {
+			link := &widget.Groups[g].Links[l]
+			link.SameTab = link.SameTab || widget.opensLinksInSameTab()
+
+			if link.Shortcut != "" {
+				if utf8.RuneCountInString(link.Shortcut) != 1 {
+					return fmt.Errorf("shortcut for bookmark %q must be a single character", link.Title)
+				}
+
+				if link.Shortcut == "s" {
+					return fmt.Errorf("shortcut for bookmark %q can't be s since it's used by the search widget", link.Title)
+				}
+
+				if other, exists := shortcuts[link.Shortcut]; exists {
+					return fmt.Errorf("bookmarks %q and %q have the same shortcut: %s", other, link.Title, link.Shortcut)
+				}
+
+				shortcuts[link.Shortcut] = link.Title
+			}
+
+			if link.Check {
+				requiresUpdate = true
+			}
+
+			if link.Icon == "auto" {
+				link.Icon = ""
+				link.autoIcon = true
+				requiresUpdate = true
+				continue
+			}
+
+			if link.Icon == "" {
 				continue
 			}
 
-			link := &widget.Groups[g].Links[l]
 			link.Icon, link.IsSimpleIcon = toSimpleIconIfPrefixed(link.Icon)
 		}
 	}
 
+	if requiresUpdate {
+		widget.withCacheDuration(5 * time.Minute)
+	}
+
 	widget.cachedHTML = widget.render(widget, assets.BookmarksTemplate)
 
 	return nil
 }
 
+func (widget *Bookmarks) Update(ctx context.Context) {
+	requests := make([]*feed.SiteStatusRequest, 0)
+
+	for g := range widget.Groups {
+		for l := range widget.Groups[g].Links {
+			link := &widget.Groups[g].Links[l]
+
+			// favicons are cached by the resolver, failures are retried on the next update
+			if link.autoIcon && link.AutoIcon == "" {
+				if icon, err := feed.ResolveFavicon(ctx, link.URL); err == nil {
+					// the resolver only returns data URIs of validated images
+					link.AutoIcon = template.URL(icon)
+				}
+			}
+
+			if link.Check {
+				requests = append(requests, &feed.SiteStatusRequest{URL: link.URL})
+			}
+		}
+	}
+
+	statuses, err := feed.FetchStatusForSitesWithWorkers(ctx, requests, bookmarksCheckWorkers)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	i := 0
+
+	for g := range widget.Groups {
+		for l := range widget.Groups[g].Links {
+			link := &widget.Groups[g].Links[l]
+
+			if !link.Check {
+				continue
+			}
+
+			link.Status = &statuses[i]
+			i++
+
+			if link.Status.Error == nil {
+				link.StatusStyle = statusCodeToStyle(link.Status.Code)
+			} else {
+				link.StatusStyle = "error"
+			}
+		}
+	}
+
+	widget.cachedHTML = widget.render(widget, assets.BookmarksTemplate)
+}
+
 func (widget *Bookmarks) Render() template.HTML {
 	return widget.cachedHTML
 }
@@ -15,6 +15,7 @@ type ChangeDetection struct {
 	WatchUUIDs       []string                    `yaml:"watches"`
 	InstanceURL      string                      `yaml:"instance-url"`
 	Token            OptionalEnvString           `yaml:"token"`
+	Tag              string                      `yaml:"tag"`
 	Limit            int                         `yaml:"limit"`
 	CollapseAfter    int                         `yaml:"collapse-after"`
 }
@@ -39,7 +40,7 @@ func (widget *ChangeDetection) Initialize() error {
 
 func (widget *ChangeDetection) Update(ctx context.Context) {
 	if len(widget.WatchUUIDs) == 0 {
-		uuids, err := feed.FetchWatchUUIDsFromChangeDetection(widget.InstanceURL, string(widget.Token))
+		uuids, err := feed.FetchWatchUUIDsFromChangeDetection(ctx, widget.InstanceURL, string(widget.Token), widget.Tag)
 
 		if !widget.canContinueUpdateAfterHandlingErr(err) {
 			return
@@ -48,7 +49,7 @@ func (widget *ChangeDetection) Update(ctx context.Context) {
 		widget.WatchUUIDs = uuids
 	}
 
-	watches, err := feed.FetchWatchesFromChangeDetection(widget.InstanceURL, widget.WatchUUIDs, string(widget.Token))
+	watches, err := feed.FetchWatchesFromChangeDetection(ctx, widget.InstanceURL, widget.WatchUUIDs, string(widget.Token))
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
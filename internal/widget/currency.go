@@ -0,0 +1,56 @@
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type Currency struct {
+	widgetBase `yaml:",inline"`
+	Pairs      []feed.CurrencyPairRequest `yaml:"pairs"`
+	Rates      feed.CurrencyRates         `yaml:"-"`
+}
+
+func (widget *Currency) Initialize() error {
+	widget.withTitle("Exchange Rates").withCacheDuration(6 * time.Hour)
+
+	if len(widget.Pairs) == 0 {
+		return fmt.Errorf("no currency pairs specified")
+	}
+
+	for i := range widget.Pairs {
+		pair := &widget.Pairs[i]
+		pair.From = strings.ToUpper(strings.TrimSpace(pair.From))
+		pair.To = strings.ToUpper(strings.TrimSpace(pair.To))
+
+		if pair.From == "" || pair.To == "" {
+			return fmt.Errorf("currency pair %d must have both from and to specified", i+1)
+		}
+
+		if pair.Amount < 0 {
+			return fmt.Errorf("currency pair %d has a negative amount", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (widget *Currency) Update(ctx context.Context) {
+	rates, err := feed.FetchCurrencyRates(ctx, widget.Pairs)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Rates = rates
+}
+
+func (widget *Currency) Render() template.HTML {
+	return widget.render(widget, assets.CurrencyTemplate)
+}
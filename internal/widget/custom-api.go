@@ -0,0 +1,302 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type CustomAPIField struct {
+	Label  string `yaml:"label"`
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+}
+
+type customAPIFieldValue struct {
+	Label    string
+	Format   string
+	Text     string
+	Number   float64
+	IsNumber bool
+	Missing  bool
+}
+
+type CustomAPI struct {
+	widgetBase `yaml:",inline"`
+	URL        OptionalEnvString            `yaml:"url"`
+	Method     string                       `yaml:"method"`
+	Headers    map[string]OptionalEnvString `yaml:"headers"`
+	Body       string                       `yaml:"body"`
+	APIKeys    struct {
+		Header            string `yaml:"header"`
+		Prefix            string `yaml:"prefix"`
+		RotateOnRateLimit bool   `yaml:"rotate-on-rate-limit"`
+		Keys              []struct {
+			Key    OptionalEnvString `yaml:"key"`
+			Weight int               `yaml:"weight"`
+		} `yaml:"keys"`
+	} `yaml:"api-keys"`
+	Fields []CustomAPIField `yaml:"fields"`
+	Items  struct {
+		Path   string           `yaml:"path"`
+		Limit  int              `yaml:"limit"`
+		Fields []CustomAPIField `yaml:"fields"`
+	} `yaml:"items"`
+	Template      string                  `yaml:"template"`
+	Schema        any                     `yaml:"schema"`
+	CollapseAfter int                     `yaml:"collapse-after"`
+	Values        []customAPIFieldValue   `yaml:"-"`
+	ItemValues    [][]customAPIFieldValue `yaml:"-"`
+	CompiledHTML  template.HTML           `yaml:"-"`
+	userTemplate  *template.Template      `yaml:"-"`
+	client        feed.RequestDoer        `yaml:"-"`
+	schema        *feed.JSONSchema        `yaml:"-"`
+}
+
+var customAPITemplateFunctions = template.FuncMap{
+	"get": func(path string, value any) any {
+		result, _ := feed.ResolveJSONPath(value, path)
+		return result
+	},
+	"has": func(path string, value any) bool {
+		_, ok := feed.ResolveJSONPath(value, path)
+		return ok
+	},
+}
+
+func (widget *CustomAPI) Initialize() error {
+	widget.withTitle("Custom API").withCacheDuration(time.Hour)
+
+	if widget.URL == "" {
+		return errors.New("no URL specified for custom-api widget")
+	}
+
+	if _, err := url.Parse(string(widget.URL)); err != nil {
+		return fmt.Errorf("invalid URL for custom-api widget: %v", err)
+	}
+
+	if widget.Method == "" {
+		widget.Method = http.MethodGet
+	} else {
+		widget.Method = strings.ToUpper(widget.Method)
+	}
+
+	if widget.Template == "" && len(widget.Fields) == 0 && len(widget.Items.Fields) == 0 {
+		return errors.New("custom-api widget needs either fields, items or a template")
+	}
+
+	for _, field := range append(widget.Fields, widget.Items.Fields...) {
+		if field.Path == "" {
+			return fmt.Errorf("field %q has no path", field.Label)
+		}
+	}
+
+	if len(widget.APIKeys.Keys) > 0 {
+		keys := make([]feed.APIKey, len(widget.APIKeys.Keys))
+
+		for i := range widget.APIKeys.Keys {
+			keys[i] = feed.APIKey{
+				Key:    string(widget.APIKeys.Keys[i].Key),
+				Weight: widget.APIKeys.Keys[i].Weight,
+			}
+		}
+
+		if widget.APIKeys.Header == "" {
+			widget.APIKeys.Header = "Authorization"
+		}
+
+		client, err := feed.NewCredentialRotator(
+			nil,
+			widget.APIKeys.Header,
+			widget.APIKeys.Prefix,
+			keys,
+			widget.APIKeys.RotateOnRateLimit,
+		)
+
+		if err != nil {
+			return fmt.Errorf("invalid api-keys for custom-api widget: %v", err)
+		}
+
+		widget.client = client
+	}
+
+	if widget.Items.Limit <= 0 {
+		widget.Items.Limit = 10
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if widget.Template != "" {
+		t, err := assets.CompileUserTemplate("custom-api", widget.Template, customAPITemplateFunctions)
+
+		if err != nil {
+			return fmt.Errorf("invalid template for custom-api widget: %v", err)
+		}
+
+		widget.userTemplate = t
+	}
+
+	if widget.Schema != nil {
+		schema, err := compileCustomAPISchema(widget.Schema)
+
+		if err != nil {
+			return fmt.Errorf("invalid schema for custom-api widget: %v", err)
+		}
+
+		widget.schema = schema
+	}
+
+	return nil
+}
+
+// the schema can either be written inline in the config or be a path
+// to a JSON file containing it
+func compileCustomAPISchema(schema any) (*feed.JSONSchema, error) {
+	var source []byte
+	var err error
+
+	if path, ok := schema.(string); ok {
+		source, err = os.ReadFile(path)
+	} else {
+		source, err = json.Marshal(schema)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return feed.CompileJSONSchema(source)
+}
+
+func (widget *CustomAPI) Update(ctx context.Context) {
+	headers := make(map[string]string, len(widget.Headers))
+
+	for key, value := range widget.Headers {
+		headers[key] = string(value)
+	}
+
+	data, err := feed.FetchCustomAPI(ctx, feed.CustomAPIRequest{
+		URL:     string(widget.URL),
+		Method:  widget.Method,
+		Headers: headers,
+		Body:    widget.Body,
+		Client:  widget.client,
+		Schema:  widget.schema,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	if widget.userTemplate != nil {
+		var buffer bytes.Buffer
+
+		err := widget.userTemplate.Execute(&buffer, struct{ JSON any }{JSON: data})
+
+		if err != nil {
+			widget.withError(fmt.Errorf("failed to execute template: %w", err))
+			return
+		}
+
+		widget.CompiledHTML = template.HTML(buffer.String())
+		return
+	}
+
+	widget.Values = extractCustomAPIFields(data, widget.Fields)
+	widget.ItemValues = nil
+	missing := countMissingCustomAPIFields(widget.Values)
+
+	if len(widget.Items.Fields) > 0 {
+		items, ok := feed.ResolveJSONPath(data, widget.Items.Path)
+		list, isList := items.([]any)
+
+		if !ok || !isList {
+			widget.withNotice(fmt.Errorf("items path %q does not point to an array", widget.Items.Path))
+			return
+		}
+
+		list = feed.FlattenJSONArrays(list)
+
+		if len(list) > widget.Items.Limit {
+			list = list[:widget.Items.Limit]
+		}
+
+		widget.ItemValues = make([][]customAPIFieldValue, 0, len(list))
+
+		for i := range list {
+			values := extractCustomAPIFields(list[i], widget.Items.Fields)
+			missing += countMissingCustomAPIFields(values)
+			widget.ItemValues = append(widget.ItemValues, values)
+		}
+	}
+
+	if missing > 0 {
+		widget.withNotice(fmt.Errorf("%d field(s) could not be resolved", missing))
+	}
+}
+
+func extractCustomAPIFields(data any, fields []CustomAPIField) []customAPIFieldValue {
+	values := make([]customAPIFieldValue, len(fields))
+
+	for i := range fields {
+		values[i].Label = fields[i].Label
+		values[i].Format = fields[i].Format
+
+		value, ok := feed.ResolveJSONPath(data, fields[i].Path)
+
+		if !ok || value == nil {
+			values[i].Missing = true
+			continue
+		}
+
+		switch v := value.(type) {
+		case float64:
+			values[i].Number = v
+			values[i].IsNumber = true
+			values[i].Text = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			values[i].Text = v
+
+			if number, err := strconv.ParseFloat(v, 64); err == nil {
+				values[i].Number = number
+				values[i].IsNumber = true
+			}
+		case bool:
+			values[i].Text = strconv.FormatBool(v)
+		default:
+			values[i].Text = fmt.Sprint(v)
+		}
+	}
+
+	return values
+}
+
+func countMissingCustomAPIFields(values []customAPIFieldValue) int {
+	missing := 0
+
+	for i := range values {
+		if values[i].Missing {
+			missing++
+		}
+	}
+
+	return missing
+}
+
+func (widget *CustomAPI) Render() template.HTML {
+	return widget.render(widget, assets.CustomAPITemplate)
+}
@@ -0,0 +1,137 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type departureStopConfig struct {
+	Backend     string                       `yaml:"backend"`
+	URL         OptionalEnvString            `yaml:"url"`
+	StopID      string                       `yaml:"stop-id"`
+	Name        string                       `yaml:"name"`
+	WalkingTime DurationField                `yaml:"walking-time"`
+	Headers     map[string]OptionalEnvString `yaml:"headers"`
+}
+
+type departureView struct {
+	feed.Departure
+	Minutes      int
+	DelayMinutes int
+}
+
+type stopDeparturesView struct {
+	Name        string
+	WalkingTime int
+	Departures  []departureView
+}
+
+type Departures struct {
+	widgetBase `yaml:",inline"`
+	Stops      []departureStopConfig `yaml:"stops"`
+	Limit      int                   `yaml:"limit"`
+	Results    []feed.StopDepartures `yaml:"-"`
+	Visible    []stopDeparturesView  `yaml:"-"`
+	stops      []feed.DepartureStop  `yaml:"-"`
+}
+
+func (widget *Departures) Initialize() error {
+	widget.withTitle("Departures").withCacheDuration(time.Minute)
+
+	if len(widget.Stops) == 0 {
+		return errors.New("departures widget requires at least one stop")
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 5
+	}
+
+	widget.stops = make([]feed.DepartureStop, len(widget.Stops))
+
+	for i := range widget.Stops {
+		stop := &widget.Stops[i]
+
+		if stop.StopID == "" {
+			return fmt.Errorf("departures stop %d has no stop-id", i+1)
+		}
+
+		switch stop.Backend {
+		case "", "transitous":
+			stop.Backend = "transitous"
+		case "gtfs-rt":
+			if stop.URL == "" {
+				return fmt.Errorf("departures stop %d: the gtfs-rt backend requires a feed url", i+1)
+			}
+		default:
+			return fmt.Errorf("departures stop %d: backend must be either transitous or gtfs-rt", i+1)
+		}
+
+		headers := make(map[string]string, len(stop.Headers))
+
+		for key, value := range stop.Headers {
+			headers[key] = string(value)
+		}
+
+		widget.stops[i] = feed.DepartureStop{
+			Backend:     stop.Backend,
+			URL:         string(stop.URL),
+			StopID:      stop.StopID,
+			Name:        stop.Name,
+			WalkingTime: time.Duration(stop.WalkingTime),
+			Headers:     headers,
+		}
+	}
+
+	return nil
+}
+
+func (widget *Departures) Update(ctx context.Context) {
+	departures, err := feed.FetchDepartures(ctx, widget.stops, widget.Limit)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Results = departures
+}
+
+func (widget *Departures) Render() template.HTML {
+	// the departures are cached for a while and the page can be loaded long
+	// after the last update, so the ones that can no longer be caught have to
+	// be dropped and the minutes until departure recalculated on every render
+	now := time.Now()
+	widget.Visible = make([]stopDeparturesView, len(widget.Results))
+
+	for i := range widget.Results {
+		stop := &widget.Results[i]
+		catchable := stop.Departures.Catchable(now, stop.WalkingTime)
+
+		if len(catchable) > widget.Limit {
+			catchable = catchable[:widget.Limit]
+		}
+
+		views := make([]departureView, len(catchable))
+
+		for j := range catchable {
+			views[j] = departureView{
+				Departure:    catchable[j],
+				Minutes:      int(catchable[j].Expected.Sub(now).Minutes()),
+				DelayMinutes: int(catchable[j].Delay.Round(time.Minute).Minutes()),
+			}
+		}
+
+		widget.Visible[i] = stopDeparturesView{
+			Name:        stop.Name,
+			WalkingTime: int(stop.WalkingTime.Minutes()),
+			Departures:  views,
+		}
+	}
+
+	return widget.render(widget, assets.DeparturesTemplate)
+}
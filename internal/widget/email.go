@@ -0,0 +1,97 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type emailAccountConfig struct {
+	Name       string            `yaml:"name"`
+	Host       string            `yaml:"host"`
+	Port       int               `yaml:"port"`
+	Username   OptionalEnvString `yaml:"username"`
+	Password   OptionalEnvString `yaml:"password"`
+	Folders    []string          `yaml:"folders"`
+	CACertFile string            `yaml:"ca-cert-file"`
+}
+
+type Email struct {
+	widgetBase    `yaml:",inline"`
+	Accounts      []emailAccountConfig `yaml:"accounts"`
+	Limit         int                  `yaml:"limit"`
+	CollapseAfter int                  `yaml:"collapse-after"`
+	Folders       []feed.MailFolder    `yaml:"-"`
+	accounts      []feed.IMAPAccount   `yaml:"-"`
+}
+
+func (widget *Email) Initialize() error {
+	widget.withTitle("Email").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Accounts) == 0 {
+		return errors.New("email widget requires at least one account")
+	}
+
+	if widget.Limit < 0 {
+		widget.Limit = 0
+	} else if widget.Limit == 0 {
+		widget.Limit = 5
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 3
+	}
+
+	widget.accounts = make([]feed.IMAPAccount, len(widget.Accounts))
+
+	for i := range widget.Accounts {
+		account := &widget.Accounts[i]
+
+		if account.Host == "" || account.Username == "" || account.Password == "" {
+			return fmt.Errorf("email account %d requires a host, username and password", i+1)
+		}
+
+		if account.Port == 0 {
+			account.Port = 993
+		}
+
+		if len(account.Folders) == 0 {
+			account.Folders = []string{"INBOX"}
+		}
+
+		if account.Name == "" {
+			account.Name = string(account.Username)
+		}
+
+		widget.accounts[i] = feed.IMAPAccount{
+			Host:       account.Host,
+			Port:       account.Port,
+			Username:   string(account.Username),
+			Password:   string(account.Password),
+			Folders:    account.Folders,
+			CACertFile: account.CACertFile,
+			Name:       account.Name,
+		}
+	}
+
+	return nil
+}
+
+func (widget *Email) Update(ctx context.Context) {
+	folders, err := feed.FetchUnreadMail(widget.accounts, widget.Limit)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Folders = folders
+}
+
+func (widget *Email) Render() template.HTML {
+	return widget.render(widget, assets.EmailTemplate)
+}
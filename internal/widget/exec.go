@@ -0,0 +1,135 @@
+package widget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type Exec struct {
+	widgetBase `yaml:",inline"`
+	Command    []string                     `yaml:"command"`
+	Dir        string                       `yaml:"dir"`
+	Env        map[string]OptionalEnvString `yaml:"env"`
+	InheritEnv []string                     `yaml:"inherit-env"`
+	Timeout    DurationField                `yaml:"timeout"`
+	Format     string                       `yaml:"format"`
+	Fields     []CustomAPIField             `yaml:"fields"`
+	Output     *feed.CommandOutput          `yaml:"-"`
+	Values     []customAPIFieldValue        `yaml:"-"`
+	Failed     bool                         `yaml:"-"`
+	env        []string                     `yaml:"-"`
+	running    sync.Mutex                   `yaml:"-"`
+}
+
+func (widget *Exec) Initialize() error {
+	widget.withTitle("Command").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Command) == 0 || widget.Command[0] == "" {
+		return errors.New("no command specified for exec widget")
+	}
+
+	if widget.Timeout == 0 {
+		widget.Timeout = DurationField(10 * time.Second)
+	}
+
+	if widget.Format == "" {
+		widget.Format = "text"
+	}
+
+	if widget.Format != "text" && widget.Format != "json" {
+		return fmt.Errorf("invalid format for exec widget: %s", widget.Format)
+	}
+
+	if widget.Format == "json" && len(widget.Fields) == 0 {
+		return errors.New("exec widget with json format needs fields")
+	}
+
+	for _, field := range widget.Fields {
+		if field.Path == "" {
+			return fmt.Errorf("field %q has no path", field.Label)
+		}
+	}
+
+	// only PATH is passed on by default so that secrets in the environment
+	// of glance don't leak into scripts unless explicitly asked for
+	inherited := append([]string{"PATH"}, widget.InheritEnv...)
+	widget.env = make([]string, 0, len(inherited)+len(widget.Env))
+
+	for _, name := range inherited {
+		if _, ok := widget.Env[name]; ok {
+			continue
+		}
+
+		if value, ok := os.LookupEnv(name); ok {
+			widget.env = append(widget.env, name+"="+value)
+		}
+	}
+
+	for name, value := range widget.Env {
+		widget.env = append(widget.env, name+"="+string(value))
+	}
+
+	return nil
+}
+
+func (widget *Exec) Update(ctx context.Context) {
+	// a previous run is still going, likely because it's slower than the
+	// cache duration, let it finish instead of piling up processes
+	if !widget.running.TryLock() {
+		return
+	}
+
+	defer widget.running.Unlock()
+
+	output, err := feed.RunCommand(ctx, feed.CommandRequest{
+		Command: widget.Command,
+		Dir:     widget.Dir,
+		Env:     widget.env,
+		Timeout: time.Duration(widget.Timeout),
+	})
+
+	if errors.Is(err, feed.ErrCommandFailed) {
+		widget.Output = output
+		widget.Failed = true
+		widget.Values = nil
+		widget.withError(nil).withNotice(err).scheduleNextUpdate()
+		return
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Output = output
+	widget.Failed = false
+	widget.Values = nil
+
+	if widget.Format == "json" {
+		var data any
+
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output.Stdout)), &data); err != nil {
+			widget.withError(fmt.Errorf("command output is not valid JSON: %w", err))
+			return
+		}
+
+		widget.Values = extractCustomAPIFields(data, widget.Fields)
+
+		if missing := countMissingCustomAPIFields(widget.Values); missing > 0 {
+			widget.withNotice(fmt.Errorf("%d field(s) could not be resolved", missing))
+		}
+	}
+}
+
+func (widget *Exec) Render() template.HTML {
+	return widget.render(widget, assets.ExecTemplate)
+}
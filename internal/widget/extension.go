@@ -37,7 +37,7 @@ func (widget *Extension) Initialize() error {
 }
 
 func (widget *Extension) Update(ctx context.Context) {
-	extension, err := feed.FetchExtension(feed.ExtensionRequestOptions{
+	extension, err := feed.FetchExtension(ctx, feed.ExtensionRequestOptions{
 		URL:        widget.URL,
 		Parameters: widget.Parameters,
 		AllowHtml:  widget.AllowHtml,
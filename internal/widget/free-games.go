@@ -0,0 +1,195 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+var (
+	freeGamesCountryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	freeGamesLocalePattern  = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+var ErrFreeGameInvalid = errors.New("invalid game id")
+
+// claimed games are shared by every free-games widget since
+// they're tied to the store accounts rather than to a widget
+const freeGamesClaimedStorageKey = "free-games:claimed"
+
+var freeGamesClaimedMu sync.Mutex
+
+type FreeGames struct {
+	widgetBase    `yaml:",inline"`
+	Country       string          `yaml:"country"`
+	Locale        string          `yaml:"locale"`
+	IncludeGOG    bool            `yaml:"include-gog"`
+	HideUpcoming  bool            `yaml:"hide-upcoming"`
+	HideClaimed   bool            `yaml:"hide-claimed"`
+	CollapseAfter int             `yaml:"collapse-after"`
+	Games         feed.FreeGames  `yaml:"-"`
+	Visible       feed.FreeGames  `yaml:"-"`
+	Claimed       map[string]bool `yaml:"-"`
+}
+
+func (widget *FreeGames) Initialize() error {
+	widget.withTitle("Free Games").withCacheDuration(time.Hour)
+
+	if widget.Country == "" {
+		widget.Country = "US"
+	} else {
+		widget.Country = strings.ToUpper(widget.Country)
+	}
+
+	if !freeGamesCountryPattern.MatchString(widget.Country) {
+		return fmt.Errorf("country for free-games widget must be a two letter country code, such as US: %s", widget.Country)
+	}
+
+	if widget.Locale == "" {
+		widget.Locale = "en-US"
+	}
+
+	if !freeGamesLocalePattern.MatchString(widget.Locale) {
+		return fmt.Errorf("invalid locale for free-games widget, must be a language tag such as en-US: %s", widget.Locale)
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *FreeGames) Update(ctx context.Context) {
+	games, err := feed.FetchFreeGames(ctx, feed.FreeGamesRequest{
+		Country: widget.Country,
+		Locale:  widget.Locale,
+		GOG:     widget.IncludeGOG,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	if widget.HideUpcoming {
+		current := make(feed.FreeGames, 0, len(games))
+
+		for i := range games {
+			if !games[i].Upcoming {
+				current = append(current, games[i])
+			}
+		}
+
+		games = current
+	}
+
+	widget.Games = games
+
+	// pick up the next game as soon as it becomes free rather than up to an hour later
+	for i := range games {
+		for _, t := range []time.Time{games[i].StartsAt, games[i].EndsAt} {
+			if t.After(time.Now()) && t.Before(widget.nextUpdate) {
+				widget.nextUpdate = t.Add(time.Minute)
+			}
+		}
+	}
+}
+
+func loadClaimedFreeGames() (map[string]bool, error) {
+	claimed := make(map[string]bool)
+
+	if _, err := storage.Load(freeGamesClaimedStorageKey, &claimed); err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// SetFreeGameClaimed marks the game as claimed or not, the state is
+// kept in the data file so that it's the same on every device
+func SetFreeGameClaimed(id string, claimed bool) error {
+	if id == "" || len(id) > 256 {
+		return ErrFreeGameInvalid
+	}
+
+	freeGamesClaimedMu.Lock()
+	defer freeGamesClaimedMu.Unlock()
+
+	games, err := loadClaimedFreeGames()
+
+	if err != nil {
+		return err
+	}
+
+	if claimed {
+		games[id] = true
+	} else {
+		delete(games, id)
+	}
+
+	return storage.Save(freeGamesClaimedStorageKey, games)
+}
+
+func (widget *FreeGames) IsClaimed(id string) bool {
+	return widget.Claimed[id]
+}
+
+// EndsIn is worked out when rendering since the games are cached
+func (widget *FreeGames) EndsIn(t time.Time) string {
+	left := time.Until(t)
+
+	if left <= 0 {
+		return "ended"
+	}
+
+	days := int(left.Hours()) / 24
+	hours := int(left.Hours()) % 24
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, int(left.Minutes())%60)
+	}
+
+	return fmt.Sprintf("%dm", max(int(left.Minutes()), 1))
+}
+
+func (widget *FreeGames) FormatDate(t time.Time) string {
+	return t.In(timezone).Format("Jan 2")
+}
+
+func (widget *FreeGames) Render() template.HTML {
+	freeGamesClaimedMu.Lock()
+	claimed, err := loadClaimedFreeGames()
+	freeGamesClaimedMu.Unlock()
+
+	if err != nil {
+		widget.withNotice(fmt.Errorf("could not load claimed games: %w", err))
+		claimed = make(map[string]bool)
+	}
+
+	widget.Claimed = claimed
+	widget.Visible = widget.Games
+
+	if widget.HideClaimed {
+		widget.Visible = make(feed.FreeGames, 0, len(widget.Games))
+
+		for i := range widget.Games {
+			if !claimed[widget.Games[i].ID] {
+				widget.Visible = append(widget.Visible, widget.Games[i])
+			}
+		}
+	}
+
+	return widget.render(widget, assets.FreeGamesTemplate)
+}
@@ -11,13 +11,14 @@ import (
 
 type HackerNews struct {
 	widgetBase          `yaml:",inline"`
-	Posts               feed.ForumPosts `yaml:"-"`
-	Limit               int             `yaml:"limit"`
-	SortBy              string          `yaml:"sort-by"`
-	ExtraSortBy         string          `yaml:"extra-sort-by"`
-	CollapseAfter       int             `yaml:"collapse-after"`
-	CommentsUrlTemplate string          `yaml:"comments-url-template"`
-	ShowThumbnails      bool            `yaml:"-"`
+	Posts               feed.ForumPosts     `yaml:"-"`
+	Limit               int                 `yaml:"limit"`
+	SortBy              string              `yaml:"sort-by"`
+	ExtraSortBy         string              `yaml:"extra-sort-by"`
+	CollapseAfter       int                 `yaml:"collapse-after"`
+	OnClick             ForumPostClickField `yaml:"on-click"`
+	CommentsUrlTemplate string              `yaml:"comments-url-template"`
+	ShowThumbnails      bool                `yaml:"-"`
 }
 
 func (widget *HackerNews) Initialize() error {
@@ -39,7 +40,7 @@ func (widget *HackerNews) Initialize() error {
 }
 
 func (widget *HackerNews) Update(ctx context.Context) {
-	posts, err := feed.FetchHackerNewsPosts(widget.SortBy, 40, widget.CommentsUrlTemplate)
+	posts, err := feed.FetchHackerNewsPosts(ctx, widget.SortBy, 40, widget.CommentsUrlTemplate)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
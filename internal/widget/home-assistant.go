@@ -0,0 +1,282 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+var homeAssistantRuleColors = []string{"positive", "negative", "primary", "highlight", "subdue"}
+
+// icons from https://pictogrammers.com/library/mdi/, the same set used by Home Assistant
+var homeAssistantDeviceClassIcons = map[string]string{
+	"temperature":     "thermometer",
+	"humidity":        "water-percent",
+	"moisture":        "water",
+	"battery":         "battery",
+	"power":           "flash",
+	"energy":          "lightning-bolt",
+	"voltage":         "sine-wave",
+	"current":         "current-ac",
+	"illuminance":     "brightness-5",
+	"pressure":        "gauge",
+	"carbon_dioxide":  "molecule-co2",
+	"gas":             "meter-gas",
+	"signal_strength": "wifi",
+	"timestamp":       "clock-outline",
+	"motion":          "motion-sensor",
+	"occupancy":       "home-account",
+	"presence":        "home-account",
+	"door":            "door",
+	"garage_door":     "garage",
+	"window":          "window-closed",
+	"lock":            "lock",
+	"plug":            "power-plug",
+	"outlet":          "power-socket",
+	"connectivity":    "lan-connect",
+	"smoke":           "smoke-detector",
+	"problem":         "alert-circle",
+}
+
+var homeAssistantDomainIcons = map[string]string{
+	"sensor":         "eye",
+	"binary_sensor":  "checkbox-blank-circle-outline",
+	"light":          "lightbulb",
+	"switch":         "toggle-switch",
+	"input_boolean":  "toggle-switch-outline",
+	"person":         "account",
+	"device_tracker": "account",
+	"climate":        "thermostat",
+	"lock":           "lock",
+	"cover":          "window-shutter",
+	"fan":            "fan",
+	"media_player":   "cast",
+	"vacuum":         "robot-vacuum",
+	"camera":         "video",
+	"weather":        "weather-partly-cloudy",
+	"sun":            "white-balance-sunny",
+	"update":         "package-up",
+	"automation":     "robot",
+	"script":         "script-text",
+}
+
+// for states which aren't worth showing as they are
+var homeAssistantStateLabels = map[string]string{
+	"not_home":    "Away",
+	"unavailable": "Unavailable",
+	"unknown":     "Unknown",
+}
+
+func mdiIconURL(name string) string {
+	return "https://cdn.jsdelivr.net/npm/@mdi/svg@7.4.47/svg/" + name + ".svg"
+}
+
+type homeAssistantColorRule struct {
+	State string   `yaml:"state"`
+	Above *float64 `yaml:"above"`
+	Below *float64 `yaml:"below"`
+	Color string   `yaml:"color"`
+}
+
+// matches when every condition which is set holds, a rule
+// without any conditions matches any state
+func (rule *homeAssistantColorRule) matches(state string) bool {
+	if rule.State != "" && !strings.EqualFold(rule.State, state) {
+		return false
+	}
+
+	if rule.Above == nil && rule.Below == nil {
+		return true
+	}
+
+	value, err := strconv.ParseFloat(state, 64)
+
+	if err != nil {
+		return false
+	}
+
+	return (rule.Above == nil || value > *rule.Above) && (rule.Below == nil || value < *rule.Below)
+}
+
+type homeAssistantEntity struct {
+	ID           string                    `yaml:"id"`
+	Name         string                    `yaml:"name"`
+	Icon         string                    `yaml:"icon"`
+	Colors       []homeAssistantColorRule  `yaml:"colors"`
+	IsSimpleIcon bool                      `yaml:"-"`
+	Entity       *feed.HomeAssistantEntity `yaml:"-"`
+	customIcon   bool                      `yaml:"-"`
+}
+
+func (e *homeAssistantEntity) DisplayName() string {
+	if e.Name != "" {
+		return e.Name
+	}
+
+	if e.Entity != nil && e.Entity.FriendlyName != "" {
+		return e.Entity.FriendlyName
+	}
+
+	return e.ID
+}
+
+func (e *homeAssistantEntity) DisplayState() string {
+	if e.Entity == nil {
+		return "Not found"
+	}
+
+	state := e.Entity.State
+
+	if label, ok := homeAssistantStateLabels[state]; ok {
+		return label
+	}
+
+	if value, err := strconv.ParseFloat(state, 64); err == nil {
+		// sensors report as many decimals as the device gives them
+		state = strconv.FormatFloat(value, 'f', -1, 64)
+
+		if dot := strings.IndexByte(state, '.'); dot != -1 && len(state)-dot > 3 {
+			state = strconv.FormatFloat(value, 'f', 2, 64)
+		}
+	} else if state != "" {
+		state = strings.ToUpper(state[:1]) + strings.ReplaceAll(state[1:], "_", " ")
+	}
+
+	if e.Entity.Unit == "" || e.Entity.IsUnavailable() {
+		return state
+	}
+
+	if e.Entity.Unit == "%" {
+		return state + e.Entity.Unit
+	}
+
+	return state + " " + e.Entity.Unit
+}
+
+func (e *homeAssistantEntity) ColorClass() string {
+	if e.Entity == nil || e.Entity.IsUnavailable() {
+		return "color-subdue"
+	}
+
+	for i := range e.Colors {
+		if e.Colors[i].matches(e.Entity.State) {
+			return "color-" + e.Colors[i].Color
+		}
+	}
+
+	return "color-highlight"
+}
+
+// the icon from the config is used if there is one, falling back to the one set
+// in Home Assistant and then to one picked based on the kind of entity it is
+func (e *homeAssistantEntity) resolveIcon() {
+	if e.customIcon || e.Entity == nil {
+		return
+	}
+
+	e.Icon, e.IsSimpleIcon = "", false
+
+	if name, ok := strings.CutPrefix(e.Entity.Icon, "mdi:"); ok {
+		e.Icon, e.IsSimpleIcon = mdiIconURL(name), true
+	} else if name, ok := homeAssistantDeviceClassIcons[e.Entity.DeviceClass]; ok {
+		e.Icon, e.IsSimpleIcon = mdiIconURL(name), true
+	} else if name, ok := homeAssistantDomainIcons[e.Entity.Domain()]; ok {
+		e.Icon, e.IsSimpleIcon = mdiIconURL(name), true
+	}
+}
+
+type HomeAssistant struct {
+	widgetBase `yaml:",inline"`
+	URL        OptionalEnvString     `yaml:"url"`
+	Token      OptionalEnvString     `yaml:"token"`
+	Style      string                `yaml:"style"`
+	Entities   []homeAssistantEntity `yaml:"entities"`
+}
+
+func (widget *HomeAssistant) Initialize() error {
+	widget.withTitle("Home Assistant").withCacheDuration(time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url is required for home-assistant widget")
+	}
+
+	if widget.Token == "" {
+		return errors.New("token is required for home-assistant widget")
+	}
+
+	if len(widget.Entities) == 0 {
+		return errors.New("home-assistant widget requires at least one entity")
+	}
+
+	if widget.Style != "" && widget.Style != "grid" {
+		return fmt.Errorf("style for home-assistant widget must be either empty or grid: %s", widget.Style)
+	}
+
+	for i := range widget.Entities {
+		entity := &widget.Entities[i]
+
+		if entity.ID == "" {
+			return fmt.Errorf("entity #%d of home-assistant widget is missing an id", i+1)
+		}
+
+		for j := range entity.Colors {
+			if !slices.Contains(homeAssistantRuleColors, entity.Colors[j].Color) {
+				return fmt.Errorf(
+					"color of entity %s must be one of %s: %s",
+					entity.ID,
+					strings.Join(homeAssistantRuleColors, ", "),
+					entity.Colors[j].Color,
+				)
+			}
+		}
+
+		if entity.Icon != "" {
+			entity.customIcon = true
+
+			if name, ok := strings.CutPrefix(entity.Icon, "mdi:"); ok {
+				entity.Icon, entity.IsSimpleIcon = mdiIconURL(name), true
+			} else {
+				entity.Icon, entity.IsSimpleIcon = toSimpleIconIfPrefixed(entity.Icon)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (widget *HomeAssistant) Update(ctx context.Context) {
+	ids := make([]string, len(widget.Entities))
+
+	for i := range widget.Entities {
+		ids[i] = widget.Entities[i].ID
+	}
+
+	entities, err := feed.FetchHomeAssistantEntities(ctx, string(widget.URL), string(widget.Token), ids)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range widget.Entities {
+		entity := &widget.Entities[i]
+		entity.Entity = nil
+
+		if state, ok := entities[entity.ID]; ok {
+			entity.Entity = &state
+		}
+
+		entity.resolveIcon()
+	}
+}
+
+func (widget *HomeAssistant) Render() template.HTML {
+	return widget.render(widget, assets.HomeAssistantTemplate)
+}
@@ -4,16 +4,42 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
 )
 
+var iframeSandboxTokens = map[string]bool{
+	"allow-downloads":                          true,
+	"allow-forms":                              true,
+	"allow-modals":                             true,
+	"allow-orientation-lock":                   true,
+	"allow-pointer-lock":                       true,
+	"allow-popups":                             true,
+	"allow-popups-to-escape-sandbox":           true,
+	"allow-presentation":                       true,
+	"allow-same-origin":                        true,
+	"allow-scripts":                            true,
+	"allow-storage-access-by-user-activation":  true,
+	"allow-top-navigation":                     true,
+	"allow-top-navigation-by-user-activation":  true,
+	"allow-top-navigation-to-custom-protocols": true,
+}
+
 type IFrame struct {
-	widgetBase `yaml:",inline"`
-	cachedHTML template.HTML `yaml:"-"`
-	Source     string        `yaml:"source"`
-	Height     int           `yaml:"height"`
+	widgetBase  `yaml:",inline"`
+	cachedHTML  template.HTML `yaml:"-"`
+	Source      string        `yaml:"source"`
+	Height      int           `yaml:"height"`
+	AspectRatio string        `yaml:"aspect-ratio"`
+	Sandbox     *[]string     `yaml:"sandbox"`
+	Lazy        bool          `yaml:"lazy"`
+	Ratio       template.CSS  `yaml:"-"`
+	origin      string        `yaml:"-"`
 }
 
 func (widget *IFrame) Initialize() error {
@@ -23,23 +49,78 @@ func (widget *IFrame) Initialize() error {
 		return errors.New("missing source for iframe")
 	}
 
-	_, err := url.Parse(widget.Source)
+	source, err := url.Parse(widget.Source)
 
 	if err != nil {
 		return fmt.Errorf("invalid source for iframe: %v", err)
 	}
 
-	if widget.Height == 50 {
+	if source.Scheme == "http" || source.Scheme == "https" {
+		widget.origin = source.Scheme + "://" + source.Host
+	}
+
+	if widget.Height == 0 {
 		widget.Height = 300
 	} else if widget.Height < 50 {
 		widget.Height = 50
 	}
 
+	if widget.AspectRatio != "" {
+		width, height, ok := strings.Cut(widget.AspectRatio, ":")
+		w, werr := strconv.ParseFloat(width, 64)
+		h, herr := strconv.ParseFloat(height, 64)
+
+		if !ok || werr != nil || herr != nil || w <= 0 || h <= 0 {
+			return fmt.Errorf("invalid aspect ratio for iframe, expected width:height, got %q", widget.AspectRatio)
+		}
+
+		widget.Ratio = template.CSS(strconv.FormatFloat(w, 'f', -1, 64) + " / " + strconv.FormatFloat(h, 'f', -1, 64))
+	}
+
+	if widget.Sandbox != nil {
+		for _, token := range *widget.Sandbox {
+			if !iframeSandboxTokens[token] {
+				return fmt.Errorf("invalid sandbox value for iframe: %s", token)
+			}
+		}
+	}
+
+	if widget.origin != "" {
+		go warnIfIFrameEmbeddingBlocked(widget.Source)
+	}
+
 	widget.cachedHTML = widget.render(widget, assets.IFrameTemplate)
 
 	return nil
 }
 
+// Origin returns the origin of the source so that it can be allowed through
+// the Content-Security-Policy of the page, or an empty string if it has none
+func (widget *IFrame) Origin() string {
+	return widget.origin
+}
+
+func (widget *IFrame) SandboxAttr() string {
+	if widget.Sandbox == nil {
+		return ""
+	}
+
+	return strings.Join(*widget.Sandbox, " ")
+}
+
+func warnIfIFrameEmbeddingBlocked(source string) {
+	reason, err := feed.DetectEmbeddingBlocked(source)
+
+	if err != nil {
+		slog.Warn("Could not check whether iframe source allows embedding", "source", source, "error", err)
+		return
+	}
+
+	if reason != "" {
+		slog.Warn("Iframe source will likely refuse to be embedded", "source", source, "reason", reason)
+	}
+}
+
 func (widget *IFrame) Render() template.HTML {
 	return widget.cachedHTML
 }
@@ -0,0 +1,99 @@
+package widget
+
+import (
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// widgets listing items with the time they were published, which can mark the
+// ones published since the last visit to the page as new
+type newItemsWidget interface {
+	setLastVisit(lastVisit time.Time, now time.Time, published []time.Time)
+	publishedTimes() []time.Time
+}
+
+// SetLastVisit marks the items of the widget which were published since lastVisit
+// as new until the next call, a zero lastVisit meaning it's the first visit and
+// that nothing should be marked. Must be called before rendering the widget and
+// by the same goroutine, like Update.
+func SetLastVisit(w Widget, lastVisit time.Time) {
+	if widget, ok := w.(newItemsWidget); ok {
+		widget.setLastVisit(lastVisit, time.Now(), widget.publishedTimes())
+	}
+}
+
+func (w *widgetBase) setLastVisit(lastVisit time.Time, now time.Time, published []time.Time) {
+	w.lastVisit = lastVisit
+	w.lastVisitNow = now
+	w.NewItems = 0
+
+	for i := range published {
+		if w.IsNew(published[i]) {
+			w.NewItems++
+		}
+	}
+}
+
+// IsNew reports whether an item published at t was published since the last visit, items
+// dated in the future are only considered new once that time has come so that they aren't
+// marked on every visit when the clock of whoever published them is ahead
+func (w *widgetBase) IsNew(t time.Time) bool {
+	if w.lastVisit.IsZero() {
+		return false
+	}
+
+	return t.After(w.lastVisit) && !t.After(w.lastVisitNow)
+}
+
+func (widget *RSS) publishedTimes() []time.Time {
+	times := make([]time.Time, len(widget.Items))
+
+	for i := range widget.Items {
+		times[i] = widget.Items[i].PublishedAt
+	}
+
+	return times
+}
+
+func forumPostsPublishedTimes(posts feed.ForumPosts) []time.Time {
+	times := make([]time.Time, len(posts))
+
+	for i := range posts {
+		times[i] = posts[i].TimePosted
+	}
+
+	return times
+}
+
+func (widget *HackerNews) publishedTimes() []time.Time {
+	return forumPostsPublishedTimes(widget.Posts)
+}
+
+func (widget *Lobsters) publishedTimes() []time.Time {
+	return forumPostsPublishedTimes(widget.Posts)
+}
+
+func (widget *Reddit) publishedTimes() []time.Time {
+	return forumPostsPublishedTimes(widget.Posts)
+}
+
+func (widget *Videos) publishedTimes() []time.Time {
+	times := make([]time.Time, len(widget.Videos))
+
+	for i := range widget.Videos {
+		times[i] = widget.Videos[i].TimePosted
+	}
+
+	return times
+}
+
+func (widget *Releases) publishedTimes() []time.Time {
+	times := make([]time.Time, len(widget.Releases))
+
+	for i := range widget.Releases {
+		times[i] = widget.Releases[i].TimeReleased
+	}
+
+	return times
+}
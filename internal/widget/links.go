@@ -0,0 +1,123 @@
+package widget
+
+import (
+	"fmt"
+	"html/template"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+	"gopkg.in/yaml.v3"
+)
+
+// LinkTargetField sets whether the links of a widget open in a new tab, which
+// they do unless it's set to same-tab
+type LinkTargetField string
+
+const (
+	linkTargetNewTab  LinkTargetField = "new-tab"
+	linkTargetSameTab LinkTargetField = "same-tab"
+)
+
+func (f *LinkTargetField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	if value != string(linkTargetNewTab) && value != string(linkTargetSameTab) {
+		return fmt.Errorf("invalid link-target: %s, must be either %s or %s", value, linkTargetNewTab, linkTargetSameTab)
+	}
+
+	*f = LinkTargetField(value)
+
+	return nil
+}
+
+func (w *widgetBase) opensLinksInSameTab() bool {
+	return w.LinkTarget == linkTargetSameTab
+}
+
+// LinkAttrs returns the target and rel attributes for the links of the widget
+func (w *widgetBase) LinkAttrs() template.HTMLAttr {
+	return assets.LinkAttrs(w.opensLinksInSameTab())
+}
+
+// LinkedItem is what templates pass to the templates they render their items
+// with, which don't otherwise have access to the link-target of the widget
+type LinkedItem struct {
+	Item      any
+	LinkAttrs template.HTMLAttr
+}
+
+func (w *widgetBase) WithLinkAttrs(item any) LinkedItem {
+	return LinkedItem{Item: item, LinkAttrs: w.LinkAttrs()}
+}
+
+// ForumPostClickField sets where clicking the title of a post goes, either to
+// its comments, which is the default, or to the link that was posted
+type ForumPostClickField string
+
+const (
+	forumPostClickComments ForumPostClickField = "comments"
+	forumPostClickLink     ForumPostClickField = "link"
+)
+
+func (f *ForumPostClickField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	if value != string(forumPostClickComments) && value != string(forumPostClickLink) {
+		return fmt.Errorf("invalid on-click: %s, must be either %s or %s", value, forumPostClickComments, forumPostClickLink)
+	}
+
+	*f = ForumPostClickField(value)
+
+	return nil
+}
+
+// URL returns where the title of the post links to, posts without a link
+// always go to their comments
+func (f ForumPostClickField) URL(post feed.ForumPost) string {
+	if f == forumPostClickLink && post.HasTargetUrl() {
+		return post.TargetUrl
+	}
+
+	return post.DiscussionUrl
+}
+
+// ReleaseClickField sets where clicking a release goes, either to its release
+// notes, which is the default, or to the changelog of the repository at its tag
+type ReleaseClickField string
+
+const (
+	releaseClickNotes     ReleaseClickField = "release-notes"
+	releaseClickChangelog ReleaseClickField = "changelog"
+)
+
+func (f *ReleaseClickField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	if value != string(releaseClickNotes) && value != string(releaseClickChangelog) {
+		return fmt.Errorf("invalid on-click: %s, must be either %s or %s", value, releaseClickNotes, releaseClickChangelog)
+	}
+
+	*f = ReleaseClickField(value)
+
+	return nil
+}
+
+func (f ReleaseClickField) URL(release feed.AppRelease) string {
+	if f == releaseClickChangelog && release.Tag != "" {
+		return "https://github.com/" + release.Name + "/blob/" + release.Tag + "/CHANGELOG.md"
+	}
+
+	return release.NotesUrl
+}
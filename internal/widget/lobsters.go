@@ -11,12 +11,13 @@ import (
 
 type Lobsters struct {
 	widgetBase     `yaml:",inline"`
-	Posts          feed.ForumPosts `yaml:"-"`
-	Limit          int             `yaml:"limit"`
-	CollapseAfter  int             `yaml:"collapse-after"`
-	SortBy         string          `yaml:"sort-by"`
-	Tags           []string        `yaml:"tags"`
-	ShowThumbnails bool            `yaml:"-"`
+	Posts          feed.ForumPosts     `yaml:"-"`
+	Limit          int                 `yaml:"limit"`
+	CollapseAfter  int                 `yaml:"collapse-after"`
+	OnClick        ForumPostClickField `yaml:"on-click"`
+	SortBy         string              `yaml:"sort-by"`
+	Tags           []string            `yaml:"tags"`
+	ShowThumbnails bool                `yaml:"-"`
 }
 
 func (widget *Lobsters) Initialize() error {
@@ -38,7 +39,7 @@ func (widget *Lobsters) Initialize() error {
 }
 
 func (widget *Lobsters) Update(ctx context.Context) {
-	posts, err := feed.FetchLobstersPosts(widget.SortBy, widget.Tags)
+	posts, err := feed.FetchLobstersPosts(ctx, widget.SortBy, widget.Tags)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
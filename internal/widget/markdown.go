@@ -0,0 +1,103 @@
+package widget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+var (
+	markdownRenderer       = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	markdownUnsafeRenderer = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+)
+
+type Markdown struct {
+	widgetBase `yaml:",inline"`
+	Content    string        `yaml:"content"`
+	File       string        `yaml:"file"`
+	AllowHTML  bool          `yaml:"allow-html"`
+	Rendered   template.HTML `yaml:"-"`
+}
+
+func (widget *Markdown) Initialize() error {
+	widget.withTitle("Notes")
+
+	if widget.Content == "" && widget.File == "" {
+		return errors.New("markdown widget requires either content or file")
+	}
+
+	if widget.Content != "" && widget.File != "" {
+		return errors.New("markdown widget can't have both content and file")
+	}
+
+	if widget.File == "" {
+		rendered, err := widget.renderMarkdown([]byte(widget.Content))
+
+		if err != nil {
+			return err
+		}
+
+		widget.Rendered = rendered
+		widget.withError(nil)
+
+		return nil
+	}
+
+	// the file is read again on every update so that
+	// changes to it show up without having to restart
+	widget.withCacheDuration(0)
+
+	return nil
+}
+
+func (widget *Markdown) Update(ctx context.Context) {
+	if widget.File == "" {
+		return
+	}
+
+	source, err := os.ReadFile(widget.File)
+
+	if err == nil {
+		var rendered template.HTML
+		rendered, err = widget.renderMarkdown(source)
+		widget.Rendered = rendered
+	} else {
+		err = fmt.Errorf("could not read %s: %w", widget.File, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+}
+
+func (widget *Markdown) renderMarkdown(source []byte) (template.HTML, error) {
+	renderer := markdownRenderer
+
+	// raw HTML is replaced with a comment unless explicitly allowed
+	if widget.AllowHTML {
+		renderer = markdownUnsafeRenderer
+	}
+
+	var buffer bytes.Buffer
+
+	if err := renderer.Convert(source, &buffer); err != nil {
+		return "", fmt.Errorf("could not render markdown: %w", err)
+	}
+
+	return template.HTML(buffer.String()), nil
+}
+
+func (widget *Markdown) Render() template.HTML {
+	return widget.render(widget, assets.MarkdownTemplate)
+}
@@ -41,6 +41,29 @@ func statusCodeToStyle(status int) string {
 	return "error"
 }
 
+// empty unless the certificate expires within warning
+func certificateExpiryText(expiresAt time.Time, warning time.Duration) string {
+	if expiresAt.IsZero() {
+		return ""
+	}
+
+	left := time.Until(expiresAt)
+
+	if left > warning {
+		return ""
+	}
+
+	if left <= 0 {
+		return "Certificate expired"
+	}
+
+	if days := int(left.Hours() / 24); days > 0 {
+		return "Certificate expires in " + strconv.Itoa(days) + "d"
+	}
+
+	return "Certificate expires in " + strconv.Itoa(int(left.Hours())) + "h"
+}
+
 type Monitor struct {
 	widgetBase `yaml:",inline"`
 	Sites      []struct {
@@ -52,15 +75,21 @@ type Monitor struct {
 		SameTab                 bool             `yaml:"same-tab"`
 		StatusText              string           `yaml:"-"`
 		StatusStyle             string           `yaml:"-"`
+		CertificateWarning      DurationField    `yaml:"certificate-expiry-warning"`
+		CertificateStatus       string           `yaml:"-"`
 	} `yaml:"sites"`
-	Style string `yaml:"style"`
+	Style  string `yaml:"style"`
+	Always bool   `yaml:"always"`
 }
 
 func (widget *Monitor) Initialize() error {
 	widget.withTitle("Monitor").withCacheDuration(5 * time.Minute)
+	widget.ignoresQuietHours = widget.Always
 
 	for i := range widget.Sites {
 		widget.Sites[i].IconUrl, widget.Sites[i].IsSimpleIcon = toSimpleIconIfPrefixed(widget.Sites[i].IconUrl)
+		widget.Sites[i].CheckCertificate = widget.Sites[i].CertificateWarning > 0
+		widget.Sites[i].SameTab = widget.Sites[i].SameTab || widget.opensLinksInSameTab()
 	}
 
 	return nil
@@ -73,7 +102,7 @@ func (widget *Monitor) Update(ctx context.Context) {
 		requests[i] = widget.Sites[i].SiteStatusRequest
 	}
 
-	statuses, err := feed.FetchStatusForSites(requests)
+	statuses, err := feed.FetchStatusForSites(ctx, requests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -89,6 +118,8 @@ func (widget *Monitor) Update(ctx context.Context) {
 			site.StatusText = statusCodeToText(status.Code)
 			site.StatusStyle = statusCodeToStyle(status.Code)
 		}
+
+		site.CertificateStatus = certificateExpiryText(status.CertificateExpiresAt, time.Duration(site.CertificateWarning))
 	}
 }
 
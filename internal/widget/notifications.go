@@ -0,0 +1,137 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// used to identify the widget when acknowledging notifications
+var notificationsWidgetCount atomic.Int64
+
+type notificationSourceConfig struct {
+	Type             string            `yaml:"type"`
+	URL              string            `yaml:"url"`
+	Title            string            `yaml:"title"`
+	Token            OptionalEnvString `yaml:"token"`
+	Topics           []string          `yaml:"topics"`
+	AppID            int               `yaml:"app-id"`
+	AllowAcknowledge bool              `yaml:"allow-acknowledge"`
+}
+
+type Notifications struct {
+	widgetBase    `yaml:",inline"`
+	ID            string                     `yaml:"-"`
+	Sources       []notificationSourceConfig `yaml:"sources"`
+	HideOlderThan DurationField              `yaml:"hide-older-than"`
+	Limit         int                        `yaml:"limit"`
+	CollapseAfter int                        `yaml:"collapse-after"`
+	Items         feed.Notifications         `yaml:"-"`
+	sources       []feed.NotificationSource  `yaml:"-"`
+}
+
+func (widget *Notifications) Initialize() error {
+	widget.withTitle("Notifications").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Sources) == 0 {
+		return errors.New("notifications widget requires at least one source")
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 15
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	widget.sources = make([]feed.NotificationSource, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.URL == "" {
+			return fmt.Errorf("notification source %d has no url", i+1)
+		}
+
+		switch source.Type {
+		case "ntfy":
+			if len(source.Topics) == 0 {
+				return fmt.Errorf("ntfy source %d requires at least one topic", i+1)
+			}
+
+			if source.AllowAcknowledge {
+				return fmt.Errorf("ntfy source %d: messages from ntfy can't be acknowledged", i+1)
+			}
+		case "gotify":
+			if source.Token == "" {
+				return fmt.Errorf("gotify source %d requires a client token", i+1)
+			}
+
+			if source.Title == "" {
+				source.Title = "gotify"
+			}
+		default:
+			return fmt.Errorf("notification source %d: type must be either ntfy or gotify", i+1)
+		}
+
+		widget.sources[i] = feed.NotificationSource{
+			Type:   source.Type,
+			URL:    source.URL,
+			Title:  source.Title,
+			Token:  string(source.Token),
+			Topics: source.Topics,
+			AppID:  source.AppID,
+		}
+	}
+
+	widget.ID = strconv.FormatInt(notificationsWidgetCount.Add(1), 10)
+
+	return nil
+}
+
+func (widget *Notifications) Update(ctx context.Context) {
+	notifications, err := feed.FetchNotifications(ctx, widget.sources, widget.Limit, time.Duration(widget.HideOlderThan))
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Items = notifications
+}
+
+func (widget *Notifications) CanAcknowledge(n feed.Notification) bool {
+	return widget.Sources[n.SourceIndex].AllowAcknowledge
+}
+
+// Acknowledge deletes the notification from its source and removes it
+// from the widget so that it doesn't reappear until the next update
+func (widget *Notifications) Acknowledge(sourceIndex int, id string) error {
+	if sourceIndex < 0 || sourceIndex >= len(widget.Sources) || !widget.Sources[sourceIndex].AllowAcknowledge {
+		return feed.ErrNotificationNotAcknowledgeable
+	}
+
+	if err := feed.AcknowledgeNotification(&widget.sources[sourceIndex], id); err != nil {
+		return err
+	}
+
+	for i := range widget.Items {
+		if widget.Items[i].SourceIndex == sourceIndex && widget.Items[i].ID == id {
+			widget.Items = append(widget.Items[:i:i], widget.Items[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (widget *Notifications) Render() template.HTML {
+	return widget.render(widget, assets.NotificationsTemplate)
+}
@@ -0,0 +1,120 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type Proxmox struct {
+	widgetBase    `yaml:",inline"`
+	URL           OptionalEnvString    `yaml:"url"`
+	Token         OptionalEnvString    `yaml:"token"`
+	CACertFile    string               `yaml:"ca-cert-file"`
+	AllowInsecure bool                 `yaml:"allow-insecure"`
+	TopGuests     int                  `yaml:"top-guests"`
+	Cluster       *feed.ProxmoxCluster `yaml:"-"`
+	TopByCPU      feed.ProxmoxGuests   `yaml:"-"`
+	client        *http.Client         `yaml:"-"`
+}
+
+func (widget *Proxmox) Initialize() error {
+	widget.withTitle("Proxmox").withCacheDuration(time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url is required for proxmox widget")
+	}
+
+	if widget.Token == "" {
+		return errors.New("token is required for proxmox widget")
+	}
+
+	if !strings.Contains(string(widget.Token), "!") || !strings.Contains(string(widget.Token), "=") {
+		return errors.New("token for proxmox widget must be in the form of USER@REALM!TOKENID=SECRET")
+	}
+
+	if widget.TopGuests < 0 {
+		return fmt.Errorf("top-guests for proxmox widget can't be negative: %d", widget.TopGuests)
+	}
+
+	client, err := feed.GetClientWithOptions(
+		feed.WithCACertFile(widget.CACertFile),
+		feed.WithInsecureSkipVerify(widget.AllowInsecure),
+	)
+
+	if err != nil {
+		return fmt.Errorf("could not create client for proxmox widget: %w", err)
+	}
+
+	widget.client = client
+
+	return nil
+}
+
+func (widget *Proxmox) Update(ctx context.Context) {
+	cluster, err := feed.FetchProxmoxCluster(ctx, widget.client, string(widget.URL), string(widget.Token))
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Cluster = cluster
+	widget.TopByCPU = nil
+
+	if widget.TopGuests > 0 {
+		running := make(feed.ProxmoxGuests, 0, len(cluster.Guests))
+
+		for i := range cluster.Guests {
+			if cluster.Guests[i].Running {
+				running = append(running, cluster.Guests[i])
+			}
+		}
+
+		running.SortByCPU()
+
+		if len(running) > widget.TopGuests {
+			running = running[:widget.TopGuests]
+		}
+
+		widget.TopByCPU = running
+	}
+}
+
+func (widget *Proxmox) FormatBytes(bytes uint64) string {
+	const unit = 1024
+
+	if bytes < unit*unit*unit {
+		return fmt.Sprintf("%.0f MiB", float64(bytes)/(unit*unit))
+	}
+
+	if bytes < unit*unit*unit*unit {
+		return fmt.Sprintf("%.1f GiB", float64(bytes)/(unit*unit*unit))
+	}
+
+	return fmt.Sprintf("%.1f TiB", float64(bytes)/(unit*unit*unit*unit))
+}
+
+func (widget *Proxmox) FormatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+
+	if days > 0 {
+		return fmt.Sprintf("%dd", days)
+	}
+
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+func (widget *Proxmox) Render() template.HTML {
+	return widget.render(widget, assets.ProxmoxTemplate)
+}
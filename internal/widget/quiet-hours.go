@@ -0,0 +1,103 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QuietHoursField is set through quiet-hours as a window such as "23:00-07:00",
+// in the configured timezone, which wraps around midnight when it ends earlier
+// than it starts
+type QuietHoursField struct {
+	// minutes since midnight
+	start int
+	end   int
+}
+
+func (q *QuietHoursField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	from, to, found := strings.Cut(value, "-")
+
+	if !found {
+		return fmt.Errorf("invalid quiet-hours %s, expected HH:MM-HH:MM", value)
+	}
+
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+
+	if err != nil {
+		return fmt.Errorf("invalid quiet-hours start %s, expected HH:MM", from)
+	}
+
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+
+	if err != nil {
+		return fmt.Errorf("invalid quiet-hours end %s, expected HH:MM", to)
+	}
+
+	q.start = start.Hour()*60 + start.Minute()
+	q.end = end.Hour()*60 + end.Minute()
+
+	if q.start == q.end {
+		return fmt.Errorf("quiet-hours %s starts and ends at the same time", value)
+	}
+
+	return nil
+}
+
+func (q *QuietHoursField) contains(t time.Time) bool {
+	t = t.In(timezone)
+	minutes := t.Hour()*60 + t.Minute()
+
+	if q.start < q.end {
+		return minutes >= q.start && minutes < q.end
+	}
+
+	return minutes >= q.start || minutes < q.end
+}
+
+// nextEnd returns the first time the window ends after now
+func (q *QuietHoursField) nextEnd(now time.Time) time.Time {
+	now = now.In(timezone)
+
+	for day := 0; day <= 1; day++ {
+		// time.Date moves an end skipped over by a DST transition by the size of the
+		// transition, which is close enough for when widgets resume updating
+		end := time.Date(now.Year(), now.Month(), now.Day()+day, q.end/60, q.end%60, 0, 0, timezone)
+
+		if end.After(now) {
+			return end
+		}
+	}
+
+	return now.Add(24 * time.Hour)
+}
+
+var quietHours *QuietHoursField
+
+// SetQuietHours sets the window during which widgets don't update, nil for none
+func SetQuietHours(window *QuietHoursField) {
+	quietHours = window
+}
+
+// InQuietHours reports whether now is within the configured quiet hours
+func InQuietHours(now time.Time) bool {
+	return quietHours != nil && quietHours.contains(now)
+}
+
+// QuietHoursEnd returns when the current or next quiet hours end, the zero
+// time if none are configured
+func QuietHoursEnd(now time.Time) time.Time {
+	if quietHours == nil {
+		return time.Time{}
+	}
+
+	return quietHours.nextEnd(now)
+}
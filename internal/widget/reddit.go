@@ -13,18 +13,19 @@ import (
 
 type Reddit struct {
 	widgetBase          `yaml:",inline"`
-	Posts               feed.ForumPosts `yaml:"-"`
-	Subreddit           string          `yaml:"subreddit"`
-	Style               string          `yaml:"style"`
-	ShowThumbnails      bool            `yaml:"show-thumbnails"`
-	SortBy              string          `yaml:"sort-by"`
-	TopPeriod           string          `yaml:"top-period"`
-	Search              string          `yaml:"search"`
-	ExtraSortBy         string          `yaml:"extra-sort-by"`
-	CommentsUrlTemplate string          `yaml:"comments-url-template"`
-	Limit               int             `yaml:"limit"`
-	CollapseAfter       int             `yaml:"collapse-after"`
-	RequestUrlTemplate  string          `yaml:"request-url-template"`
+	Posts               feed.ForumPosts     `yaml:"-"`
+	Subreddit           string              `yaml:"subreddit"`
+	Style               string              `yaml:"style"`
+	ShowThumbnails      bool                `yaml:"show-thumbnails"`
+	SortBy              string              `yaml:"sort-by"`
+	TopPeriod           string              `yaml:"top-period"`
+	Search              string              `yaml:"search"`
+	ExtraSortBy         string              `yaml:"extra-sort-by"`
+	CommentsUrlTemplate string              `yaml:"comments-url-template"`
+	Limit               int                 `yaml:"limit"`
+	CollapseAfter       int                 `yaml:"collapse-after"`
+	OnClick             ForumPostClickField `yaml:"on-click"`
+	RequestUrlTemplate  string              `yaml:"request-url-template"`
 }
 
 func (widget *Reddit) Initialize() error {
@@ -78,6 +79,7 @@ func isValidRedditTopPeriod(period string) bool {
 func (widget *Reddit) Update(ctx context.Context) {
 	// TODO: refactor, use a struct to pass all of these
 	posts, err := feed.FetchSubredditPosts(
+		ctx,
 		widget.Subreddit,
 		widget.SortBy,
 		widget.TopPeriod,
@@ -0,0 +1,229 @@
+package widget
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RefreshScheduleField is set through refresh-at, either as a cron expression
+// such as "0 9 * * 1-5" or as a list of times of day such as ["09:00", "16:00"],
+// both being in the configured timezone
+type RefreshScheduleField struct {
+	// minutes since midnight, sorted
+	times []int
+	// nil for every day
+	days func(time.Time) bool
+}
+
+func (s *RefreshScheduleField) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var values []string
+
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+
+		return s.parseTimes(values)
+	}
+
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	if strings.Count(strings.TrimSpace(value), " ") == 0 {
+		return s.parseTimes([]string{value})
+	}
+
+	return s.parseCron(value)
+}
+
+func (s *RefreshScheduleField) parseTimes(values []string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("refresh-at must have at least one time")
+	}
+
+	for _, value := range values {
+		parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+
+		if err != nil {
+			return fmt.Errorf("invalid refresh-at time %s, expected HH:MM", value)
+		}
+
+		s.times = append(s.times, parsed.Hour()*60+parsed.Minute())
+	}
+
+	slices.Sort(s.times)
+	s.times = slices.Compact(s.times)
+
+	return nil
+}
+
+func (s *RefreshScheduleField) parseCron(expression string) error {
+	fields := strings.Fields(expression)
+
+	if len(fields) != 5 {
+		return fmt.Errorf("invalid refresh-at expression %s: expected 5 fields (minute hour day month weekday)", expression)
+	}
+
+	minutes, err := parseCronField(fields[0], 0, 59)
+
+	if err != nil {
+		return fmt.Errorf("invalid minute in refresh-at: %v", err)
+	}
+
+	hours, err := parseCronField(fields[1], 0, 23)
+
+	if err != nil {
+		return fmt.Errorf("invalid hour in refresh-at: %v", err)
+	}
+
+	daysOfMonth, err := parseCronField(fields[2], 1, 31)
+
+	if err != nil {
+		return fmt.Errorf("invalid day of month in refresh-at: %v", err)
+	}
+
+	months, err := parseCronField(fields[3], 1, 12)
+
+	if err != nil {
+		return fmt.Errorf("invalid month in refresh-at: %v", err)
+	}
+
+	// 7 is also Sunday, as it is in most cron implementations
+	weekdays, err := parseCronField(fields[4], 0, 7)
+
+	if err != nil {
+		return fmt.Errorf("invalid weekday in refresh-at: %v", err)
+	}
+
+	if weekdays[7] {
+		weekdays[0] = true
+	}
+
+	for hour := 0; hour <= 23; hour++ {
+		for minute := 0; minute <= 59; minute++ {
+			if hours[hour] && minutes[minute] {
+				s.times = append(s.times, hour*60+minute)
+			}
+		}
+	}
+
+	domRestricted := fields[2] != "*"
+	dowRestricted := fields[4] != "*"
+
+	s.days = func(day time.Time) bool {
+		if !months[int(day.Month())] {
+			return false
+		}
+
+		domMatches := daysOfMonth[day.Day()]
+		dowMatches := weekdays[int(day.Weekday())]
+
+		// like cron, when both are restricted a day matching either of them counts
+		if domRestricted && dowRestricted {
+			return domMatches || dowMatches
+		}
+
+		return domMatches && dowMatches
+	}
+
+	return nil
+}
+
+// parseCronField supports *, single values, ranges, lists and steps such as */15 or 1-5/2
+func parseCronField(field string, lowest, highest int) ([]bool, error) {
+	matches := make([]bool, highest+1)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid step in %s", part)
+			}
+
+			step = parsed
+		}
+
+		start, end := lowest, highest
+
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			parsed, err := strconv.Atoi(from)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %s", part)
+			}
+
+			start, end = parsed, parsed
+
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("invalid value %s", part)
+				}
+			} else if hasStep {
+				end = highest
+			}
+		}
+
+		if start < lowest || end > highest || start > end {
+			return nil, fmt.Errorf("%s is outside of %d-%d", part, lowest, highest)
+		}
+
+		for i := start; i <= end; i += step {
+			matches[i] = true
+		}
+	}
+
+	return matches, nil
+}
+
+// next returns the first scheduled time after now. Times which don't exist on a day
+// because of a DST transition happen once the clocks have moved forward, such as
+// 02:30 becoming 03:30, and times which happen twice only happen the first time.
+func (s *RefreshScheduleField) next(now time.Time) time.Time {
+	now = now.In(timezone)
+
+	// schedules such as the 29th of February can be years apart
+	for day := 0; day <= 8*366; day++ {
+		date := time.Date(now.Year(), now.Month(), now.Day()+day, 0, 0, 0, 0, timezone)
+
+		if s.days != nil && !s.days(date) {
+			continue
+		}
+
+		var earliest time.Time
+
+		// times moved forward by a DST transition can end up after later ones, so the
+		// earliest of all of them is taken rather than the first one in the future
+		for _, minutes := range s.times {
+			at := time.Date(date.Year(), date.Month(), date.Day(), minutes/60, minutes%60, 0, 0, timezone)
+
+			// a time skipped over by the clocks moving forward comes out as an earlier one
+			if wall := at.Hour()*60 + at.Minute(); wall < minutes && at.Day() == date.Day() {
+				at = at.Add(time.Duration(minutes-wall) * time.Minute)
+			}
+
+			if at.After(now) && (earliest.IsZero() || at.Before(earliest)) {
+				earliest = at
+			}
+		}
+
+		if !earliest.IsZero() {
+			return earliest
+		}
+	}
+
+	// only reachable with impossible dates such as the 31st of February
+	return now.Add(24 * time.Hour)
+}
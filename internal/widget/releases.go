@@ -16,6 +16,7 @@ type Releases struct {
 	Token         OptionalEnvString `yaml:"token"`
 	Limit         int               `yaml:"limit"`
 	CollapseAfter int               `yaml:"collapse-after"`
+	OnClick       ReleaseClickField `yaml:"on-click"`
 }
 
 func (widget *Releases) Initialize() error {
@@ -33,7 +34,7 @@ func (widget *Releases) Initialize() error {
 }
 
 func (widget *Releases) Update(ctx context.Context) {
-	releases, err := feed.FetchLatestReleasesFromGithub(widget.Repositories, string(widget.Token))
+	releases, err := feed.FetchLatestReleasesFromGithub(ctx, widget.Repositories, string(widget.Token))
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
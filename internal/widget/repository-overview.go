@@ -34,6 +34,7 @@ func (widget *Repository) Initialize() error {
 
 func (widget *Repository) Update(ctx context.Context) {
 	details, err := feed.FetchRepositoryDetailsFromGithub(
+		ctx,
 		widget.RequestedRepository,
 		string(widget.Token),
 		widget.PullRequestsLimit,
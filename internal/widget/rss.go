@@ -18,6 +18,7 @@ type RSS struct {
 	Items           feed.RSSFeedItems     `yaml:"-"`
 	Limit           int                   `yaml:"limit"`
 	CollapseAfter   int                   `yaml:"collapse-after"`
+	Deduplicate     bool                  `yaml:"deduplicate"`
 }
 
 func (widget *RSS) Initialize() error {
@@ -50,7 +51,14 @@ func (widget *RSS) Initialize() error {
 }
 
 func (widget *RSS) Update(ctx context.Context) {
-	items, err := feed.GetItemsFromRSSFeeds(widget.FeedRequests)
+	var items feed.RSSFeedItems
+	var err error
+
+	if widget.Deduplicate {
+		items, _, err = feed.FetchMergedRSSFeeds(ctx, widget.FeedRequests)
+	} else {
+		items, err = feed.GetItemsFromRSSFeeds(ctx, widget.FeedRequests)
+	}
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
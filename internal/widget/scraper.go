@@ -0,0 +1,96 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type Scraper struct {
+	widgetBase `yaml:",inline"`
+	URL        OptionalEnvString            `yaml:"url"`
+	Headers    map[string]OptionalEnvString `yaml:"headers"`
+	Fields     []feed.ScrapeSelector        `yaml:"fields"`
+	List       struct {
+		Selector string                `yaml:"selector"`
+		Limit    int                   `yaml:"limit"`
+		Fields   []feed.ScrapeSelector `yaml:"fields"`
+	} `yaml:"list"`
+	CollapseAfter int                `yaml:"collapse-after"`
+	Result        *feed.ScrapeResult `yaml:"-"`
+}
+
+func (widget *Scraper) Initialize() error {
+	widget.withTitle("Scraper").withCacheDuration(time.Hour)
+
+	if widget.URL == "" {
+		return errors.New("no URL specified for scraper widget")
+	}
+
+	if _, err := url.Parse(string(widget.URL)); err != nil {
+		return fmt.Errorf("invalid URL for scraper widget: %v", err)
+	}
+
+	if len(widget.Fields) == 0 && len(widget.List.Fields) == 0 {
+		return errors.New("scraper widget needs either fields or a list")
+	}
+
+	if len(widget.List.Fields) > 0 && widget.List.Selector == "" {
+		return errors.New("no selector specified for scraper list")
+	}
+
+	for i := range widget.Fields {
+		if err := widget.Fields[i].Compile(); err != nil {
+			return err
+		}
+	}
+
+	for i := range widget.List.Fields {
+		if err := widget.List.Fields[i].Compile(); err != nil {
+			return err
+		}
+	}
+
+	if widget.List.Limit <= 0 {
+		widget.List.Limit = 10
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *Scraper) Update(ctx context.Context) {
+	headers := make(map[string]string, len(widget.Headers))
+
+	for key, value := range widget.Headers {
+		headers[key] = string(value)
+	}
+
+	result, err := feed.FetchScrapedValues(ctx, feed.ScrapeRequest{
+		URL:          string(widget.URL),
+		Headers:      headers,
+		Fields:       widget.Fields,
+		ListSelector: widget.List.Selector,
+		ListLimit:    widget.List.Limit,
+		ListFields:   widget.List.Fields,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Result = result
+}
+
+func (widget *Scraper) Render() template.HTML {
+	return widget.render(widget, assets.ScraperTemplate)
+}
@@ -3,7 +3,9 @@ package widget
 import (
 	"fmt"
 	"html/template"
+	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/glanceapp/glance/internal/assets"
 )
@@ -19,8 +21,13 @@ type Search struct {
 	cachedHTML   template.HTML `yaml:"-"`
 	SearchEngine string        `yaml:"search-engine"`
 	Bangs        []SearchBang  `yaml:"bangs"`
+	NewTab       bool          `yaml:"new-tab"`
+	ID           string        `yaml:"-"`
 }
 
+// used to give the bangs list of each search widget a unique id
+var searchWidgetCount atomic.Int64
+
 func convertSearchUrl(url string) string {
 	// Go's template is being stubborn and continues to escape the curlies in the
 	// URL regardless of what the type of the variable is so this is my way around it
@@ -30,6 +37,28 @@ func convertSearchUrl(url string) string {
 var searchEngines = map[string]string{
 	"duckduckgo": "https://duckduckgo.com/?q={QUERY}",
 	"google":     "https://www.google.com/search?q={QUERY}",
+	"bing":       "https://www.bing.com/search?q={QUERY}",
+	"brave":      "https://search.brave.com/search?q={QUERY}",
+	"startpage":  "https://www.startpage.com/search?q={QUERY}",
+}
+
+func validateSearchUrl(searchUrl string) error {
+	if !strings.Contains(searchUrl, "{QUERY}") {
+		return fmt.Errorf("URL must contain {QUERY} to indicate where the query is placed: %s", searchUrl)
+	}
+
+	parsed, err := url.Parse(strings.ReplaceAll(searchUrl, "{QUERY}", "query"))
+
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	// prevents things like javascript: URLs from ending up in the page
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("URL must start with http:// or https://: %s", searchUrl)
+	}
+
+	return nil
 }
 
 func (widget *Search) Initialize() error {
@@ -43,20 +72,41 @@ func (widget *Search) Initialize() error {
 		widget.SearchEngine = url
 	}
 
+	if err := validateSearchUrl(widget.SearchEngine); err != nil {
+		return fmt.Errorf("Search engine: %v", err)
+	}
+
 	widget.SearchEngine = convertSearchUrl(widget.SearchEngine)
+	shortcuts := make(map[string]bool, len(widget.Bangs))
 
 	for i := range widget.Bangs {
 		if widget.Bangs[i].Shortcut == "" {
 			return fmt.Errorf("Search bang %d has no shortcut", i+1)
 		}
 
+		if strings.ContainsAny(widget.Bangs[i].Shortcut, " \t") {
+			return fmt.Errorf("Search bang %d has a shortcut containing whitespace", i+1)
+		}
+
+		if shortcuts[widget.Bangs[i].Shortcut] {
+			return fmt.Errorf("Search bang %d has the same shortcut as a previous one: %s", i+1, widget.Bangs[i].Shortcut)
+		}
+
+		shortcuts[widget.Bangs[i].Shortcut] = true
+
 		if widget.Bangs[i].URL == "" {
 			return fmt.Errorf("Search bang %d has no URL", i+1)
 		}
 
+		if err := validateSearchUrl(widget.Bangs[i].URL); err != nil {
+			return fmt.Errorf("Search bang %d: %v", i+1, err)
+		}
+
 		widget.Bangs[i].URL = convertSearchUrl(widget.Bangs[i].URL)
 	}
 
+	widget.ID = fmt.Sprintf("search-%d", searchWidgetCount.Add(1))
+
 	widget.cachedHTML = widget.render(widget, assets.SearchTemplate)
 	return nil
 }
@@ -0,0 +1,126 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type ServiceUpdates struct {
+	widgetBase    `yaml:",inline"`
+	Token         OptionalEnvString `yaml:"token"`
+	HideUpToDate  bool              `yaml:"hide-up-to-date"`
+	Services      []*updateService  `yaml:"services"`
+	ShownServices []*updateService  `yaml:"-"`
+}
+
+type updateService struct {
+	Type          string             `yaml:"type"`
+	Title         string             `yaml:"title"`
+	URL           OptionalEnvString  `yaml:"url"`
+	IconUrl       string             `yaml:"icon"`
+	IsSimpleIcon  bool               `yaml:"-"`
+	Repository    string             `yaml:"repository"`
+	VersionURL    OptionalEnvString  `yaml:"version-url"`
+	VersionPath   string             `yaml:"version-path"`
+	AllowInsecure bool               `yaml:"allow-insecure"`
+	Update        feed.ServiceUpdate `yaml:"-"`
+	client        *http.Client       `yaml:"-"`
+}
+
+func (widget *ServiceUpdates) Initialize() error {
+	widget.withTitle("Updates").withCacheDuration(6 * time.Hour)
+
+	if len(widget.Services) == 0 {
+		return errors.New("no services specified for service-updates widget")
+	}
+
+	for i, service := range widget.Services {
+		if service.Type == "" {
+			service.Type = "custom"
+		}
+
+		if service.Type == "custom" {
+			if service.VersionURL == "" {
+				return fmt.Errorf("version-url is required for custom service %d", i+1)
+			}
+
+			if service.Repository == "" {
+				return fmt.Errorf("repository is required for custom service %d", i+1)
+			}
+		} else if !feed.IsKnownServiceType(service.Type) {
+			return fmt.Errorf("unknown service type: %s", service.Type)
+		} else if service.URL == "" {
+			return fmt.Errorf("url is required for %s service", service.Type)
+		}
+
+		if service.Title == "" {
+			service.Title = service.Type
+		}
+
+		if service.AllowInsecure {
+			client, err := feed.GetClientWithOptions(feed.WithInsecureSkipVerify(true))
+
+			if err != nil {
+				return fmt.Errorf("could not create client for %s service: %w", service.Title, err)
+			}
+
+			service.client = client
+		}
+
+		service.IconUrl, service.IsSimpleIcon = toSimpleIconIfPrefixed(service.IconUrl)
+	}
+
+	return nil
+}
+
+func (widget *ServiceUpdates) Update(ctx context.Context) {
+	requests := make([]*feed.ServiceUpdateRequest, len(widget.Services))
+
+	for i, service := range widget.Services {
+		request := &feed.ServiceUpdateRequest{
+			Type:        service.Type,
+			URL:         string(service.URL),
+			Repository:  service.Repository,
+			VersionPath: service.VersionPath,
+		}
+
+		if service.Type == "custom" {
+			request.URL = string(service.VersionURL)
+		}
+
+		if service.client != nil {
+			request.Client = service.client
+		}
+
+		requests[i] = request
+	}
+
+	updates, err := feed.FetchServiceUpdates(ctx, requests, string(widget.Token))
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.ShownServices = make([]*updateService, 0, len(widget.Services))
+
+	for i, service := range widget.Services {
+		service.Update = updates[i]
+
+		if widget.HideUpToDate && service.Update.Error == nil && !service.Update.Outdated {
+			continue
+		}
+
+		widget.ShownServices = append(widget.ShownServices, service)
+	}
+}
+
+func (widget *ServiceUpdates) Render() template.HTML {
+	return widget.render(widget, assets.ServiceUpdatesTemplate)
+}
@@ -0,0 +1,332 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+const (
+	speedtestMinInterval = 30 * time.Minute
+	// how long to wait before trying again after a measurement failed
+	speedtestRetryDelay = 15 * time.Minute
+)
+
+var speedtestIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+var defaultSpeedtestCommand = []string{"speedtest", "--format=json", "--accept-license", "--accept-gdpr"}
+
+// only one measurement runs at a time across every widget, two running at
+// once would compete for the same connection and skew both results
+var speedtestMeasurementMu sync.Mutex
+
+var (
+	speedtestSchedulersMu sync.Mutex
+	speedtestSchedulers   = make(map[string]bool)
+	speedtestMeasuring    = make(map[string]bool)
+)
+
+type SpeedtestHistory struct {
+	Results     feed.SpeedtestResults `json:"results"`
+	LastAttempt time.Time             `json:"last_attempt"`
+	LastError   string                `json:"last_error,omitempty"`
+}
+
+type Speedtest struct {
+	widgetBase `yaml:",inline"`
+	Source     string                `yaml:"source"`
+	ID         string                `yaml:"id"`
+	Command    []string              `yaml:"command"`
+	Timeout    DurationField         `yaml:"timeout"`
+	Interval   DurationField         `yaml:"interval"`
+	URL        OptionalEnvString     `yaml:"url"`
+	Token      OptionalEnvString     `yaml:"token"`
+	History    int                   `yaml:"history"`
+	Results    feed.SpeedtestResults `yaml:"-"`
+	Measuring  bool                  `yaml:"-"`
+	env        []string              `yaml:"-"`
+}
+
+func (widget *Speedtest) Initialize() error {
+	widget.withTitle("Speedtest")
+
+	if widget.Source == "" {
+		widget.Source = "command"
+	}
+
+	if widget.History <= 0 {
+		widget.History = 24
+	}
+
+	switch widget.Source {
+	case "command":
+		// measurements happen in the background, this is
+		// only how often the results are picked up
+		widget.withCacheDuration(5 * time.Minute)
+
+		if widget.ID == "" {
+			widget.ID = "default"
+		}
+
+		if !speedtestIDPattern.MatchString(widget.ID) {
+			return fmt.Errorf("invalid id for speedtest widget, can only contain letters, numbers, - and _: %s", widget.ID)
+		}
+
+		if len(widget.Command) == 0 {
+			widget.Command = defaultSpeedtestCommand
+		}
+
+		if widget.Timeout == 0 {
+			widget.Timeout = DurationField(2 * time.Minute)
+		}
+
+		if widget.Interval == 0 {
+			widget.Interval = DurationField(6 * time.Hour)
+		}
+
+		if time.Duration(widget.Interval) < speedtestMinInterval {
+			return fmt.Errorf("interval for speedtest widget must be at least %s", speedtestMinInterval)
+		}
+
+		// the tools keep their license acceptance and config under HOME
+		for _, name := range []string{"PATH", "HOME"} {
+			if value, ok := os.LookupEnv(name); ok {
+				widget.env = append(widget.env, name+"="+value)
+			}
+		}
+	case "speedtest-tracker":
+		widget.withCacheDuration(15 * time.Minute)
+
+		if widget.URL == "" {
+			return errors.New("speedtest widget with the speedtest-tracker source requires a url")
+		}
+
+		if widget.Token == "" {
+			return errors.New("speedtest widget with the speedtest-tracker source requires a token")
+		}
+	default:
+		return fmt.Errorf("speedtest widget source must be either command or speedtest-tracker: %s", widget.Source)
+	}
+
+	return nil
+}
+
+func speedtestStorageKey(id string) string {
+	return "speedtest:" + id
+}
+
+func loadSpeedtestHistory(id string) (*SpeedtestHistory, error) {
+	history := &SpeedtestHistory{Results: make(feed.SpeedtestResults, 0)}
+
+	if _, err := storage.Load(speedtestStorageKey(id), history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (widget *Speedtest) Update(ctx context.Context) {
+	if widget.Source == "speedtest-tracker" {
+		results, err := feed.FetchSpeedtestTrackerResults(ctx, string(widget.URL), string(widget.Token), widget.History)
+
+		if !widget.canContinueUpdateAfterHandlingErr(err) {
+			return
+		}
+
+		widget.Results = results
+		return
+	}
+
+	widget.startScheduler()
+
+	history, err := loadSpeedtestHistory(widget.ID)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	speedtestSchedulersMu.Lock()
+	widget.Measuring = speedtestMeasuring[widget.ID]
+	speedtestSchedulersMu.Unlock()
+
+	widget.Results = history.Results
+
+	if len(widget.Results) > widget.History {
+		widget.Results = widget.Results[len(widget.Results)-widget.History:]
+	}
+
+	if history.LastError != "" {
+		widget.withNotice(fmt.Errorf("last measurement failed: %s", history.LastError))
+	}
+}
+
+// startScheduler starts measuring in the background on the first update,
+// after which measurements happen on their own interval regardless of how
+// often the page is loaded. Widgets sharing an id share one schedule.
+func (widget *Speedtest) startScheduler() {
+	speedtestSchedulersMu.Lock()
+	defer speedtestSchedulersMu.Unlock()
+
+	if speedtestSchedulers[widget.ID] {
+		return
+	}
+
+	speedtestSchedulers[widget.ID] = true
+
+	go func() {
+		var lastRun time.Time
+
+		for {
+			history, err := loadSpeedtestHistory(widget.ID)
+
+			if err != nil {
+				slog.Error("Failed to load speedtest history", "id", widget.ID, "error", err)
+				time.Sleep(speedtestRetryDelay)
+				continue
+			}
+
+			// if the history couldn't be saved the last attempt isn't in it,
+			// which would otherwise have measurements running back to back
+			next := widget.nextMeasurement(history)
+
+			if !lastRun.IsZero() && next.Before(lastRun.Add(speedtestRetryDelay)) {
+				next = lastRun.Add(speedtestRetryDelay)
+			}
+
+			time.Sleep(time.Until(next))
+			lastRun = time.Now()
+			widget.measure()
+		}
+	}()
+}
+
+func (widget *Speedtest) nextMeasurement(history *SpeedtestHistory) time.Time {
+	if history.LastAttempt.IsZero() {
+		return time.Now()
+	}
+
+	if history.LastError != "" {
+		return history.LastAttempt.Add(min(speedtestRetryDelay, time.Duration(widget.Interval)))
+	}
+
+	return history.LastAttempt.Add(time.Duration(widget.Interval))
+}
+
+func (widget *Speedtest) measure() {
+	speedtestMeasurementMu.Lock()
+	defer speedtestMeasurementMu.Unlock()
+
+	speedtestSchedulersMu.Lock()
+	speedtestMeasuring[widget.ID] = true
+	speedtestSchedulersMu.Unlock()
+
+	defer func() {
+		speedtestSchedulersMu.Lock()
+		delete(speedtestMeasuring, widget.ID)
+		speedtestSchedulersMu.Unlock()
+	}()
+
+	slog.Info("Running speedtest", "id", widget.ID)
+
+	startedAt := time.Now()
+	output, err := feed.RunCommand(context.Background(), feed.CommandRequest{
+		Command: widget.Command,
+		Env:     widget.env,
+		Timeout: time.Duration(widget.Timeout),
+	})
+
+	var result *feed.SpeedtestResult
+
+	if err == nil {
+		result, err = feed.ParseSpeedtestOutput([]byte(output.Stdout))
+	}
+
+	history, loadErr := loadSpeedtestHistory(widget.ID)
+
+	if loadErr != nil {
+		slog.Error("Failed to load speedtest history", "id", widget.ID, "error", loadErr)
+		return
+	}
+
+	history.LastAttempt = startedAt
+	history.LastError = ""
+
+	if err != nil {
+		slog.Error("Speedtest failed", "id", widget.ID, "error", err)
+		history.LastError = err.Error()
+	} else {
+		history.Results = append(history.Results, *result)
+
+		// keep enough for any of the widgets sharing the id
+		if len(history.Results) > max(widget.History, 100) {
+			history.Results = slices.Clone(history.Results[len(history.Results)-max(widget.History, 100):])
+		}
+	}
+
+	if err = storage.Save(speedtestStorageKey(widget.ID), history); err != nil {
+		slog.Error("Failed to save speedtest history", "id", widget.ID, "error", err)
+	}
+}
+
+func (widget *Speedtest) Latest() *feed.SpeedtestResult {
+	return widget.Results.Latest()
+}
+
+func sparklinePoints(values []float64) string {
+	if len(values) < 2 {
+		return ""
+	}
+
+	// a flat line can't be scaled between the minimum and maximum
+	if slices.Min(values) == slices.Max(values) {
+		return "0,25 100,25"
+	}
+
+	return feed.SvgPolylineCoordsFromYValues(100, 50, values)
+}
+
+func (widget *Speedtest) DownloadChartPoints() string {
+	values := make([]float64, len(widget.Results))
+
+	for i := range widget.Results {
+		values[i] = widget.Results[i].Download
+	}
+
+	return sparklinePoints(values)
+}
+
+func (widget *Speedtest) UploadChartPoints() string {
+	values := make([]float64, len(widget.Results))
+
+	for i := range widget.Results {
+		values[i] = widget.Results[i].Upload
+	}
+
+	return sparklinePoints(values)
+}
+
+func (widget *Speedtest) FormatSpeed(mbps float64) string {
+	if mbps >= 1000 {
+		return fmt.Sprintf("%.2f Gbps", mbps/1000)
+	}
+
+	if mbps >= 100 {
+		return fmt.Sprintf("%.0f Mbps", mbps)
+	}
+
+	return fmt.Sprintf("%.1f Mbps", mbps)
+}
+
+func (widget *Speedtest) Render() template.HTML {
+	return widget.render(widget, assets.SpeedtestTemplate)
+}
@@ -0,0 +1,110 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+const sportsLiveCacheDuration = time.Minute
+
+type Sports struct {
+	widgetBase `yaml:",inline"`
+	Provider   string             `yaml:"provider"`
+	League     string             `yaml:"league"`
+	Team       string             `yaml:"team"`
+	APIKey     OptionalEnvString  `yaml:"api-key"`
+	Schedule   *feed.TeamSchedule `yaml:"-"`
+	provider   feed.SportsProvider
+}
+
+func (widget *Sports) Initialize() error {
+	widget.withTitle("Sports").withCacheDuration(time.Hour)
+
+	if widget.Provider == "" {
+		widget.Provider = "espn"
+	}
+
+	if !feed.IsSportsProviderSupported(widget.Provider) {
+		return fmt.Errorf("unknown sports provider: %s", widget.Provider)
+	}
+
+	if widget.Team == "" {
+		return errors.New("sports widget requires a team")
+	}
+
+	switch widget.Provider {
+	case "espn":
+		if widget.League == "" {
+			return errors.New("the espn provider requires a league, such as soccer/eng.1 or basketball/nba")
+		}
+	case "football-data":
+		if widget.APIKey == "" {
+			return errors.New("the football-data provider requires an api-key")
+		}
+	}
+
+	provider, err := feed.NewSportsProvider(widget.Provider, string(widget.APIKey))
+
+	if err != nil {
+		return err
+	}
+
+	widget.provider = provider
+
+	return nil
+}
+
+func (widget *Sports) Update(ctx context.Context) {
+	schedule, err := feed.FetchTeamSchedule(ctx, widget.provider, widget.League, widget.Team)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Schedule = schedule
+
+	// keep the score up to date while a match is in progress and pick it
+	// up as soon as the next one starts rather than an hour into it
+	if schedule.Live != nil {
+		widget.nextUpdate = time.Now().Add(sportsLiveCacheDuration)
+	} else if schedule.Next != nil && schedule.Next.Kickoff.Before(widget.nextUpdate) {
+		widget.nextUpdate = schedule.Next.Kickoff.Add(sportsLiveCacheDuration)
+	}
+}
+
+func (widget *Sports) FormatKickoff(t time.Time) string {
+	return t.In(timezone).Format("Mon, Jan 2 15:04")
+}
+
+// Countdown is worked out when rendering since the schedule is cached
+func (widget *Sports) Countdown(t time.Time) string {
+	left := time.Until(t)
+
+	if left <= 0 {
+		return "starting"
+	}
+
+	days := int(left.Hours()) / 24
+	hours := int(left.Hours()) % 24
+	minutes := int(left.Minutes()) % 60
+
+	if days > 0 {
+		return fmt.Sprintf("in %dd %dh", days, hours)
+	}
+
+	if hours > 0 {
+		return fmt.Sprintf("in %dh %dm", hours, minutes)
+	}
+
+	return fmt.Sprintf("in %dm", max(minutes, 1))
+}
+
+func (widget *Sports) Render() template.HTML {
+	return widget.render(widget, assets.SportsTemplate)
+}
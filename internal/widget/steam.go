@@ -0,0 +1,84 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+var steamIDPattern = regexp.MustCompile(`^\d{17}$`)
+
+type Steam struct {
+	widgetBase      `yaml:",inline"`
+	APIKey          OptionalEnvString  `yaml:"api-key"`
+	SteamID         OptionalEnvString  `yaml:"steam-id"`
+	HideFriends     bool               `yaml:"hide-friends"`
+	HideRecentGames bool               `yaml:"hide-recent-games"`
+	HideWishlist    bool               `yaml:"hide-wishlist"`
+	CountryCode     string             `yaml:"country-code"`
+	Limit           int                `yaml:"limit"`
+	CollapseAfter   int                `yaml:"collapse-after"`
+	Profile         *feed.SteamProfile `yaml:"-"`
+}
+
+func (widget *Steam) Initialize() error {
+	widget.withTitle("Steam").withCacheDuration(10 * time.Minute)
+
+	if !steamIDPattern.MatchString(string(widget.SteamID)) {
+		return errors.New("steam widget requires steam-id to be a 64-bit Steam ID, such as 76561197960287930")
+	}
+
+	if widget.HideFriends && widget.HideRecentGames && widget.HideWishlist {
+		return errors.New("steam widget has every section hidden")
+	}
+
+	if widget.APIKey == "" && (!widget.HideFriends || !widget.HideRecentGames) {
+		return errors.New("steam widget requires an api-key unless both friends and recent games are hidden")
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 10
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *Steam) Update(ctx context.Context) {
+	profile, err := feed.FetchSteamProfile(ctx, feed.SteamRequest{
+		APIKey:      string(widget.APIKey),
+		SteamID:     string(widget.SteamID),
+		Friends:     !widget.HideFriends,
+		RecentGames: !widget.HideRecentGames,
+		Wishlist:    !widget.HideWishlist,
+		CountryCode: widget.CountryCode,
+		Limit:       widget.Limit,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Profile = profile
+}
+
+func (widget *Steam) FormatPlaytime(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+
+	return fmt.Sprintf("%.1fh", d.Hours())
+}
+
+func (widget *Steam) Render() template.HTML {
+	return widget.render(widget, assets.SteamTemplate)
+}
@@ -29,7 +29,7 @@ func (widget *Markets) Initialize() error {
 }
 
 func (widget *Markets) Update(ctx context.Context) {
-	markets, err := feed.FetchMarketsDataFromYahoo(widget.MarketRequests)
+	markets, err := feed.FetchMarketsDataFromYahoo(ctx, widget.MarketRequests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -0,0 +1,135 @@
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+)
+
+// the version of the format written by Export, files from newer versions can
+// still be imported with the sections this version doesn't know about being kept
+const stateFormatVersion = 1
+
+var ErrInvalidState = errors.New("invalid state file")
+
+type stateValue struct {
+	Value     json.RawMessage `json:"value"`
+	UpdatedAt time.Time       `json:"updated-at"`
+}
+
+type ImportSummary struct {
+	// values which were newer than the ones already stored, or weren't stored at all
+	Imported int `json:"imported"`
+	// values which were the same age or older than the ones already stored
+	Skipped int `json:"skipped"`
+}
+
+// Export writes everything in the storage as a versioned JSON document
+// which can be merged into the storage of another instance with Import
+func (s *Storage) Export(w io.Writer) error {
+	s.mu.Lock()
+
+	values := make(map[string]stateValue, len(s.data))
+
+	for key, value := range s.data {
+		values[key] = stateValue{Value: value, UpdatedAt: s.updated[key]}
+	}
+
+	document := make(map[string]any, len(s.unknown)+3)
+
+	for section, contents := range s.unknown {
+		document[section] = contents
+	}
+
+	s.mu.Unlock()
+
+	document["version"] = stateFormatVersion
+	document["exported-at"] = time.Now().UTC()
+	document["values"] = values
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
+
+// Import merges state written by Export into the storage, with the newest of the
+// two values winning for every key and the one already stored winning ties.
+// Nothing is changed if the result can't be saved.
+func (s *Storage) Import(r io.Reader) (ImportSummary, error) {
+	var document map[string]json.RawMessage
+
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return ImportSummary{}, fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+
+	var version int
+
+	if err := json.Unmarshal(document["version"], &version); err != nil || version < 1 {
+		return ImportSummary{}, fmt.Errorf("%w: missing or invalid version", ErrInvalidState)
+	}
+
+	var values map[string]stateValue
+
+	if raw, ok := document["values"]; ok {
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return ImportSummary{}, fmt.Errorf("%w: %v", ErrInvalidState, err)
+		}
+	}
+
+	for key, value := range values {
+		if key == storageUpdatedAtKey || key == storageUnknownSectionsKey {
+			return ImportSummary{}, fmt.Errorf("%w: %s is reserved", ErrInvalidState, key)
+		}
+
+		if len(value.Value) == 0 {
+			return ImportSummary{}, fmt.Errorf("%w: %s has no value", ErrInvalidState, key)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previousData := maps.Clone(s.data)
+	previousUpdated := maps.Clone(s.updated)
+	previousUnknown := maps.Clone(s.unknown)
+
+	var summary ImportSummary
+
+	for key, value := range values {
+		if _, exists := s.data[key]; exists && !value.UpdatedAt.After(s.updated[key]) {
+			summary.Skipped++
+			continue
+		}
+
+		s.data[key] = value.Value
+		s.updated[key] = value.UpdatedAt
+		summary.Imported++
+	}
+
+	for section, contents := range document {
+		if section != "version" && section != "exported-at" && section != "values" {
+			s.unknown[section] = contents
+		}
+	}
+
+	if err := s.persist(); err != nil {
+		s.data, s.updated, s.unknown = previousData, previousUpdated, previousUnknown
+		return ImportSummary{}, err
+	}
+
+	return summary, nil
+}
+
+// ExportState exports the storage used by widgets, see Storage.Export
+func ExportState(w io.Writer) error {
+	return storage.Export(w)
+}
+
+// ImportState imports into the storage used by widgets, see Storage.Import
+func ImportState(r io.Reader) (ImportSummary, error) {
+	return storage.Import(r)
+}
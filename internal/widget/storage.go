@@ -0,0 +1,192 @@
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// when each value was last saved, kept in the data file alongside the values so
+// that importing state can tell which of two values is the newer one
+const storageUpdatedAtKey = "storage:updated-at"
+
+// sections of imported state which this version doesn't know about, kept so that
+// they're exported again rather than lost when moving state through it
+const storageUnknownSectionsKey = "storage:unknown-sections"
+
+// Storage is a small key-value store for the state of widgets which needs to
+// survive restarts, kept in memory and written to a single JSON file on every
+// change. An empty path keeps everything in memory only.
+type Storage struct {
+	path    string
+	mu      sync.Mutex
+	data    map[string]json.RawMessage
+	updated map[string]time.Time
+	unknown map[string]json.RawMessage
+}
+
+var storage = newStorage("")
+
+func newStorage(path string) *Storage {
+	return &Storage{
+		path:    path,
+		data:    make(map[string]json.RawMessage),
+		updated: make(map[string]time.Time),
+		unknown: make(map[string]json.RawMessage),
+	}
+}
+
+func OpenStorage(path string) (*Storage, error) {
+	s := newStorage(path)
+
+	if path == "" {
+		return s, nil
+	}
+
+	contents, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read data file: %w", err)
+	}
+
+	if len(contents) > 0 {
+		if err = json.Unmarshal(contents, &s.data); err != nil {
+			return nil, fmt.Errorf("could not parse data file %s: %w", path, err)
+		}
+	}
+
+	// files written before the times were kept don't have them, their
+	// values counting as older than any other when importing
+	for key, into := range map[string]any{storageUpdatedAtKey: &s.updated, storageUnknownSectionsKey: &s.unknown} {
+		raw, ok := s.data[key]
+
+		if !ok {
+			continue
+		}
+
+		delete(s.data, key)
+
+		if err = json.Unmarshal(raw, into); err != nil {
+			return nil, fmt.Errorf("could not parse data file %s: %w", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// SetStorage sets the storage used by widgets, must be called before the
+// server starts handling requests
+func SetStorage(s *Storage) {
+	storage = s
+}
+
+// Load decodes the value stored under key into value, reporting
+// whether anything was stored under it
+func (s *Storage) Load(key string, value any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.data[key]
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(raw, value)
+}
+
+func (s *Storage) Save(key string, value any) error {
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.data[key]
+	previousUpdated := s.updated[key]
+	s.data[key] = encoded
+	s.updated[key] = time.Now()
+
+	if err = s.persist(); err != nil {
+		if existed {
+			s.data[key] = previous
+		} else {
+			delete(s.data, key)
+		}
+
+		if previousUpdated.IsZero() {
+			delete(s.updated, key)
+		} else {
+			s.updated[key] = previousUpdated
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// persist writes to a temporary file first so that a crash
+// midway through doesn't leave behind a truncated file
+func (s *Storage) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := make(map[string]json.RawMessage, len(s.data)+2)
+
+	for key, value := range s.data {
+		file[key] = value
+	}
+
+	for key, value := range map[string]any{storageUpdatedAtKey: s.updated, storageUnknownSectionsKey: s.unknown} {
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return err
+		}
+
+		file[key] = encoded
+	}
+
+	contents, err := json.MarshalIndent(file, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("could not write data file: %w", err)
+	}
+
+	if _, err = temp.Write(contents); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("could not write data file: %w", err)
+	}
+
+	if err = temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("could not write data file: %w", err)
+	}
+
+	if err = os.Rename(temp.Name(), s.path); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("could not write data file: %w", err)
+	}
+
+	return nil
+}
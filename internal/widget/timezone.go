@@ -0,0 +1,13 @@
+package widget
+
+import "time"
+
+var timezone = time.Local
+
+// SetTimezone sets the timezone that widgets which format times on the
+// server, rather than in the browser, display them in
+func SetTimezone(location *time.Location) {
+	if location != nil {
+		timezone = location
+	}
+}
@@ -0,0 +1,387 @@
+package widget
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+var (
+	ErrTodoVersionConflict = errors.New("list was changed by someone else")
+	ErrTodoItemNotFound    = errors.New("item not found")
+	ErrTodoInvalid         = errors.New("invalid request")
+)
+
+var todoListIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+type TodoItem struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TodoList struct {
+	Version int        `json:"version"`
+	Items   []TodoItem `json:"items"`
+}
+
+// held for the whole load, modify and save cycle so that
+// concurrent changes to the same list can't interleave
+var todoListsMu sync.Mutex
+
+type todoCalDAVConfig struct {
+	URL      string            `yaml:"url"`
+	Username OptionalEnvString `yaml:"username"`
+	Password OptionalEnvString `yaml:"password"`
+}
+
+type Todo struct {
+	widgetBase    `yaml:",inline"`
+	ListID        string            `yaml:"id"`
+	MaxItems      int               `yaml:"max-items"`
+	MaxItemLength int               `yaml:"max-item-length"`
+	CalDAV        *todoCalDAVConfig `yaml:"caldav"`
+	List          *TodoList         `yaml:"-"`
+	calDAV        *feed.CalDAVClient
+}
+
+func (widget *Todo) Initialize() error {
+	widget.withTitle("To-do").withError(nil)
+
+	if widget.ListID == "" {
+		widget.ListID = "default"
+	}
+
+	if !todoListIDPattern.MatchString(widget.ListID) {
+		return fmt.Errorf("invalid id for todo widget, can only contain letters, numbers, - and _: %s", widget.ListID)
+	}
+
+	if widget.MaxItems <= 0 {
+		widget.MaxItems = 100
+	}
+
+	if widget.MaxItemLength <= 0 {
+		widget.MaxItemLength = 200
+	}
+
+	if widget.CalDAV != nil {
+		client, err := feed.NewCalDAVClient(widget.CalDAV.URL, string(widget.CalDAV.Username), string(widget.CalDAV.Password))
+
+		if err != nil {
+			return fmt.Errorf("todo widget: %w", err)
+		}
+
+		widget.calDAV = client
+	}
+
+	return nil
+}
+
+// UsesStorage reports whether the list is kept in the storage of widgets
+// rather than on a CalDAV server
+func (widget *Todo) UsesStorage() bool {
+	return widget.CalDAV == nil
+}
+
+func todoStorageKey(listID string) string {
+	return "todo:" + listID
+}
+
+func loadTodoList(listID string) (*TodoList, error) {
+	list := &TodoList{Items: make([]TodoItem, 0)}
+
+	if _, err := storage.Load(todoStorageKey(listID), list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (widget *Todo) GetList(ctx context.Context) (*TodoList, error) {
+	todoListsMu.Lock()
+	defer todoListsMu.Unlock()
+
+	if widget.calDAV != nil {
+		list, _, err := widget.loadCalDAVList(ctx)
+		return list, err
+	}
+
+	return loadTodoList(widget.ListID)
+}
+
+// loadCalDAVList returns the list along with the to-dos its items were made from.
+// Item IDs are derived from the URLs of the to-dos and the version of the list from
+// their ETags, so that any change made by another client results in a new version.
+func (widget *Todo) loadCalDAVList(ctx context.Context) (*TodoList, map[string]*feed.CalDAVTodo, error) {
+	todos, err := widget.calDAV.FetchTodos(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := &TodoList{Items: make([]TodoItem, 0, len(todos))}
+	byID := make(map[string]*feed.CalDAVTodo, len(todos))
+	version := fnv.New32a()
+
+	for _, todo := range todos {
+		sum := sha256.Sum256([]byte(todo.URL))
+		id := hex.EncodeToString(sum[:8])
+		byID[id] = todo
+
+		list.Items = append(list.Items, TodoItem{
+			ID:        id,
+			Text:      todo.Summary,
+			Done:      todo.Completed,
+			CreatedAt: todo.Created,
+		})
+
+		fmt.Fprintf(version, "%s\x00%s\x00%s\x00%d\x00", todo.URL, todo.ETag, todo.Summary, todo.SortOrder)
+	}
+
+	// kept positive so that it round trips through the If-Match header
+	list.Version = int(version.Sum32() & 0x7fffffff)
+
+	return list, byID, nil
+}
+
+type TodoOperation struct {
+	Kind    string
+	Version int
+	ItemID  string
+	Text    string
+	Order   []string
+}
+
+// ApplyOperation applies a change to the list, failing with ErrTodoVersionConflict
+// if the list has been changed since the version the change was based on
+func (widget *Todo) ApplyOperation(ctx context.Context, op TodoOperation) (*TodoList, error) {
+	todoListsMu.Lock()
+	defer todoListsMu.Unlock()
+
+	if widget.calDAV != nil {
+		return widget.applyCalDAVOperation(ctx, op)
+	}
+
+	list, err := loadTodoList(widget.ListID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if op.Version != list.Version {
+		return list, ErrTodoVersionConflict
+	}
+
+	index := -1
+
+	if op.ItemID != "" {
+		for i := range list.Items {
+			if list.Items[i].ID == op.ItemID {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			return list, ErrTodoItemNotFound
+		}
+	}
+
+	switch op.Kind {
+	case "add":
+		text, err := widget.validateNewItem(list, op.Text)
+
+		if err != nil {
+			return list, err
+		}
+
+		list.Items = append(list.Items, TodoItem{
+			ID:        newTodoItemID(),
+			Text:      text,
+			CreatedAt: time.Now(),
+		})
+	case "toggle":
+		list.Items[index].Done = !list.Items[index].Done
+	case "delete":
+		list.Items = append(list.Items[:index], list.Items[index+1:]...)
+	case "reorder":
+		reordered, err := reorderTodoItems(list.Items, op.Order)
+
+		if err != nil {
+			return list, err
+		}
+
+		list.Items = reordered
+	default:
+		return list, fmt.Errorf("%w: unknown operation %s", ErrTodoInvalid, op.Kind)
+	}
+
+	list.Version++
+
+	if err = storage.Save(todoStorageKey(widget.ListID), list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (widget *Todo) validateNewItem(list *TodoList, text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", fmt.Errorf("%w: item is empty", ErrTodoInvalid)
+	}
+
+	if utf8.RuneCountInString(text) > widget.MaxItemLength {
+		return "", fmt.Errorf("%w: item is longer than %d characters", ErrTodoInvalid, widget.MaxItemLength)
+	}
+
+	if len(list.Items) >= widget.MaxItems {
+		return "", fmt.Errorf("%w: list can't have more than %d items", ErrTodoInvalid, widget.MaxItems)
+	}
+
+	return text, nil
+}
+
+func (widget *Todo) applyCalDAVOperation(ctx context.Context, op TodoOperation) (*TodoList, error) {
+	list, todos, err := widget.loadCalDAVList(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if op.Version != list.Version {
+		return list, ErrTodoVersionConflict
+	}
+
+	todo, exists := todos[op.ItemID]
+
+	if op.ItemID != "" && !exists {
+		return list, ErrTodoItemNotFound
+	}
+
+	now := time.Now()
+
+	switch op.Kind {
+	case "add":
+		text, invalid := widget.validateNewItem(list, op.Text)
+
+		if invalid != nil {
+			return list, invalid
+		}
+
+		todo = feed.NewCalDAVTodo(text, now)
+
+		// the server's to-dos without a sort order are shown last, so a new
+		// one only needs one when some of the others have it
+		for _, other := range todos {
+			if other.SortOrder != 0 && other.SortOrder >= todo.SortOrder {
+				todo.SetSortOrder(other.SortOrder + 1)
+			}
+		}
+
+		err = widget.calDAV.PutTodo(ctx, todo)
+	case "toggle":
+		todo.SetCompleted(!todo.Completed, now)
+		err = widget.calDAV.PutTodo(ctx, todo)
+	case "delete":
+		err = widget.calDAV.DeleteTodo(ctx, todo)
+	case "reorder":
+		reordered, invalid := reorderTodoItems(list.Items, op.Order)
+
+		if invalid != nil {
+			return list, invalid
+		}
+
+		for i, item := range reordered {
+			if todos[item.ID].SortOrder == i+1 {
+				continue
+			}
+
+			todos[item.ID].SetSortOrder(i + 1)
+
+			if err = widget.calDAV.PutTodo(ctx, todos[item.ID]); err != nil {
+				break
+			}
+		}
+	default:
+		return list, fmt.Errorf("%w: unknown operation %s", ErrTodoInvalid, op.Kind)
+	}
+
+	if err != nil && !errors.Is(err, feed.ErrCalDAVConflict) {
+		return nil, err
+	}
+
+	current, _, loadErr := widget.loadCalDAVList(ctx)
+
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	if err != nil {
+		return current, ErrTodoVersionConflict
+	}
+
+	return current, nil
+}
+
+func reorderTodoItems(items []TodoItem, order []string) ([]TodoItem, error) {
+	if len(order) != len(items) {
+		return nil, fmt.Errorf("%w: order must contain every item exactly once", ErrTodoInvalid)
+	}
+
+	byID := make(map[string]TodoItem, len(items))
+
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	reordered := make([]TodoItem, 0, len(items))
+
+	for _, id := range order {
+		item, ok := byID[id]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: order must contain every item exactly once", ErrTodoInvalid)
+		}
+
+		delete(byID, id)
+		reordered = append(reordered, item)
+	}
+
+	return reordered, nil
+}
+
+func newTodoItemID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (widget *Todo) Render() template.HTML {
+	list, err := widget.GetList(context.Background())
+
+	if err != nil {
+		widget.withError(fmt.Errorf("could not load list: %w", err))
+		widget.ContentAvailable = false
+	} else {
+		widget.withError(nil)
+		widget.List = list
+	}
+
+	return widget.render(widget, assets.TodoTemplate)
+}
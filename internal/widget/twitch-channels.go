@@ -32,7 +32,7 @@ func (widget *TwitchChannels) Initialize() error {
 }
 
 func (widget *TwitchChannels) Update(ctx context.Context) {
-	channels, err := feed.FetchChannelsFromTwitch(widget.ChannelsRequest)
+	channels, err := feed.FetchChannelsFromTwitch(ctx, widget.ChannelsRequest)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
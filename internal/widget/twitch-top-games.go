@@ -32,7 +32,7 @@ func (widget *TwitchGames) Initialize() error {
 }
 
 func (widget *TwitchGames) Update(ctx context.Context) {
-	categories, err := feed.FetchTopGamesFromTwitch(widget.Exclude, widget.Limit)
+	categories, err := feed.FetchTopGamesFromTwitch(ctx, widget.Exclude, widget.Limit)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
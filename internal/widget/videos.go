@@ -36,12 +36,12 @@ func (widget *Videos) Initialize() error {
 }
 
 func (widget *Videos) Update(ctx context.Context) {
-	bilibiliVideos, err := feed.FetchBilibiliUploads(widget.BilibiliUIDs)
+	bilibiliVideos, err := feed.FetchBilibiliUploads(ctx, widget.BilibiliUIDs)
 
 	if err != nil {
 		return
 	}
-	youtubeVideos, err := feed.FetchYoutubeChannelUploads(widget.Channels, widget.VideoUrlTemplate)
+	youtubeVideos, err := feed.FetchYoutubeChannelUploads(ctx, widget.Channels, widget.VideoUrlTemplate)
 	if err != nil {
 		return
 	}
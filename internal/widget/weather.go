@@ -50,7 +50,7 @@ func (widget *Weather) Initialize() error {
 
 func (widget *Weather) Update(ctx context.Context) {
 	if widget.Place == nil {
-		place, err := feed.FetchPlaceFromName(widget.Location)
+		place, err := feed.FetchPlaceFromName(ctx, widget.Location)
 
 		if err != nil {
 			widget.withError(err).scheduleEarlyUpdate()
@@ -60,7 +60,7 @@ func (widget *Weather) Update(ctx context.Context) {
 		widget.Place = place
 	}
 
-	weather, err := feed.FetchWeatherForPlace(widget.Place, widget.Units)
+	weather, err := feed.FetchWeatherForPlace(ctx, widget.Place, widget.Units)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"log/slog"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/glanceapp/glance/internal/feed"
@@ -37,6 +38,8 @@ func New(widgetType string) (Widget, error) {
 		return &Videos{}, nil
 	case "markets", "stocks":
 		return &Markets{}, nil
+	case "currency":
+		return &Currency{}, nil
 	case "reddit":
 		return &Reddit{}, nil
 	case "rss":
@@ -57,6 +60,38 @@ func New(widgetType string) (Widget, error) {
 		return &Search{}, nil
 	case "extension":
 		return &Extension{}, nil
+	case "custom-api":
+		return &CustomAPI{}, nil
+	case "scraper":
+		return &Scraper{}, nil
+	case "exec":
+		return &Exec{}, nil
+	case "todo":
+		return &Todo{}, nil
+	case "markdown":
+		return &Markdown{}, nil
+	case "notifications":
+		return &Notifications{}, nil
+	case "email":
+		return &Email{}, nil
+	case "departures":
+		return &Departures{}, nil
+	case "sports":
+		return &Sports{}, nil
+	case "steam":
+		return &Steam{}, nil
+	case "free-games":
+		return &FreeGames{}, nil
+	case "speedtest":
+		return &Speedtest{}, nil
+	case "home-assistant":
+		return &HomeAssistant{}, nil
+	case "proxmox":
+		return &Proxmox{}, nil
+	case "analytics":
+		return &Analytics{}, nil
+	case "service-updates":
+		return &ServiceUpdates{}, nil
 	default:
 		return nil, fmt.Errorf("unknown widget type: %s", widgetType)
 	}
@@ -64,6 +99,9 @@ func New(widgetType string) (Widget, error) {
 
 type Widgets []Widget
 
+// IDs are unique across all pages, allowing a widget to be requested on its own
+var widgetIDs atomic.Uint64
+
 func (w *Widgets) UnmarshalYAML(node *yaml.Node) error {
 	var nodes []yaml.Node
 
@@ -73,19 +111,27 @@ func (w *Widgets) UnmarshalYAML(node *yaml.Node) error {
 
 	for _, node := range nodes {
 		meta := struct {
-			Type string `yaml:"type"`
+			Type      string `yaml:"type"`
+			Cache     any    `yaml:"cache"`
+			RefreshAt any    `yaml:"refresh-at"`
 		}{}
 
 		if err := node.Decode(&meta); err != nil {
 			return err
 		}
 
+		if meta.Cache != nil && meta.RefreshAt != nil {
+			return fmt.Errorf("%s widget: cache and refresh-at can't be used together", meta.Type)
+		}
+
 		widget, err := New(meta.Type)
 
 		if err != nil {
 			return err
 		}
 
+		widget.setID(widgetIDs.Add(1))
+
 		if err = node.Decode(widget); err != nil {
 			return err
 		}
@@ -106,6 +152,26 @@ type Widget interface {
 	Update(context.Context)
 	Render() template.HTML
 	GetType() string
+	GetID() uint64
+	setID(uint64)
+}
+
+type erroringWidget interface {
+	updateError() error
+}
+
+// UpdateError returns the error which the last update of the widget ran into, nil
+// if it succeeded. Must be called by the same goroutine as Update.
+func UpdateError(w Widget) error {
+	if widget, ok := w.(erroringWidget); ok {
+		return widget.updateError()
+	}
+
+	return nil
+}
+
+func (w *widgetBase) updateError() error {
+	return w.Error
 }
 
 type cacheType int
@@ -117,17 +183,25 @@ const (
 )
 
 type widgetBase struct {
-	Type                string        `yaml:"type"`
-	Title               string        `yaml:"title"`
-	CustomCacheDuration DurationField `yaml:"cache"`
-	ContentAvailable    bool          `yaml:"-"`
-	Error               error         `yaml:"-"`
-	Notice              error         `yaml:"-"`
-	templateBuffer      bytes.Buffer  `yaml:"-"`
-	cacheDuration       time.Duration `yaml:"-"`
-	cacheType           cacheType     `yaml:"-"`
-	nextUpdate          time.Time     `yaml:"-"`
-	updateRetriedTimes  int           `yaml:"-"`
+	Type                string                `yaml:"type"`
+	Title               string                `yaml:"title"`
+	CustomCacheDuration DurationField         `yaml:"cache"`
+	RefreshAt           *RefreshScheduleField `yaml:"refresh-at"`
+	LinkTarget          LinkTargetField       `yaml:"link-target"`
+	ContentAvailable    bool                  `yaml:"-"`
+	Error               error                 `yaml:"-"`
+	Notice              error                 `yaml:"-"`
+	Stale               bool                  `yaml:"-"`
+	templateBuffer      bytes.Buffer          `yaml:"-"`
+	cacheDuration       time.Duration         `yaml:"-"`
+	cacheType           cacheType             `yaml:"-"`
+	nextUpdate          time.Time             `yaml:"-"`
+	updateRetriedTimes  int                   `yaml:"-"`
+	NewItems            int                   `yaml:"-"`
+	lastVisit           time.Time             `yaml:"-"`
+	lastVisitNow        time.Time             `yaml:"-"`
+	id                  uint64                `yaml:"-"`
+	ignoresQuietHours   bool                  `yaml:"-"`
 }
 
 func (w *widgetBase) RequiresUpdate(now *time.Time) bool {
@@ -139,9 +213,19 @@ func (w *widgetBase) RequiresUpdate(now *time.Time) bool {
 		return true
 	}
 
+	if !w.ignoresQuietHours && InQuietHours(*now) {
+		return false
+	}
+
 	return now.After(w.nextUpdate)
 }
 
+// IsPaused reports whether the widget isn't updating because of quiet hours,
+// in which case it's expected for its data to be out of date
+func (w *widgetBase) IsPaused() bool {
+	return !w.ignoresQuietHours && InQuietHours(time.Now())
+}
+
 func (w *widgetBase) Update(ctx context.Context) {
 
 }
@@ -150,6 +234,14 @@ func (w *widgetBase) GetType() string {
 	return w.Type
 }
 
+func (w *widgetBase) GetID() uint64 {
+	return w.id
+}
+
+func (w *widgetBase) setID(id uint64) {
+	w.id = id
+}
+
 func (w *widgetBase) render(data any, t *template.Template) template.HTML {
 	w.templateBuffer.Reset()
 	err := t.Execute(&w.templateBuffer, data)
@@ -233,6 +325,9 @@ func (w *widgetBase) canContinueUpdateAfterHandlingErr(err error) bool {
 	// alternatively have a resource cache and only refetch the failed resources,
 	// then rebuild the widget.
 
+	// the data being shown is from an earlier update which succeeded
+	w.Stale = errors.Is(err, feed.ErrStaleContent)
+
 	if err != nil {
 		w.scheduleEarlyUpdate()
 
@@ -254,8 +349,24 @@ func (w *widgetBase) canContinueUpdateAfterHandlingErr(err error) bool {
 }
 
 func (w *widgetBase) getNextUpdateTime() time.Time {
+	next := w.getScheduledUpdateTime()
+
+	// updates which would happen during quiet hours happen once they're over instead
+	if !next.IsZero() && !w.ignoresQuietHours && InQuietHours(next) {
+		return QuietHoursEnd(next)
+	}
+
+	return next
+}
+
+func (w *widgetBase) getScheduledUpdateTime() time.Time {
 	now := time.Now()
 
+	// widgets which never update have nothing to schedule
+	if w.RefreshAt != nil && w.cacheType != cacheTypeInfinite {
+		return w.RefreshAt.next(now)
+	}
+
 	if w.cacheType == cacheTypeDuration {
 		return now.Add(w.cacheDuration)
 	}